@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Настройки учета точности прогноза: прогноз максимального порыва на день
+// сравнивается с фактическим максимумом по наблюдениям
+type AccuracyConfig struct {
+	File       string        // JSON-файл с прогнозом и фактом по дням; пустая строка - учет отключен
+	Interval   time.Duration // Период опроса фактической погоды
+	Days       int           // Число последних дней для скользящей статистики
+	StationURL string        // Метеостанция основного города вместо текущей погоды OpenWeatherMap
+}
+
+// Загрузка настроек учета точности из переменных окружения
+func loadAccuracyConfig() AccuracyConfig {
+	cfg := AccuracyConfig{
+		File:       os.Getenv("ACCURACY_FILE"),
+		Interval:   time.Hour,
+		Days:       30,
+		StationURL: os.Getenv("ACCURACY_STATION_URL"),
+	}
+
+	if envInterval := os.Getenv("ACCURACY_INTERVAL"); envInterval != "" {
+		if val, err := time.ParseDuration(envInterval); err == nil && val > 0 {
+			cfg.Interval = val
+		} else {
+			logWarnf("Ошибка парсинга ACCURACY_INTERVAL: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envDays := os.Getenv("ACCURACY_DAYS"); envDays != "" {
+		if val, err := strconv.Atoi(envDays); err == nil && val >= 1 && val <= 365 {
+			cfg.Days = val
+		} else {
+			logWarnf("Ошибка парсинга ACCURACY_DAYS: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	return cfg
+}
+
+// Прогноз и факт по пункту за один день (по часовому поясу пункта), порывы в м/с
+type accuracyDay struct {
+	Place      string    `json:"place"`
+	Date       string    `json:"date"`
+	Forecast   *float64  `json:"forecast_max_gust,omitempty"` // Последний прогноз, полученный до начала дня
+	ForecastAt time.Time `json:"forecast_at,omitempty"`
+	Observed   *float64  `json:"observed_max_gust,omitempty"`
+	Samples    int       `json:"samples"` // Число наблюдений за день
+	Threshold  float64   `json:"threshold"`
+	Closed     bool      `json:"closed"` // День закончился, запись учитывается в статистике
+}
+
+// Скользящая статистика точности прогноза по пункту
+type AccuracyStats struct {
+	Place       string
+	Days        int     // Дней с прогнозом и наблюдениями
+	MeanError   float64 // Средняя абсолютная ошибка прогноза максимального порыва
+	Bias        float64 // Среднее (прогноз - факт): больше нуля - прогноз завышает порывы
+	MaxError    float64 // Наибольшая абсолютная ошибка
+	Hits        int     // Порог превышен по прогнозу и фактически
+	Misses      int     // Порог превышен фактически, но не по прогнозу
+	FalseAlarms int     // Порог превышен по прогнозу, но не фактически
+}
+
+// Завышает ли прогноз порывы в среднем
+func (s AccuracyStats) Overestimates() bool {
+	return s.Bias > 0
+}
+
+// Среднее смещение прогноза без знака, для текста сводки
+func (s AccuracyStats) AbsBias() float64 {
+	return math.Abs(s.Bias)
+}
+
+// Учет точности прогноза: прогнозы записываются при каждом запросе прогноза,
+// наблюдения - по расписанию ACCURACY_INTERVAL
+type AccuracyTracker struct {
+	config AccuracyConfig
+
+	mu   sync.Mutex
+	days []*accuracyDay
+}
+
+// Создание учета точности с загрузкой сохраненных данных; без ACCURACY_FILE учет отключен
+func newAccuracyTracker(config AccuracyConfig) (*AccuracyTracker, error) {
+	if config.File == "" {
+		return nil, nil
+	}
+
+	t := &AccuracyTracker{config: config}
+	data, err := os.ReadFile(config.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении файла точности прогноза: %w", err)
+	}
+	if err := json.Unmarshal(data, &t.days); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе файла точности прогноза: %w", err)
+	}
+	return t, nil
+}
+
+// Запись дня по пункту и дате; вызывается с захваченной блокировкой
+func (t *AccuracyTracker) day(place, date string, threshold float64) *accuracyDay {
+	for _, d := range t.days {
+		if d.Place == place && d.Date == date {
+			return d
+		}
+	}
+	d := &accuracyDay{Place: place, Date: date, Threshold: threshold}
+	t.days = append(t.days, d)
+	return d
+}
+
+// Запись прогноза максимального порыва по дням. Для статистики берется последний
+// прогноз, полученный до начала дня; если сервис запущен в течение дня, - первый
+// прогноз за оставшуюся часть дня.
+func (t *AccuracyTracker) RecordForecast(place string, weatherData *WeatherResponse, threshold float64, now time.Time) {
+	if t == nil {
+		return
+	}
+
+	maxGust := make(map[string]float64)
+	var dates []string
+	for _, forecast := range weatherData.List {
+		date := forecast.Time().Format("2006-01-02")
+		if _, ok := maxGust[date]; !ok {
+			dates = append(dates, date)
+		}
+		maxGust[date] = max(maxGust[date], forecast.Wind.Gust)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	today := now.In(weatherData.loc).Format("2006-01-02")
+	for _, date := range dates {
+		if date < today {
+			continue
+		}
+		d := t.day(place, date, threshold)
+		if date == today && d.Forecast != nil {
+			continue
+		}
+		gust := maxGust[date]
+		d.Forecast, d.ForecastAt, d.Threshold = &gust, now.UTC(), threshold
+	}
+	t.save()
+}
+
+// Запись наблюдения и закрытие прошедших дней с выводом результата в журнал
+func (t *AccuracyTracker) RecordObservation(place string, gust, threshold float64, now time.Time) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	today := now.Format("2006-01-02")
+	d := t.day(place, today, threshold)
+	if d.Observed == nil || gust > *d.Observed {
+		d.Observed = &gust
+	}
+	d.Samples++
+	d.Threshold = threshold
+
+	for _, d := range t.days {
+		if d.Place != place || d.Closed || d.Date >= today {
+			continue
+		}
+		d.Closed = true
+		if d.Forecast == nil || d.Observed == nil {
+			log.Printf("Точность прогноза, %s, %s: нет данных для сравнения", place, d.Date)
+			continue
+		}
+		log.Printf("Точность прогноза, %s, %s: прогноз %.1f м/с, факт %.1f м/с (%d наблюдений), ошибка %+.1f м/с",
+			place, d.Date, *d.Forecast, *d.Observed, d.Samples, *d.Forecast-*d.Observed)
+	}
+	t.prune(now)
+	t.save()
+}
+
+// Удаление дней старше периода статистики и еженедельной сводки; вызывается с захваченной блокировкой
+func (t *AccuracyTracker) prune(now time.Time) {
+	oldest := now.AddDate(0, 0, -max(t.config.Days, 7)-1).Format("2006-01-02")
+	kept := t.days[:0]
+	for _, d := range t.days {
+		if d.Date >= oldest {
+			kept = append(kept, d)
+		}
+	}
+	t.days = kept
+}
+
+// Сохранение данных в файл; вызывается с захваченной блокировкой
+func (t *AccuracyTracker) save() {
+	data, err := json.MarshalIndent(t.days, "", "  ")
+	if err != nil {
+		logErrorf("Ошибка при формировании JSON: %v", err)
+		return
+	}
+	tmp := t.config.File + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		logErrorf("Ошибка при записи файла точности прогноза: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, t.config.File); err != nil {
+		logErrorf("Ошибка при записи файла точности прогноза: %v", err)
+	}
+}
+
+// Статистика по закрытым дням за последние ACCURACY_DAYS дней, по пунктам в порядке первого упоминания
+func (t *AccuracyTracker) Stats(now time.Time) []AccuracyStats {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	from := now.AddDate(0, 0, -t.config.Days).Format("2006-01-02")
+	var stats []AccuracyStats
+	index := make(map[string]int)
+	for _, d := range t.days {
+		if !d.Closed || d.Date < from || d.Forecast == nil || d.Observed == nil {
+			continue
+		}
+		i, ok := index[d.Place]
+		if !ok {
+			i = len(stats)
+			index[d.Place] = i
+			stats = append(stats, AccuracyStats{Place: d.Place})
+		}
+		s := &stats[i]
+
+		diff := *d.Forecast - *d.Observed
+		s.Days++
+		s.MeanError += math.Abs(diff)
+		s.Bias += diff
+		s.MaxError = max(s.MaxError, math.Abs(diff))
+		forecastAlert, observedAlert := *d.Forecast > d.Threshold, *d.Observed > d.Threshold
+		switch {
+		case forecastAlert && observedAlert:
+			s.Hits++
+		case observedAlert:
+			s.Misses++
+		case forecastAlert:
+			s.FalseAlarms++
+		}
+	}
+	for i := range stats {
+		stats[i].MeanError /= float64(stats[i].Days)
+		stats[i].Bias /= float64(stats[i].Days)
+	}
+	return stats
+}
+
+// Опрос фактической погоды по всем контролируемым пунктам каждые ACCURACY_INTERVAL
+func runAccuracyTracking(ctx context.Context, store *ConfigStore, tracker *AccuracyTracker) {
+	ticker := time.NewTicker(tracker.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		config := store.Load()
+		places := []*Config{config}
+		if len(config.Locations.List) > 0 {
+			places = places[:0]
+			for _, loc := range config.Locations.List {
+				places = append(places, config.forLocation(loc))
+			}
+		}
+		for _, place := range places {
+			gust, err := getObservedWindGust(place, tracker.config.StationURL)
+			if err != nil {
+				logErrorf("Ошибка при получении фактической погоды (%s): %v", place.placeName(), err)
+				continue
+			}
+			tracker.RecordObservation(place.placeName(), gust, place.WindGustThreshold, place.Clock.Now())
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Ответ OpenWeatherMap с текущей погодой; на метеостанции достаточно поля wind_gust
+type observedWeather struct {
+	Wind struct {
+		Speed float64  `json:"speed"`
+		Gust  *float64 `json:"gust"`
+	} `json:"wind"`
+	WindGust *float64 `json:"wind_gust"`
+}
+
+// Фактический порыв ветра в м/с: с метеостанции (только для основного города)
+// или из текущей погоды OpenWeatherMap; без порывов в наблюдении берется скорость ветра
+func getObservedWindGust(config *Config, stationURL string) (float64, error) {
+	url := stationURL
+	if url == "" || len(config.Locations.List) > 0 {
+		location, err := getGeoCoordinates(config)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка при получении координат: %w", err)
+		}
+		url = fmt.Sprintf("https://api.openweathermap.org/data/2.5/weather?lat=%.4f&lon=%.4f&units=metric&appid=%s",
+			location.Lat, location.Lon, config.OpenWeatherAPIKey)
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при запросе к API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при чтении ответа: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var observed observedWeather
+	if err := json.Unmarshal(body, &observed); err != nil {
+		return 0, fmt.Errorf("ошибка при разборе JSON: %w", err)
+	}
+	switch {
+	case observed.WindGust != nil:
+		return *observed.WindGust, nil
+	case observed.Wind.Gust != nil:
+		return *observed.Wind.Gust, nil
+	default:
+		return observed.Wind.Speed, nil
+	}
+}
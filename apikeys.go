@@ -0,0 +1,235 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Области доступа ключей API
+const (
+	scopeTrigger       = "trigger"       // Мероприятия, пауза рассылки и подтверждение предупреждений
+	scopeSubscriptions = "subscriptions" // Получатели /api/recipients
+	scopeConfig        = "config"        // Страница настроек /settings
+	scopeAll           = "*"
+)
+
+// Минимальная длина ключа API
+const minAPIKeyLength = 16
+
+// Маршруты управления и области, необходимые для доступа к ним. Маршруты только для чтения
+// (/api/v1, ленты, графики, /api/history) и ссылки с подписью (/ack/, /unsubscribe) открыты.
+// Без API_KEYS страница /settings закрыта собственным паролем SETTINGS_PASSWORD, а изменения
+// через маршруты с keyedWrites отклоняются: подтверждение по угадываемому идентификатору
+// предупреждения и правка списка получателей без ключа недопустимы.
+var apiKeyRoutes = []struct {
+	path, scope string
+	keyedWrites bool
+}{
+	{"/api/events", scopeTrigger, false},
+	{"/api/pause", scopeTrigger, false},
+	{"/api/alerts", scopeTrigger, true},
+	{"/api/recipients", scopeSubscriptions, true},
+	{"/settings", scopeConfig, false},
+}
+
+// Ключ API
+type APIKey struct {
+	Name    string    // Имя для журнала: по нему видно, каким ключом еще пользуются
+	Key     string    // Значение ключа
+	Scopes  []string  // Области доступа
+	Expires time.Time // Окончание действия; нулевое - бессрочно
+}
+
+// Ключи API для маршрутов управления (API_KEYS)
+type APIKeysConfig struct {
+	Configured bool // API_KEYS задана: без подходящего ключа маршруты управления закрыты
+	Keys       []APIKey
+}
+
+// Загрузка ключей API из переменных окружения. При ошибке разбора маршруты управления
+// закрываются полностью, а не остаются открытыми.
+func loadAPIKeysConfig() APIKeysConfig {
+	envKeys := os.Getenv("API_KEYS")
+	if envKeys == "" {
+		return APIKeysConfig{}
+	}
+	keys, err := parseAPIKeys(envKeys)
+	if err != nil {
+		logErrorf("Ошибка парсинга API_KEYS: %v, маршруты управления закрыты", err)
+		return APIKeysConfig{Configured: true}
+	}
+	return APIKeysConfig{Configured: true, Keys: keys}
+}
+
+// Разбор ключей: имя=ключ:область,область[:ГГГГ-ММ-ДД];... Для замены ключа под тем же
+// именем задается второй ключ, а прежний удаляется, когда клиенты перейдут на новый.
+func parseAPIKeys(value string) ([]APIKey, error) {
+	var keys []APIKey
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, rest, ok := strings.Cut(entry, "=")
+		parts := strings.Split(rest, ":")
+		if !ok || strings.TrimSpace(name) == "" || len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("ожидается формат имя=ключ:области[:ГГГГ-ММ-ДД], получено %q", maskAPIKeyEntry(entry))
+		}
+		key := APIKey{Name: strings.TrimSpace(name), Key: strings.TrimSpace(parts[0])}
+		if len(key.Key) < minAPIKeyLength {
+			return nil, fmt.Errorf("ключ %s короче %d символов", key.Name, minAPIKeyLength)
+		}
+		if seen[key.Key] {
+			return nil, fmt.Errorf("ключ %s повторяется", key.Name)
+		}
+		seen[key.Key] = true
+
+		for _, scope := range strings.Split(parts[1], ",") {
+			scope = strings.ToLower(strings.TrimSpace(scope))
+			switch scope {
+			case scopeTrigger, scopeSubscriptions, scopeConfig, scopeAll:
+				key.Scopes = append(key.Scopes, scope)
+			case "":
+			default:
+				return nil, fmt.Errorf("ключ %s: неизвестная область %q (trigger, subscriptions, config или *)", key.Name, scope)
+			}
+		}
+		if len(key.Scopes) == 0 {
+			return nil, fmt.Errorf("ключ %s: не заданы области доступа", key.Name)
+		}
+
+		if len(parts) == 3 {
+			// Ключ действует по указанный день включительно
+			date, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(parts[2]), time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("ключ %s: ожидается дата окончания ГГГГ-ММ-ДД, получено %q", key.Name, parts[2])
+			}
+			key.Expires = date.AddDate(0, 0, 1)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Запись ключа без его значения для сообщений об ошибках
+func maskAPIKeyEntry(entry string) string {
+	name, _, _ := strings.Cut(entry, "=")
+	return name + "=***"
+}
+
+// Разрешает ли ключ доступ к области
+func (k APIKey) allows(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == scopeAll {
+			return true
+		}
+	}
+	return false
+}
+
+// Есть ли действующий ключ с доступом к области
+func (c APIKeysConfig) hasScope(scope string, now time.Time) bool {
+	for _, key := range c.Keys {
+		if key.allows(scope) && (key.Expires.IsZero() || now.Before(key.Expires)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ключ из запроса: заголовок Authorization: Bearer, X-API-Key или пароль базовой аутентификации
+// (для страниц в браузере)
+func requestAPIKey(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if _, password, ok := r.BasicAuth(); ok {
+		return password
+	}
+	return ""
+}
+
+// Ключ, которым подписан запрос; nil - ключа нет или он не подходит
+func (c APIKeysConfig) authenticate(r *http.Request, now time.Time) *APIKey {
+	presented := requestAPIKey(r)
+	if presented == "" {
+		return nil
+	}
+	var found *APIKey
+	// Сравниваются все ключи, чтобы время ответа не зависело от того, какой из них совпал
+	for i := range c.Keys {
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(c.Keys[i].Key)) == 1 {
+			found = &c.Keys[i]
+		}
+	}
+	if found != nil && !found.Expires.IsZero() && !now.Before(found.Expires) {
+		logWarnf("Отклонен запрос %s %s: срок действия ключа API %s истек", r.Method, r.URL.Path, found.Name)
+		return nil
+	}
+	return found
+}
+
+// Разрешен ли запрос к области ключом API
+func (c APIKeysConfig) allows(r *http.Request, scope string, now time.Time) bool {
+	key := c.authenticate(r, now)
+	return key != nil && key.allows(scope)
+}
+
+// Область доступа маршрута управления; пусто - маршрут открыт. keyedWrites - изменения
+// через маршрут принимаются только при заданных API_KEYS.
+func apiKeyScope(path string) (scope string, keyedWrites bool) {
+	for _, route := range apiKeyRoutes {
+		if path == route.path || strings.HasPrefix(path, route.path+"/") {
+			return route.scope, route.keyedWrites
+		}
+	}
+	return "", false
+}
+
+// Проверка ключа API перед маршрутами управления. Без API_KEYS маршруты открыты, как и прежде,
+// кроме изменений через /api/alerts и /api/recipients.
+// Ключи берутся из текущей конфигурации, поэтому добавленный или удаленный ключ действует
+// после перезагрузки конфигурации без перезапуска.
+func requireAPIKeys(store *ConfigStore, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope, keyedWrites := apiKeyScope(r.URL.Path)
+		config := store.Load()
+		if scope == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !config.APIKeys.Configured {
+			if keyedWrites && r.Method != http.MethodGet && r.Method != http.MethodHead {
+				logWarnf("Отклонен запрос %s %s: изменения через этот маршрут доступны только с API_KEYS", r.Method, r.URL.Path)
+				writeError(w, http.StatusForbidden, "изменения через этот маршрут доступны только с ключом API, задайте API_KEYS")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := config.APIKeys.authenticate(r, time.Now())
+		switch {
+		case key == nil:
+			w.Header().Set("WWW-Authenticate", `Bearer realm="windalerts"`)
+			// Браузер запрашивает ключ как пароль только по запросу базовой аутентификации
+			w.Header().Add("WWW-Authenticate", `Basic realm="windalerts", charset="UTF-8"`)
+			writeError(w, http.StatusUnauthorized, "требуется ключ API")
+		case !key.allows(scope):
+			logWarnf("Отклонен запрос %s %s: у ключа API %s нет области %s", r.Method, r.URL.Path, key.Name, scope)
+			writeError(w, http.StatusForbidden, "у ключа API нет доступа к области "+scope)
+		default:
+			logDebugf("Запрос %s %s с ключом API %s", r.Method, r.URL.Path, key.Name)
+			next.ServeHTTP(w, r)
+		}
+	})
+}
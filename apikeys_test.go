@@ -0,0 +1,137 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []APIKey
+		wantErr string
+	}{
+		{
+			name:  "одна область",
+			value: "ci=0123456789abcdef:trigger",
+			want:  []APIKey{{Name: "ci", Key: "0123456789abcdef", Scopes: []string{scopeTrigger}}},
+		},
+		{
+			name:  "несколько ключей, областей и срок действия",
+			value: " ci = 0123456789abcdef : trigger , CONFIG ; crm=fedcba9876543210:subscriptions:2026-12-31;",
+			want: []APIKey{
+				{Name: "ci", Key: "0123456789abcdef", Scopes: []string{scopeTrigger, scopeConfig}},
+				{Name: "crm", Key: "fedcba9876543210", Scopes: []string{scopeSubscriptions}, Expires: time.Date(2027, 1, 1, 0, 0, 0, 0, time.Local)},
+			},
+		},
+		{
+			name:  "замена ключа под тем же именем",
+			value: "ci=0123456789abcdef:*;ci=fedcba9876543210:*",
+			want: []APIKey{
+				{Name: "ci", Key: "0123456789abcdef", Scopes: []string{scopeAll}},
+				{Name: "ci", Key: "fedcba9876543210", Scopes: []string{scopeAll}},
+			},
+		},
+		{name: "без имени", value: "=0123456789abcdef:trigger", wantErr: "ожидается формат"},
+		{name: "без областей", value: "ci=0123456789abcdef", wantErr: "ожидается формат"},
+		{name: "лишние части", value: "ci=0123456789abcdef:trigger:2026-12-31:x", wantErr: "ожидается формат"},
+		{name: "короткий ключ", value: "ci=short:trigger", wantErr: "короче 16 символов"},
+		{name: "повтор ключа", value: "a=0123456789abcdef:trigger;b=0123456789abcdef:config", wantErr: "повторяется"},
+		{name: "неизвестная область", value: "ci=0123456789abcdef:admin", wantErr: "неизвестная область"},
+		{name: "пустые области", value: "ci=0123456789abcdef: , ", wantErr: "не заданы области"},
+		{name: "некорректная дата", value: "ci=0123456789abcdef:trigger:31.12.2026", wantErr: "дата окончания"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAPIKeys(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидалась %q", err, tt.wantErr)
+				}
+				if strings.Contains(err.Error(), "0123456789abcdef") {
+					t.Errorf("сообщение об ошибке содержит ключ: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("получено %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireAPIKeys(t *testing.T) {
+	keys, err := parseAPIKeys("ci=trigger-key-0000001:trigger;crm=subscr-key-0000001:subscriptions;admin=config-key-0000001:config;" +
+		"old=expired-key-000001:*:2000-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		config     APIKeysConfig
+		path       string
+		header     func(r *http.Request)
+		wantStatus int
+	}{
+		{"без API_KEYS маршрут открыт", APIKeysConfig{}, "/api/pause", nil, http.StatusTeapot},
+		{"без API_KEYS подтверждение по идентификатору закрыто", APIKeysConfig{}, "/api/alerts/alert-1/ack", nil, http.StatusForbidden},
+		{"без API_KEYS изменение получателей закрыто", APIKeysConfig{}, "/api/recipients", nil, http.StatusForbidden},
+		{"маршрут только для чтения", APIKeysConfig{Configured: true, Keys: keys}, "/api/v1/status", nil, http.StatusTeapot},
+		{"ссылка подтверждения", APIKeysConfig{Configured: true, Keys: keys}, "/ack/secret", nil, http.StatusTeapot},
+		{"без ключа", APIKeysConfig{Configured: true, Keys: keys}, "/api/pause", nil, http.StatusUnauthorized},
+		{"неизвестный ключ", APIKeysConfig{Configured: true, Keys: keys}, "/api/pause", bearer("unknown-key-000001"), http.StatusUnauthorized},
+		{"Bearer", APIKeysConfig{Configured: true, Keys: keys}, "/api/pause", bearer("trigger-key-0000001"), http.StatusTeapot},
+		{"X-API-Key", APIKeysConfig{Configured: true, Keys: keys}, "/api/alerts/alert-1/ack", func(r *http.Request) {
+			r.Header.Set("X-API-Key", "trigger-key-0000001")
+		}, http.StatusTeapot},
+		{"без нужной области", APIKeysConfig{Configured: true, Keys: keys}, "/api/recipients", bearer("trigger-key-0000001"), http.StatusForbidden},
+		{"вложенный маршрут", APIKeysConfig{Configured: true, Keys: keys}, "/api/recipients/a@b.c", bearer("subscr-key-0000001"), http.StatusTeapot},
+		{"истекший ключ", APIKeysConfig{Configured: true, Keys: keys}, "/api/pause", bearer("expired-key-000001"), http.StatusUnauthorized},
+		{"страница настроек без ключа", APIKeysConfig{Configured: true, Keys: keys}, "/settings", nil, http.StatusUnauthorized},
+		{"страница настроек с ключом другой области", APIKeysConfig{Configured: true, Keys: keys}, "/settings", func(r *http.Request) {
+			r.SetBasicAuth("admin", "trigger-key-0000001")
+		}, http.StatusForbidden},
+		{"страница настроек с паролем-ключом", APIKeysConfig{Configured: true, Keys: keys}, "/settings", func(r *http.Request) {
+			r.SetBasicAuth("любое имя", "config-key-0000001")
+		}, http.StatusTeapot},
+		{"ошибка разбора закрывает маршруты", APIKeysConfig{Configured: true}, "/api/events", bearer("trigger-key-0000001"), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &ConfigStore{}
+			store.current.Store(&Config{APIKeys: tt.config})
+			handler := requireAPIKeys(store, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			if tt.header != nil {
+				tt.header(req)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("статус %d, ожидался %d", rec.Code, tt.wantStatus)
+			}
+			if rec.Code == http.StatusUnauthorized && len(rec.Header().Values("WWW-Authenticate")) == 0 {
+				t.Error("ответ 401 без WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func bearer(key string) func(r *http.Request) {
+	return func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+key)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Настройки архива ответов прогноза: каждый полученный прогноз сохраняется в файл,
+// чтобы разбирать спорные случаи по тем данным, которые видел сервис
+type ForecastArchiveConfig struct {
+	Dir       string        // Каталог архива; пустая строка - архив отключен
+	Retention time.Duration // Срок хранения файлов; 0 - файлы не удаляются
+}
+
+// Загрузка настроек архива из FORECAST_ARCHIVE_DIR и FORECAST_ARCHIVE_RETENTION
+func loadForecastArchiveConfig() ForecastArchiveConfig {
+	cfg := ForecastArchiveConfig{Dir: os.Getenv("FORECAST_ARCHIVE_DIR")}
+
+	if envRetention := os.Getenv("FORECAST_ARCHIVE_RETENTION"); envRetention != "" {
+		if val, err := time.ParseDuration(envRetention); err == nil && val >= 0 {
+			cfg.Retention = val
+		} else {
+			logWarnf("Ошибка парсинга FORECAST_ARCHIVE_RETENTION: %v, файлы архива не удаляются", err)
+		}
+	}
+	return cfg
+}
+
+// Снимок ответа OpenWeatherMap с данными запроса
+type forecastSnapshot struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	Place     string          `json:"place"`
+	Lat       float64         `json:"lat"`
+	Lon       float64         `json:"lon"`
+	Status    int             `json:"status"`             // HTTP-статус ответа
+	Response  json.RawMessage `json:"response,omitempty"` // Ответ API без изменений
+	Body      string          `json:"body,omitempty"`     // Ответ, не являющийся JSON (страница ошибки прокси)
+}
+
+// Сохранение ответа прогноза в архив; ошибки записи не прерывают проверку
+func (c ForecastArchiveConfig) save(place string, location *GeoLocation, status int, body []byte, fetchedAt time.Time) {
+	if c.Dir == "" {
+		return
+	}
+
+	snapshot := forecastSnapshot{
+		FetchedAt: fetchedAt.UTC(),
+		Place:     place,
+		Lat:       location.Lat,
+		Lon:       location.Lon,
+		Status:    status,
+	}
+	if json.Valid(body) {
+		snapshot.Response = body
+	} else {
+		snapshot.Body = string(body)
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		logErrorf("Ошибка при формировании снимка прогноза: %v", err)
+		return
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		logErrorf("Ошибка при создании каталога архива прогнозов: %v", err)
+		return
+	}
+
+	// Имя файла: время получения (UTC) и пункт, например 20261015T060000Z-moscow.json;
+	// в лексикографическом порядке файлы идут по времени
+	name := fmt.Sprintf("%s-%s.json", fetchedAt.UTC().Format("20060102T150405Z"), archiveSlug(place))
+	path := filepath.Join(c.Dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		logErrorf("Ошибка при записи снимка прогноза: %v", err)
+		return
+	}
+
+	c.prune(fetchedAt)
+}
+
+// Удаление снимков старше срока хранения
+func (c ForecastArchiveConfig) prune(now time.Time) {
+	if c.Retention == 0 {
+		return
+	}
+	paths, err := filepath.Glob(filepath.Join(c.Dir, "*.json"))
+	if err != nil {
+		return
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || now.Sub(info.ModTime()) < c.Retention {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			logErrorf("Ошибка при удалении устаревшего снимка прогноза %s: %v", path, err)
+		}
+	}
+}
+
+// Название пункта для имени файла: нижний регистр, пробелы и разделители пути заменены дефисом
+func archiveSlug(place string) string {
+	parts := strings.FieldsFunc(strings.ToLower(place), func(r rune) bool {
+		return strings.ContainsRune(" ,/\\:*?\"<>|", r)
+	})
+	if len(parts) == 0 {
+		return "forecast"
+	}
+	return strings.Join(parts, "-")
+}
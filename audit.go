@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Решения в журнале аудита
+const (
+	auditAlert      = "alert"      // Предупреждение разослано
+	auditNoAlert    = "no_alert"   // Порог не превышен
+	auditSuppressed = "suppressed" // Порог превышен, но предупреждение не разослано (пауза, повтор)
+	auditSkipped    = "skipped"    // Проверка не выполнялась (день без уведомлений)
+	auditError      = "error"      // Прогноз не получен
+)
+
+// Запись журнала аудита: решение по одной проверке пункта и его объяснение
+type AuditRecord struct {
+	Time              time.Time `json:"time"`
+	City              string    `json:"city,omitempty"` // Пустая строка - все пункты
+	Decision          string    `json:"decision"`
+	Reason            string    `json:"reason"`
+	MaxWindGust       float64   `json:"max_wind_gust,omitempty"`
+	WindGustThreshold float64   `json:"wind_gust_threshold,omitempty"`
+	Severity          string    `json:"severity,omitempty"`
+	Rule              string    `json:"rule,omitempty"`
+	Reminder          bool      `json:"reminder,omitempty"`
+	HistoryID         int64     `json:"history_id,omitempty"`
+}
+
+// Журнал аудита (AUDIT_FILE): по строке JSON на каждое решение о предупреждении,
+// в том числе об отказе от него. Файл только дополняется, чтобы по нему можно было
+// восстановить, почему предупреждение было или не было отправлено в любой день.
+type AuditLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Журнал аудита по переменной окружения; в пробном запуске журнал не ведется
+func loadAuditLog(dryRun bool) *AuditLog {
+	path := os.Getenv("AUDIT_FILE")
+	if path == "" || dryRun {
+		return nil
+	}
+	return &AuditLog{path: path}
+}
+
+// Запись решения в конец файла
+func (a *AuditLog) record(r AuditRecord) {
+	if a == nil {
+		return
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	// Сравнения в объяснениях остаются читаемыми: > и < не заменяются на \u003e и \u003c
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(r); err != nil {
+		logErrorf("Ошибка при записи журнала аудита: %v", err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	file, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		logErrorf("Ошибка при записи журнала аудита %s: %v", a.path, err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		logErrorf("Ошибка при записи журнала аудита %s: %v", a.path, err)
+	}
+}
+
+// Запись решения по результату проверки. suppressedBy - причина, по которой превышение
+// порога не разослано; сводный отчет записывается отдельной строкой по каждому пункту.
+func (a *AuditLog) recordReport(report *AlertReport, suppressedBy string) {
+	if a == nil {
+		return
+	}
+	if len(report.Locations) > 0 {
+		for _, loc := range report.Locations {
+			a.recordReport(loc, suppressedBy)
+		}
+		return
+	}
+
+	r := AuditRecord{
+		Time:              report.CheckedAt,
+		City:              report.City,
+		Decision:          auditNoAlert,
+		Reason:            report.Reason,
+		MaxWindGust:       report.MaxWindGust,
+		WindGustThreshold: report.WindGustThreshold,
+		Severity:          report.Severity.String(),
+		Rule:              report.Rule,
+		Reminder:          report.Reminder,
+		HistoryID:         report.HistoryID,
+	}
+	if report.ExceedsThreshold {
+		r.Decision = auditAlert
+		if suppressedBy != "" {
+			r.Decision = auditSuppressed
+			r.Reason += "; " + suppressedBy
+		}
+	}
+	a.record(r)
+}
+
+// Запись пропущенной проверки в день без уведомлений
+func (a *AuditLog) recordBlackout(config *Config) {
+	if a == nil {
+		return
+	}
+	reason := "день без уведомлений, проверка не выполнялась"
+	if period, ok := config.Blackout.match(config.Clock.Now()); ok && period.Reason != "" {
+		reason = fmt.Sprintf("день без уведомлений (%s), проверка не выполнялась", period.Reason)
+	}
+	a.record(AuditRecord{Decision: auditSkipped, Reason: reason})
+}
+
+// Запись проверки, для которой не удалось получить прогноз
+func (a *AuditLog) recordForecastError(city string) {
+	a.record(AuditRecord{City: city, Decision: auditError, Reason: "прогноз не получен, порывы ветра не оценивались (подробности в журнале сервиса)"})
+}
+
+// Объяснение сработавшего правила: «правило wind_gust: wind_gust 17.40 > 15.00 ⇒ предупреждение (уровень желтый)»
+func ruleReason(rule AlertRule, value, threshold float64, severity Severity) string {
+	return fmt.Sprintf("правило %s: %s %.2f %s %.2f ⇒ предупреждение (уровень %s)",
+		rule.Name, rule.Metric, value, rule.Comparator, threshold, severity.Title())
+}
+
+// Объяснение отказа от предупреждения: «макс. порыв 13.40 м/с ≤ порог 15.00 м/с ⇒ без предупреждения»
+func calmReason(report *AlertReport, rules int) string {
+	if rules > 0 {
+		return fmt.Sprintf("ни одно из правил (%d) не сработало, макс. порыв %.2f м/с при пороге %.2f м/с ⇒ без предупреждения",
+			rules, report.MaxWindGust, report.WindGustThreshold)
+	}
+	return fmt.Sprintf("макс. порыв %.2f м/с ≤ порог %.2f м/с ⇒ без предупреждения", report.MaxWindGust, report.WindGustThreshold)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Команда audit: решения о предупреждениях из журнала аудита (AUDIT_FILE) за период.
+// Возвращает код завершения: 0 - успешно, 1 - ошибка чтения журнала, 2 - неверные аргументы.
+func runAuditCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("windalerts audit", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	from := fs.String("from", "", "с даты ГГГГ-ММ-ДД включительно (по умолчанию с первой записи)")
+	to := fs.String("to", "", "по дату ГГГГ-ММ-ДД включительно (по умолчанию по текущий момент)")
+	city := fs.String("city", "", "только указанный пункт (без учета регистра)")
+	decision := fs.String("decision", "", "только решения: alert, no_alert, suppressed, skipped или error")
+	format := fs.String("format", "text", "формат: text или json (строки JSON, как в файле)")
+	path := fs.String("file", "", "файл журнала аудита (по умолчанию AUDIT_FILE)")
+	profile := fs.String("profile", "", "профиль конфигурации (PROFILE)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Использование: windalerts audit [флаги]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "Неожиданные аргументы: %s\n", strings.Join(fs.Args(), " "))
+		return 2
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintln(stderr, "--format: ожидается text или json")
+		return 2
+	}
+	switch *decision {
+	case "", auditAlert, auditNoAlert, auditSuppressed, auditSkipped, auditError:
+	default:
+		fmt.Fprintln(stderr, "--decision: ожидается alert, no_alert, suppressed, skipped или error")
+		return 2
+	}
+	var since, until time.Time
+	if *from != "" {
+		t, err := time.ParseInLocation("2006-01-02", *from, time.Local)
+		if err != nil {
+			fmt.Fprintln(stderr, "--from: ожидается дата ГГГГ-ММ-ДД")
+			return 2
+		}
+		since = t
+	}
+	if *to != "" {
+		t, err := time.ParseInLocation("2006-01-02", *to, time.Local)
+		if err != nil {
+			fmt.Fprintln(stderr, "--to: ожидается дата ГГГГ-ММ-ДД")
+			return 2
+		}
+		until = t.AddDate(0, 0, 1)
+	}
+	if !until.IsZero() && !since.Before(until) {
+		fmt.Fprintln(stderr, "--to: дата раньше --from")
+		return 2
+	}
+
+	if *path == "" {
+		if err := loadCommandEnv(*profile, stderr); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		*path = os.Getenv("AUDIT_FILE")
+	}
+	if *path == "" {
+		fmt.Fprintln(stderr, "Журнал аудита не настроен: укажите AUDIT_FILE или --file")
+		return 1
+	}
+	file, err := os.Open(*path)
+	if err != nil {
+		fmt.Fprintf(stderr, "Ошибка при открытии журнала аудита: %v\n", err)
+		return 1
+	}
+	defer file.Close()
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	if *format == "text" {
+		fmt.Fprintln(tw, "ВРЕМЯ\tПУНКТ\tРЕШЕНИЕ\tОБЪЯСНЕНИЕ")
+	}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var r AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// Строка, оборванная при аварийной остановке, не мешает читать остальные
+			fmt.Fprintf(stderr, "%s:%d: %v\n", *path, line, err)
+			continue
+		}
+		if !since.IsZero() && r.Time.Before(since) || !until.IsZero() && !r.Time.Before(until) {
+			continue
+		}
+		// Пропуск дня без уведомлений относится ко всем пунктам
+		if *city != "" && r.City != "" && !strings.EqualFold(r.City, *city) {
+			continue
+		}
+		if *decision != "" && r.Decision != *decision {
+			continue
+		}
+
+		if *format == "json" {
+			fmt.Fprintf(stdout, "%s\n", scanner.Bytes())
+			continue
+		}
+		place := r.City
+		if place == "" {
+			place = "все пункты"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Time.Local().Format("2006-01-02 15:04"), place, r.Decision, r.Reason)
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(stderr, "Ошибка при чтении журнала аудита: %v\n", err)
+		return 1
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Период без уведомлений: даты начала и окончания включительно в формате 2006-01-02
+type blackoutPeriod struct {
+	From   string
+	To     string
+	Reason string
+}
+
+// Календарь дат без уведомлений (закрытие офиса, остановка производства)
+type BlackoutConfig struct {
+	Periods  []blackoutPeriod // Даты из BLACKOUT_DATES
+	ICalFile string           // Файл календаря .ics, перечитывается перед каждым запуском
+}
+
+// Загрузка календаря из BLACKOUT_DATES ("2026-12-31,2027-01-01..2027-01-08") и BLACKOUT_ICAL
+func loadBlackoutConfig() BlackoutConfig {
+	cfg := BlackoutConfig{ICalFile: os.Getenv("BLACKOUT_ICAL")}
+
+	for _, item := range parseList(os.Getenv("BLACKOUT_DATES")) {
+		period, err := parseBlackoutPeriod(item)
+		if err != nil {
+			logWarnf("Ошибка парсинга BLACKOUT_DATES: %v, период пропущен", err)
+			continue
+		}
+		cfg.Periods = append(cfg.Periods, period)
+	}
+
+	return cfg
+}
+
+// Разбор даты ГГГГ-ММ-ДД или диапазона ГГГГ-ММ-ДД..ГГГГ-ММ-ДД
+func parseBlackoutPeriod(value string) (blackoutPeriod, error) {
+	from, to, isRange := strings.Cut(value, "..")
+	if !isRange {
+		to = from
+	}
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+
+	for _, date := range []string{from, to} {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return blackoutPeriod{}, fmt.Errorf("некорректная дата %q", date)
+		}
+	}
+	if to < from {
+		return blackoutPeriod{}, fmt.Errorf("конец периода раньше начала: %q", value)
+	}
+
+	return blackoutPeriod{From: from, To: to}, nil
+}
+
+// Поиск периода без уведомлений, в который попадает указанный день
+func (c BlackoutConfig) match(day time.Time) (blackoutPeriod, bool) {
+	periods := c.Periods
+	if c.ICalFile != "" {
+		events, err := loadICalBlackouts(c.ICalFile, day.Location())
+		if err != nil {
+			logErrorf("Ошибка при чтении календаря %s: %v", c.ICalFile, err)
+		}
+		periods = append(periods[:len(periods):len(periods)], events...)
+	}
+
+	date := day.Format("2006-01-02")
+	for _, period := range periods {
+		if date >= period.From && date <= period.To {
+			return period, true
+		}
+	}
+	return blackoutPeriod{}, false
+}
+
+// Проверка календаря перед запуском: true, если запуск нужно пропустить
+func blackedOut(config *Config, name string) bool {
+	period, ok := config.Blackout.match(config.Clock.Now())
+	if !ok {
+		return false
+	}
+
+	if period.Reason != "" {
+		log.Printf("Сегодня день без уведомлений (%s), %s пропущена", period.Reason, name)
+	} else {
+		log.Printf("Сегодня день без уведомлений, %s пропущена", name)
+	}
+	return true
+}
+
+// Чтение событий календаря iCalendar (RFC 5545) как периодов без уведомлений.
+// Учитываются DTSTART, DTEND и SUMMARY; повторяющиеся события (RRULE) не разворачиваются.
+func loadICalBlackouts(path string, loc *time.Location) ([]blackoutPeriod, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Склейка перенесенных строк: продолжение начинается с пробела или табуляции
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var periods []blackoutPeriod
+	var inEvent bool
+	var start, end, summary string
+	var endExclusive bool
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		params := strings.Split(name, ";")
+		switch strings.ToUpper(params[0]) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				inEvent, start, end, summary, endExclusive = true, "", "", "", false
+			}
+		case "DTSTART":
+			if inEvent {
+				start = icalDate(params, value, loc)
+			}
+		case "DTEND":
+			if inEvent {
+				end = icalDate(params, value, loc)
+				// Для событий на весь день DTEND указывает на следующий день
+				endExclusive = !strings.Contains(value, "T")
+			}
+		case "SUMMARY":
+			if inEvent {
+				summary = strings.ReplaceAll(value, `\,`, ",")
+			}
+		case "END":
+			if !strings.EqualFold(value, "VEVENT") || !inEvent {
+				continue
+			}
+			inEvent = false
+			if start == "" {
+				continue
+			}
+			if end == "" {
+				end = start
+			} else if endExclusive {
+				if t, err := time.Parse("2006-01-02", end); err == nil && end > start {
+					end = t.AddDate(0, 0, -1).Format("2006-01-02")
+				}
+			}
+			periods = append(periods, blackoutPeriod{From: start, To: end, Reason: summary})
+		}
+	}
+
+	return periods, nil
+}
+
+// Дата из значения DTSTART/DTEND в часовом поясе города
+func icalDate(params []string, value string, loc *time.Location) string {
+	if !strings.Contains(value, "T") {
+		if t, err := time.Parse("20060102", value); err == nil {
+			return t.Format("2006-01-02")
+		}
+		return ""
+	}
+
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t.In(loc).Format("2006-01-02")
+	}
+
+	// Локальное время или время с TZID
+	eventLoc := loc
+	for _, param := range params[1:] {
+		if tzid, ok := strings.CutPrefix(param, "TZID="); ok {
+			if l, err := time.LoadLocation(tzid); err == nil {
+				eventLoc = l
+			}
+		}
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, eventLoc); err == nil {
+		return t.In(loc).Format("2006-01-02")
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBlackoutPeriod(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    blackoutPeriod
+		wantErr string
+	}{
+		{name: "одна дата", value: "2026-12-31", want: blackoutPeriod{From: "2026-12-31", To: "2026-12-31"}},
+		{name: "диапазон", value: "2027-01-01..2027-01-08", want: blackoutPeriod{From: "2027-01-01", To: "2027-01-08"}},
+		{name: "пробелы вокруг дат", value: " 2027-01-01 .. 2027-01-08 ", want: blackoutPeriod{From: "2027-01-01", To: "2027-01-08"}},
+		{name: "диапазон из одного дня", value: "2027-01-01..2027-01-01", want: blackoutPeriod{From: "2027-01-01", To: "2027-01-01"}},
+		{name: "конец раньше начала", value: "2027-01-08..2027-01-01", wantErr: "конец периода раньше начала"},
+		{name: "другой формат даты", value: "31.12.2026", wantErr: "некорректная дата"},
+		{name: "несуществующая дата", value: "2026-02-30", wantErr: "некорректная дата"},
+		{name: "открытый диапазон", value: "2027-01-01..", wantErr: "некорректная дата"},
+		{name: "пустое значение", value: "", wantErr: "некорректная дата"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBlackoutPeriod(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидалась %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("получено %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadICalBlackouts(t *testing.T) {
+	vladivostok := time.FixedZone("UTC+10", 10*60*60)
+
+	tests := []struct {
+		name   string
+		events string
+		want   []blackoutPeriod
+	}{
+		{
+			name:   "событие на весь день",
+			events: "DTSTART;VALUE=DATE:20261231\nDTEND;VALUE=DATE:20270101\nSUMMARY:Новый год\n",
+			want:   []blackoutPeriod{{From: "2026-12-31", To: "2026-12-31", Reason: "Новый год"}},
+		},
+		{
+			name:   "несколько дней",
+			events: "DTSTART;VALUE=DATE:20270101\nDTEND;VALUE=DATE:20270109\nSUMMARY:Каникулы\n",
+			want:   []blackoutPeriod{{From: "2027-01-01", To: "2027-01-08", Reason: "Каникулы"}},
+		},
+		{
+			name:   "без DTEND",
+			events: "DTSTART;VALUE=DATE:20270223\n",
+			want:   []blackoutPeriod{{From: "2027-02-23", To: "2027-02-23"}},
+		},
+		{
+			name:   "время UTC в часовом поясе города",
+			events: "DTSTART:20270307T200000Z\nDTEND:20270308T200000Z\nSUMMARY:Праздник\n",
+			want:   []blackoutPeriod{{From: "2027-03-08", To: "2027-03-09", Reason: "Праздник"}},
+		},
+		{
+			name:   "время с TZID",
+			events: "DTSTART;TZID=Europe/Moscow:20270501T120000\nDTEND;TZID=Europe/Moscow:20270501T200000\n",
+			want:   []blackoutPeriod{{From: "2027-05-01", To: "2027-05-02"}},
+		},
+		{
+			name:   "перенесенная строка и экранированная запятая",
+			events: "DTSTART;VALUE=DATE:20270612\nSUMMARY:День России\\, \n выходной\n",
+			want:   []blackoutPeriod{{From: "2027-06-12", To: "2027-06-12", Reason: "День России, выходной"}},
+		},
+		{
+			name:   "без DTSTART",
+			events: "SUMMARY:Без даты\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calendar := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\n" +
+				strings.ReplaceAll(tt.events, "\n", "\r\n") +
+				"END:VEVENT\r\nEND:VCALENDAR\r\n"
+			path := filepath.Join(t.TempDir(), "blackout.ics")
+			if err := os.WriteFile(path, []byte(calendar), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := loadICalBlackouts(path, vladivostok)
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("получено %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := loadICalBlackouts(filepath.Join(t.TempDir(), "missing.ics"), vladivostok); err == nil {
+		t.Error("нет ошибки для отсутствующего файла")
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Рекомендуемый интервал обновления календаря для программ, которые его учитывают
+const calendarRefreshInterval = "PT1H"
+
+// Период сильного ветра: соседние интервалы прогноза с превышением порога
+type windyPeriod struct {
+	City        string
+	Start, End  time.Time
+	MaxWindGust float64
+	Threshold   float64
+	Points      []WindGustForecast
+}
+
+// Периоды сильного ветра по пунктам. Основа - интервалы из выпущенных предупреждений истории;
+// последняя проверка пункта заменяет их на время, которое покрывает ее прогноз, поэтому
+// период, который по свежему прогнозу стих, из календаря пропадает.
+func windyPeriods(records []AlertRecord, reports []*AlertReport) []windyPeriod {
+	type point struct {
+		gust, threshold float64
+	}
+	points := map[string]map[int64]point{}
+	add := func(city string, forecasts []WindGustForecast, threshold float64) {
+		if points[city] == nil {
+			points[city] = map[int64]point{}
+		}
+		for _, f := range forecasts {
+			points[city][f.Time.Unix()] = point{gust: f.WindGust, threshold: threshold}
+		}
+	}
+
+	// Записи истории идут от новых к старым; более новое предупреждение уточняет прежнее
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Kind == recordAlert {
+			add(records[i].City, records[i].Forecasts, records[i].WindGustThreshold)
+		}
+	}
+	for _, report := range reports {
+		if len(report.Points) == 0 {
+			continue
+		}
+		from, to := report.Points[0].Time.Unix(), report.Points[len(report.Points)-1].Time.Unix()
+		for t := range points[report.City] {
+			if t >= from && t <= to {
+				delete(points[report.City], t)
+			}
+		}
+		if report.ExceedsThreshold {
+			add(report.City, report.Forecasts, report.WindGustThreshold)
+		}
+	}
+
+	var periods []windyPeriod
+	for city, byTime := range points {
+		times := make([]int64, 0, len(byTime))
+		for t := range byTime {
+			times = append(times, t)
+		}
+		sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+		var current *windyPeriod
+		for _, t := range times {
+			start, p := time.Unix(t, 0).UTC(), byTime[t]
+			forecast := WindGustForecast{Time: start, WindGust: p.gust}
+			if current != nil && current.End.Equal(start) {
+				current.End = start.Add(forecastStep)
+				current.MaxWindGust = max(current.MaxWindGust, p.gust)
+				current.Threshold = p.threshold
+				current.Points = append(current.Points, forecast)
+				continue
+			}
+			periods = append(periods, windyPeriod{
+				City:        city,
+				Start:       start,
+				End:         start.Add(forecastStep),
+				MaxWindGust: p.gust,
+				Threshold:   p.threshold,
+				Points:      []WindGustForecast{forecast},
+			})
+			current = &periods[len(periods)-1]
+		}
+	}
+	sort.Slice(periods, func(i, j int) bool {
+		if !periods[i].Start.Equal(periods[j].Start) {
+			return periods[i].Start.Before(periods[j].Start)
+		}
+		return periods[i].City < periods[j].City
+	})
+	return periods
+}
+
+// Формирование календаря iCalendar (RFC 5545) с событием на каждый период сильного ветра.
+// Время событий указывается в UTC, программы календаря показывают его в часовом поясе пользователя;
+// в описании события время прогноза указано по часовому поясу пункта.
+func renderICal(periods []windyPeriod, cfg FeedConfig, now time.Time) []byte {
+	calendarName, summary := "Сильный ветер", "Сильный ветер: %s, порывы до %s"
+	if cfg.Language == languageEN {
+		calendarName, summary = "Strong wind", "Strong wind: %s, gusts up to %s"
+	}
+	speed := func(ms float64) string { return formatSpeedIn(ms, cfg.Units, cfg.Language, 1) }
+	stamp := now.UTC().Format("20060102T150405Z")
+
+	var b strings.Builder
+	line := func(name, value string) {
+		b.WriteString(foldICalLine(name + ":" + value))
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//WindAlerts//Wind alerts//RU")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escapeICalText(calendarName))
+	line("X-PUBLISHED-TTL", calendarRefreshInterval)
+	line("REFRESH-INTERVAL;VALUE=DURATION", calendarRefreshInterval)
+	for _, period := range periods {
+		var description strings.Builder
+		if cfg.Language == languageEN {
+			fmt.Fprintf(&description, "Wind gusts exceed the safe threshold (%s).", speed(period.Threshold))
+		} else {
+			fmt.Fprintf(&description, "Порывы ветра превышают безопасный порог (%s).", speed(period.Threshold))
+		}
+		for _, p := range period.Points {
+			fmt.Fprintf(&description, "\n%s: %s", p.Time.In(now.Location()).Format("02.01 15:04"), speed(p.WindGust))
+		}
+
+		line("BEGIN", "VEVENT")
+		line("UID", calendarUID(period))
+		line("DTSTAMP", stamp)
+		line("DTSTART", period.Start.UTC().Format("20060102T150405Z"))
+		line("DTEND", period.End.UTC().Format("20060102T150405Z"))
+		line("SUMMARY", escapeICalText(fmt.Sprintf(summary, period.City, speed(period.MaxWindGust))))
+		line("DESCRIPTION", escapeICalText(description.String()))
+		line("LOCATION", escapeICalText(period.City))
+		// Событие не занимает время в календаре и не мешает назначать встречи
+		line("TRANSP", "TRANSPARENT")
+		if cfg.Link != "" {
+			line("URL", cfg.Link+"/feed.ics")
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return []byte(b.String())
+}
+
+// Постоянный идентификатор события: при обновлении календаря тот же период не дублируется
+func calendarUID(period windyPeriod) string {
+	sum := sha256.Sum256([]byte(period.City))
+	return fmt.Sprintf("wind-%d-%s@windalerts", period.Start.Unix(), hex.EncodeToString(sum[:6]))
+}
+
+// Экранирование текстового значения iCalendar
+func escapeICalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// Строка iCalendar с переносом через каждые 75 байт и окончанием CRLF; символы UTF-8 не разрываются
+func foldICalLine(s string) string {
+	const limit = 75
+	var b strings.Builder
+	width := limit
+	for len(s) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// Пробел в начале строки продолжения тоже занимает байт
+		width = limit - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+// GET /feed.ics - календарь периодов сильного ветра для подписки в Outlook и Google Календаре
+func registerCalendarRoute(mux *http.ServeMux, store *ConfigStore, history *AlertHistory) {
+	mux.HandleFunc("/feed.ics", func(w http.ResponseWriter, r *http.Request) {
+		config := store.Load()
+		periods := windyPeriods(history.Recent(feedSize), config.Dashboard.latest())
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Write(renderICal(periods, config.Feed, config.Clock.Now()))
+	})
+}
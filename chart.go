@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"strings"
+)
+
+// Идентификатор графика, встроенного в письмо: <img src="cid:forecast-chart.png">
+const chartContentID = "forecast-chart.png"
+
+// Цвета графика, те же, что на странице состояния
+var (
+	chartBackground = color.RGBA{0xfa, 0xfa, 0xfa, 0xff}
+	chartAxis       = color.RGBA{0xcc, 0xcc, 0xcc, 0xff}
+	chartLine       = color.RGBA{0x1c, 0x7e, 0xd6, 0xff}
+	chartThreshold  = color.RGBA{0xd9, 0x48, 0x0f, 0xff}
+	chartText       = color.RGBA{0x22, 0x22, 0x22, 0xff}
+)
+
+// Шрифт 3x5 для подписей PNG-графика: только цифры и знаки времени и скорости
+var chartGlyphs = map[rune][5]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", "###", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", "..#", "..#", "..#"},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+	':': {"...", ".#.", "...", ".#.", "..."},
+	'.': {"...", "...", "...", "...", ".#."},
+	'-': {"...", "...", "###", "...", "..."},
+	' ': {"...", "...", "...", "...", "..."},
+}
+
+// Увеличение шрифта PNG-графика: знак 6x10 пикселей
+const chartGlyphScale = 2
+
+// График порывов ветра за сегодня по последней проверке пункта; nil - сегодня точек прогноза нет
+func todayChart(report *AlertReport, config *Config) *dashboardChart {
+	today := *report
+	today.Points = nil
+	now := config.Clock.Now()
+	for _, p := range report.Points {
+		if sameDay(p.Time.In(now.Location()), now) {
+			today.Points = append(today.Points, p)
+		}
+	}
+	if len(today.Points) == 0 {
+		return nil
+	}
+	today.MaxWindGust = findMaxWindGust(today.Points)
+	chart := dashboardChartFor(&today, func(ms float64) string { return formatSpeedIn(ms, config.Units, config.Language, 1) })
+	return &chart
+}
+
+// Растровое изображение графика для писем: почтовые программы не показывают SVG.
+// Геометрия та же, что у SVG-графика; у подписей скорости нет обозначения единиц.
+func renderChartPNG(chart *dashboardChart) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, chart.Width, chart.Height))
+	for x := 0; x < chart.Width; x++ {
+		for y := 0; y < chart.Height; y++ {
+			img.Set(x, y, chartBackground)
+		}
+	}
+
+	left := float64(chart.Left)
+	drawLine(img, left, 8, left, chart.Base, 1, chartAxis)
+	drawLine(img, left, chart.Base, float64(chart.Width), chart.Base, 1, chartAxis)
+	// Пунктир порога: штрих 6 пикселей, промежуток 4
+	for x := left; x < float64(chart.Width); x += 10 {
+		drawLine(img, x, chart.ThresholdY, min(x+6, float64(chart.Width)), chart.ThresholdY, 1, chartThreshold)
+	}
+	for i := 1; i < len(chart.Points); i++ {
+		a, b := chart.Points[i-1], chart.Points[i]
+		drawLine(img, a.X, a.Y, b.X, b.Y, 2, chartLine)
+	}
+	for _, p := range chart.Points {
+		c := chartLine
+		if p.Above {
+			c = chartThreshold
+		}
+		fillCircle(img, p.X, p.Y, 3, c)
+	}
+
+	// Подписи: максимум шкалы и порог слева от оси, время под осью
+	glyphHeight := 5 * chartGlyphScale
+	drawText(img, speedNumber(chart.MaxLabel), chart.Left-4, 8, 1, chartText)
+	drawText(img, speedNumber(chart.ThresholdLabel), chart.Left-4, int(chart.ThresholdY)-glyphHeight/2, 1, chartThreshold)
+	for _, tick := range chart.Ticks {
+		drawText(img, tick.Label, int(tick.X), chart.Height-6-glyphHeight, 0.5, chartText)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("ошибка при формировании графика: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Число из подписи скорости без обозначения единиц
+func speedNumber(label string) string {
+	number, _, _ := strings.Cut(label, " ")
+	return number
+}
+
+// Отрезок толщиной width
+func drawLine(img *image.RGBA, x1, y1, x2, y2 float64, width int, c color.Color) {
+	steps := int(max(abs(x2-x1), abs(y2-y1))) + 1
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x, y := int(x1+(x2-x1)*t), int(y1+(y2-y1)*t)
+		for dx := 0; dx < width; dx++ {
+			for dy := 0; dy < width; dy++ {
+				img.Set(x+dx, y+dy, c)
+			}
+		}
+	}
+}
+
+// Закрашенный круг радиуса r
+func fillCircle(img *image.RGBA, cx, cy float64, r int, c color.Color) {
+	x0, y0 := int(cx), int(cy)
+	for dx := -r; dx <= r; dx++ {
+		for dy := -r; dy <= r; dy++ {
+			if dx*dx+dy*dy <= r*r {
+				img.Set(x0+dx, y0+dy, c)
+			}
+		}
+	}
+}
+
+// Подпись шрифтом chartGlyphs; anchor - доля ширины текста левее x: 0 - от x, 0.5 - по центру, 1 - до x
+func drawText(img *image.RGBA, text string, x, y int, anchor float64, c color.Color) {
+	advance := 4 * chartGlyphScale
+	x -= int(float64(len([]rune(text))*advance-chartGlyphScale) * anchor)
+	for _, r := range text {
+		glyph, ok := chartGlyphs[r]
+		if !ok {
+			glyph = chartGlyphs[' ']
+		}
+		for row, line := range glyph {
+			for col, pixel := range line {
+				if pixel != '#' {
+					continue
+				}
+				for dx := 0; dx < chartGlyphScale; dx++ {
+					for dy := 0; dy < chartGlyphScale; dy++ {
+						img.Set(x+col*chartGlyphScale+dx, y+row*chartGlyphScale+dy, c)
+					}
+				}
+			}
+		}
+		x += advance
+	}
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// GET /chart/today.png и /chart/today.svg - график порывов ветра за сегодня с линией порога
+// по последней проверке. Параметр city выбирает пункт, по умолчанию - первый по алфавиту.
+func registerChartRoutes(mux *http.ServeMux, store *ConfigStore) {
+	serve := func(w http.ResponseWriter, r *http.Request, render func(w http.ResponseWriter, chart *dashboardChart)) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+			return
+		}
+		config := store.Load()
+		city := r.URL.Query().Get("city")
+		var report *AlertReport
+		for _, latest := range config.Dashboard.latest() {
+			if city == "" || strings.EqualFold(latest.City, city) {
+				report = latest
+				break
+			}
+		}
+		if report == nil {
+			writeError(w, http.StatusNotFound, "проверок пункта еще не было")
+			return
+		}
+		chart := todayChart(report, config)
+		if chart == nil {
+			writeError(w, http.StatusNotFound, "на сегодня в проверяемом времени суток нет точек прогноза")
+			return
+		}
+		// График меняется после каждой проверки
+		w.Header().Set("Cache-Control", "no-cache")
+		render(w, chart)
+	}
+
+	mux.HandleFunc("/chart/today.png", func(w http.ResponseWriter, r *http.Request) {
+		serve(w, r, func(w http.ResponseWriter, chart *dashboardChart) {
+			data, err := renderChartPNG(chart)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(data)
+		})
+	})
+	mux.HandleFunc("/chart/today.svg", func(w http.ResponseWriter, r *http.Request) {
+		serve(w, r, func(w http.ResponseWriter, chart *dashboardChart) {
+			w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+			if err := forecastChartTemplate.Execute(w, chart); err != nil {
+				logErrorf("Ошибка при формировании графика: %v", err)
+			}
+		})
+	})
+}
+
+// PNG-график прогноза предупреждения для письма (EMAIL_CHART) в единицах и на языке получателя.
+// В отличие от /chart/today.png показывает весь проверяемый период, а не только сегодня.
+func emailChart(report *AlertReport) []byte {
+	if len(report.Points) == 0 || len(report.Locations) > 0 {
+		return nil
+	}
+	chart := dashboardChartFor(report, func(ms float64) string { return formatSpeedIn(ms, report.Units, report.Language, 1) })
+	data, err := renderChartPNG(&chart)
+	if err != nil {
+		logErrorf("%v, письмо отправляется без графика", err)
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+	_ "time/tzdata" // База часовых поясов для контейнеров без tzdata
+)
+
+// Часовой пояс контролируемого города, в котором планируется отправка и считается «текущий день».
+// Задается явно через TIMEZONE или определяется по смещению из ответа прогноза.
+type CityClock struct {
+	mu    sync.RWMutex
+	loc   *time.Location
+	fixed bool // Часовой пояс задан через TIMEZONE и не обновляется по прогнозу
+}
+
+// Загрузка часового пояса из переменной TIMEZONE (имя IANA, например Europe/Moscow)
+func loadCityClock() *CityClock {
+	c := &CityClock{loc: time.Local}
+
+	if envTZ := os.Getenv("TIMEZONE"); envTZ != "" {
+		if loc, err := time.LoadLocation(envTZ); err == nil {
+			c.loc = loc
+			c.fixed = true
+		} else {
+			logWarnf("Ошибка парсинга TIMEZONE: %v, часовой пояс будет определен по прогнозу", err)
+		}
+	}
+
+	return c
+}
+
+// Часовой пояс города
+func (c *CityClock) Location() *time.Location {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.loc
+}
+
+// Текущее время в часовом поясе города
+func (c *CityClock) Now() time.Time {
+	return time.Now().In(c.Location())
+}
+
+// Обновление часового пояса по смещению от UTC в секундах из ответа прогноза
+func (c *CityClock) updateOffset(offset int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fixed {
+		return
+	}
+	if _, current := time.Now().In(c.loc).Zone(); current == offset && c.loc != time.Local {
+		return
+	}
+
+	c.loc = time.FixedZone(formatUTCOffset(offset), offset)
+	log.Printf("Часовой пояс города: %s", c.loc)
+}
+
+// Имя часового пояса по смещению, например UTC+03:00
+func formatUTCOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	return fmt.Sprintf("UTC%c%02d:%02d", sign, offset/3600, offset%3600/60)
+}
+
+// Определение часового пояса города при запуске, если он не задан явно
+func resolveCityTimezone(config *Config) {
+	if config.Clock.fixed {
+		log.Printf("Часовой пояс города: %s", config.Clock.Location())
+		return
+	}
+
+	if _, err := getWeatherData(config); err != nil {
+		log.Printf("Не удалось определить часовой пояс города, используется часовой пояс сервера: %v", err)
+	}
+}
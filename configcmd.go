@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// Команда config: "config sample" выводит образец .env с пояснениями,
+// "config schema" - JSON Schema параметров конфигурации, "config keygen" и
+// "config encrypt" - ключ age и зашифрованное значение для файла конфигурации,
+// "config migrate" - обновление файла до текущей версии формата
+func runConfigCommand(args []string) int {
+	if len(args) >= 1 && args[0] == "encrypt" {
+		return runConfigEncrypt(args[1:])
+	}
+	if len(args) >= 1 && args[0] == "migrate" {
+		return runConfigMigrate(args[1:], os.Stdout, os.Stderr)
+	}
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Использование: windalerts config sample|schema|keygen|encrypt|migrate")
+		return 2
+	}
+
+	switch args[0] {
+	case "sample":
+		writeConfigSample(os.Stdout)
+	case "schema":
+		if err := writeConfigSchema(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	case "keygen":
+		if err := writeAgeKey(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Неизвестная команда config %s, ожидается sample, schema, keygen, encrypt или migrate\n", args[0])
+		return 2
+	}
+	return 0
+}
+
+// Новый ключ age в формате age-keygen: открытый ключ в комментарии, затем секретный
+func writeAgeKey(w io.Writer) error {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "# public key: %s\n%s\n", identity.Recipient(), identity)
+	return nil
+}
+
+// Шифрование значения из стандартного ввода для получателей age:
+// windalerts config encrypt age1... < smtp_password
+func runConfigEncrypt(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Использование: windalerts config encrypt <получатель age1...>... < значение")
+		return 2
+	}
+
+	var recipients []age.Recipient
+	for _, arg := range args {
+		recipient, err := age.ParseX25519Recipient(arg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	value, err := encryptValue(strings.TrimRight(string(data), "\r\n"), recipients)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(value)
+	return 0
+}
+
+// Образец файла .env: обязательные параметры заполнены примерами, необязательные закомментированы
+func writeConfigSample(w io.Writer) {
+	fmt.Fprintln(w, "# Образец конфигурации WindAlerts (windalerts config sample).")
+	fmt.Fprintln(w, "# Имена без префикса WINDALERTS_ тоже поддерживаются, но могут совпасть с переменными других программ.")
+	fmt.Fprintln(w, "# Любой параметр можно задать флагом --имя-через-дефис, а секрет - файлом через <ИМЯ>_FILE.")
+	fmt.Fprintln(w, "# Значение для именованного профиля задается как <ПРОФИЛЬ>__<ИМЯ>, например WINDALERTS_DACHA__CITY.")
+
+	for _, group := range configOptionGroups {
+		fmt.Fprintf(w, "\n# --- %s ---\n", group.Title)
+		for _, opt := range group.Options {
+			fmt.Fprintf(w, "\n# %s\n", capitalize(opt.describe()))
+
+			value := opt.Default
+			if value == "" {
+				value = opt.Example
+			}
+			if opt.Required || opt.Essential {
+				fmt.Fprintf(w, "%s%s=%s\n", envPrefix, opt.Name, quoteEnvValue(value))
+			} else {
+				fmt.Fprintf(w, "#%s%s=%s\n", envPrefix, opt.Name, quoteEnvValue(value))
+			}
+		}
+	}
+}
+
+// Пояснение к параметру для образца: описание, тип, допустимые значения и значение по умолчанию
+func (o configOption) describe() string {
+	var parts []string
+	switch o.Type {
+	case optEnum:
+		parts = append(parts, "одно из: "+strings.Join(o.Enum, ", "))
+	case optInt, optNumber:
+		if o.Bounded {
+			parts = append(parts, fmt.Sprintf("от %g до %g", o.Min, o.Max))
+		}
+	case optDuration:
+		parts = append(parts, "длительность, например 15m или 1h")
+	case optClock:
+		parts = append(parts, "ЧЧ:ММ")
+	case optList:
+		parts = append(parts, "через запятую")
+	case optBool:
+		parts = append(parts, "true или false")
+	}
+	if o.Default != "" {
+		parts = append(parts, "по умолчанию "+o.Default)
+	}
+	if o.Required {
+		parts = append(parts, "обязательно")
+	}
+
+	text := o.Help
+	if len(parts) > 0 {
+		text += " (" + strings.Join(parts, "; ") + ")"
+	}
+	return text
+}
+
+// Значение в кавычках, если в нем есть пробелы или спецсимволы .env
+func quoteEnvValue(value string) string {
+	if strings.ContainsAny(value, " #;\"'") {
+		return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+	}
+	return value
+}
+
+// Регулярные выражения для проверки строковых значений в схеме
+const (
+	schemaIntPattern      = `^-?[0-9]+$`
+	schemaNumberPattern   = `^-?[0-9]+(\.[0-9]+)?$`
+	schemaDurationPattern = `^(0|([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$`
+	schemaClockPattern    = `^([01]?[0-9]|2[0-3]):[0-5][0-9]$`
+)
+
+// JSON Schema параметров: объект с переменными окружения в качестве свойств. Числа и логические
+// значения допускаются и строками, как в .env и в разделе environment docker-compose.
+func writeConfigSchema(w io.Writer) error {
+	properties := map[string]any{}
+	var required []any
+	for _, group := range configOptionGroups {
+		for _, opt := range group.Options {
+			// Параметр допускается с префиксом WINDALERTS_ и без него
+			properties[opt.Name] = opt.schema()
+			properties[envPrefix+opt.Name] = opt.schema()
+			// Обязательный параметр можно передать и файлом секрета
+			if opt.Required {
+				required = append(required, schemaAnyOf(opt.Name, opt.Name+"_FILE"))
+			}
+		}
+	}
+	required = append(required, schemaAnyOf("CITY", "LOCATIONS_FILE"))
+	required = append(required, schemaAnyOf("EMAIL_TO", "EMAIL_TO_FILE", "RECIPIENTS_FILE"))
+
+	schema := map[string]any{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "WindAlerts",
+		"description": "Параметры конфигурации WindAlerts (переменные окружения)",
+		"type":        "object",
+		"properties":  properties,
+		"patternProperties": map[string]any{
+			"^[A-Z0-9_]+_FILE$":        map[string]any{"type": "string", "description": "путь к файлу со значением параметра"},
+			"^[A-Z0-9_]+__[A-Z0-9_]+$": map[string]any{"type": []string{"string", "number", "boolean"}, "description": "значение параметра в именованном профиле"},
+		},
+		"additionalProperties": false,
+		"allOf":                required,
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(schema)
+}
+
+// Условие схемы: задан хотя бы один из параметров, с префиксом WINDALERTS_ или без него
+func schemaAnyOf(names ...string) map[string]any {
+	var variants []any
+	for _, name := range names {
+		variants = append(variants,
+			map[string]any{"required": []string{name}},
+			map[string]any{"required": []string{envPrefix + name}})
+	}
+	return map[string]any{"anyOf": variants}
+}
+
+// Описание параметра в JSON Schema
+func (o configOption) schema() map[string]any {
+	s := map[string]any{"description": capitalize(o.Help)}
+
+	switch o.Type {
+	case optInt:
+		s["type"] = []string{"integer", "string"}
+		s["pattern"] = schemaIntPattern
+	case optNumber:
+		s["type"] = []string{"number", "string"}
+		s["pattern"] = schemaNumberPattern
+	case optBool:
+		s["type"] = []string{"boolean", "string"}
+		s["enum"] = []any{true, false, "true", "false", "1", "0"}
+	case optDuration:
+		s["type"] = "string"
+		s["pattern"] = schemaDurationPattern
+	case optClock:
+		s["type"] = "string"
+		s["pattern"] = schemaClockPattern
+	case optEnum:
+		s["type"] = "string"
+		s["enum"] = o.Enum
+	default:
+		s["type"] = "string"
+	}
+	if o.Bounded {
+		s["minimum"] = o.Min
+		s["maximum"] = o.Max
+	}
+	if o.Default != "" {
+		s["default"] = o.Default
+	}
+	if o.Example != "" {
+		s["examples"] = []string{o.Example}
+	}
+	if o.Secret {
+		s["writeOnly"] = true
+	}
+	return s
+}
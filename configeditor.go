@@ -0,0 +1,415 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Пользователь страницы настроек по умолчанию
+const defaultSettingsUser = "admin"
+
+// Параметры, которые можно изменить на странице настроек: пороги, расписание и получатели
+var editableOptions = []string{
+	"WIND_GUST_THRESHOLD", "WIND_GUST_ORANGE_THRESHOLD", "WIND_GUST_RED_THRESHOLD",
+	"NOTIFICATION_HOUR", "NOTIFICATION_MIN", "CHECK_WINDOW", "LOOKAHEAD_DAYS",
+	"SCHEDULE", "CRON_SCHEDULE", "POLL_INTERVAL",
+	"EMAIL_TO",
+}
+
+// Настройки страницы редактирования конфигурации
+type SettingsConfig struct {
+	User     string // Имя пользователя (SETTINGS_USER)
+	Password string // Пароль (SETTINGS_PASSWORD); пусто - страница отключена
+}
+
+// Загрузка настроек страницы редактирования из переменных окружения
+func loadSettingsConfig() SettingsConfig {
+	cfg := SettingsConfig{User: os.Getenv("SETTINGS_USER"), Password: os.Getenv("SETTINGS_PASSWORD")}
+	if cfg.User == "" {
+		cfg.User = defaultSettingsUser
+	}
+	return cfg
+}
+
+// Проверка имени пользователя и пароля базовой аутентификации
+func (c SettingsConfig) authorized(r *http.Request) bool {
+	user, password, ok := r.BasicAuth()
+	if !ok || c.Password == "" {
+		return false
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(c.User)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(c.Password)) == 1
+	return userOK && passwordOK
+}
+
+// Токен формы: браузер отправляет пароль базовой аутентификации с любым запросом,
+// поэтому без токена чужая страница могла бы отправить форму от имени администратора.
+// Токен подписан паролем или ключом API, с которым открыта страница.
+func settingsFormToken(r *http.Request) string {
+	mac := hmac.New(sha256.New, []byte(requestAPIKey(r)))
+	mac.Write([]byte("settings"))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Поле страницы настроек
+type settingsField struct {
+	Name     string
+	Help     string
+	Default  string
+	Value    string
+	Enum     []string
+	ReadOnly string // Причина, по которой значение нельзя изменить на странице
+}
+
+// Данные страницы настроек
+type settingsPage struct {
+	File     string
+	Token    string
+	Fields   []settingsField
+	Saved    bool
+	Problems []string
+}
+
+// Локальный файл, в который записываются изменения: последний слой конфигурации
+// с наивысшим приоритетом (по умолчанию .env.local или .env.<профиль>.local)
+func (s *ConfigStore) settingsFile() string {
+	for i := len(s.paths) - 1; i >= 0; i-- {
+		if !isRemoteConfig(s.paths[i]) {
+			return s.paths[i]
+		}
+	}
+	return ""
+}
+
+// Поля страницы с текущими значениями; значения, заданные в окружении процесса, флагами,
+// профилем или в файлах после редактируемого, на странице не изменить
+func (s *ConfigStore) settingsFields() []settingsField {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file := s.settingsFile()
+	var later map[string]string
+	for i, path := range s.paths {
+		if path == file {
+			later, _, _ = readConfigFiles(s.paths[i+1:])
+		}
+	}
+	profile := strings.TrimSpace(os.Getenv("PROFILE"))
+
+	options := map[string]configOption{}
+	for _, group := range configOptionGroups {
+		for _, opt := range group.Options {
+			options[opt.Name] = opt
+		}
+	}
+
+	fields := make([]settingsField, 0, len(editableOptions))
+	for _, name := range editableOptions {
+		opt := options[name]
+		field := settingsField{Name: name, Help: opt.Help, Default: opt.Default, Value: os.Getenv(name), Enum: opt.Enum}
+		switch {
+		case file == "":
+			field.ReadOnly = "нет локального файла конфигурации"
+		case s.protected[name] || s.protected[envPrefix+name]:
+			field.ReadOnly = "задано в окружении процесса или флагом"
+		case profile != "" && hasEnv(profilePrefix(profile)+name):
+			field.ReadOnly = "задано в профиле " + profile
+		default:
+			if _, ok := later[name]; ok {
+				field.ReadOnly = "задано в файле с более высоким приоритетом"
+			} else if _, ok := later[envPrefix+name]; ok {
+				field.ReadOnly = "задано в файле с более высоким приоритетом"
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// Задана ли переменная с префиксом WINDALERTS_ или без него
+func hasEnv(name string) bool {
+	_, ok := os.LookupEnv(name)
+	return ok || namespaced(name)
+}
+
+// Изменение параметров со страницы настроек: новые значения проверяются так же, как командой
+// validate, записываются в локальный файл конфигурации и применяются перезагрузкой.
+// Пустое значение удаляет параметр из файла. Возвращает найденные проблемы; при проблемах
+// и ошибках файл не изменяется.
+func (s *ConfigStore) editSettings(changes map[string]string) (configProblems, error) {
+	path, previous, problems, err := s.writeSettings(changes)
+	if err != nil || len(problems) > 0 {
+		return problems, err
+	}
+	if err := s.Reload(); err != nil {
+		// Прежний файл возвращается, чтобы при следующей перезагрузке не применились отвергнутые значения
+		if restoreErr := restoreFile(path, previous); restoreErr != nil {
+			logErrorf("Ошибка при восстановлении %s: %v", path, restoreErr)
+		} else if reloadErr := s.Reload(); reloadErr != nil {
+			logErrorf("Ошибка при перезагрузке конфигурации: %v", reloadErr)
+		}
+		return nil, err
+	}
+	log.Printf("Параметры изменены на странице настроек и записаны в %s: %s", path, strings.Join(sortedKeys(changes), ", "))
+	return nil, nil
+}
+
+// Проверка и запись изменений в файл; возвращает путь и прежнее содержимое файла
+func (s *ConfigStore) writeSettings(changes map[string]string) (string, []byte, configProblems, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.settingsFile()
+	if path == "" {
+		return "", nil, nil, errors.New("нет локального файла конфигурации")
+	}
+
+	var problems configProblems
+	for name, value := range changes {
+		if strings.ContainsAny(value, "\r\n") {
+			problems.add("%s: значение не может содержать перевод строки", name)
+		}
+	}
+	if len(problems) > 0 {
+		return path, nil, problems, nil
+	}
+
+	// Новые значения проверяются на копии окружения; проблемы, которые были
+	// и до изменения, не мешают сохранить страницу
+	env := snapshotEnv()
+	known := map[string]bool{}
+	for _, problem := range validateEnv(env.get) {
+		known[problem] = true
+	}
+	for name, value := range changes {
+		if value == "" {
+			delete(env, name)
+		} else {
+			env[name] = value
+		}
+	}
+	for _, problem := range validateEnv(env.get) {
+		if !known[problem] {
+			problems = append(problems, problem)
+		}
+	}
+	if len(problems) > 0 {
+		return path, nil, problems, nil
+	}
+
+	previous, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return path, nil, nil, fmt.Errorf("ошибка при чтении %s: %w", path, err)
+	}
+	if err := writeFileAtomic(path, []byte(rewriteEnvFile(string(previous), changes))); err != nil {
+		return path, nil, nil, fmt.Errorf("ошибка при записи %s: %w", path, err)
+	}
+	return path, previous, nil, nil
+}
+
+// Замена значений в тексте файла .env с сохранением комментариев и порядка строк.
+// Параметр записывается с префиксом WINDALERTS_ на месте первого присваивания
+// (с префиксом или без), остальные его присваивания удаляются; новые добавляются в конец.
+func rewriteEnvFile(data string, changes map[string]string) string {
+	written := map[string]bool{}
+	var out []string
+	lines := strings.Split(strings.TrimRight(data, "\n"), "\n")
+	if data == "" {
+		lines = nil
+	}
+	for _, line := range lines {
+		match := envAssignment.FindStringSubmatch(line)
+		if match == nil {
+			out = append(out, line)
+			continue
+		}
+		name := strings.TrimPrefix(match[2], envPrefix)
+		value, ok := changes[name]
+		if !ok {
+			out = append(out, line)
+			continue
+		}
+		if written[name] || value == "" {
+			written[name] = true
+			continue
+		}
+		written[name] = true
+		out = append(out, match[1]+envPrefix+name+"="+quoteEnvValue(value))
+	}
+	for _, name := range sortedKeys(changes) {
+		if value := changes[name]; !written[name] && value != "" {
+			out = append(out, envPrefix+name+"="+quoteEnvValue(value))
+		}
+	}
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// Возврат прежнего содержимого файла; nil - файла не было
+func restoreFile(path string, previous []byte) error {
+	if previous == nil {
+		return os.Remove(path)
+	}
+	return writeFileAtomic(path, previous)
+}
+
+// Запись файла через временный файл в том же каталоге: при сбое файл конфигурации
+// не остается записанным наполовину. Права прежнего файла сохраняются.
+func writeFileAtomic(path string, data []byte) error {
+	perm := os.FileMode(0o600)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Страница настроек
+var settingsPageTemplate = template.Must(template.New("settings").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Настройки предупреждений о ветре</title>
+<style>
+body { font-family: Arial, sans-serif; margin: 0 auto; padding: 16px; max-width: 720px; color: #222; }
+.saved { padding: 12px; border-radius: 6px; background: #e3f4e1; color: #1e6b1a; }
+.problems { padding: 12px; border-radius: 6px; background: #fde2dc; color: #a12a12; }
+label { display: block; margin-top: 14px; font-weight: bold; }
+input, select { width: 100%; box-sizing: border-box; padding: 6px; font-size: 1em; }
+button { margin-top: 20px; padding: 10px 20px; font-size: 16px; background-color: #337ab7; color: #ffffff; border: 0; border-radius: 4px; }
+.muted { color: #777; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Настройки</h1>
+{{- if .Saved}}
+<p class="saved">Изменения сохранены и применены.</p>
+{{- end}}
+{{- if .Problems}}
+<div class="problems"><p>Изменения не сохранены:</p><ul>
+{{- range .Problems}}<li>{{.}}</li>{{end}}
+</ul></div>
+{{- end}}
+{{- if .File}}
+<p class="muted">Изменения записываются в {{.File}}. Пустое поле - значение по умолчанию.</p>
+{{- end}}
+<form method="post">
+<input type="hidden" name="token" value="{{.Token}}">
+{{- range .Fields}}
+<label for="{{.Name}}">{{.Name}}</label>
+{{- if .Enum}}
+<select id="{{.Name}}" name="{{.Name}}"{{if .ReadOnly}} disabled{{end}}>
+<option value=""{{if eq .Value ""}} selected{{end}}>по умолчанию</option>
+{{- $value := .Value}}
+{{- range .Enum}}
+<option value="{{.}}"{{if eq . $value}} selected{{end}}>{{.}}</option>
+{{- end}}
+</select>
+{{- else}}
+<input id="{{.Name}}" name="{{.Name}}" value="{{.Value}}"{{if .Default}} placeholder="{{.Default}}"{{end}}{{if .ReadOnly}} disabled{{end}}>
+{{- end}}
+<div class="muted">{{.Help}}{{if .ReadOnly}}; не изменить: {{.ReadOnly}}{{end}}</div>
+{{- end}}
+<button type="submit">Сохранить</button>
+</form>
+</body>
+</html>`))
+
+// GET /settings - форма с текущими значениями, POST /settings - проверка, запись и перезагрузка.
+// При API_KEYS вход на страницу, как и на другие маршруты управления, проверяет requireAPIKeys
+// по ключу области config (в браузере - как пароль при любом имени пользователя), иначе -
+// базовая аутентификация SETTINGS_USER и SETTINGS_PASSWORD.
+func registerSettingsRoutes(mux *http.ServeMux, store *ConfigStore) {
+	mux.HandleFunc("/settings", func(w http.ResponseWriter, r *http.Request) {
+		config := store.Load()
+		if !config.APIKeys.Configured {
+			if config.Settings.Password == "" {
+				http.NotFound(w, r)
+				return
+			}
+			if !config.Settings.authorized(r) {
+				w.Header().Set("WWW-Authenticate", `Basic realm="windalerts", charset="UTF-8"`)
+				http.Error(w, "Требуется вход", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		page := settingsPage{File: store.settingsFile(), Token: settingsFormToken(r)}
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			if err := r.ParseForm(); err != nil {
+				writeError(w, http.StatusBadRequest, "форма: "+err.Error())
+				return
+			}
+			if !hmac.Equal([]byte(r.PostForm.Get("token")), []byte(page.Token)) {
+				http.Error(w, "Форма устарела, откройте страницу заново", http.StatusForbidden)
+				return
+			}
+			changes := map[string]string{}
+			for _, field := range store.settingsFields() {
+				values, ok := r.PostForm[field.Name]
+				if !ok || field.ReadOnly != "" {
+					continue
+				}
+				if value := strings.TrimSpace(values[0]); value != field.Value {
+					changes[field.Name] = value
+				}
+			}
+			if len(changes) > 0 {
+				problems, err := store.editSettings(changes)
+				if err != nil {
+					logErrorf("Ошибка при сохранении настроек: %v", err)
+					problems = append(problems, err.Error())
+				}
+				page.Problems = problems
+			}
+			page.Saved = len(page.Problems) == 0
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+			return
+		}
+
+		page.Fields = store.settingsFields()
+		// Отклоненные значения остаются в форме, чтобы их можно было исправить
+		if len(page.Problems) > 0 {
+			for i, field := range page.Fields {
+				if values, ok := r.PostForm[field.Name]; ok && field.ReadOnly == "" {
+					page.Fields[i].Value = strings.TrimSpace(values[0])
+				}
+			}
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := settingsPageTemplate.Execute(w, page); err != nil {
+			logErrorf("Ошибка при формировании страницы настроек: %v", err)
+		}
+	})
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Файл конфигурации по умолчанию
+const defaultConfigFile = ".env"
+
+// Интервал опроса удаленного файла конфигурации по умолчанию
+const defaultConfigRefreshInterval = 5 * time.Minute
+
+// Значение переменной с учетом префикса WINDALERTS_; используется до загрузки конфигурации
+func lookupEnv(name string) string {
+	if value, ok := os.LookupEnv(envPrefix + name); ok {
+		return value
+	}
+	return os.Getenv(name)
+}
+
+// Файлы конфигурации (CONFIG_FILE) через запятую: локальные файлы в формате .env или адреса HTTP(S)
+func configFilePaths() []string {
+	if paths := parseList(lookupEnv("CONFIG_FILE")); len(paths) > 0 {
+		return paths
+	}
+	return defaultConfigFiles()
+}
+
+// Слои файла .env по умолчанию, от низшего приоритета к высшему: общие настройки .env,
+// настройки профиля .env.<профиль>, локальные переопределения .env.local и .env.<профиль>.local.
+// Локальные файлы не предназначены для репозитория и переопределяют общие без их изменения.
+func defaultConfigFiles() []string {
+	paths := []string{defaultConfigFile}
+	profile := configFileProfile()
+	if profile != "" {
+		paths = append(paths, defaultConfigFile+"."+profile)
+	}
+	paths = append(paths, defaultConfigFile+".local")
+	if profile != "" {
+		paths = append(paths, defaultConfigFile+"."+profile+".local")
+	}
+	return paths
+}
+
+// Есть ли файл .env.<профиль> или .env.<профиль>.local; профиль может состоять только из него
+func profileFileExists(profile string) bool {
+	if lookupEnv("CONFIG_FILE") != "" {
+		return false
+	}
+	for _, path := range []string{defaultConfigFile + "." + profile, defaultConfigFile + "." + profile + ".local"} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Профиль для выбора файлов .env.<профиль>: из окружения и флагов, а если не задан там -
+// из .env.local или .env
+func configFileProfile() string {
+	if profile := strings.TrimSpace(lookupEnv("PROFILE")); profile != "" {
+		return profile
+	}
+	for _, path := range []string{defaultConfigFile + ".local", defaultConfigFile} {
+		values, err := godotenv.Read(path)
+		if err != nil {
+			continue
+		}
+		for _, name := range []string{envPrefix + "PROFILE", "PROFILE"} {
+			if profile := strings.TrimSpace(values[name]); profile != "" {
+				return profile
+			}
+		}
+	}
+	return ""
+}
+
+// Задан ли файл конфигурации адресом HTTP(S)
+func isRemoteConfig(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// Интервал опроса удаленного файла конфигурации (CONFIG_REFRESH_INTERVAL)
+func configRefreshInterval() time.Duration {
+	interval := defaultConfigRefreshInterval
+	if envInterval := lookupEnv("CONFIG_REFRESH_INTERVAL"); envInterval != "" {
+		if val, err := time.ParseDuration(envInterval); err != nil {
+			logWarnf("Ошибка парсинга CONFIG_REFRESH_INTERVAL: %v, используется значение по умолчанию", err)
+		} else if val > 0 {
+			interval = val
+		}
+	}
+	return interval
+}
+
+// Удаленный файл конфигурации в формате .env. Повторная загрузка выполняется условным
+// запросом If-None-Match: пока файл на сервере не изменился, сервер отвечает 304 без тела.
+type remoteConfigFile struct {
+	url string
+
+	mu      sync.Mutex
+	etag    string            // ETag последнего полученного ответа
+	values  map[string]string // Переменные из последнего полученного файла
+	fetched bool
+}
+
+var (
+	remoteConfigMu    sync.Mutex
+	remoteConfigFiles = map[string]*remoteConfigFile{}
+)
+
+// Удаленный файл конфигурации по адресу; загруженные значения сохраняются между перезагрузками
+func remoteConfig(url string) *remoteConfigFile {
+	remoteConfigMu.Lock()
+	defer remoteConfigMu.Unlock()
+
+	f, ok := remoteConfigFiles[url]
+	if !ok {
+		f = &remoteConfigFile{url: url}
+		remoteConfigFiles[url] = f
+	}
+	return f
+}
+
+// Запрос файла с сервера; возвращает true, если получена новая версия
+func (f *remoteConfigFile) refresh(ctx context.Context) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при создании запроса: %w", err)
+	}
+	if f.etag != "" {
+		req.Header.Set("If-None-Match", f.etag)
+	}
+	if token := lookupEnv("CONFIG_AUTH_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при загрузке конфигурации: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+	default:
+		return false, fmt.Errorf("сервер конфигурации вернул статус %d", resp.StatusCode)
+	}
+
+	values, err := godotenv.Parse(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при разборе конфигурации: %w", err)
+	}
+	// Сервер без поддержки ETag отвечает 200 каждый раз: новой версией считается измененное содержимое
+	changed := !f.fetched || !sameValues(values, f.values)
+	f.values, f.etag, f.fetched = values, resp.Header.Get("ETag"), true
+	return changed, nil
+}
+
+// Переменные из файла; при первом обращении файл загружается с сервера
+func (f *remoteConfigFile) read() (map[string]string, error) {
+	f.mu.Lock()
+	fetched := f.fetched
+	f.mu.Unlock()
+	if !fetched {
+		if _, err := f.refresh(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	values := make(map[string]string, len(f.values))
+	for name, value := range f.values {
+		values[name] = value
+	}
+	return values, nil
+}
+
+// Совпадают ли наборы переменных
+func sameValues(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if other, ok := b[name]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Чтение переменных из файла конфигурации: локального или удаленного
+func readConfigFile(path string) (map[string]string, error) {
+	if isRemoteConfig(path) {
+		return remoteConfig(path).read()
+	}
+	return godotenv.Read(path)
+}
+
+// Объединение файлов конфигурации в порядке перечисления: значение из следующего файла
+// заменяет значение из предыдущего. Отсутствующие локальные файлы пропускаются и
+// возвращаются в missing; удаленный файл обязан быть доступен.
+func readConfigFiles(paths []string) (values map[string]string, missing []string, err error) {
+	values = map[string]string{}
+	for _, path := range paths {
+		fileValues, err := readConfigFile(path)
+		if err != nil {
+			if !isRemoteConfig(path) && errors.Is(err, os.ErrNotExist) {
+				missing = append(missing, path)
+				continue
+			}
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for name, value := range fileValues {
+			values[name] = value
+		}
+	}
+	return values, missing, nil
+}
+
+// Имена переменных из последней загрузки файлов конфигурации (для строгого режима)
+var configFileNames []string
+
+// Загрузка файлов конфигурации в окружение процесса. Как и godotenv.Load,
+// не переопределяет уже заданные переменные окружения и флаги.
+func loadConfigFiles() (missing []string, err error) {
+	values, missing, err := readConfigFiles(configFilePaths())
+	if err != nil {
+		return nil, err
+	}
+	configFileNames = sortedKeys(values)
+	for name, value := range values {
+		if _, set := os.LookupEnv(name); !set {
+			os.Setenv(name, value)
+		}
+	}
+	return missing, nil
+}
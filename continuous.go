@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Настройки непрерывного режима: прогноз опрашивается периодически, а не раз в день
+type PollConfig struct {
+	Interval     time.Duration // Обычный интервал опроса; 0 - непрерывный режим отключен
+	NearInterval time.Duration // Интервал опроса, когда прогноз близок к порогу
+	NearRatio    float64       // Близость к порогу как доля от него (0.2 - в пределах 20 %)
+}
+
+// Загрузка настроек непрерывного режима из переменных окружения
+func loadPollConfig() PollConfig {
+	cfg := PollConfig{
+		NearInterval: 15 * time.Minute,
+		NearRatio:    0.2,
+	}
+
+	if envInterval := os.Getenv("POLL_INTERVAL"); envInterval != "" {
+		if val, err := time.ParseDuration(envInterval); err == nil && val > 0 {
+			cfg.Interval = val
+		} else {
+			logWarnf("Ошибка парсинга POLL_INTERVAL: %v, непрерывный режим отключен", err)
+		}
+	}
+
+	if envNear := os.Getenv("POLL_INTERVAL_NEAR"); envNear != "" {
+		if val, err := time.ParseDuration(envNear); err == nil && val > 0 {
+			cfg.NearInterval = val
+		} else {
+			logWarnf("Ошибка парсинга POLL_INTERVAL_NEAR: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envRatio := os.Getenv("POLL_NEAR_RATIO"); envRatio != "" {
+		if val, err := strconv.ParseFloat(envRatio, 64); err == nil && val >= 0 {
+			cfg.NearRatio = val
+		} else {
+			logWarnf("Ошибка парсинга POLL_NEAR_RATIO: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	// Частый опрос не должен оказаться реже обычного
+	if cfg.Interval > 0 && cfg.NearInterval > cfg.Interval {
+		cfg.NearInterval = cfg.Interval
+	}
+
+	return cfg
+}
+
+// Интервал до следующего опроса: чаще, если максимальный порыв близок к порогу
+func (c PollConfig) intervalFor(report *AlertReport) time.Duration {
+	if math.Abs(report.MaxWindGust-report.WindGustThreshold) <= c.NearRatio*report.WindGustThreshold {
+		return c.NearInterval
+	}
+	return c.Interval
+}
+
+// Непрерывный режим: уведомления рассылаются при смене уровня опасности,
+// а не при каждом опросе
+type continuousScheduler struct {
+	store      *ConfigStore
+	dispatcher *Dispatcher
+	next       atomic.Int64 // Время следующего опроса, Unix в наносекундах
+}
+
+func (s *continuousScheduler) Name() string {
+	config := s.store.Load()
+	return fmt.Sprintf("непрерывный опрос каждые %s (вблизи порога - каждые %s)",
+		config.Poll.Interval, config.Poll.NearInterval)
+}
+
+func (s *continuousScheduler) Next(time.Time) time.Time {
+	if next := s.next.Load(); next != 0 {
+		return time.Unix(0, next)
+	}
+	return time.Time{}
+}
+
+func (s *continuousScheduler) Run(ctx context.Context) {
+	dispatcher := s.dispatcher
+
+	// Последний разосланный результат по каждому пункту
+	last := map[string]*AlertReport{}
+	for {
+		config := s.store.Load()
+		interval := config.Poll.Interval
+		config.Health.checked(time.Now())
+
+		ok := true
+		if blackedOut(config, "проверка") {
+			// В день без уведомлений состояние сбрасывается, чтобы после него предупреждение пришло снова
+			clear(last)
+			if dispatcher.store.leading() {
+				config.Audit.recordBlackout(config)
+			}
+		} else {
+			// Пока рассылка приостановлена или экземпляр в резерве, уведомление не отправляется:
+			// результат не запоминается, чтобы после возобновления текущий уровень разослать снова
+			held := dispatcher.pause.Paused() || !dispatcher.store.leading()
+
+			var reports, changed, unchanged []*AlertReport
+			for _, target := range pollTargets(config) {
+				report := evaluateWeather(target)
+				if report == nil {
+					ok = false
+					if dispatcher.store.leading() {
+						config.Audit.recordForecastError(target.placeName())
+					}
+					continue
+				}
+				// Опрос учащается, если к порогу близок хотя бы один пункт
+				if near := config.Poll.intervalFor(report); near < interval {
+					interval = near
+				}
+				reports = append(reports, report)
+
+				key := target.placeName()
+				if prev := last[key]; prev == nil || report.Severity != prev.Severity {
+					log.Printf("%s: уровень опасности: %s (максимальный порыв %.2f м/с), рассылаю результат проверки",
+						report.City, report.Severity.Title(), report.MaxWindGust)
+					changed = append(changed, report)
+				} else {
+					log.Printf("%s: уровень опасности не изменился (%s), уведомления не требуются",
+						report.City, report.Severity.Title())
+					unchanged = append(unchanged, report)
+				}
+				if held {
+					delete(last, key)
+				} else {
+					last[key] = report
+				}
+			}
+			for _, report := range reports {
+				report.NextCheck = report.CheckedAt.Add(interval)
+			}
+
+			// Сводное предупреждение рассылается целиком, если уровень изменился хотя бы по одному пункту
+			if len(changed) > 0 && config.Locations.Report == locationsCombined {
+				dispatcher.Dispatch(combineReports(reports))
+			} else {
+				for _, report := range changed {
+					dispatcher.Dispatch(report)
+				}
+				if dispatcher.store.leading() {
+					for _, report := range unchanged {
+						config.Audit.recordReport(report, "уровень опасности не изменился с прошлого опроса")
+					}
+				}
+			}
+
+			if len(reports) > 0 && interval == config.Poll.NearInterval && interval != config.Poll.Interval {
+				log.Printf("Прогноз близок к порогу, следующий опрос через %s", interval)
+			}
+		}
+		sendHeartbeat(config, dispatcher.store, ok)
+		config.Metrics.recordRun(time.Now(), ok, dispatcher.store.leading())
+
+		s.next.Store(time.Now().Add(interval).UnixNano())
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// Конфигурации опрашиваемых пунктов: все пункты LOCATIONS_FILE или основной город
+func pollTargets(config *Config) []*Config {
+	if len(config.Locations.List) == 0 {
+		return []*Config{config}
+	}
+	targets := make([]*Config, 0, len(config.Locations.List))
+	for _, loc := range config.Locations.List {
+		targets = append(targets, config.forLocation(loc))
+	}
+	return targets
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Выражение cron из пяти полей: минута, час, день месяца, месяц, день недели
+type CronSchedule struct {
+	minutes  []bool
+	hours    []bool
+	days     []bool
+	months   []bool
+	weekdays []bool
+	anyDay   bool // День месяца не ограничен ("*")
+	anyWeek  bool // День недели не ограничен ("*")
+	expr     string
+}
+
+// Разбор выражения cron: поддерживаются "*", списки, диапазоны и шаг ("*/15", "1-5", "6,9")
+func parseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("ожидается 5 полей (минута час день месяц день_недели), получено %q", expr)
+	}
+
+	c := &CronSchedule{expr: expr, anyDay: fields[2] == "*", anyWeek: fields[4] == "*"}
+	var err error
+	if c.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("минута: %w", err)
+	}
+	if c.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("час: %w", err)
+	}
+	if c.days, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("день месяца: %w", err)
+	}
+	if c.months, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("месяц: %w", err)
+	}
+	if c.weekdays, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("день недели: %w", err)
+	}
+	// Воскресенье можно указать как 0 или 7
+	if c.weekdays[7] {
+		c.weekdays[0] = true
+	}
+	// Выражение, которое никогда не срабатывает (например, 31 февраля), считается ошибкой
+	if !c.satisfiable() {
+		return nil, fmt.Errorf("выражение %q не срабатывает ни в один день", expr)
+	}
+
+	return c, nil
+}
+
+// Есть ли подходящий день за полный цикл из четырех лет, включая високосный
+func (c *CronSchedule) satisfiable() bool {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(4, 0, 0)
+	for t := start; t.Before(end); t = t.AddDate(0, 0, 1) {
+		if c.matchDay(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Разбор одного поля cron в набор допустимых значений
+func parseCronField(field string, min, max int) ([]bool, error) {
+	allowed := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return nil, fmt.Errorf("некорректный шаг %q", part)
+			}
+		}
+
+		from, to := min, max
+		if rangePart != "*" {
+			lo, hi, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if from, err = strconv.Atoi(lo); err != nil {
+				return nil, fmt.Errorf("некорректное значение %q", part)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(hi); err != nil {
+					return nil, fmt.Errorf("некорректное значение %q", part)
+				}
+			} else if hasStep {
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("значение %q вне диапазона %d-%d", part, min, max)
+		}
+
+		for v := from; v <= to; v += step {
+			allowed[v] = true
+		}
+	}
+	return allowed, nil
+}
+
+// Подходит ли день: как в cron, при ограничении обоих полей достаточно совпадения одного из них
+func (c *CronSchedule) matchDay(t time.Time) bool {
+	if !c.months[int(t.Month())] {
+		return false
+	}
+	day, weekday := c.days[t.Day()], c.weekdays[int(t.Weekday())]
+	switch {
+	case c.anyDay && c.anyWeek:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeek:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+// Ближайшее время запуска после указанного момента (в его часовом поясе)
+func (c *CronSchedule) Next(now time.Time) time.Time {
+	t := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute()+1, 0, 0, now.Location())
+
+	// Невозможные выражения отклоняются при разборе, ограничение поиска - страховка
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return limit
+}
+
+func (c *CronSchedule) String() string {
+	return c.expr
+}
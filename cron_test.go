@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr string
+	}{
+		{name: "каждый день", expr: "0 7 * * *"},
+		{name: "будни", expr: "30 6 * * 1-5"},
+		{name: "29 февраля", expr: "0 0 29 2 *"},
+		{name: "31 февраля или понедельник", expr: "0 0 31 2 1"},
+		{name: "31 февраля", expr: "0 0 31 2 *", wantErr: "не срабатывает ни в один день"},
+		{name: "30 февраля и 31 апреля", expr: "0 0 30,31 2 *", wantErr: "не срабатывает ни в один день"},
+		{name: "31 апреля", expr: "0 0 31 4,6,9,11 *", wantErr: "не срабатывает ни в один день"},
+		{name: "четыре поля", expr: "0 7 * *", wantErr: "ожидается 5 полей"},
+		{name: "час вне диапазона", expr: "0 24 * * *", wantErr: "вне диапазона"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseCron(tt.expr)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидалась %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+		})
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	now := time.Date(2026, time.February, 27, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{name: "сегодня позже", expr: "30 12 * * *", want: time.Date(2026, time.February, 27, 12, 30, 0, 0, time.UTC)},
+		{name: "завтра", expr: "0 7 * * *", want: time.Date(2026, time.February, 28, 7, 0, 0, 0, time.UTC)},
+		{name: "следующий понедельник", expr: "0 7 * * 1", want: time.Date(2026, time.March, 2, 7, 0, 0, 0, time.UTC)},
+		{name: "ближайшее 29 февраля", expr: "0 0 29 2 *", want: time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if got := cron.Next(now); !got.Equal(tt.want) {
+				t.Errorf("получено %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
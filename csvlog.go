@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Настройки ежедневного журнала максимальных порывов в CSV
+type DailyCSVConfig struct {
+	File      string // Путь к файлу; пустая строка - журнал не ведется
+	Delimiter rune   // Разделитель полей; ';' - формат Excel в русской локали: десятичная запятая и метка BOM
+}
+
+// Загрузка настроек журнала CSV из переменных окружения
+func loadDailyCSVConfig() DailyCSVConfig {
+	cfg := DailyCSVConfig{File: os.Getenv("DAILY_CSV_FILE"), Delimiter: ','}
+
+	switch envDelimiter := os.Getenv("DAILY_CSV_DELIMITER"); envDelimiter {
+	case "":
+	case ",", ";":
+		cfg.Delimiter = rune(envDelimiter[0])
+	case "tab", `\t`:
+		cfg.Delimiter = '\t'
+	default:
+		logWarnf("Ошибка парсинга DAILY_CSV_DELIMITER: ожидается ',', ';' или tab, получено %q, используется значение по умолчанию", envDelimiter)
+	}
+
+	return cfg
+}
+
+// Метка порядка байтов UTF-8
+const utf8BOM = "\ufeff"
+
+// Заголовок журнала
+var dailyCSVHeader = []string{"date", "city", "max_gust", "threshold", "unit", "alert_sent"}
+
+// Журнал CSV: одна строка на день и пункт с максимальным порывом по прогнозу,
+// порогом и признаком отправленного предупреждения. Повторные проверки в тот же
+// день обновляют строку, поэтому в непрерывном режиме строк не становится больше.
+type dailyCSVNotifier struct {
+	config DailyCSVConfig
+
+	mu sync.Mutex
+}
+
+func newDailyCSVNotifier(config DailyCSVConfig) *dailyCSVNotifier {
+	return &dailyCSVNotifier{config: config}
+}
+
+func (n *dailyCSVNotifier) Name() string {
+	return "csv"
+}
+
+func (n *dailyCSVNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	rows, err := n.read()
+	if err != nil {
+		return err
+	}
+
+	reports := []*AlertReport{report}
+	if len(report.Locations) > 0 {
+		reports = report.Locations
+	}
+	for _, r := range reports {
+		rows = n.upsert(rows, r)
+	}
+	return n.write(rows)
+}
+
+// Обновление строки дня и пункта или добавление новой в конец журнала
+func (n *dailyCSVNotifier) upsert(rows [][]string, report *AlertReport) [][]string {
+	row := []string{
+		report.CheckedAt.Format("2006-01-02"),
+		report.City,
+		n.formatNumber(convertSpeed(report.MaxWindGust, report.Units)),
+		n.formatNumber(convertSpeed(report.WindGustThreshold, report.Units)),
+		speedUnitLabel(report.Units, report.Language),
+		"no",
+	}
+	if report.ExceedsThreshold {
+		row[5] = "yes"
+	}
+
+	for i, existing := range rows {
+		if len(existing) < len(dailyCSVHeader) || existing[0] != row[0] || existing[1] != row[1] {
+			continue
+		}
+		// За день сохраняется наибольший порыв; отметка о предупреждении не снимается
+		if existing[4] == row[4] && n.parseNumber(existing[2]) > n.parseNumber(row[2]) {
+			row[2] = existing[2]
+		}
+		if existing[5] == "yes" {
+			row[5] = "yes"
+		}
+		rows[i] = row
+		return rows
+	}
+	return append(rows, row)
+}
+
+// Строки журнала без заголовка; отсутствующий файл - пустой журнал
+func (n *dailyCSVNotifier) read() ([][]string, error) {
+	f, err := os.Open(n.config.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении журнала CSV: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comma = n.config.Delimiter
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при разборе журнала CSV %s: %w", n.config.File, err)
+	}
+	if len(rows) > 0 && len(rows[0]) > 0 && strings.TrimPrefix(rows[0][0], utf8BOM) == dailyCSVHeader[0] {
+		rows = rows[1:]
+	}
+	return rows, nil
+}
+
+// Запись журнала с заголовком через временный файл
+func (n *dailyCSVNotifier) write(rows [][]string) error {
+	tmp := n.config.File + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("ошибка при записи журнала CSV: %w", err)
+	}
+
+	// Без метки BOM Excel открывает файл в кодировке Windows-1251
+	if n.config.Delimiter == ';' {
+		f.WriteString(utf8BOM)
+	}
+	w := csv.NewWriter(f)
+	w.Comma = n.config.Delimiter
+	w.Write(dailyCSVHeader)
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		f.Close()
+		return fmt.Errorf("ошибка при записи журнала CSV: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("ошибка при записи журнала CSV: %w", err)
+	}
+	return os.Rename(tmp, n.config.File)
+}
+
+// Число с двумя знаками; при разделителе ';' - с десятичной запятой
+func (n *dailyCSVNotifier) formatNumber(value float64) string {
+	s := strconv.FormatFloat(value, 'f', 2, 64)
+	if n.config.Delimiter == ';' {
+		s = strings.Replace(s, ".", ",", 1)
+	}
+	return s
+}
+
+func (n *dailyCSVNotifier) parseNumber(s string) float64 {
+	val, _ := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	return val
+}
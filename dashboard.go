@@ -0,0 +1,409 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Размеры графика прогноза на странице состояния, пиксели
+const (
+	dashboardChartWidth  = 640
+	dashboardChartHeight = 220
+	dashboardChartLeft   = 56 // Отступ под подписи оси скорости
+	dashboardChartBottom = 24 // Отступ под подписи оси времени
+)
+
+// Число последних записей истории за неделю на странице состояния
+const dashboardHistorySize = 15
+
+// Состояние сервиса для людей и интеграций: встроенная страница (DASHBOARD=true) по адресу /
+// HTTP-сервера и API /api/v1. Страница показывает сводку настроек, прогноз порывов по последней
+// проверке, выпущено ли сегодня предупреждение, следующую проверку и последние записи истории;
+// она только для чтения и обновляется в браузере раз в минуту.
+type Dashboard struct {
+	showPage bool // Страница по адресу / включена (DASHBOARD)
+
+	mu      sync.Mutex
+	reports map[string]*AlertReport       // Последний результат проверки по пункту
+	streams map[chan streamEvent]struct{} // Клиенты потока /api/v1/stream
+
+	done      chan struct{} // Закрывается при остановке сервиса
+	closeOnce sync.Once
+}
+
+// Создание состояния сервиса с настройкой страницы из переменной окружения
+func loadDashboard() *Dashboard {
+	d := &Dashboard{
+		reports: make(map[string]*AlertReport),
+		streams: make(map[chan streamEvent]struct{}),
+		done:    make(chan struct{}),
+	}
+	if envDashboard := os.Getenv("DASHBOARD"); envDashboard != "" {
+		if val, err := strconv.ParseBool(envDashboard); err == nil {
+			d.showPage = val
+		} else {
+			logWarnf("Ошибка парсинга DASHBOARD: %v, используется значение по умолчанию", err)
+		}
+	}
+	if d.showPage && os.Getenv("HTTP_ADDR") == "" {
+		logWarnf("DASHBOARD: страница состояния доступна только при заданном HTTP_ADDR")
+	}
+	return d
+}
+
+// Сохранение результата проверки пункта для графика прогноза и рассылка клиентам потока событий
+func (d *Dashboard) recordReport(report *AlertReport) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.reports[report.City] = report
+	d.mu.Unlock()
+	d.publish(streamEvent{report: report})
+}
+
+// Последние результаты проверок по пунктам в алфавитном порядке
+func (d *Dashboard) latest() []*AlertReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	reports := make([]*AlertReport, 0, len(d.reports))
+	for _, report := range d.reports {
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].City < reports[j].City })
+	return reports
+}
+
+// Данные страницы состояния
+type dashboardPage struct {
+	Title       string
+	Status      string
+	StatusClass string // ok, alert или unknown
+	Today       string // Выпущено ли сегодня предупреждение
+	Summary     []dashboardItem
+	Charts      []dashboardChart
+	History     []dashboardHistoryRow
+	GeneratedAt string
+}
+
+type dashboardItem struct {
+	Name, Value string
+}
+
+// График порывов ветра по точкам прогноза последней проверки пункта
+type dashboardChart struct {
+	City           string
+	CheckedAt      string
+	Summary        string
+	Alert          bool
+	Width, Height  int
+	Left, Bottom   int
+	Base           float64 // Нулевая отметка оси скорости
+	Line           string  // Точки ломаной в формате SVG polyline
+	Points         []dashboardPoint
+	ThresholdY     float64
+	ThresholdLabel string
+	MaxLabel       string
+	Ticks          []dashboardTick
+}
+
+type dashboardPoint struct {
+	X, Y  float64
+	Above bool
+	Title string
+}
+
+type dashboardTick struct {
+	X     float64
+	Label string
+}
+
+type dashboardHistoryRow struct {
+	Time, City, Kind, MaxWindGust, Threshold string
+	Alert                                    bool
+}
+
+// Маршруты API /api/v1 и, если она включена, страницы состояния /
+func (d *Dashboard) registerRoutes(mux *http.ServeMux, store *ConfigStore, scheduler Scheduler, history *AlertHistory, pause *PauseControl, notifiers []Notifier) {
+	d.registerAPIRoutes(mux, store, scheduler, history, pause)
+	if !d.showPage {
+		return
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		page := d.page(store.Load(), scheduler, history, pause, notifiers)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := dashboardTemplate.Execute(w, page); err != nil {
+			logErrorf("Ошибка при формировании страницы состояния: %v", err)
+		}
+	})
+}
+
+// Сбор данных страницы по текущей конфигурации
+func (d *Dashboard) page(config *Config, scheduler Scheduler, history *AlertHistory, pause *PauseControl, notifiers []Notifier) dashboardPage {
+	now := config.Clock.Now()
+	speed := func(ms float64) string { return formatSpeed(ms, config.Units, 1) }
+	page := dashboardPage{
+		Title:       config.placeName(),
+		Status:      "Проверок еще не было",
+		StatusClass: "unknown",
+		GeneratedAt: now.Format("02.01.2006 15:04"),
+	}
+
+	// Сводка настроек
+	places := []string{config.placeName()}
+	if len(config.Locations.List) > 0 {
+		places = places[:0]
+		for _, loc := range config.Locations.List {
+			places = append(places, loc.title())
+		}
+		page.Title = strings.Join(places, ", ")
+	}
+	window := config.CheckWindow.String()
+	if config.LookaheadDays > 0 {
+		window += fmt.Sprintf(", дней вперед: %d", config.LookaheadDays)
+	}
+	next := "не запланирована"
+	if t := scheduler.Next(now); !t.IsZero() {
+		next = t.In(now.Location()).Format("02.01.2006 15:04")
+	}
+	channels := make([]string, 0, len(notifiers))
+	for _, n := range notifiers {
+		channels = append(channels, n.Name())
+	}
+	delivery := "работает"
+	switch status := pause.Status(); {
+	case config.DryRun:
+		delivery = "пробный запуск, уведомления не отправляются"
+	case status.Paused && status.Until != nil:
+		delivery = "приостановлена до " + status.Until.In(now.Location()).Format("02.01.2006 15:04")
+	case status.Paused:
+		delivery = "приостановлена"
+	}
+	page.Summary = []dashboardItem{
+		{"Пункты", strings.Join(places, ", ")},
+		{"Порог порывов ветра", speed(config.WindGustThreshold)},
+		{"Проверяемое время суток", window},
+		{"Расписание", scheduler.Name()},
+		{"Следующая проверка", next},
+		{"Рассылка", delivery},
+		{"Каналы", strings.Join(channels, ", ")},
+		{"Получателей писем", strconv.Itoa(len(config.EmailTo))},
+	}
+
+	// Состояние и графики по последним проверкам
+	reports := d.latest()
+	if len(reports) > 0 {
+		page.Status, page.StatusClass = "Порывы ветра в норме", "ok"
+	}
+	for _, report := range reports {
+		page.Charts = append(page.Charts, dashboardChartFor(report, speed))
+	}
+	if worst := mostSevere(reports); worst != nil {
+		page.Status = fmt.Sprintf("Сильный ветер: уровень опасности %s (%s)", worst.Severity.Title(), worst.City)
+		page.StatusClass = "alert"
+	}
+
+	// Предупреждения за сегодня и последние записи истории
+	today := alertsOn(history, now)
+	page.Today = "Сегодня предупреждения не выпускались"
+	if len(today) > 0 {
+		last := today[len(today)-1]
+		page.Today = fmt.Sprintf("Сегодня выпущено предупреждений: %d, последнее в %s (%s, порывы до %s)",
+			len(today), last.IssuedAt.In(now.Location()).Format("15:04"), last.City, speed(last.MaxWindGust))
+	}
+	// Проверки и предупреждения за неделю, начиная с самой свежей записи
+	recent := history.Since(now.AddDate(0, 0, -7))
+	for i := len(recent) - 1; i >= 0 && len(page.History) < dashboardHistorySize; i-- {
+		record := recent[i]
+		kind := "без предупреждения"
+		if record.Kind == recordAlert {
+			kind = "предупреждение"
+		}
+		page.History = append(page.History, dashboardHistoryRow{
+			Time:        record.IssuedAt.In(now.Location()).Format("02.01.2006 15:04"),
+			City:        record.City,
+			Kind:        kind,
+			MaxWindGust: speed(record.MaxWindGust),
+			Threshold:   speed(record.WindGustThreshold),
+			Alert:       record.Kind == recordAlert,
+		})
+	}
+	return page
+}
+
+// Пункт с превышением порога и наибольшим уровнем опасности; nil - порог нигде не превышен
+func mostSevere(reports []*AlertReport) *AlertReport {
+	var worst *AlertReport
+	for _, report := range reports {
+		if report.ExceedsThreshold && (worst == nil || report.Severity > worst.Severity) {
+			worst = report
+		}
+	}
+	return worst
+}
+
+// Предупреждения, выпущенные в календарный день момента day, в хронологическом порядке
+func alertsOn(history *AlertHistory, day time.Time) []AlertRecord {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+	var alerts []AlertRecord
+	for _, record := range history.Since(start) {
+		if record.Kind == recordAlert && record.IssuedAt.Before(end) {
+			alerts = append(alerts, record)
+		}
+	}
+	return alerts
+}
+
+// График прогноза: ломаная порывов и линия порога; ось скорости начинается с нуля
+func dashboardChartFor(report *AlertReport, speed func(float64) string) dashboardChart {
+	chart := dashboardChart{
+		City:      report.City,
+		CheckedAt: report.CheckedAt.Format("02.01.2006 15:04"),
+		Summary:   fmt.Sprintf("Максимальный порыв %s при пороге %s", speed(report.MaxWindGust), speed(report.WindGustThreshold)),
+		Alert:     report.ExceedsThreshold,
+		Width:     dashboardChartWidth,
+		Height:    dashboardChartHeight,
+		Left:      dashboardChartLeft,
+		Bottom:    dashboardChartBottom,
+	}
+	points := report.Points
+	if len(points) == 0 {
+		return chart
+	}
+
+	top := max(report.MaxWindGust, report.WindGustThreshold) * 1.15
+	if top <= 0 {
+		top = 1
+	}
+	plotWidth := float64(dashboardChartWidth - dashboardChartLeft - 36)
+	plotHeight := float64(dashboardChartHeight - dashboardChartBottom - 8)
+	y := func(ms float64) float64 { return 8 + plotHeight*(1-ms/top) }
+	x := func(i int) float64 {
+		if len(points) == 1 {
+			return dashboardChartLeft + plotWidth/2
+		}
+		return dashboardChartLeft + plotWidth*float64(i)/float64(len(points)-1)
+	}
+
+	// Не больше шести подписей времени, при нескольких днях - с датой
+	layout := "15:04"
+	if !sameDay(points[0].Time, points[len(points)-1].Time) {
+		layout = "02.01 15:04"
+	}
+	step := (len(points) + 5) / 6
+	var line []string
+	for i, p := range points {
+		px, py := x(i), y(p.WindGust)
+		line = append(line, fmt.Sprintf("%.1f,%.1f", px, py))
+		chart.Points = append(chart.Points, dashboardPoint{
+			X: px, Y: py,
+			Above: p.WindGust > report.WindGustThreshold,
+			Title: fmt.Sprintf("%s: %s", p.Time.Format(layout), speed(p.WindGust)),
+		})
+		if i%step == 0 {
+			chart.Ticks = append(chart.Ticks, dashboardTick{X: px, Label: p.Time.Format(layout)})
+		}
+	}
+	chart.Line = strings.Join(line, " ")
+	chart.Base = y(0)
+	chart.ThresholdY = y(report.WindGustThreshold)
+	chart.ThresholdLabel = speed(report.WindGustThreshold)
+	chart.MaxLabel = speed(top)
+	return chart
+}
+
+// Совпадают ли календарные дни двух моментов
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// График порывов ветра: встраивается в страницу состояния и отдается отдельно по /chart/today.svg
+var forecastChartTemplate = template.Must(template.New("chart").Parse(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 {{.Width}} {{.Height}}" width="{{.Width}}" height="{{.Height}}" font-family="Arial, sans-serif" style="background: #fafafa;" role="img" aria-label="Порывы ветра по прогнозу">
+<line x1="{{.Left}}" y1="8" x2="{{.Left}}" y2="{{.Base}}" stroke="#ccc"/>
+<line x1="{{.Left}}" y1="{{.Base}}" x2="{{.Width}}" y2="{{.Base}}" stroke="#ccc"/>
+<text x="{{.Left}}" y="16" dx="-4" text-anchor="end" font-size="11">{{.MaxLabel}}</text>
+<line x1="{{.Left}}" y1="{{.ThresholdY}}" x2="{{.Width}}" y2="{{.ThresholdY}}" stroke="#d9480f" stroke-dasharray="6 4"/>
+<text x="{{.Left}}" y="{{.ThresholdY}}" dx="-4" dy="4" text-anchor="end" font-size="11" fill="#d9480f">{{.ThresholdLabel}}</text>
+<polyline points="{{.Line}}" fill="none" stroke="#1c7ed6" stroke-width="2"/>
+{{- range .Points}}
+<circle cx="{{.X}}" cy="{{.Y}}" r="3" fill="{{if .Above}}#d9480f{{else}}#1c7ed6{{end}}"><title>{{.Title}}</title></circle>
+{{- end}}
+{{- $height := .Height}}
+{{- range .Ticks}}
+<text x="{{.X}}" y="{{$height}}" dy="-6" text-anchor="middle" font-size="11">{{.Label}}</text>
+{{- end}}
+</svg>`))
+
+var dashboardTemplate = template.Must(template.Must(forecastChartTemplate.Clone()).New("dashboard").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta http-equiv="refresh" content="60">
+<title>Ветер: {{.Title}}</title>
+<style>
+body { font-family: Arial, sans-serif; margin: 0 auto; padding: 16px; max-width: 720px; color: #222; }
+.status { padding: 16px; border-radius: 6px; font-size: 1.3em; font-weight: bold; }
+.ok { background: #e3f4e1; color: #1e6b1a; }
+.alert { background: #fde2dc; color: #a12a12; }
+.unknown { background: #eee; color: #555; }
+table { border-collapse: collapse; width: 100%; margin: 8px 0 16px; }
+td, th { padding: 4px 8px; border-bottom: 1px solid #ddd; text-align: left; }
+tr.alert td { color: #a12a12; }
+svg { width: 100%; height: auto; background: #fafafa; }
+.muted { color: #777; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Ветер: {{.Title}}</h1>
+<div class="status {{.StatusClass}}">{{.Status}}</div>
+<p>{{.Today}}</p>
+
+<h2>Настройки</h2>
+<table>
+{{- range .Summary}}
+<tr><th>{{.Name}}</th><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+
+{{- range .Charts}}
+<h2>Прогноз: {{.City}}</h2>
+<p>{{.Summary}}. <span class="muted">Проверка {{.CheckedAt}}</span></p>
+{{- if .Points}}
+{{template "chart" .}}
+{{- else}}
+<p class="muted">В проверяемом времени суток нет точек прогноза.</p>
+{{- end}}
+{{- end}}
+
+<h2>История за неделю</h2>
+{{- if .History}}
+<table>
+<tr><th>Время</th><th>Пункт</th><th>Результат</th><th>Порывы</th><th>Порог</th></tr>
+{{- range .History}}
+<tr{{if .Alert}} class="alert"{{end}}><td>{{.Time}}</td><td>{{.City}}</td><td>{{.Kind}}</td><td>{{.MaxWindGust}}</td><td>{{.Threshold}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p class="muted">Записей пока нет.</p>
+{{- end}}
+
+<p class="muted">Обновлено {{.GeneratedAt}}. Страница обновляется раз в минуту.</p>
+</body>
+</html>
+`))
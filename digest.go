@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Названия дней недели для DIGEST_WEEKDAY
+var weekdayNames = map[string]time.Weekday{
+	"mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday, "thu": time.Thursday,
+	"fri": time.Friday, "sat": time.Saturday, "sun": time.Sunday,
+}
+
+// Названия дней недели для писем
+var weekdayTitles = [...]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"}
+
+// Настройки еженедельной сводки
+type DigestConfig struct {
+	Enabled bool
+	Weekday time.Weekday
+	Hour    int
+	Minute  int
+	EmailTo []string // Получатели (по умолчанию EMAIL_TO)
+}
+
+// Загрузка настроек еженедельной сводки из переменных окружения
+func loadDigestConfig() DigestConfig {
+	cfg := DigestConfig{
+		Weekday: time.Monday,
+		Hour:    9,
+		EmailTo: parseEmailList(os.Getenv("DIGEST_EMAIL_TO")),
+	}
+
+	if envWeekday := os.Getenv("DIGEST_WEEKDAY"); envWeekday != "" {
+		// Допускаются и полные английские названия: monday, tuesday...
+		key := strings.ToLower(envWeekday)
+		if len(key) > 3 {
+			key = key[:3]
+		}
+		if weekday, ok := weekdayNames[key]; ok {
+			cfg.Weekday = weekday
+		} else {
+			logWarnf("Ошибка парсинга DIGEST_WEEKDAY: неизвестный день недели %q, используется значение по умолчанию", envWeekday)
+		}
+	}
+
+	if envTime := os.Getenv("DIGEST_TIME"); envTime != "" {
+		if minutes, err := parseClock(envTime); err == nil {
+			cfg.Enabled = true
+			cfg.Hour, cfg.Minute = minutes/60, minutes%60
+		} else {
+			logWarnf("Ошибка парсинга DIGEST_TIME: %v, еженедельная сводка отключена", err)
+		}
+	}
+
+	return cfg
+}
+
+// Строка сводки за один день
+type DigestDay struct {
+	Date        time.Time
+	Weekday     string
+	Checked     bool    // Были ли проверки в этот день
+	MaxWindGust float64 // Максимальный порыв ветра по прогнозам проверок за день
+	Alerts      int     // Количество выпущенных предупреждений
+}
+
+// Структура данных для шаблона письма со сводкой
+type DigestEmailData struct {
+	City              string
+	From              time.Time
+	To                time.Time
+	Days              []DigestDay
+	Alerts            int
+	MaxWindGust       float64
+	WindGustThreshold float64
+	AccuracyDays      int             // Период скользящей статистики точности прогноза (ACCURACY_DAYS)
+	Accuracy          []AccuracyStats // Точность прогноза по пунктам; пусто - учет отключен или нет данных
+}
+
+// Шаблон для HTML письма со сводкой
+const digestEmailHTMLTemplateText = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Сводка за неделю</title>
+</head>
+<body style="margin: 0; padding: 0; background-color: #f4f4f4; font-family: Arial, sans-serif;">
+    <table border="0" cellpadding="0" cellspacing="0" width="100%" bgcolor="#f4f4f4" style="background-color: #f4f4f4;">
+        <tr>
+            <td align="center" style="padding: 20px 0;">
+                <table border="0" cellpadding="0" cellspacing="0" width="600" style="background-color: #ffffff; border-radius: 8px; max-width: 600px; width: 100%;">
+                    <tr>
+                        <td style="padding: 20px;">
+                            <h1 style="color: #337ab7; font-size: 24px; text-align: center; margin-top: 0; margin-bottom: 20px;">Сводка за неделю: {{.City}}</h1>
+                            <p style="font-size: 16px; line-height: 1.5; color: #333333;">{{.From.Format "02.01.2006"}}–{{.To.Format "02.01.2006"}}: выпущено предупреждений - <b>{{.Alerts}}</b>, максимальный порыв ветра - <b>{{speed .MaxWindGust 2}}</b> (порог {{speed .WindGustThreshold 2}}).</p>
+                            <table border="0" cellpadding="6" cellspacing="0" width="100%" style="font-size: 15px; color: #333333; border-collapse: collapse;">
+                                <tr style="background-color: #f4f4f4;"><th align="left">День</th><th align="right">Макс. порыв, {{unit}}</th><th align="right">Предупреждения</th></tr>
+                                {{range .Days}}<tr style="border-top: 1px solid #eeeeee;"><td>{{.Weekday}}, {{.Date.Format "02.01"}}</td>{{if .Checked}}<td align="right"{{if .Alerts}} style="color: #d9534f; font-weight: bold;"{{end}}>{{printf "%.2f" .MaxWindGust}}</td><td align="right">{{.Alerts}}</td>{{else}}<td align="right" colspan="2" style="color: #777777;">нет данных</td>{{end}}</tr>
+                                {{end}}
+                            </table>
+                            {{if .Accuracy}}<h2 style="color: #337ab7; font-size: 18px; margin-top: 20px;">Точность прогноза за {{.AccuracyDays}} дн.</h2>
+                            {{range .Accuracy}}<p style="font-size: 15px; line-height: 1.5; color: #333333;"><b>{{.Place}}</b> ({{.Days}} дн.): средняя ошибка максимального порыва {{speed .MeanError 1}}, наибольшая {{speed .MaxError 1}}; прогноз в среднем {{if .Overestimates}}завышает{{else}}занижает{{end}} порывы на {{speed .AbsBias 1}}. Превышение порога: предсказано и было - {{.Hits}}, не предсказано - {{.Misses}}, ложных - {{.FalseAlarms}}.</p>
+                            {{end}}{{end}}
+                            <p style="font-size: 14px; line-height: 1.5; color: #777777; text-align: center;">Это автоматическое уведомление от системы мониторинга погоды.</p>
+                        </td>
+                    </tr>
+                </table>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>`
+
+// Шаблон для текстового письма со сводкой
+const digestEmailPlainTextTemplate = `Сводка за неделю: {{.City}}
+
+{{.From.Format "02.01.2006"}}–{{.To.Format "02.01.2006"}}: выпущено предупреждений - {{.Alerts}}, максимальный порыв ветра - {{speed .MaxWindGust 2}} (порог {{speed .WindGustThreshold 2}}).
+{{range .Days}}
+- {{.Weekday}}, {{.Date.Format "02.01"}}: {{if .Checked}}{{speed .MaxWindGust 2}}, предупреждений: {{.Alerts}}{{else}}нет данных{{end}}{{end}}
+{{if .Accuracy}}
+Точность прогноза за {{.AccuracyDays}} дн.:{{range .Accuracy}}
+- {{.Place}} ({{.Days}} дн.): средняя ошибка максимального порыва {{speed .MeanError 1}}, наибольшая {{speed .MaxError 1}}; прогноз в среднем {{if .Overestimates}}завышает{{else}}занижает{{end}} порывы на {{speed .AbsBias 1}}. Превышение порога: предсказано и было - {{.Hits}}, не предсказано - {{.Misses}}, ложных - {{.FalseAlarms}}.{{end}}
+{{end}}
+Это автоматическое уведомление от системы мониторинга погоды.`
+
+// Ближайшее время еженедельного запуска после указанного момента
+func nextWeeklyTime(now time.Time, weekday time.Weekday, hour, minute int) time.Time {
+	next := nextDailyTime(now, hour, minute)
+	for next.Weekday() != weekday {
+		next = time.Date(next.Year(), next.Month(), next.Day()+1, hour, minute, 0, 0, next.Location())
+	}
+	return next
+}
+
+// Еженедельный запуск сводки
+func runDigestSchedule(ctx context.Context, store *ConfigStore, history *AlertHistory, state StateStore) {
+	config := store.Load()
+	cfg := config.Digest
+	next := func(now time.Time) time.Time { return nextWeeklyTime(now, cfg.Weekday, cfg.Hour, cfg.Minute) }
+
+	for waitUntil(ctx, config.Clock, next, "отправка еженедельной сводки") {
+		config := store.Load()
+		if blackedOut(config, "отправка еженедельной сводки") || standby(state, "отправка еженедельной сводки") {
+			continue
+		}
+		sendWeeklyDigest(config, history)
+	}
+}
+
+// Сводка по истории за семь полных дней, предшествующих текущему
+func buildWeeklyDigest(config *Config, history *AlertHistory) DigestEmailData {
+	now := config.Clock.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	from := today.AddDate(0, 0, -7)
+
+	data := DigestEmailData{
+		City:              config.City,
+		From:              from,
+		To:                today.AddDate(0, 0, -1),
+		WindGustThreshold: config.WindGustThreshold,
+		Accuracy:          config.Accuracy.Stats(now),
+	}
+	if config.Accuracy != nil {
+		data.AccuracyDays = config.Accuracy.config.Days
+	}
+	days := make(map[string]int)
+	for i := 0; i < 7; i++ {
+		date := from.AddDate(0, 0, i)
+		days[date.Format("2006-01-02")] = i
+		data.Days = append(data.Days, DigestDay{Date: date, Weekday: weekdayTitles[date.Weekday()]})
+	}
+
+	for _, record := range history.Since(from) {
+		i, ok := days[record.IssuedAt.In(now.Location()).Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+
+		day := &data.Days[i]
+		day.Checked = true
+		day.MaxWindGust = max(day.MaxWindGust, record.MaxWindGust)
+		data.MaxWindGust = max(data.MaxWindGust, record.MaxWindGust)
+		if record.Kind == recordAlert {
+			day.Alerts++
+			data.Alerts++
+		}
+	}
+
+	return data
+}
+
+// Формирование и отправка еженедельной сводки
+func sendWeeklyDigest(config *Config, history *AlertHistory) {
+	log.Println("Формирование еженедельной сводки...")
+
+	data := buildWeeklyDigest(config, history)
+	htmlBody, plainTextBody, err := renderEmailBodies(digestEmailHTMLTemplateText, digestEmailPlainTextTemplate, data, speedFuncs(config.Units, languageRU))
+	if err != nil {
+		logErrorf("Ошибка при формировании письма: %v\n", err)
+		return
+	}
+
+	recipients := config.Digest.EmailTo
+	if len(recipients) == 0 {
+		recipients = config.EmailTo
+	}
+
+	subject := fmt.Sprintf("Сводка по ветру за неделю: предупреждений - %d", data.Alerts)
+	if err := sendEmailTo(config, recipients, subject, htmlBody, plainTextBody); err != nil {
+		logErrorf("Ошибка при отправке еженедельной сводки: %v\n", err)
+	} else {
+		log.Println("Еженедельная сводка успешно отправлена")
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Допустимое расхождение часов с сервером OpenWeatherMap: заголовок Date точен до секунды,
+// а расписание проверок и дни без уведомлений считаются по системным часам
+const doctorMaxClockSkew = time.Minute
+
+// Результат одной проверки команды doctor
+type doctorResult struct {
+	name    string
+	err     error
+	detail  string // Подробности успешной или пропущенной проверки
+	skipped bool
+}
+
+// Команда doctor: самодиагностика окружения для обращений в поддержку. Проверяет
+// конфигурацию, DNS, доступность OpenWeatherMap, ключ API, вход на SMTP-сервер,
+// часы и запись в хранилище состояния; уведомления не отправляются.
+// Возвращает код завершения: 0 - все проверки пройдены, 1 - есть ошибки, 2 - неверные аргументы.
+func runDoctor(args []string, stdout io.Writer) int {
+	if err := applyFlags(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var results []doctorResult
+	config, err := loadConfig()
+	if err != nil {
+		results = append(results, doctorResult{name: "Конфигурация", err: err})
+		return printDoctorReport(stdout, results)
+	}
+	results = append(results, doctorConfig())
+
+	ctx := context.Background()
+	hosts := []string{"api.openweathermap.org", config.SMTPServer}
+	if config.Store.Backend == storeRedis {
+		if options, err := redis.ParseURL(config.Store.RedisURL); err == nil {
+			host, _, _ := net.SplitHostPort(options.Addr)
+			hosts = append(hosts, host)
+		}
+	}
+	for _, host := range hosts {
+		results = append(results, doctorDNS(ctx, host))
+	}
+
+	reachability, clock := doctorProvider(ctx, config)
+	results = append(results, reachability, clock)
+	results = append(results, doctorCheck(ctx, "Ключ OpenWeatherMap", func(ctx context.Context) error {
+		return preflightOpenWeather(ctx, config)
+	}))
+	results = append(results, doctorCheck(ctx, "SMTP: подключение и вход", func(ctx context.Context) error {
+		return preflightSMTP(ctx, config)
+	}))
+	results = append(results, doctorStateStore(ctx, config)...)
+
+	return printDoctorReport(stdout, results)
+}
+
+// Проверка с ограничением времени
+func doctorCheck(ctx context.Context, name string, check func(ctx context.Context) error) doctorResult {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return doctorResult{name: name, err: check(ctx)}
+}
+
+// Проблемы конфигурации, которые находит windalerts validate
+func doctorConfig() doctorResult {
+	problems := validateConfig()
+	if len(problems) == 0 {
+		return doctorResult{name: "Конфигурация"}
+	}
+	return doctorResult{name: "Конфигурация", err: fmt.Errorf("найдено проблем: %d, первая - %s (все: windalerts validate)", len(problems), problems[0])}
+}
+
+// Разрешение имени хоста через системный DNS
+func doctorDNS(ctx context.Context, host string) doctorResult {
+	name := "DNS " + host
+	if host == "" {
+		return doctorResult{name: "DNS", skipped: true, detail: "адрес не задан"}
+	}
+	if net.ParseIP(host) != nil {
+		return doctorResult{name: name, skipped: true, detail: "указан IP-адрес"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return doctorResult{name: name, err: err}
+	}
+	if len(addrs) > 3 {
+		addrs = append(addrs[:3], "...")
+	}
+	return doctorResult{name: name, detail: strings.Join(addrs, ", ")}
+}
+
+// Доступность OpenWeatherMap по HTTPS и расхождение часов с заголовком Date его ответа
+func doctorProvider(ctx context.Context, config *Config) (doctorResult, doctorResult) {
+	reachability := doctorResult{name: "Доступность api.openweathermap.org"}
+	clock := doctorResult{name: "Часы"}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, "https://api.openweathermap.org/", nil)
+	if err != nil {
+		reachability.err = err
+		clock.skipped, clock.detail = true, "нет ответа сервера для сравнения"
+		return reachability, clock
+	}
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		reachability.err = fmt.Errorf("%w. Проверьте доступ к сети, прокси (HTTPS_PROXY) и межсетевой экран", err)
+		clock.skipped, clock.detail = true, "нет ответа сервера для сравнения"
+		return reachability, clock
+	}
+	resp.Body.Close()
+	elapsed := time.Since(start)
+	reachability.detail = fmt.Sprintf("ответ за %s", elapsed.Round(time.Millisecond))
+
+	zone := config.Clock.Now().Format("MST -07:00")
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		clock.skipped, clock.detail = true, "сервер не передал время; часовой пояс "+zone
+		return reachability, clock
+	}
+	// Время ответа сравнивается с серединой запроса
+	skew := start.Add(elapsed / 2).Sub(serverTime).Round(time.Second)
+	if skew < -doctorMaxClockSkew || skew > doctorMaxClockSkew {
+		clock.err = fmt.Errorf("системные часы расходятся с сервером на %s. Включите синхронизацию времени (NTP)", skew)
+		return reachability, clock
+	}
+	clock.detail = fmt.Sprintf("расхождение с сервером %s, часовой пояс %s", skew, zone)
+	return reachability, clock
+}
+
+// Запись в хранилище состояния: JSON-файлы и bbolt - права на файл и каталог, Redis - запись временного ключа
+func doctorStateStore(ctx context.Context, config *Config) []doctorResult {
+	switch config.Store.Backend {
+	case storeRedis:
+		return []doctorResult{doctorCheck(ctx, "Хранилище состояния: Redis", func(ctx context.Context) error {
+			return doctorRedis(ctx, config.Store)
+		})}
+	case storeBolt:
+		return []doctorResult{doctorWritable("Хранилище состояния: "+config.Store.File, config.Store.File)}
+	}
+
+	var results []doctorResult
+	for _, file := range []string{config.RunStateFile, config.HistoryFile, config.Retry.File, config.Escalation.File, config.SubscriptionsFile, config.PauseFile} {
+		if file != "" {
+			results = append(results, doctorWritable("Файл состояния "+file, file))
+		}
+	}
+	if len(results) == 0 {
+		results = append(results, doctorResult{name: "Хранилище состояния", skipped: true, detail: "файлы *_FILE не заданы, состояние хранится в памяти"})
+	}
+	return results
+}
+
+// Можно ли записать файл: существующий открывается на запись без изменения, в каталоге
+// создается и удаляется временный файл (файлы состояния записываются через переименование)
+func doctorWritable(name, path string) doctorResult {
+	if file, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+		file.Close()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return doctorResult{name: name, err: err}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".windalerts-doctor-*")
+	if err != nil {
+		return doctorResult{name: name, err: fmt.Errorf("каталог недоступен для записи: %w", err)}
+	}
+	tmp.Close()
+	os.Remove(tmp.Name())
+	return doctorResult{name: name}
+}
+
+// Подключение к Redis и запись временного ключа с префиксом REDIS_PREFIX
+func doctorRedis(ctx context.Context, config StoreConfig) error {
+	if config.RedisURL == "" {
+		return errors.New("не указан REDIS_URL")
+	}
+	options, err := redis.ParseURL(config.RedisURL)
+	if err != nil {
+		return fmt.Errorf("ошибка в REDIS_URL: %w", err)
+	}
+	client := redis.NewClient(options)
+	defer client.Close()
+
+	key := config.RedisPrefix + "doctor"
+	if err := client.Set(ctx, key, time.Now().Format(time.RFC3339), time.Minute).Err(); err != nil {
+		return fmt.Errorf("%s: %w", redactedRedisURL(config.RedisURL), err)
+	}
+	return client.Del(ctx, key).Err()
+}
+
+// Вывод отчета; код завершения 1, если хотя бы одна проверка не пройдена
+func printDoctorReport(w io.Writer, results []doctorResult) int {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	failed := 0
+	for _, r := range results {
+		status, detail := "OK", r.detail
+		switch {
+		case r.err != nil:
+			status, detail = "ОШИБКА", r.err.Error()
+			failed++
+		case r.skipped:
+			status = "ПРОПУСК"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", status, r.name, detail)
+	}
+	tw.Flush()
+
+	if failed > 0 {
+		fmt.Fprintf(w, "\nПроверок с ошибками: %d из %d\n", failed, len(results))
+		return 1
+	}
+	fmt.Fprintf(w, "\nВсе проверки пройдены (%d)\n", len(results))
+	return 0
+}
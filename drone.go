@@ -0,0 +1,197 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Длительность интервала прогноза OpenWeatherMap (5 day / 3 hour)
+const forecastStep = 3 * time.Hour
+
+// Ограничения для полетов БПЛА
+type DroneConfig struct {
+	MaxWindGust   float64 // Максимально допустимые порывы ветра в м/с
+	MinVisibility int     // Минимальная видимость в метрах
+}
+
+// Окно времени, пригодное для полетов
+type FlightWindow struct {
+	Start       time.Time
+	End         time.Time
+	MaxWindGust float64 // Максимальный порыв ветра внутри окна
+}
+
+// Структура данных для шаблона письма с окнами для полетов
+type DroneEmailData struct {
+	Date          string
+	Windows       []FlightWindow
+	MaxWindGust   float64
+	MinVisibility int
+}
+
+// Шаблон для HTML письма с окнами для полетов
+const droneEmailHTMLTemplateText = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Окна для полетов</title>
+</head>
+<body style="margin: 0; padding: 0; background-color: #f4f4f4; font-family: Arial, sans-serif;">
+    <table border="0" cellpadding="0" cellspacing="0" width="100%" bgcolor="#f4f4f4" style="background-color: #f4f4f4;">
+        <tr>
+            <td align="center" style="padding: 20px 0;">
+                <table border="0" cellpadding="0" cellspacing="0" width="600" style="background-color: #ffffff; border-radius: 8px; max-width: 600px; width: 100%;">
+                    <tr>
+                        <td style="padding: 20px;">
+                            <h1 style="color: #337ab7; font-size: 24px; text-align: center; margin-top: 0; margin-bottom: 20px;">Окна для полетов на {{.Date}}</h1>
+                            {{if .Windows}}
+                            <p style="font-size: 16px; line-height: 1.5; color: #333333;">Безопасные интервалы для полетов (порывы ветра до {{speed .MaxWindGust 1}}, без осадков, видимость от {{.MinVisibility}} м):</p>
+                            <ul style="font-size: 16px; line-height: 1.5; color: #333333;">
+                                {{range .Windows}}<li><b>{{.Start.Format "15:04"}}–{{.End.Format "15:04"}}</b> (порывы до {{speed .MaxWindGust 1}})</li>
+                                {{end}}
+                            </ul>
+                            {{else}}
+                            <p style="font-size: 16px; line-height: 1.5; color: #d9534f; font-weight: bold;">Сегодня нет интервалов, пригодных для полетов.</p>
+                            {{end}}
+                            <p style="font-size: 14px; line-height: 1.5; color: #777777; text-align: center;">Это автоматическое уведомление от системы мониторинга погоды.</p>
+                        </td>
+                    </tr>
+                </table>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>`
+
+// Шаблон для текстового письма с окнами для полетов
+const droneEmailPlainTextTemplate = `Окна для полетов на {{.Date}}
+{{if .Windows}}
+Безопасные интервалы для полетов (порывы ветра до {{speed .MaxWindGust 1}}, без осадков, видимость от {{.MinVisibility}} м):
+{{range .Windows}}
+- {{.Start.Format "15:04"}}–{{.End.Format "15:04"}} (порывы до {{speed .MaxWindGust 1}}){{end}}
+{{else}}
+Сегодня нет интервалов, пригодных для полетов.
+{{end}}
+Это автоматическое уведомление от системы мониторинга погоды.`
+
+// Загрузка ограничений для полетов из переменных окружения
+func loadDroneConfig(units string) DroneConfig {
+	cfg := DroneConfig{
+		MaxWindGust:   10.0, // По умолчанию 10 м/с
+		MinVisibility: 5000, // По умолчанию 5 км
+	}
+
+	if envGust := os.Getenv("DRONE_MAX_GUST"); envGust != "" {
+		if val, err := strconv.ParseFloat(envGust, 64); err == nil {
+			cfg.MaxWindGust = toMetersPerSecond(val, units)
+		} else {
+			logWarnf("Ошибка парсинга DRONE_MAX_GUST: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envVisibility := os.Getenv("DRONE_MIN_VISIBILITY"); envVisibility != "" {
+		if val, err := strconv.Atoi(envVisibility); err == nil && val >= 0 {
+			cfg.MinVisibility = val
+		} else {
+			logWarnf("Ошибка парсинга DRONE_MIN_VISIBILITY: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	return cfg
+}
+
+// Проверка пригодности интервала прогноза для полетов
+func isFlyable(forecast DailyForecast, cfg DroneConfig) bool {
+	if forecast.Wind.Gust >= cfg.MaxWindGust {
+		return false
+	}
+	if forecast.Visibility < cfg.MinVisibility {
+		return false
+	}
+	if forecast.Rain.ThreeHours > 0 || forecast.Snow.ThreeHours > 0 {
+		return false
+	}
+	for _, w := range forecast.Weather {
+		switch w.Main {
+		case "Rain", "Drizzle", "Snow", "Thunderstorm":
+			return false
+		}
+	}
+	return true
+}
+
+// Поиск окон для полетов: соседние пригодные интервалы объединяются в одно окно
+func findFlightWindows(entries []DailyForecast, cfg DroneConfig) []FlightWindow {
+	var windows []FlightWindow
+	var current *FlightWindow
+
+	for _, forecast := range entries {
+		forecastTime := forecast.Time()
+
+		if !isFlyable(forecast, cfg) {
+			current = nil
+			continue
+		}
+
+		if current != nil && current.End.Equal(forecastTime) {
+			current.End = forecastTime.Add(forecastStep)
+			if forecast.Wind.Gust > current.MaxWindGust {
+				current.MaxWindGust = forecast.Wind.Gust
+			}
+			continue
+		}
+
+		windows = append(windows, FlightWindow{
+			Start:       forecastTime,
+			End:         forecastTime.Add(forecastStep),
+			MaxWindGust: forecast.Wind.Gust,
+		})
+		current = &windows[len(windows)-1]
+	}
+
+	return windows
+}
+
+// Подбор окон для полетов на текущий день и отправка утреннего сообщения; false - при ошибке
+func checkFlightWindowsAndNotify(config *Config) bool {
+	log.Println("Запуск подбора окон для полетов...")
+
+	weatherData, err := getWeatherData(config)
+	if err != nil {
+		logErrorf("Ошибка при получении данных о погоде: %v\n", err)
+		return false
+	}
+
+	entries := forecastEntriesForTheDay(weatherData, config.CheckWindow, 0)
+	if len(entries) == 0 {
+		log.Println("Нет данных о погоде на текущий день в ответе API")
+		return false
+	}
+
+	windows := findFlightWindows(entries, config.Drone)
+	log.Printf("Найдено окон для полетов: %d", len(windows))
+
+	data := DroneEmailData{
+		Date:          config.Clock.Now().Format("02.01.2006"),
+		Windows:       windows,
+		MaxWindGust:   config.Drone.MaxWindGust,
+		MinVisibility: config.Drone.MinVisibility,
+	}
+
+	htmlBody, plainTextBody, err := renderEmailBodies(droneEmailHTMLTemplateText, droneEmailPlainTextTemplate, data, speedFuncs(config.Units, languageRU))
+	if err != nil {
+		logErrorf("Ошибка при формировании письма: %v\n", err)
+		return false
+	}
+
+	subject := "Окна для полетов БПЛА на сегодня"
+	if err := sendEmail(config, subject, htmlBody, plainTextBody); err != nil {
+		logErrorf("Ошибка при отправке сообщения об окнах для полетов: %v\n", err)
+		return false
+	}
+	log.Println("Сообщение об окнах для полетов успешно отправлено")
+	return true
+}
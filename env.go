@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// Префикс переменных окружения сервиса: WINDALERTS_CITY вместо CITY
+const envPrefix = "WINDALERTS_"
+
+// Переменная, значение которой подставлено из другой переменной
+type envOverride struct {
+	base    string // Значение до подстановки
+	hadBase bool   // Было ли значение задано до подстановки
+	value   string // Подставленное значение
+}
+
+// Подставленные значения переменных с префиксом WINDALERTS_
+var namespaceOverrides = map[string]envOverride{}
+
+// Копия окружения процесса: проверка новых значений на ней не затрагивает
+// остальные части сервиса, которые в это время читают окружение
+type envSnapshot map[string]string
+
+func snapshotEnv() envSnapshot {
+	env := envSnapshot{}
+	for _, entry := range os.Environ() {
+		if name, value, ok := strings.Cut(entry, "="); ok {
+			env[name] = value
+		}
+	}
+	return env
+}
+
+func (e envSnapshot) get(name string) string {
+	return e[name]
+}
+
+// Задана ли переменная с префиксом WINDALERTS_
+func namespaced(name string) bool {
+	_, ok := os.LookupEnv(envPrefix + name)
+	return ok
+}
+
+// Подстановка значения переменной с запоминанием прежнего
+func overrideEnv(overrides map[string]envOverride, name, value string) {
+	base, hadBase := os.LookupEnv(name)
+	if prev, ok := overrides[name]; ok {
+		base, hadBase = prev.base, prev.hadBase
+	}
+	os.Setenv(name, value)
+	overrides[name] = envOverride{base: base, hadBase: hadBase, value: value}
+}
+
+// Возврат прежних значений, если подставленные не обновила перезагрузка .env
+func restoreEnv(overrides map[string]envOverride) {
+	for name, override := range overrides {
+		if os.Getenv(name) == override.value {
+			if override.hadBase {
+				os.Setenv(name, override.base)
+			} else {
+				os.Unsetenv(name)
+			}
+		}
+		delete(overrides, name)
+	}
+}
+
+// Переменные с префиксом WINDALERTS_ имеют приоритет над одноименными без префикса,
+// которые поддерживаются для обратной совместимости. Префикс снимается с любой переменной,
+// поэтому работают и WINDALERTS_SMTP_PASSWORD_FILE, и WINDALERTS_DACHA__CITY.
+// Флаги командной строки по-прежнему имеют приоритет.
+func applyEnvNamespace() {
+	// Подстановки отменяются в порядке, обратном применению: расшифровка, профиль, префикс
+	restoreEnv(decryptedOverrides)
+	restoreEnv(profileOverrides)
+	restoreEnv(namespaceOverrides)
+
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		short, ok := strings.CutPrefix(name, envPrefix)
+		if !ok || short == "" || flagVars[short] {
+			continue
+		}
+		overrideEnv(namespaceOverrides, short, value)
+	}
+}
@@ -0,0 +1,550 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Настройки цепочки эскалации
+type EscalationConfig struct {
+	Channels  []string      // Каналы, в которые предупреждение уходит только без подтверждения
+	Delay     time.Duration // Время ожидания подтверждения
+	File      string        // Файл состояния предупреждений (пустая строка - только в памяти)
+	PublicURL string        // Публичный адрес сервиса для ссылки подтверждения
+}
+
+// Загрузка настроек эскалации из переменных окружения
+func loadEscalationConfig() EscalationConfig {
+	cfg := EscalationConfig{
+		Channels:  parseList(strings.ToLower(os.Getenv("ESCALATION_CHANNELS"))),
+		Delay:     15 * time.Minute,
+		File:      os.Getenv("ESCALATION_FILE"),
+		PublicURL: strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/"),
+	}
+
+	if envDelay := os.Getenv("ESCALATION_DELAY"); envDelay != "" {
+		if val, err := time.ParseDuration(envDelay); err == nil {
+			cfg.Delay = val
+		} else {
+			logWarnf("Ошибка парсинга ESCALATION_DELAY: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	// Для ссылки подтверждения подходит и адрес, указанный для ленты
+	if cfg.PublicURL == "" {
+		cfg.PublicURL = strings.TrimSuffix(os.Getenv("FEED_LINK"), "/")
+	}
+
+	return cfg
+}
+
+// Состояние выпущенного предупреждения в цепочке эскалации
+type AlertAck struct {
+	ID         string       `json:"id"`
+	Token      string       `json:"token,omitempty"` // Секрет ссылки подтверждения, в ответах API не выдается
+	City       string       `json:"city"`
+	IssuedAt   time.Time    `json:"issued_at"`
+	EscalateAt time.Time    `json:"escalate_at"`
+	AckedAt    *time.Time   `json:"acked_at,omitempty"` // Первое подтверждение
+	Acks       []AckRecord  `json:"acks,omitempty"`
+	ViewedAt   *time.Time   `json:"viewed_at,omitempty"` // Первое открытие ссылки подтверждения
+	Views      int          `json:"views"`
+	Escalated  bool         `json:"escalated"`
+	Resolved   bool         `json:"resolved"` // Порывы ветра опустились ниже порога
+	Report     *AlertReport `json:"report,omitempty"`
+}
+
+// Запись о подтверждении предупреждения
+type AckRecord struct {
+	By  string    `json:"by,omitempty"` // Кто подтвердил (имя или адрес, если указан)
+	Via string    `json:"via"`          // link - по ссылке, api - через API
+	At  time.Time `json:"at"`
+}
+
+// Ожидает ли предупреждение подтверждения
+func (a *AlertAck) open() bool {
+	return a.AckedAt == nil && !a.Escalated && !a.Resolved
+}
+
+// Цепочка эскалации: предупреждение сначала уходит в основные каналы,
+// а при отсутствии подтверждения за ESCALATION_DELAY - в каналы эскалации
+type Escalator struct {
+	config       EscalationConfig
+	channels     map[string]bool
+	escalate     func(channel string, report *AlertReport) // Доставка в канал эскалации
+	acknowledged func(report *AlertReport, by, via string) // Запись подтверждения в базу истории
+	store        StateStore
+
+	mu     sync.Mutex
+	alerts []*AlertAck
+	wake   chan struct{}
+}
+
+// Создание цепочки эскалации с загрузкой сохраненного состояния
+func newEscalator(config EscalationConfig, store StateStore) (*Escalator, error) {
+	e := &Escalator{
+		config:   config,
+		channels: make(map[string]bool),
+		store:    store,
+		wake:     make(chan struct{}, 1),
+	}
+	for _, c := range config.Channels {
+		e.channels[c] = true
+	}
+	store.onLeading(func() {
+		e.mu.Lock()
+		e.refresh()
+		e.mu.Unlock()
+		e.notify()
+	})
+
+	data, err := store.read(stateEscalation, config.File)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении состояния эскалации: %w", err)
+	}
+	if data == nil {
+		return e, nil
+	}
+
+	if err := json.Unmarshal(data, &e.alerts); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе состояния эскалации: %w", err)
+	}
+	return e, nil
+}
+
+// Включено ли отслеживание подтверждений: для эскалации или для ссылок в уведомлениях
+func (e *Escalator) tracking() bool {
+	return e.escalating() || e.config.PublicURL != ""
+}
+
+// Включена ли эскалация
+func (e *Escalator) escalating() bool {
+	return len(e.channels) > 0
+}
+
+// Удерживается ли канал до окончания ожидания подтверждения
+func (e *Escalator) holds(channel string) bool {
+	return e.channels[channel]
+}
+
+// Сохранение состояния в хранилище; вызывается с захваченной блокировкой
+func (e *Escalator) save() error {
+	if !e.store.persistent(e.config.File) {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(e.alerts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка при формировании JSON: %w", err)
+	}
+
+	if err := e.store.write(stateEscalation, e.config.File, data); err != nil {
+		return fmt.Errorf("ошибка при записи состояния эскалации: %w", err)
+	}
+	return nil
+}
+
+// Загрузка состояния, измененного другими экземплярами сервиса: подтверждение по ссылке
+// может прийти на любой из них. Вызывается с захваченной блокировкой перед каждым изменением.
+func (e *Escalator) refresh() {
+	if !e.store.shared() {
+		return
+	}
+
+	data, err := e.store.read(stateEscalation, e.config.File)
+	if err != nil {
+		logErrorf("Ошибка при чтении состояния эскалации: %v", err)
+		return
+	}
+	var alerts []*AlertAck
+	if data != nil {
+		if err := json.Unmarshal(data, &alerts); err != nil {
+			logErrorf("Ошибка при разборе состояния эскалации: %v", err)
+			return
+		}
+	}
+	e.alerts = alerts
+}
+
+// Пробуждение цикла эскалации после изменения списка
+func (e *Escalator) notify() {
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Адрес ссылки подтверждения
+func (e *Escalator) ackURL(a *AlertAck) string {
+	if e.config.PublicURL == "" {
+		return ""
+	}
+	return e.config.PublicURL + "/ack/" + a.Token
+}
+
+// Регистрация результата проверки. Для нового предупреждения запускается ожидание подтверждения,
+// повторное предупреждение по городу продолжает уже открытое ожидание, отмена закрывает его.
+// Возвращает ссылку подтверждения для включения в уведомления.
+func (e *Escalator) Track(report *AlertReport) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.refresh()
+
+	var current *AlertAck
+	for _, a := range e.alerts {
+		if a.City == report.City && a.open() {
+			current = a
+		}
+	}
+
+	if !report.ExceedsThreshold {
+		if current != nil {
+			current.Resolved = true
+			log.Printf("Эскалация предупреждения %s отменена: порывы ветра в норме", current.ID)
+			e.persist()
+		}
+		return ""
+	}
+
+	if current != nil {
+		current.Report = report
+		e.persist()
+		return e.ackURL(current)
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		logErrorf("Ошибка при создании ссылки подтверждения: %v", err)
+		return ""
+	}
+
+	current = &AlertAck{
+		ID:         alertID(report),
+		Token:      hex.EncodeToString(token),
+		City:       report.City,
+		IssuedAt:   report.CheckedAt,
+		EscalateAt: report.CheckedAt.Add(e.config.Delay),
+		Report:     report,
+	}
+	e.alerts = append(e.alerts, current)
+	e.persist()
+	e.notify()
+
+	if e.escalating() {
+		log.Printf("Ожидание подтверждения предупреждения %s до %s", current.ID, current.EscalateAt.Format("15:04:05"))
+	}
+	return e.ackURL(current)
+}
+
+// Идентификатор предупреждения: время проверки и хэш пункта, чтобы пункты,
+// проверенные в одну секунду, получили разные идентификаторы
+func alertID(report *AlertReport) string {
+	sum := sha256.Sum256([]byte(report.City))
+	return fmt.Sprintf("alert-%d-%s", report.CheckedAt.Unix(), hex.EncodeToString(sum[:4]))
+}
+
+// Сохранение состояния с журналированием ошибки; вызывается с захваченной блокировкой
+func (e *Escalator) persist() {
+	// Закрытые предупреждения старше недели больше не нужны
+	cutoff := time.Now().AddDate(0, 0, -7)
+	kept := e.alerts[:0]
+	for _, a := range e.alerts {
+		if a.open() || a.IssuedAt.After(cutoff) {
+			kept = append(kept, a)
+		}
+	}
+	e.alerts = kept
+
+	if err := e.save(); err != nil {
+		logErrorf("Ошибка при сохранении состояния эскалации: %v", err)
+	}
+}
+
+// Поиск предупреждения по секрету ссылки; вызывается с захваченной блокировкой.
+// Секреты сравниваются за постоянное время, чтобы время ответа не выдавало их начало.
+func (e *Escalator) find(token string) *AlertAck {
+	if token == "" {
+		return nil
+	}
+	for _, a := range e.alerts {
+		if subtle.ConstantTimeCompare([]byte(a.Token), []byte(token)) == 1 {
+			return a
+		}
+	}
+	return nil
+}
+
+// Поиск предупреждения по идентификатору; вызывается с захваченной блокировкой.
+// Идентификатор легко угадать, поэтому подтверждение по нему принимается только маршрутами
+// /api/alerts при заданных API_KEYS, а ссылки подтверждения ищут предупреждение по секрету.
+func (e *Escalator) findID(id string) *AlertAck {
+	if id == "" {
+		return nil
+	}
+	for _, a := range e.alerts {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// Отметка об открытии ссылки подтверждения
+func (e *Escalator) View(token string) (AlertAck, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.refresh()
+
+	a := e.find(token)
+	if a == nil {
+		return AlertAck{}, false
+	}
+
+	now := time.Now()
+	if a.ViewedAt == nil {
+		a.ViewedAt = &now
+	}
+	a.Views++
+	e.persist()
+	return *a, true
+}
+
+// Подтверждение предупреждения по ссылке с секретом
+func (e *Escalator) Acknowledge(token, by, via string) (AlertAck, bool) {
+	return e.acknowledge(e.find, token, by, via)
+}
+
+// Подтверждение предупреждения по идентификатору через API
+func (e *Escalator) AcknowledgeID(id, by, via string) (AlertAck, bool) {
+	return e.acknowledge(e.findID, id, by, via)
+}
+
+// Подтверждение предупреждения; каждое подтверждение записывается, эскалацию отменяет первое
+func (e *Escalator) acknowledge(find func(string) *AlertAck, key, by, via string) (AlertAck, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.refresh()
+
+	a := find(key)
+	if a == nil {
+		return AlertAck{}, false
+	}
+
+	now := time.Now()
+	if a.AckedAt == nil {
+		a.AckedAt = &now
+	}
+	a.Acks = append(a.Acks, AckRecord{By: by, Via: via, At: now})
+	e.persist()
+	if e.acknowledged != nil {
+		e.acknowledged(a.Report, by, via)
+	}
+
+	if by == "" {
+		by = "аноним"
+	}
+	log.Printf("Предупреждение %s подтверждено (%s, %s)", a.ID, by, via)
+	return *a, true
+}
+
+// Список предупреждений, начиная с самого свежего
+func (e *Escalator) List() []AlertAck {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.refresh()
+
+	alerts := make([]AlertAck, 0, len(e.alerts))
+	for i := len(e.alerts) - 1; i >= 0; i-- {
+		alerts = append(alerts, *e.alerts[i])
+	}
+	return alerts
+}
+
+// Ближайшее время эскалации; экземпляр, который не рассылает уведомления, эскалацию не выполняет
+func (e *Escalator) nextEscalation() (time.Time, bool) {
+	if !e.escalating() || !e.store.leading() {
+		return time.Time{}, false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var next time.Time
+	for _, a := range e.alerts {
+		if a.open() && (next.IsZero() || a.EscalateAt.Before(next)) {
+			next = a.EscalateAt
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// Сохранение состояния эскалации перед завершением сервиса
+func (e *Escalator) Flush() {
+	if !e.store.leading() {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.persist()
+}
+
+// Основной цикл эскалации; завершается при отмене ctx
+func (e *Escalator) Run(ctx context.Context) {
+	for {
+		var timer <-chan time.Time
+		if next, ok := e.nextEscalation(); ok {
+			timer = time.After(time.Until(next))
+		}
+
+		select {
+		case <-timer:
+			e.escalateDue()
+		case <-e.wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Эскалация неподтвержденных предупреждений, время ожидания которых истекло
+func (e *Escalator) escalateDue() {
+	now := time.Now()
+
+	e.mu.Lock()
+	e.refresh()
+	var due []*AlertAck
+	for _, a := range e.alerts {
+		if a.open() && !a.EscalateAt.After(now) {
+			a.Escalated = true
+			due = append(due, a)
+		}
+	}
+	if len(due) > 0 {
+		e.persist()
+	}
+	e.mu.Unlock()
+
+	for _, a := range due {
+		log.Printf("Предупреждение %s не подтверждено за %s, эскалация в каналы: %s",
+			a.ID, e.config.Delay, strings.Join(e.config.Channels, ", "))
+		for _, channel := range e.config.Channels {
+			e.escalate(channel, a.Report)
+		}
+	}
+}
+
+func (e *Escalator) registerRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/ack/", e.handleAckLink)
+	mux.HandleFunc("/api/alerts", e.handleAlerts)
+	mux.HandleFunc("/api/alerts/", e.handleAlert)
+}
+
+// Страница, открываемая по ссылке подтверждения
+var ackPageTemplate = template.Must(template.New("ack").Funcs(messageTemplateFuncs).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"><title>Подтверждение предупреждения</title></head>
+<body style="font-family: Arial, sans-serif; text-align: center; padding: 40px;">
+    {{if .AckedAt}}<h1 style="color: #3c763d;">Предупреждение подтверждено</h1>{{else}}<h1 style="color: #d9534f;">Предупреждение о сильном ветре</h1>{{end}}
+    <p>{{.City}}, {{.IssuedAt.Format "02.01.2006 15:04"}}{{with .Report}}: порывы до {{speed .MaxWindGust .Units 1}}{{end}}.</p>
+    {{range .Acks}}<p style="color: #777777;">Подтвердил{{if .By}} {{.By}}{{end}} в {{.At.Format "15:04 02.01.2006"}}</p>{{end}}
+    {{if not .AckedAt}}
+    <form method="post">
+        <p><input type="text" name="by" placeholder="Ваше имя" style="padding: 8px; font-size: 16px;"></p>
+        <p><button type="submit" style="padding: 10px 20px; font-size: 16px; background-color: #d9534f; color: #ffffff; border: 0; border-radius: 4px;">Подтвердить получение</button></p>
+    </form>
+    {{end}}
+</body>
+</html>`))
+
+// GET /ack/{token} - страница подтверждения (открытие отмечается как прочтение),
+// POST /ack/{token} - подтверждение по ссылке из уведомления
+func (e *Escalator) handleAckLink(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.URL.Path, "/ack/")
+
+	var alert AlertAck
+	var found bool
+	switch r.Method {
+	case http.MethodGet:
+		alert, found = e.View(token)
+	case http.MethodPost:
+		alert, found = e.Acknowledge(token, strings.TrimSpace(r.FormValue("by")), "link")
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+		return
+	}
+
+	if !found {
+		http.Error(w, "Предупреждение не найдено", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := ackPageTemplate.Execute(w, alert); err != nil {
+		logErrorf("Ошибка при записи ответа: %v", err)
+	}
+}
+
+// GET /api/alerts - предупреждения с состоянием подтверждения
+func (e *Escalator) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+		return
+	}
+
+	alerts := e.List()
+	for i := range alerts {
+		alerts[i].Token = ""
+	}
+	writeJSON(w, http.StatusOK, alerts)
+}
+
+// GET /api/alerts/{id} - состояние предупреждения, POST /api/alerts/{id}/ack - подтверждение через API
+func (e *Escalator) handleAlert(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/alerts/")
+	id, ack := strings.CutSuffix(path, "/ack")
+
+	var alert AlertAck
+	var found bool
+	switch {
+	case ack && r.Method == http.MethodPost:
+		var body struct {
+			By string `json:"by"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeError(w, http.StatusBadRequest, "некорректный JSON: "+err.Error())
+				return
+			}
+		}
+		alert, found = e.AcknowledgeID(id, strings.TrimSpace(body.By), "api")
+	case !ack && r.Method == http.MethodGet:
+		e.mu.Lock()
+		if a := e.findID(id); a != nil {
+			alert, found = *a, true
+		}
+		e.mu.Unlock()
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+		return
+	}
+
+	if !found {
+		writeError(w, http.StatusNotFound, "предупреждение не найдено")
+		return
+	}
+	alert.Token = ""
+	writeJSON(w, http.StatusOK, alert)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestEscalator(t *testing.T) (*Escalator, *AlertAck) {
+	t.Helper()
+	e, err := newEscalator(EscalationConfig{Delay: 15 * time.Minute, PublicURL: "https://wind.example"}, jsonStateStore{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := &AlertReport{City: "Москва", CheckedAt: time.Date(2024, 6, 10, 9, 0, 0, 0, time.UTC), ExceedsThreshold: true, MaxWindGust: 18}
+	if link := e.Track(report); link == "" {
+		t.Fatal("Track не вернул ссылку подтверждения")
+	}
+	return e, e.alerts[0]
+}
+
+func TestEscalatorFind(t *testing.T) {
+	e, alert := newTestEscalator(t)
+
+	tests := []struct {
+		name     string
+		find     func(string) *AlertAck
+		key      string
+		wantFind bool
+	}{
+		{"секрет ссылки", e.find, alert.Token, true},
+		{"идентификатор по ссылке", e.find, alert.ID, false},
+		{"пустой секрет", e.find, "", false},
+		{"чужой секрет", e.find, strings.Repeat("0", 32), false},
+		{"идентификатор через API", e.findID, alert.ID, true},
+		{"секрет через API", e.findID, alert.Token, false},
+		{"пустой идентификатор", e.findID, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.find(tt.key); (got != nil) != tt.wantFind {
+				t.Errorf("найдено %v, ожидалось %v", got != nil, tt.wantFind)
+			}
+		})
+	}
+}
+
+func TestAckLinkRequiresToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        func(a *AlertAck) string
+		wantStatus int
+		wantAcked  bool
+	}{
+		{"по идентификатору", func(a *AlertAck) string { return a.ID }, http.StatusNotFound, false},
+		{"по секрету", func(a *AlertAck) string { return a.Token }, http.StatusOK, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, alert := newTestEscalator(t)
+			mux := http.NewServeMux()
+			e.registerRoutes(mux)
+
+			form := url.Values{"by": {"Иванов"}}
+			req := httptest.NewRequest(http.MethodPost, "/ack/"+tt.key(alert), strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("статус %d, ожидался %d", rec.Code, tt.wantStatus)
+			}
+			if acked := alert.AckedAt != nil; acked != tt.wantAcked {
+				t.Errorf("подтверждено %v, ожидалось %v", acked, tt.wantAcked)
+			}
+		})
+	}
+}
+
+func TestAPIAcknowledgeByID(t *testing.T) {
+	e, alert := newTestEscalator(t)
+	mux := http.NewServeMux()
+	e.registerRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/alerts/"+alert.ID+"/ack", strings.NewReader(`{"by": "Петров"}`))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("статус %d, ожидался %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), alert.Token) {
+		t.Error("ответ API содержит секрет ссылки")
+	}
+	if alert.AckedAt == nil || alert.Acks[0].Via != "api" {
+		t.Errorf("подтверждение через API не записано: %+v", alert.Acks)
+	}
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Длительность мероприятия по умолчанию
+const defaultEventDuration = 3.0
+
+// Разовая проверка прогноза для мероприятия
+type ScheduledEvent struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Start         time.Time  `json:"start"`                    // Начало мероприятия
+	DurationHours float64    `json:"duration_hours,omitempty"` // Длительность мероприятия в часах (по умолчанию 3)
+	CheckAt       *time.Time `json:"check_at,omitempty"`       // Время проверки (по умолчанию за сутки до начала)
+	Threshold     float64    `json:"threshold,omitempty"`      // Порог порывов ветра (по умолчанию WIND_GUST_THRESHOLD)
+	Recipients    []string   `json:"recipients,omitempty"`     // Получатели (по умолчанию EMAIL_TO)
+	Done          bool       `json:"done"`
+}
+
+// Окончание мероприятия
+func (e *ScheduledEvent) End() time.Time {
+	duration := e.DurationHours
+	if duration <= 0 {
+		duration = defaultEventDuration
+	}
+	return e.Start.Add(time.Duration(duration * float64(time.Hour)))
+}
+
+// Время проверки прогноза для мероприятия
+func (e *ScheduledEvent) CheckTime() time.Time {
+	if e.CheckAt != nil {
+		return *e.CheckAt
+	}
+	return e.Start.Add(-24 * time.Hour)
+}
+
+// Структура данных для шаблона письма о мероприятии
+type EventEmailData struct {
+	Name              string
+	Start             time.Time
+	End               time.Time
+	ExceedsThreshold  bool
+	MaxWindGust       float64
+	WindGustThreshold float64
+	Forecasts         []WindGustForecast
+}
+
+// Шаблон для HTML письма о мероприятии
+const eventEmailHTMLTemplateText = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Прогноз на мероприятие</title>
+</head>
+<body style="margin: 0; padding: 0; background-color: #f4f4f4; font-family: Arial, sans-serif;">
+    <table border="0" cellpadding="0" cellspacing="0" width="100%" bgcolor="#f4f4f4" style="background-color: #f4f4f4;">
+        <tr>
+            <td align="center" style="padding: 20px 0;">
+                <table border="0" cellpadding="0" cellspacing="0" width="600" style="background-color: #ffffff; border-radius: 8px; max-width: 600px; width: 100%;">
+                    <tr>
+                        <td style="padding: 20px;">
+                            <h1 style="color: {{if .ExceedsThreshold}}#d9534f{{else}}#3c763d{{end}}; font-size: 24px; text-align: center; margin-top: 0; margin-bottom: 20px;">{{.Name}}</h1>
+                            <p style="font-size: 16px; line-height: 1.5; color: #333333;">Мероприятие: {{.Start.Format "02.01.2006 15:04"}}–{{.End.Format "15:04"}}.</p>
+                            {{if .ExceedsThreshold}}
+                            <p style="font-size: 16px; line-height: 1.5; color: #333333;">Во время мероприятия ожидаются <b style="color: #d9534f;">сильные порывы ветра ({{speed .MaxWindGust 2}})</b>, что превышает порог ({{speed .WindGustThreshold 2}}).</p>
+                            <ul style="font-size: 16px; line-height: 1.5; color: #333333;">
+                                {{range .Forecasts}}<li>{{.Time.Format "15:04"}}: {{speed .WindGust 2}}</li>
+                                {{end}}
+                            </ul>
+                            {{else}}
+                            <p style="font-size: 16px; line-height: 1.5; color: #333333;">Порывы ветра во время мероприятия в норме (до {{speed .MaxWindGust 2}} при пороге {{speed .WindGustThreshold 2}}).</p>
+                            {{end}}
+                            <p style="font-size: 14px; line-height: 1.5; color: #777777; text-align: center;">Это автоматическое уведомление от системы мониторинга погоды.</p>
+                        </td>
+                    </tr>
+                </table>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>`
+
+// Шаблон для текстового письма о мероприятии
+const eventEmailPlainTextTemplate = `{{.Name}}
+
+Мероприятие: {{.Start.Format "02.01.2006 15:04"}}–{{.End.Format "15:04"}}.
+{{if .ExceedsThreshold}}
+Во время мероприятия ожидаются сильные порывы ветра ({{speed .MaxWindGust 2}}), что превышает порог ({{speed .WindGustThreshold 2}}).
+{{range .Forecasts}}
+- {{.Time.Format "15:04"}}: {{speed .WindGust 2}}{{end}}
+{{else}}
+Порывы ветра во время мероприятия в норме (до {{speed .MaxWindGust 2}} при пороге {{speed .WindGustThreshold 2}}).
+{{end}}
+Это автоматическое уведомление от системы мониторинга погоды.`
+
+// Планировщик разовых проверок для мероприятий, работающий отдельно от ежедневной проверки
+type EventScheduler struct {
+	config *Config
+	path   string     // Файл для хранения мероприятий (пустая строка - только в памяти)
+	state  StateStore // С общим состоянием письма по мероприятиям отправляет один экземпляр
+
+	mu     sync.Mutex
+	events []*ScheduledEvent
+	wake   chan struct{}
+}
+
+// Создание планировщика мероприятий с загрузкой сохраненного списка
+func newEventScheduler(config *Config, state StateStore) (*EventScheduler, error) {
+	s := &EventScheduler{
+		config: config,
+		path:   config.EventsFile,
+		state:  state,
+		wake:   make(chan struct{}, 1),
+	}
+
+	if s.path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении файла мероприятий: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.events); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе файла мероприятий: %w", err)
+	}
+	for i, event := range s.events {
+		if event.ID == "" {
+			event.ID = fmt.Sprintf("event-%d", i+1)
+		}
+	}
+
+	return s, nil
+}
+
+// Сохранение списка мероприятий в файл
+func (s *EventScheduler) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.events, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка при формировании JSON: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("ошибка при записи файла мероприятий: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Пробуждение цикла планировщика после изменения списка
+func (s *EventScheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Ближайшее время проверки среди невыполненных мероприятий
+func (s *EventScheduler) nextCheck() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, event := range s.events {
+		if event.Done {
+			continue
+		}
+		if next.IsZero() || event.CheckTime().Before(next) {
+			next = event.CheckTime()
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// Основной цикл планировщика мероприятий; завершается при отмене ctx
+func (s *EventScheduler) Run(ctx context.Context) {
+	for {
+		var timer <-chan time.Time
+		if next, ok := s.nextCheck(); ok {
+			log.Printf("Следующая проверка мероприятия запланирована на %s", next.Format("2006-01-02 15:04:05"))
+			timer = time.After(time.Until(next))
+		}
+
+		select {
+		case <-timer:
+			s.runDue()
+		case <-s.wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Выполнение проверок, время которых наступило
+func (s *EventScheduler) runDue() {
+	now := time.Now()
+
+	s.mu.Lock()
+	var due []*ScheduledEvent
+	for _, event := range s.events {
+		if !event.Done && !event.CheckTime().After(now) {
+			due = append(due, event)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, event := range due {
+		// Проверку выполняет рассылающий экземпляр, остальные только отмечают ее выполненной
+		if !standby(s.state, fmt.Sprintf("проверка мероприятия %q", event.Name)) {
+			if err := s.checkEvent(event); err != nil {
+				logErrorf("Ошибка при проверке мероприятия %q: %v", event.Name, err)
+			}
+		}
+
+		s.mu.Lock()
+		event.Done = true
+		if err := s.save(); err != nil {
+			logErrorf("Ошибка при сохранении мероприятий: %v", err)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Проверка прогноза на время мероприятия и отправка письма получателям
+func (s *EventScheduler) checkEvent(event *ScheduledEvent) error {
+	log.Printf("Проверка прогноза для мероприятия %q...", event.Name)
+
+	weatherData, err := getWeatherData(s.config)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении данных о погоде: %w", err)
+	}
+
+	// Порог мероприятия задается в единицах UNITS
+	threshold := toMetersPerSecond(event.Threshold, s.config.Units)
+	if threshold <= 0 {
+		threshold = s.config.WindGustThreshold
+	}
+
+	// Точки прогноза, пересекающиеся с интервалом мероприятия
+	var points []WindGustForecast
+	for _, forecast := range weatherData.List {
+		forecastTime := forecast.Time()
+		if forecastTime.Add(forecastStep).After(event.Start) && forecastTime.Before(event.End()) {
+			points = append(points, WindGustForecast{Time: forecastTime, WindGust: forecast.Wind.Gust})
+		}
+	}
+	if len(points) == 0 {
+		return fmt.Errorf("прогноз на время мероприятия недоступен")
+	}
+
+	logForecastPoints(points)
+	exceedsThreshold, forecasts := checkWeatherForTheDay(points, threshold)
+	data := EventEmailData{
+		Name:              event.Name,
+		Start:             event.Start,
+		End:               event.End(),
+		ExceedsThreshold:  exceedsThreshold,
+		MaxWindGust:       findMaxWindGust(points),
+		WindGustThreshold: threshold,
+		Forecasts:         forecasts,
+	}
+
+	htmlBody, plainTextBody, err := renderEmailBodies(eventEmailHTMLTemplateText, eventEmailPlainTextTemplate, data, speedFuncs(s.config.Units, languageRU))
+	if err != nil {
+		return err
+	}
+
+	recipients := event.Recipients
+	if len(recipients) == 0 {
+		recipients = s.config.EmailTo
+	}
+
+	subject := fmt.Sprintf("Прогноз ветра на мероприятие «%s»", event.Name)
+	if exceedsThreshold {
+		subject = fmt.Sprintf("ВНИМАНИЕ: Сильный ветер во время мероприятия «%s»", event.Name)
+	}
+
+	if err := sendEmailTo(s.config, recipients, subject, htmlBody, plainTextBody); err != nil {
+		return err
+	}
+
+	log.Printf("Прогноз для мероприятия %q отправлен", event.Name)
+	return nil
+}
+
+// Список мероприятий, отсортированный по времени начала
+func (s *EventScheduler) List() []ScheduledEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]ScheduledEvent, 0, len(s.events))
+	for _, event := range s.events {
+		events = append(events, *event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	return events
+}
+
+// Добавление мероприятия
+func (s *EventScheduler) Add(event ScheduledEvent) (ScheduledEvent, error) {
+	event.Name = strings.TrimSpace(event.Name)
+	if event.Name == "" {
+		return event, fmt.Errorf("не указано название мероприятия")
+	}
+	if event.Start.IsZero() {
+		return event, fmt.Errorf("не указано время начала мероприятия")
+	}
+	if event.End().Before(time.Now()) {
+		return event, fmt.Errorf("мероприятие уже завершилось")
+	}
+	event.Done = false
+
+	s.mu.Lock()
+	if event.ID == "" {
+		event.ID = fmt.Sprintf("event-%d", time.Now().UnixNano())
+	}
+	for _, existing := range s.events {
+		if existing.ID == event.ID {
+			s.mu.Unlock()
+			return event, fmt.Errorf("мероприятие с идентификатором %s уже существует", event.ID)
+		}
+	}
+	s.events = append(s.events, &event)
+	err := s.save()
+	s.mu.Unlock()
+
+	s.notify()
+	return event, err
+}
+
+// Удаление мероприятия
+func (s *EventScheduler) Remove(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, event := range s.events {
+		if event.ID == id {
+			s.events = append(s.events[:i], s.events[i+1:]...)
+			s.notify()
+			return true, s.save()
+		}
+	}
+	return false, nil
+}
+
+// Регистрация HTTP-маршрутов управления мероприятиями
+func (s *EventScheduler) registerRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/events", s.handleEvents)
+	mux.HandleFunc("/api/events/", s.handleEvent)
+}
+
+// GET - список мероприятий, POST - добавление мероприятия
+func (s *EventScheduler) handleEvents(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.List())
+	case http.MethodPost:
+		var event ScheduledEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			writeError(w, http.StatusBadRequest, "некорректный JSON: "+err.Error())
+			return
+		}
+		created, err := s.Add(event)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, created)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+	}
+}
+
+// DELETE /api/events/{id} - удаление мероприятия
+func (s *EventScheduler) handleEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/events/")
+	removed, err := s.Remove(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !removed {
+		writeError(w, http.StatusNotFound, "мероприятие не найдено")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Область доступа OAuth2 для Firebase Cloud Messaging
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// Настройки отправки push-уведомлений через Firebase Cloud Messaging
+type FCMConfig struct {
+	ServiceAccountFile string   // JSON-ключ сервисного аккаунта Google
+	Topic              string   // Тема, на которую подписаны приложения
+	Tokens             []string // Регистрационные токены отдельных устройств
+}
+
+// Загрузка настроек FCM из переменных окружения
+func loadFCMConfig() FCMConfig {
+	cfg := FCMConfig{
+		ServiceAccountFile: os.Getenv("FCM_SERVICE_ACCOUNT_FILE"),
+		Topic:              os.Getenv("FCM_TOPIC"),
+		Tokens:             parseList(os.Getenv("FCM_TOKENS")),
+	}
+
+	if cfg.Topic == "" && len(cfg.Tokens) == 0 {
+		cfg.Topic = "windalerts"
+	}
+
+	return cfg
+}
+
+// Ключ сервисного аккаунта Google
+type googleServiceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// Отправка push-уведомлений через FCM HTTP v1 API
+type fcmNotifier struct {
+	config  FCMConfig
+	account googleServiceAccount
+	key     *rsa.PrivateKey
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// Создание канала FCM с загрузкой ключа сервисного аккаунта
+func newFCMNotifier(config FCMConfig) (*fcmNotifier, error) {
+	data, err := os.ReadFile(config.ServiceAccountFile)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении ключа сервисного аккаунта: %w", err)
+	}
+
+	var account googleServiceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе ключа сервисного аккаунта: %w", err)
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("закрытый ключ сервисного аккаунта не найден")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при разборе закрытого ключа: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("закрытый ключ сервисного аккаунта не является ключом RSA")
+	}
+
+	return &fcmNotifier{config: config, account: account, key: key}, nil
+}
+
+func (n *fcmNotifier) Name() string {
+	return "fcm"
+}
+
+// Получение OAuth2 токена доступа по подписанному JWT сервисного аккаунта
+func (n *fcmNotifier) token(ctx context.Context) (string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.accessToken != "" && time.Now().Before(n.expiresAt) {
+		return n.accessToken, nil
+	}
+
+	now := time.Now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   n.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   n.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("ошибка при формировании JWT: %w", err)
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, n.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("ошибка при подписи JWT: %w", err)
+	}
+	assertion := unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("ошибка при создании запроса: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при получении токена доступа: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при чтении ответа: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("сервер авторизации вернул статус %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("ошибка при разборе JSON: %w", err)
+	}
+
+	// Токен обновляется с запасом в минуту до истечения
+	n.accessToken = result.AccessToken
+	n.expiresAt = now.Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute)
+	return n.accessToken, nil
+}
+
+// Сообщение FCM HTTP v1 API
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Topic        string            `json:"topic,omitempty"`
+	Token        string            `json:"token,omitempty"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data"`
+	Android      fcmAndroid        `json:"android"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmAndroid struct {
+	Priority string `json:"priority"`
+}
+
+// Адресаты FCM: тема с префиксом fcmTopicPrefix или регистрационный токен устройства
+const fcmTopicPrefix = "topic:"
+
+// Адресаты уведомления. После частичной ошибки повторная доставка оставляет в получателях
+// уведомления только недоставленных адресатов FCM; в остальных случаях получатели - адреса
+// электронной почты, и уведомление отправляется всем настроенным адресатам.
+func (n *fcmNotifier) targets(report *AlertReport) []string {
+	var all []string
+	if n.config.Topic != "" {
+		all = append(all, fcmTopicPrefix+n.config.Topic)
+	}
+	all = append(all, n.config.Tokens...)
+
+	var pending []string
+	for _, target := range all {
+		if slices.Contains(report.Recipients, target) {
+			pending = append(pending, target)
+		}
+	}
+	if len(pending) > 0 {
+		return pending
+	}
+	return all
+}
+
+func (n *fcmNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	token, err := n.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("https://fcm.googleapis.com/v1/projects/%s/messages:send", n.account.ProjectID)
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	base := fcmMessage{
+		Notification: fcmNotification{
+			Title: report.tr("⚠️ Сильный ветер ", "⚠️ Strong wind ") + report.periodTitle(),
+			Body: report.text(fmt.Sprintf(report.tr("%s: порывы ветра до %s (порог %s)", "%s: wind gusts up to %s (threshold %s)"),
+				report.City, report.speed(report.MaxWindGust, 1), report.speed(report.WindGustThreshold, 1))),
+		},
+		// Данные для обработки в приложении; значения FCM передаются строками
+		Data: map[string]string{
+			"city":      report.City,
+			"max_gust":  strconv.FormatFloat(report.MaxWindGust, 'f', 2, 64),
+			"threshold": strconv.FormatFloat(report.WindGustThreshold, 'f', 2, 64),
+			"severity":  report.Severity.String(),
+			"ack_url":   report.AckURL,
+		},
+		Android: fcmAndroid{Priority: "high"},
+	}
+
+	targets := n.targets(report)
+	failed := make(map[string]error)
+	for _, target := range targets {
+		message := base
+		if topic, ok := strings.CutPrefix(target, fcmTopicPrefix); ok {
+			message.Topic = topic
+		} else {
+			message.Token = target
+		}
+		if _, err := sendJSON(ctx, http.MethodPost, endpoint, headers, fcmRequest{Message: message}); err != nil {
+			logErrorf("Ошибка при отправке push-уведомления FCM: %v", err)
+			failed[target] = err
+		}
+	}
+
+	// Адресаты, которым уведомление доставлено, при повторной доставке его не получают
+	if len(failed) > 0 {
+		return &recipientErrors{message: fmt.Sprintf("не доставлено push-уведомлений FCM: %d из %d", len(failed), len(targets)), failed: failed}
+	}
+
+	log.Printf("Push-уведомление отправлено через FCM (%d адресатов)", len(targets))
+	return nil
+}
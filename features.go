@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Флаги функций: необязательное поведение включается и выключается параметром FEATURES
+// без изменения кода, поэтому новые возможности можно выпускать выключенными
+const (
+	featureAllClearEmails = "all_clear_emails" // Письмо об отбое, когда ветер стих после предупреждения
+	featureContinuousMode = "continuous_mode"  // Непрерывный режим опроса (SCHEDULE=continuous)
+)
+
+// Значения флагов по умолчанию: существующие возможности включены, новые - выключены
+var featureDefaults = map[string]bool{
+	featureAllClearEmails: false,
+	featureContinuousMode: true,
+}
+
+// Состояние флагов функций
+type Features map[string]bool
+
+// Включена ли функция
+func (f Features) Enabled(name string) bool {
+	if enabled, ok := f[name]; ok {
+		return enabled
+	}
+	return featureDefaults[name]
+}
+
+// Разбор FEATURES: список через запятую, элемент "имя" или "имя=true|false",
+// например FEATURES=all_clear_emails,continuous_mode=false
+func parseFeatures(value string) (Features, error) {
+	features := Features{}
+	for _, item := range parseList(value) {
+		name, raw, hasValue := strings.Cut(item, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, known := featureDefaults[name]; !known {
+			return nil, fmt.Errorf("неизвестная функция %q, доступны: %s", name, strings.Join(featureNames(), ", "))
+		}
+
+		enabled := true
+		if hasValue {
+			val, err := strconv.ParseBool(strings.TrimSpace(raw))
+			if err != nil {
+				return nil, fmt.Errorf("функция %s: ожидается true или false, получено %q", name, raw)
+			}
+			enabled = val
+		}
+		features[name] = enabled
+	}
+	return features, nil
+}
+
+// Имена всех флагов функций по алфавиту
+func featureNames() []string {
+	names := make([]string, 0, len(featureDefaults))
+	for name := range featureDefaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Загрузка флагов функций из переменной окружения FEATURES
+func loadFeatures() Features {
+	features, err := parseFeatures(os.Getenv("FEATURES"))
+	if err != nil {
+		logWarnf("Ошибка парсинга FEATURES: %v, используются значения по умолчанию", err)
+		return Features{}
+	}
+	return features
+}
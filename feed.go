@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Количество предупреждений в ленте
+const feedSize = 50
+
+// Настройки ленты предупреждений
+type FeedConfig struct {
+	File     string // Файл для записи ленты (необязательно)
+	Format   string // Формат файла: rss, atom или json
+	Link     string // Публичный адрес сервиса для ссылок в ленте
+	Units    string // Единицы скорости ветра в записях (UNITS)
+	Language string // Язык записей (LANGUAGE)
+}
+
+// Загрузка настроек ленты из переменных окружения
+func loadFeedConfig() FeedConfig {
+	cfg := FeedConfig{
+		File:   os.Getenv("FEED_FILE"),
+		Format: strings.ToLower(os.Getenv("FEED_FORMAT")),
+		Link:   strings.TrimSuffix(os.Getenv("FEED_LINK"), "/"),
+	}
+
+	switch cfg.Format {
+	case "rss", "atom", "json":
+	case "":
+		cfg.Format = "rss"
+	default:
+		log.Printf("Неизвестный формат ленты FEED_FORMAT=%s, используется rss", cfg.Format)
+		cfg.Format = "rss"
+	}
+
+	return cfg
+}
+
+// Структуры RSS 2.0
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	PubDate     string  `xml:"pubDate"`
+	GUID        rssGUID `xml:"guid"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// Структуры Atom
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link,omitempty"`
+	Summary atomSummary `xml:"summary"`
+}
+
+type atomSummary struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Структуры JSON Feed 1.1 (https://jsonfeed.org/version/1.1)
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Description string         `json:"description"`
+	Language    string         `json:"language"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string             `json:"id"`
+	URL           string             `json:"url,omitempty"`
+	Title         string             `json:"title"`
+	ContentText   string             `json:"content_text"`
+	DatePublished string             `json:"date_published"`
+	Extension     *jsonFeedExtension `json:"_windalerts,omitempty"`
+}
+
+// Данные предупреждения для ботов, чтобы не разбирать текст записи; скорость - в м/с
+type jsonFeedExtension struct {
+	City              string             `json:"city"`
+	MaxWindGust       float64            `json:"max_wind_gust"`
+	WindGustThreshold float64            `json:"wind_gust_threshold"`
+	Forecasts         []apiForecastPoint `json:"forecasts"`
+}
+
+// Заголовок записи ленты
+func feedItemTitle(record AlertRecord, cfg FeedConfig) string {
+	if cfg.Language == languageEN {
+		return fmt.Sprintf("%s: strong wind on %s (%s)",
+			record.City, record.IssuedAt.Format("Jan 2, 2006"), formatSpeedIn(record.MaxWindGust, cfg.Units, languageEN, 1))
+	}
+	return fmt.Sprintf("%s: сильный ветер %s (%s)",
+		record.City, record.IssuedAt.Format("02.01.2006"), formatSpeed(record.MaxWindGust, cfg.Units, 1))
+}
+
+// Описание записи ленты
+func feedItemDescription(record AlertRecord, cfg FeedConfig) string {
+	speed := func(ms float64) string { return formatSpeedIn(ms, cfg.Units, cfg.Language, 2) }
+	var sb strings.Builder
+	if cfg.Language == languageEN {
+		fmt.Fprintf(&sb, "Strong wind gusts (%s) are expected, exceeding the safe threshold (%s).",
+			speed(record.MaxWindGust), speed(record.WindGustThreshold))
+	} else {
+		fmt.Fprintf(&sb, "Ожидаются сильные порывы ветра (%s), что превышает безопасный порог (%s).",
+			speed(record.MaxWindGust), speed(record.WindGustThreshold))
+	}
+	for _, f := range record.Forecasts {
+		// Для порывов в последующие дни (LOOKAHEAD_DAYS) указывается дата
+		layout := "15:04"
+		if f.Time.In(record.IssuedAt.Location()).Format("02.01") != record.IssuedAt.Format("02.01") {
+			layout = "02.01 15:04"
+			if cfg.Language == languageEN {
+				layout = "Jan 2 15:04"
+			}
+		}
+		fmt.Fprintf(&sb, " %s: %s.", f.Time.Format(layout), speed(f.WindGust))
+	}
+	return sb.String()
+}
+
+// Формирование ленты в формате RSS 2.0
+func renderRSS(records []AlertRecord, cfg FeedConfig) ([]byte, error) {
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         "Предупреждения о сильном ветре",
+			Link:          cfg.Link,
+			Description:   "Предупреждения системы мониторинга погоды",
+			Language:      "ru",
+			LastBuildDate: time.Now().Format(time.RFC1123Z),
+		},
+	}
+
+	for _, record := range records {
+		item := rssItem{
+			Title:       feedItemTitle(record, cfg),
+			Description: feedItemDescription(record, cfg),
+			PubDate:     record.IssuedAt.Format(time.RFC1123Z),
+			GUID:        rssGUID{Value: record.ID},
+		}
+		if cfg.Link != "" {
+			item.Link = cfg.Link + "/feed.rss#" + record.ID
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	return marshalFeed(feed)
+}
+
+// Формирование ленты в формате Atom
+func renderAtom(records []AlertRecord, cfg FeedConfig) ([]byte, error) {
+	updated := time.Now()
+	if len(records) > 0 {
+		updated = records[0].IssuedAt
+	}
+
+	feed := atomFeed{
+		Title:   "Предупреждения о сильном ветре",
+		ID:      "urn:windalerts:feed",
+		Updated: updated.Format(time.RFC3339),
+	}
+	if cfg.Link != "" {
+		feed.ID = cfg.Link + "/feed.atom"
+		feed.Links = []atomLink{{Href: cfg.Link + "/feed.atom", Rel: "self"}}
+	}
+
+	for _, record := range records {
+		entry := atomEntry{
+			Title:   feedItemTitle(record, cfg),
+			ID:      "urn:windalerts:" + record.ID,
+			Updated: record.IssuedAt.Format(time.RFC3339),
+			Summary: atomSummary{Type: "text", Value: feedItemDescription(record, cfg)},
+		}
+		if cfg.Link != "" {
+			entry.Links = []atomLink{{Href: cfg.Link + "/feed.atom#" + record.ID}}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return marshalFeed(feed)
+}
+
+// Формирование ленты в формате JSON Feed
+func renderJSONFeed(records []AlertRecord, cfg FeedConfig) ([]byte, error) {
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       "Предупреждения о сильном ветре",
+		Description: "Предупреждения системы мониторинга погоды",
+		Language:    "ru",
+		Items:       []jsonFeedItem{},
+	}
+	if cfg.Language == languageEN {
+		feed.Language = "en"
+	}
+	if cfg.Link != "" {
+		feed.HomePageURL = cfg.Link
+		feed.FeedURL = cfg.Link + "/feed.json"
+	}
+
+	for _, record := range records {
+		item := jsonFeedItem{
+			ID:            record.ID,
+			Title:         feedItemTitle(record, cfg),
+			ContentText:   feedItemDescription(record, cfg),
+			DatePublished: record.IssuedAt.Format(time.RFC3339),
+			Extension: &jsonFeedExtension{
+				City:              record.City,
+				MaxWindGust:       record.MaxWindGust,
+				WindGustThreshold: record.WindGustThreshold,
+				Forecasts:         apiForecastPoints(record.Forecasts, record.WindGustThreshold),
+			},
+		}
+		if cfg.Link != "" {
+			item.URL = cfg.Link + "/feed.json#" + record.ID
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	data, err := json.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при формировании ленты: %w", err)
+	}
+	return data, nil
+}
+
+// Сериализация ленты в XML с заголовком
+func marshalFeed(feed interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(feed); err != nil {
+		return nil, fmt.Errorf("ошибка при формировании ленты: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Запись ленты предупреждений в файл после каждого нового предупреждения
+type feedFileNotifier struct {
+	config  FeedConfig
+	history *AlertHistory
+}
+
+func (n *feedFileNotifier) Name() string {
+	return "feed"
+}
+
+func (n *feedFileNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	render := renderRSS
+	switch n.config.Format {
+	case "atom":
+		render = renderAtom
+	case "json":
+		render = renderJSONFeed
+	}
+
+	data, err := render(n.history.Recent(feedSize), n.config)
+	if err != nil {
+		return err
+	}
+
+	tmp := n.config.File + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("ошибка при записи ленты: %w", err)
+	}
+	return os.Rename(tmp, n.config.File)
+}
+
+// Регистрация HTTP-маршрутов ленты предупреждений
+func registerFeedRoutes(mux *http.ServeMux, history *AlertHistory, cfg FeedConfig) {
+	serve := func(contentType string, render func([]AlertRecord, FeedConfig) ([]byte, error)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			data, err := render(history.Recent(feedSize), cfg)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", contentType)
+			w.Write(data)
+		}
+	}
+
+	mux.HandleFunc("/feed.rss", serve("application/rss+xml; charset=utf-8", renderRSS))
+	mux.HandleFunc("/feed.atom", serve("application/atom+xml; charset=utf-8", renderAtom))
+	mux.HandleFunc("/feed.json", serve("application/feed+json; charset=utf-8", renderJSONFeed))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Короткие имена для часто используемых флагов
+var flagAliases = map[string]string{
+	"threshold": "WIND_GUST_THRESHOLD",
+	"api-key":   "OPENWEATHER_API_KEY",
+	"to":        "EMAIL_TO",
+	"tz":        "TIMEZONE",
+}
+
+// Имя флага по имени переменной окружения
+func flagName(envVar string) string {
+	return strings.ReplaceAll(strings.ToLower(envVar), "_", "-")
+}
+
+// Разбор флагов командной строки. Заданные флаги записываются в переменные окружения
+// процесса, поэтому имеют приоритет над окружением и файлом .env (godotenv не
+// перезаписывает уже заданные переменные).
+func applyFlags(args []string) error {
+	fs := flag.NewFlagSet("windalerts", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Использование: windalerts [флаги]\n")
+		fmt.Fprintf(fs.Output(), "       windalerts validate [флаги]   проверка конфигурации без запуска\n")
+		fmt.Fprintf(fs.Output(), "       windalerts doctor [флаги]     диагностика: DNS, OpenWeatherMap, SMTP, часы, хранилище\n")
+		fmt.Fprintf(fs.Output(), "       windalerts status [флаги]     доставка предупреждений по получателям (HISTORY_DB)\n")
+		fmt.Fprintf(fs.Output(), "       windalerts history export [флаги]  выгрузка предупреждений и максимумов по дням в CSV или JSON\n")
+		fmt.Fprintf(fs.Output(), "       windalerts audit [флаги]      решения о предупреждениях и их причины (AUDIT_FILE)\n")
+		fmt.Fprintf(fs.Output(), "       windalerts config sample|schema   образец .env и JSON Schema параметров\n")
+		fmt.Fprintf(fs.Output(), "       windalerts config keygen|encrypt  ключ age и шифрование значений\n")
+		fmt.Fprintf(fs.Output(), "       windalerts config migrate         обновление .env до текущей версии формата\n\n")
+		fmt.Fprintf(fs.Output(), "Каждый флаг переопределяет одноименную переменную окружения, например --wind-gust-threshold=12 вместо WIND_GUST_THRESHOLD=12.\n")
+		fmt.Fprintf(fs.Output(), "Короткие имена: --threshold, --api-key, --to, --tz.\n")
+		fmt.Fprintf(fs.Output(), "--profile=имя подставляет значения <ИМЯ>__<ПЕРЕМЕННАЯ> из окружения и .env, например DACHA__CITY.\n\n")
+		fs.PrintDefaults()
+	}
+
+	// Имя флага получается из имени переменной: WIND_GUST_THRESHOLD -> --wind-gust-threshold
+	values := map[string]*string{}
+	for _, group := range configOptionGroups {
+		for _, opt := range group.Options {
+			// Для DRY_RUN есть логический флаг --dry-run
+			if opt.Name == "DRY_RUN" {
+				continue
+			}
+			values[opt.Name] = fs.String(flagName(opt.Name), "", opt.Help+" ("+opt.Name+")")
+		}
+	}
+	for alias, envVar := range flagAliases {
+		fs.Var(stringAlias{values[envVar]}, alias, "то же, что --"+flagName(envVar))
+	}
+	dryRun := fs.Bool("dry-run", false, "проверить прогноз и вывести уведомления в журнал без отправки (DRY_RUN)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("неожиданные аргументы: %s", strings.Join(fs.Args(), " "))
+	}
+
+	// Переопределяются только явно указанные флаги
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for envVar, value := range values {
+		if set[flagName(envVar)] || aliasSet(set, envVar) {
+			os.Setenv(envVar, *value)
+			flagVars[envVar] = true
+		}
+	}
+	if *dryRun {
+		os.Setenv("DRY_RUN", "true")
+		flagVars["DRY_RUN"] = true
+	}
+
+	return nil
+}
+
+// Указан ли короткий флаг для переменной
+func aliasSet(set map[string]bool, envVar string) bool {
+	for alias, target := range flagAliases {
+		if target == envVar && set[alias] {
+			return true
+		}
+	}
+	return false
+}
+
+// Короткий флаг, записывающий значение в основной флаг
+type stringAlias struct {
+	target *string
+}
+
+func (a stringAlias) String() string {
+	if a.target == nil {
+		return ""
+	}
+	return *a.target
+}
+
+func (a stringAlias) Set(value string) error {
+	*a.target = value
+	return nil
+}
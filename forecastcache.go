@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Кэш ответов прогноза по пунктам: проверки, правила, каналы и HTTP API в течение
+// FORECAST_CACHE_TTL используют один запрос к OpenWeatherMap
+type ForecastCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*forecastCacheEntry
+}
+
+// Сохраненный ответ прогноза; блокировка записи не дает одновременным проверкам
+// одного пункта запрашивать прогноз параллельно
+type forecastCacheEntry struct {
+	mu        sync.Mutex
+	location  *GeoLocation
+	body      []byte
+	fetchedAt time.Time
+}
+
+// Загрузка срока хранения прогноза из переменной окружения; кэш включается только
+// ненулевым FORECAST_CACHE_TTL
+func loadForecastCache() *ForecastCache {
+	var ttl time.Duration
+	if envTTL := os.Getenv("FORECAST_CACHE_TTL"); envTTL != "" {
+		if val, err := time.ParseDuration(envTTL); err == nil && val >= 0 {
+			ttl = val
+		} else {
+			logWarnf("Ошибка парсинга FORECAST_CACHE_TTL: %v, используется значение по умолчанию", err)
+		}
+	}
+	if ttl == 0 {
+		return nil
+	}
+	return &ForecastCache{ttl: ttl, entries: make(map[string]*forecastCacheEntry)}
+}
+
+// Ключ пункта: координаты или название города без учета регистра
+func forecastCacheKey(config *Config) string {
+	if config.Coords != nil {
+		return fmt.Sprintf("%.4f,%.4f", config.Coords.Lat, config.Coords.Lon)
+	}
+	return strings.ToLower(strings.TrimSpace(config.City))
+}
+
+// Ответ прогноза из кэша или от fetch, если сохраненный ответ старше TTL. Сохраняются
+// только успешные ответы; fresh сообщает, что прогноз получен заново.
+func (c *ForecastCache) get(config *Config, now time.Time, fetch func() (*GeoLocation, int, []byte, error)) (location *GeoLocation, body []byte, fresh bool, err error) {
+	if c == nil {
+		location, _, body, err = fetch()
+		return location, body, true, err
+	}
+
+	key := forecastCacheKey(config)
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &forecastCacheEntry{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.body != nil && now.Sub(entry.fetchedAt) < c.ttl {
+		log.Printf("Прогноз для %s взят из кэша (получен %s назад)", config.placeName(), now.Sub(entry.fetchedAt).Round(time.Second))
+		return entry.location, entry.body, false, nil
+	}
+
+	location, status, body, err := fetch()
+	if err != nil {
+		return nil, nil, true, err
+	}
+	if status == http.StatusOK {
+		entry.location, entry.body, entry.fetchedAt = location, body, now
+	}
+	return location, body, true, nil
+}
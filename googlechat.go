@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+// Настройки отправки уведомлений в пространство Google Chat
+type GoogleChatConfig struct {
+	WebhookURL string // Адрес входящего веб-хука пространства
+}
+
+// Загрузка настроек Google Chat из переменных окружения
+func loadGoogleChatConfig() GoogleChatConfig {
+	return GoogleChatConfig{
+		WebhookURL: os.Getenv("GOOGLE_CHAT_WEBHOOK_URL"),
+	}
+}
+
+// Отправка предупреждения карточкой CardV2 в Google Chat
+type googleChatNotifier struct {
+	config GoogleChatConfig
+}
+
+func newGoogleChatNotifier(config GoogleChatConfig) *googleChatNotifier {
+	return &googleChatNotifier{config: config}
+}
+
+func (n *googleChatNotifier) Name() string {
+	return "googlechat"
+}
+
+// Сообщение Google Chat с карточками CardV2
+type googleChatMessage struct {
+	Text    string              `json:"text"`
+	CardsV2 []googleChatCardRef `json:"cardsV2"`
+}
+
+type googleChatCardRef struct {
+	CardID string         `json:"cardId"`
+	Card   googleChatCard `json:"card"`
+}
+
+type googleChatCard struct {
+	Header   googleChatHeader    `json:"header"`
+	Sections []googleChatSection `json:"sections"`
+}
+
+type googleChatHeader struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle,omitempty"`
+}
+
+type googleChatSection struct {
+	Header                    string             `json:"header,omitempty"`
+	Collapsible               bool               `json:"collapsible,omitempty"`
+	UncollapsibleWidgetsCount int                `json:"uncollapsibleWidgetsCount,omitempty"`
+	Widgets                   []googleChatWidget `json:"widgets"`
+}
+
+type googleChatWidget struct {
+	DecoratedText *googleChatDecoratedText `json:"decoratedText,omitempty"`
+	TextParagraph *googleChatTextParagraph `json:"textParagraph,omitempty"`
+	ButtonList    *googleChatButtonList    `json:"buttonList,omitempty"`
+}
+
+type googleChatDecoratedText struct {
+	TopLabel string `json:"topLabel,omitempty"`
+	Text     string `json:"text"`
+}
+
+type googleChatTextParagraph struct {
+	Text string `json:"text"`
+}
+
+type googleChatButtonList struct {
+	Buttons []googleChatButton `json:"buttons"`
+}
+
+type googleChatButton struct {
+	Text    string `json:"text"`
+	OnClick struct {
+		OpenLink struct {
+			URL string `json:"url"`
+		} `json:"openLink"`
+	} `json:"onClick"`
+}
+
+func (n *googleChatNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	summary := googleChatSection{
+		Widgets: []googleChatWidget{
+			{DecoratedText: &googleChatDecoratedText{TopLabel: report.tr("Максимальный порыв ветра", "Maximum wind gust"), Text: "<b>" + report.speed(report.MaxWindGust, 2) + "</b>"}},
+			{DecoratedText: &googleChatDecoratedText{TopLabel: report.tr("Безопасный порог", "Safe threshold"), Text: report.speed(report.WindGustThreshold, 2)}},
+			{DecoratedText: &googleChatDecoratedText{TopLabel: report.tr("Уровень опасности", "Severity"), Text: report.severityTitle()}},
+			{TextParagraph: &googleChatTextParagraph{Text: report.tr("Рекомендуется <b>не открывать окна в офисе</b> в течение дня.", "Please <b>keep the office windows closed</b> during the day.")}},
+		},
+	}
+
+	if report.AckURL != "" {
+		button := googleChatButton{Text: report.tr("Подтвердить получение", "Acknowledge")}
+		button.OnClick.OpenLink.URL = report.AckURL
+		summary.Widgets = append(summary.Widgets, googleChatWidget{
+			ButtonList: &googleChatButtonList{Buttons: []googleChatButton{button}},
+		})
+	}
+
+	timeline := googleChatSection{
+		Header:                    report.tr("Время сильных порывов", "Times of strong gusts"),
+		Collapsible:               len(report.Forecasts) > 3,
+		UncollapsibleWidgetsCount: 3,
+	}
+	for _, f := range report.Forecasts {
+		timeline.Widgets = append(timeline.Widgets, googleChatWidget{
+			DecoratedText: &googleChatDecoratedText{TopLabel: report.formatTime(f.Time), Text: report.speed(f.WindGust, 2)},
+		})
+	}
+
+	sections := []googleChatSection{summary}
+	if len(timeline.Widgets) > 0 {
+		sections = append(sections, timeline)
+	}
+
+	message := googleChatMessage{
+		Text: report.text(fmt.Sprintf(report.tr("Внимание! %s: сильные порывы ветра %s", "Warning! %s: strong wind gusts %s"), report.City, report.periodTitle())),
+		CardsV2: []googleChatCardRef{{
+			CardID: "windAlert",
+			Card: googleChatCard{
+				Header: googleChatHeader{
+					Title:    report.tr("⚠️ Сильный ветер ", "⚠️ Strong wind ") + report.periodTitle(),
+					Subtitle: report.City,
+				},
+				Sections: sections,
+			},
+		}},
+	}
+
+	if _, err := sendJSON(ctx, http.MethodPost, n.config.WebhookURL, nil, message); err != nil {
+		return fmt.Errorf("ошибка при отправке сообщения в Google Chat: %w", err)
+	}
+
+	log.Println("Предупреждение отправлено в Google Chat")
+	return nil
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Настройки проверок живости и готовности
+type HealthConfig struct {
+	Addr          string        // Адрес отдельного HTTP-сервера для /healthz, /readyz и /metrics (HEALTH_ADDR)
+	MaxCheckAge   time.Duration // Допустимое время с последней проверки; 0 - по расписанию
+	ProbeInterval time.Duration // Интервал проверки доступности OpenWeatherMap и SMTP; 0 - не проверять
+	Pprof         bool          // Профилирование net/http/pprof на HEALTH_ADDR (PPROF)
+}
+
+// Загрузка настроек проверок живости и готовности из переменных окружения
+func loadHealthConfig() HealthConfig {
+	cfg := HealthConfig{
+		Addr:          os.Getenv("HEALTH_ADDR"),
+		ProbeInterval: 5 * time.Minute,
+	}
+
+	if envAge := os.Getenv("HEALTH_MAX_CHECK_AGE"); envAge != "" {
+		if val, err := time.ParseDuration(envAge); err == nil && val >= 0 {
+			cfg.MaxCheckAge = val
+		} else {
+			logWarnf("Ошибка парсинга HEALTH_MAX_CHECK_AGE: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envInterval := os.Getenv("HEALTH_PROBE_INTERVAL"); envInterval != "" {
+		if val, err := time.ParseDuration(envInterval); err == nil && val >= 0 {
+			cfg.ProbeInterval = val
+		} else {
+			logWarnf("Ошибка парсинга HEALTH_PROBE_INTERVAL: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envPprof := os.Getenv("PPROF"); envPprof != "" {
+		if val, err := strconv.ParseBool(envPprof); err == nil {
+			cfg.Pprof = val
+		} else {
+			logWarnf("Ошибка парсинга PPROF: %v, используется значение по умолчанию", err)
+		}
+	}
+	if cfg.Pprof && cfg.Addr == "" {
+		logWarnf("PPROF: профилирование доступно только на служебном адресе HEALTH_ADDR, который не задан")
+	}
+
+	return cfg
+}
+
+// Маршруты профилирования /debug/pprof/ для служебного адреса HEALTH_ADDR
+func registerPprofRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
+
+// Результат проверки доступности внешнего сервиса
+type providerProbe struct {
+	checkedAt time.Time
+	err       error
+}
+
+// Состояние сервиса для /healthz и /readyz: время последней проверки по расписанию,
+// результат последнего запроса прогноза и доступность OpenWeatherMap и SMTP-сервера
+type HealthMonitor struct {
+	config  HealthConfig
+	started time.Time
+
+	mu            sync.Mutex
+	lastCheck     time.Time
+	lastForecast  time.Time
+	forecastError error
+	providers     map[string]providerProbe
+}
+
+func newHealthMonitor(config HealthConfig) *HealthMonitor {
+	return &HealthMonitor{
+		config:    config,
+		started:   time.Now(),
+		providers: make(map[string]providerProbe),
+	}
+}
+
+// Отметка о запуске проверки по расписанию, в том числе пропущенной из-за дня без уведомлений или паузы
+func (h *HealthMonitor) checked(now time.Time) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastCheck = now
+}
+
+// Результат запроса прогноза к OpenWeatherMap
+func (h *HealthMonitor) forecastFetched(now time.Time, err error) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastForecast, h.forecastError = now, err
+}
+
+// Допустимое время с последней проверки: HEALTH_MAX_CHECK_AGE или два интервала
+// непрерывного опроса, а при ежедневном расписании и cron - сутки с запасом
+func (config *Config) maxCheckAge() time.Duration {
+	if config.Health != nil && config.Health.config.MaxCheckAge > 0 {
+		return config.Health.config.MaxCheckAge
+	}
+	if config.Schedule == scheduleContinuous && config.Poll.Interval > 0 {
+		return 2*config.Poll.Interval + time.Minute
+	}
+	return 25 * time.Hour
+}
+
+// Проверка доступности OpenWeatherMap и SMTP-сервера каждые HEALTH_PROBE_INTERVAL;
+// результат используется /readyz, чтобы частые запросы проб не расходовали лимит API
+func (h *HealthMonitor) runProbes(ctx context.Context, store *ConfigStore) {
+	if h.config.ProbeInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(h.config.ProbeInterval)
+	defer ticker.Stop()
+	for {
+		h.probe(ctx, store.Load())
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *HealthMonitor) probe(ctx context.Context, config *Config) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	results := map[string]providerProbe{
+		"openweathermap": {checkedAt: time.Now(), err: preflightOpenWeather(ctx, config)},
+	}
+	// В пробном запуске письма не отправляются, поэтому SMTP не проверяется
+	if !config.DryRun {
+		results["smtp"] = providerProbe{checkedAt: time.Now(), err: preflightSMTP(ctx, config)}
+	}
+	// При остановке сервиса прерванная проверка не учитывается
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for name, result := range results {
+		if result.err != nil {
+			log.Printf("Проверка готовности: %v", result.err)
+		} else if previous, ok := h.providers[name]; ok && previous.err != nil {
+			log.Printf("Проверка готовности: %s снова доступен", name)
+		}
+	}
+	h.providers = results
+}
+
+// Результат одной проверки готовности
+type readinessCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Проверки готовности: конфигурация загружена, проверка по расписанию выполнялась
+// не раньше допустимого срока, внешние сервисы доступны
+func (h *HealthMonitor) readiness(config *Config, now time.Time) []readinessCheck {
+	checks := []readinessCheck{{Name: "config", OK: config != nil}}
+	if config == nil {
+		checks[0].Detail = "конфигурация не загружена"
+		return checks
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// До первой проверки время отсчитывается от запуска сервиса
+	maxAge := config.maxCheckAge()
+	last := h.lastCheck
+	if last.IsZero() {
+		last = h.started
+	}
+	check := readinessCheck{Name: "last_check", OK: now.Sub(last) <= maxAge}
+	if h.lastCheck.IsZero() {
+		check.Detail = fmt.Sprintf("проверок еще не было, сервис запущен %s назад (допустимо %s)", now.Sub(h.started).Round(time.Second), maxAge)
+	} else {
+		check.Detail = fmt.Sprintf("%s, %s назад (допустимо %s)", h.lastCheck.Format(time.RFC3339), now.Sub(h.lastCheck).Round(time.Second), maxAge)
+	}
+	// Ошибка последнего запроса прогноза только сообщается: следующая проверка может быть через сутки
+	if h.forecastError != nil {
+		check.Detail += fmt.Sprintf("; последний запрос прогноза %s завершился ошибкой: %v", h.lastForecast.Format(time.RFC3339), h.forecastError)
+	}
+	checks = append(checks, check)
+
+	if h.config.ProbeInterval <= 0 {
+		return checks
+	}
+	names := []string{"openweathermap"}
+	if !config.DryRun {
+		names = append(names, "smtp")
+	}
+	for _, name := range names {
+		result, ok := h.providers[name]
+		switch {
+		case !ok:
+			checks = append(checks, readinessCheck{Name: name, Detail: "проверка доступности еще не выполнена"})
+		case result.err != nil:
+			checks = append(checks, readinessCheck{Name: name, Detail: result.err.Error()})
+		default:
+			checks = append(checks, readinessCheck{Name: name, OK: true, Detail: "доступен, проверено " + result.checkedAt.Format(time.RFC3339)})
+		}
+	}
+	return checks
+}
+
+// Маршруты /healthz и /readyz для проб Kubernetes и внешнего мониторинга
+func (h *HealthMonitor) registerRoutes(mux *http.ServeMux, store *ConfigStore) {
+	// Процесс работает и отвечает на запросы
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	// Сервис готов: 503, если не пройдена хотя бы одна проверка
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		checks := h.readiness(store.Load(), time.Now())
+		status, code := "ready", http.StatusOK
+		for _, c := range checks {
+			if !c.OK {
+				status, code = "not ready", http.StatusServiceUnavailable
+				break
+			}
+		}
+		writeJSON(w, code, map[string]interface{}{"status": status, "checks": checks})
+	})
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Настройки сигнала о работе сервиса (dead man's switch): внешний сервис вроде
+// healthchecks.io или Uptime Kuma поднимает тревогу, если сигнал не пришел вовремя
+type HeartbeatConfig struct {
+	URL     string // Адрес, запрашиваемый после каждой успешной плановой проверки
+	FailURL string // Адрес, запрашиваемый после неудачной проверки; пустая строка - не сообщать
+}
+
+// Загрузка настроек сигнала о работе из переменных окружения
+func loadHeartbeatConfig() HeartbeatConfig {
+	return HeartbeatConfig{
+		URL:     os.Getenv("HEARTBEAT_URL"),
+		FailURL: os.Getenv("HEARTBEAT_FAIL_URL"),
+	}
+}
+
+// Отправка сигнала о результате плановой проверки. Сигнал отправляет только экземпляр,
+// который рассылает уведомления: иначе резервные экземпляры скрывали бы его сбой.
+func sendHeartbeat(config *Config, store StateStore, ok bool) {
+	url := config.Heartbeat.URL
+	if !ok {
+		url = config.Heartbeat.FailURL
+	}
+	if url == "" || config.DryRun || !store.leading() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := sendRequest(ctx, http.MethodGet, url, nil, nil); err != nil {
+		logErrorf("Ошибка при отправке сигнала о работе сервиса: %v", err)
+		return
+	}
+	log.Println("Сигнал о работе сервиса отправлен")
+}
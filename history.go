@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Максимальное количество хранимых записей о предупреждениях
+const maxHistoryRecords = 500
+
+// Виды записей истории
+const (
+	recordAlert = ""      // Выпущенное предупреждение (записи старых версий не содержат вида)
+	recordCheck = "check" // Проверка без превышения порога
+)
+
+// Запись о выпущенном предупреждении или о проверке без превышения порога
+type AlertRecord struct {
+	ID                string             `json:"id"`
+	Kind              string             `json:"kind,omitempty"`
+	City              string             `json:"city"`
+	IssuedAt          time.Time          `json:"issued_at"`
+	MaxWindGust       float64            `json:"max_wind_gust"`
+	WindGustThreshold float64            `json:"wind_gust_threshold"`
+	Forecasts         []WindGustForecast `json:"forecasts"`
+}
+
+// История выпущенных предупреждений, сохраняемая в хранилище состояния
+type AlertHistory struct {
+	store StateStore
+	path  string // Пустая строка при STORE_BACKEND=json - история хранится только в памяти
+
+	mu      sync.RWMutex
+	records []AlertRecord
+	added   []func(AlertRecord) // Вызываются после добавления записи
+}
+
+// Загрузка истории предупреждений из хранилища
+func loadAlertHistory(store StateStore, path string) (*AlertHistory, error) {
+	h := &AlertHistory{store: store, path: path}
+	store.onLeading(h.reload)
+
+	data, err := store.read(stateHistory, path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении истории предупреждений: %w", err)
+	}
+	if data == nil {
+		return h, nil
+	}
+
+	if err := json.Unmarshal(data, &h.records); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе истории предупреждений: %w", err)
+	}
+	return h, nil
+}
+
+// Загрузка истории, сохраненной экземпляром, который рассылал уведомления до этого
+func (h *AlertHistory) reload() {
+	data, err := h.store.read(stateHistory, h.path)
+	if err != nil {
+		logErrorf("Ошибка при чтении истории предупреждений: %v", err)
+		return
+	}
+	if data == nil {
+		return
+	}
+
+	var records []AlertRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		logErrorf("Ошибка при разборе истории предупреждений: %v", err)
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = records
+}
+
+// Добавление записи о предупреждении
+func (h *AlertHistory) Add(record AlertRecord) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.records = append(h.records, record)
+	if len(h.records) > maxHistoryRecords {
+		h.records = h.records[len(h.records)-maxHistoryRecords:]
+	}
+	for _, fn := range h.added {
+		fn(record)
+	}
+
+	if !h.store.persistent(h.path) || !h.store.leading() {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(h.records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка при формировании JSON: %w", err)
+	}
+
+	if err := h.store.write(stateHistory, h.path, data); err != nil {
+		return fmt.Errorf("ошибка при записи истории предупреждений: %w", err)
+	}
+	return nil
+}
+
+// Подписка на добавление записей; fn не должна обращаться к истории
+func (h *AlertHistory) onAdd(fn func(AlertRecord)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.added = append(h.added, fn)
+}
+
+// Последние предупреждения, начиная с самого свежего
+func (h *AlertHistory) Recent(limit int) []AlertRecord {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var records []AlertRecord
+	for i := len(h.records) - 1; i >= 0 && len(records) < limit; i-- {
+		if h.records[i].Kind == recordAlert {
+			records = append(records, h.records[i])
+		}
+	}
+	return records
+}
+
+// Все записи (предупреждения и проверки) начиная с указанного момента, в хронологическом порядке
+func (h *AlertHistory) Since(t time.Time) []AlertRecord {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var records []AlertRecord
+	for _, r := range h.records {
+		if !r.IssuedAt.Before(t) {
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+func (h *AlertHistory) Name() string {
+	return "history"
+}
+
+// Запись результата проверки в историю: предупреждения попадают в ленту,
+// проверки без превышения нужны для еженедельной сводки
+func (h *AlertHistory) Notify(ctx context.Context, report *AlertReport) error {
+	// Напоминание повторяет уже записанное предупреждение
+	if report.Reminder {
+		return nil
+	}
+
+	id, kind := fmt.Sprintf("alert-%d", report.CheckedAt.Unix()), recordAlert
+	if !report.ExceedsThreshold {
+		id, kind = fmt.Sprintf("check-%d", report.CheckedAt.Unix()), recordCheck
+	}
+
+	return h.Add(AlertRecord{
+		ID:                id,
+		Kind:              kind,
+		City:              report.City,
+		IssuedAt:          report.CheckedAt,
+		MaxWindGust:       report.MaxWindGust,
+		WindGustThreshold: report.WindGustThreshold,
+		Forecasts:         report.Forecasts,
+	})
+}
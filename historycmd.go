@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Команда history: выгрузка базы истории (HISTORY_DB) для отчетов и проверок.
+// Возвращает код завершения: 0 - успешно, 1 - ошибка чтения базы, 2 - неверные аргументы.
+func runHistoryCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) >= 1 && args[0] == "export" {
+		return runHistoryExport(args[1:], stdout, stderr)
+	}
+	fmt.Fprintln(stderr, "Использование: windalerts history export [флаги]")
+	return 2
+}
+
+// Максимальный порыв за день по пункту; день считается по часовому поясу компьютера, как в status
+type dailyMaximum struct {
+	Date              string  `json:"date"`
+	City              string  `json:"city"`
+	MaxWindGust       float64 `json:"max_wind_gust"`
+	WindGustThreshold float64 `json:"wind_gust_threshold"` // Порог проверки с наибольшим порывом
+	Severity          string  `json:"severity"`            // Наибольший уровень опасности за день
+	Checks            int     `json:"checks"`
+	Alerts            int     `json:"alerts"`     // Проверок с превышением порога
+	AlertSent         bool    `json:"alert_sent"` // Предупреждение доставлено хотя бы в один канал
+}
+
+// Выгрузка в JSON; отсутствует то, что не запрошено в --data
+type historyExport struct {
+	ExportedAt time.Time       `json:"exported_at"`
+	From       string          `json:"from,omitempty"`
+	To         string          `json:"to,omitempty"`
+	City       string          `json:"city,omitempty"`
+	Alerts     *[]CheckRecord  `json:"alerts,omitempty"`
+	Daily      *[]dailyMaximum `json:"daily,omitempty"`
+}
+
+// Команда history export: предупреждения с доставкой и максимальные порывы по дням за период
+func runHistoryExport(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("windalerts history export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	from := fs.String("from", "", "с даты ГГГГ-ММ-ДД включительно (по умолчанию с первой записи)")
+	to := fs.String("to", "", "по дату ГГГГ-ММ-ДД включительно (по умолчанию по текущий момент)")
+	format := fs.String("format", "csv", "формат: csv или json")
+	data := fs.String("data", "all", "что выгружать: alerts - предупреждения, daily - максимумы по дням, all - все")
+	city := fs.String("city", "", "только указанный пункт (без учета регистра)")
+	path := fs.String("db", "", "файл SQLite или адрес postgres:// базы истории (по умолчанию HISTORY_DB)")
+	profile := fs.String("profile", "", "профиль конфигурации (PROFILE)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Использование: windalerts history export [флаги] > файл\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "Неожиданные аргументы: %s\n", strings.Join(fs.Args(), " "))
+		return 2
+	}
+
+	if *format != "csv" && *format != "json" {
+		fmt.Fprintln(stderr, "--format: ожидается csv или json")
+		return 2
+	}
+	if *data != "all" && *data != "alerts" && *data != "daily" {
+		fmt.Fprintln(stderr, "--data: ожидается alerts, daily или all")
+		return 2
+	}
+	query := HistoryQuery{City: *city}
+	if *from != "" {
+		t, err := time.ParseInLocation("2006-01-02", *from, time.Local)
+		if err != nil {
+			fmt.Fprintln(stderr, "--from: ожидается дата ГГГГ-ММ-ДД")
+			return 2
+		}
+		query.Since = t
+	}
+	if *to != "" {
+		t, err := time.ParseInLocation("2006-01-02", *to, time.Local)
+		if err != nil {
+			fmt.Fprintln(stderr, "--to: ожидается дата ГГГГ-ММ-ДД")
+			return 2
+		}
+		query.Until = t.AddDate(0, 0, 1)
+	}
+	if !query.Until.IsZero() && !query.Since.Before(query.Until) {
+		fmt.Fprintln(stderr, "--to: дата раньше --from")
+		return 2
+	}
+
+	db, err := openHistoryDBForCommand(*path, *profile, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer db.Close()
+
+	// Максимумы по дням считаются по всем проверкам, поэтому выбираются и проверки без превышения порога
+	records, err := db.Query(query)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	// В выгрузке записи идут в хронологическом порядке
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+
+	export := historyExport{ExportedAt: time.Now().UTC(), From: *from, To: *to, City: *city}
+	if *data != "daily" {
+		alerts := []CheckRecord{}
+		for _, r := range records {
+			if r.ExceedsThreshold {
+				alerts = append(alerts, r)
+			}
+		}
+		export.Alerts = &alerts
+	}
+	if *data != "alerts" {
+		daily := dailyMaxima(records)
+		export.Daily = &daily
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(export)
+	} else {
+		err = writeHistoryCSV(stdout, export)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// Доставлено ли предупреждение хотя бы в один канал
+func alertDelivered(r CheckRecord) bool {
+	if !r.ExceedsThreshold {
+		return false
+	}
+	for _, d := range r.Deliveries {
+		switch d.Status {
+		case deliverySent, deliveryRetried, deliveryAcked:
+			return true
+		}
+	}
+	return false
+}
+
+// Максимальные порывы по дням и пунктам из проверок в хронологическом порядке
+func dailyMaxima(records []CheckRecord) []dailyMaximum {
+	days := []dailyMaximum{}
+	index := make(map[string]int)
+	for _, r := range records {
+		date := r.CheckedAt.Local().Format("2006-01-02")
+		key := date + "|" + strings.ToLower(r.City)
+		i, ok := index[key]
+		if !ok {
+			i = len(days)
+			index[key] = i
+			days = append(days, dailyMaximum{Date: date, City: r.City, Severity: r.Severity})
+		}
+		d := &days[i]
+
+		d.Checks++
+		if r.MaxWindGust > d.MaxWindGust || d.Checks == 1 {
+			d.MaxWindGust, d.WindGustThreshold = r.MaxWindGust, r.WindGustThreshold
+		}
+		if current, err := parseSeverity(d.Severity); err == nil {
+			if severity, err := parseSeverity(r.Severity); err == nil && severity > current {
+				d.Severity = r.Severity
+			}
+		}
+		if r.ExceedsThreshold {
+			d.Alerts++
+		}
+		d.AlertSent = d.AlertSent || alertDelivered(r)
+	}
+	return days
+}
+
+// Выгрузка в CSV одной таблицей: колонка record отделяет предупреждения (alert) от максимумов по дням (daily)
+func writeHistoryCSV(w io.Writer, export historyExport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"record", "date", "time", "city", "severity", "max_gust_ms", "threshold_ms", "alert_sent", "rule", "reminder", "recipients", "deliveries"})
+
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+	number := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+
+	if export.Alerts != nil {
+		for _, r := range *export.Alerts {
+			var deliveries []string
+			for _, d := range r.Deliveries {
+				channel := d.Channel
+				if d.Recipient != "" {
+					channel += " (" + d.Recipient + ")"
+				}
+				deliveries = append(deliveries, channel+": "+d.Status)
+			}
+			reminder := ""
+			if r.Reminder {
+				reminder = "yes"
+			}
+			checkedAt := r.CheckedAt.Local()
+			cw.Write([]string{"alert", checkedAt.Format("2006-01-02"), checkedAt.Format("15:04:05"), r.City, r.Severity,
+				number(r.MaxWindGust), number(r.WindGustThreshold), yesNo(alertDelivered(r)), r.Rule, reminder,
+				strings.Join(r.Recipients, ";"), strings.Join(deliveries, "; ")})
+		}
+	}
+	if export.Daily != nil {
+		for _, d := range *export.Daily {
+			cw.Write([]string{"daily", d.Date, "", d.City, d.Severity,
+				number(d.MaxWindGust), number(d.WindGustThreshold), yesNo(d.AlertSent), "", "", "", ""})
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
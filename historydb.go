@@ -0,0 +1,295 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Статусы доставки уведомлений в базе истории
+const (
+	deliverySent     = "sent"         // Уведомление доставлено
+	deliveryFailed   = "failed"       // Ошибка, уведомление поставлено в очередь повторной доставки
+	deliveryDeferred = "deferred"     // Отложено до окончания периода тишины
+	deliveryDropped  = "dropped"      // Не доставлено за RETRY_MAX_PERIOD
+	deliveryRetried  = "retried"      // Доставлено повторной попыткой после ошибки
+	deliveryAcked    = "acknowledged" // Получатель подтвердил предупреждение
+)
+
+// История проверок и доставки уведомлений в базе SQLite или PostgreSQL (HISTORY_DB).
+// В отличие от HISTORY_FILE хранит все результаты без ограничения числа записей,
+// получателей и статус доставки по каждому каналу; базу можно опрашивать через
+// /api/history, командой status или любым клиентом СУБД.
+type HistoryDB struct {
+	db    *sql.DB
+	store HistoryStore
+}
+
+// Открытие базы истории с созданием таблиц: путь к файлу SQLite или адрес
+// postgres://...; пустая строка - база не используется
+func openHistoryDB(dsn string) (*HistoryDB, error) {
+	if dsn == "" {
+		return nil, nil
+	}
+
+	store := historyStoreFor(dsn)
+	db, err := store.open(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при открытии базы истории: %w", err)
+	}
+	if err := store.migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ошибка при создании таблиц базы истории %s: %w", store.describe(dsn), err)
+	}
+	return &HistoryDB{db: db, store: store}, nil
+}
+
+// Запись результата проверки; возвращает идентификатор для записей о доставке
+func (h *HistoryDB) RecordCheck(report *AlertReport) int64 {
+	if h == nil {
+		return 0
+	}
+	var id int64
+	err := h.db.QueryRow(h.store.rebind(`INSERT INTO checks (checked_at, city, city_key, exceeds_threshold, severity, max_wind_gust, wind_gust_threshold, rule, recipients, reminder)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		report.CheckedAt.UTC(), report.City, strings.ToLower(report.City), report.ExceedsThreshold, report.Severity.String(),
+		report.MaxWindGust, report.WindGustThreshold, report.Rule, strings.Join(report.Recipients, ", "), report.Reminder).Scan(&id)
+	if err != nil {
+		logErrorf("Ошибка при записи проверки в базу истории: %v", err)
+		return 0
+	}
+	return id
+}
+
+// Запись попытки доставки уведомления по каналу: по строке на каждого получателя
+// (без получателей - одна строка на канал). При частичной ошибке (recipientErrors)
+// получатели, которым уведомление доставлено, записываются как доставленные.
+func (h *HistoryDB) RecordDelivery(report *AlertReport, channel string, recipients []string, status string, attempt int, deliveryErr error) {
+	if h == nil || report.HistoryID == 0 {
+		return
+	}
+	if len(recipients) == 0 {
+		h.insertDelivery(report.HistoryID, channel, "", status, attempt, deliveryErr)
+		return
+	}
+
+	var partial *recipientErrors
+	errors.As(deliveryErr, &partial)
+	for _, recipient := range recipients {
+		recipientStatus, recipientErr := status, deliveryErr
+		if partial != nil {
+			if err, failed := partial.failed[recipient]; failed {
+				recipientErr = err
+			} else {
+				recipientStatus, recipientErr = deliverySent, nil
+				if attempt > 1 {
+					recipientStatus = deliveryRetried
+				}
+			}
+		}
+		h.insertDelivery(report.HistoryID, channel, recipient, recipientStatus, attempt, recipientErr)
+	}
+}
+
+// Запись подтверждения предупреждения: канал - способ подтверждения (link, api)
+func (h *HistoryDB) RecordAck(report *AlertReport, by, via string) {
+	if h == nil || report == nil || report.HistoryID == 0 {
+		return
+	}
+	h.insertDelivery(report.HistoryID, via, by, deliveryAcked, 0, nil)
+}
+
+func (h *HistoryDB) insertDelivery(checkID int64, channel, recipient, status string, attempt int, deliveryErr error) {
+	message := ""
+	if deliveryErr != nil {
+		message = deliveryErr.Error()
+	}
+	if _, err := h.db.Exec(h.store.rebind(`INSERT INTO deliveries (check_id, channel, recipient, status, attempt, error, delivered_at) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		checkID, channel, recipient, status, attempt, message, time.Now().UTC()); err != nil {
+		logErrorf("Ошибка при записи доставки в базу истории: %v", err)
+	}
+}
+
+// Закрытие базы
+func (h *HistoryDB) Close() {
+	if h == nil {
+		return
+	}
+	if err := h.db.Close(); err != nil {
+		logErrorf("Ошибка при закрытии базы истории: %v", err)
+	}
+}
+
+// Доставка уведомления по каналу и получателю
+type DeliveryRecord struct {
+	Channel     string    `json:"channel"`
+	Recipient   string    `json:"recipient,omitempty"`
+	Status      string    `json:"status"`
+	Attempt     int       `json:"attempt"`
+	Error       string    `json:"error,omitempty"`
+	DeliveredAt time.Time `json:"delivered_at"`
+}
+
+// Результат проверки из базы истории вместе с доставкой по каналам
+type CheckRecord struct {
+	ID                int64            `json:"id"`
+	CheckedAt         time.Time        `json:"checked_at"`
+	City              string           `json:"city"`
+	ExceedsThreshold  bool             `json:"exceeds_threshold"`
+	Severity          string           `json:"severity"`
+	MaxWindGust       float64          `json:"max_wind_gust"`
+	WindGustThreshold float64          `json:"wind_gust_threshold"`
+	Rule              string           `json:"rule,omitempty"`
+	Recipients        []string         `json:"recipients,omitempty"`
+	Reminder          bool             `json:"reminder,omitempty"`
+	Deliveries        []DeliveryRecord `json:"deliveries"`
+}
+
+// Условия выборки из базы истории
+type HistoryQuery struct {
+	City       string    // Пункт (без учета регистра)
+	Since      time.Time // Не раньше указанного момента
+	Until      time.Time // Раньше указанного момента; нулевое значение - без ограничения
+	AlertsOnly bool      // Только проверки с превышением порога
+	Limit      int       // Число проверок; 0 - без ограничения
+}
+
+// Число проверок в одном запросе доставки: число параметров запроса в SQLite и PostgreSQL ограничено
+const historyDeliveryBatch = 500
+
+// Результаты проверок по условиям, начиная с самого свежего
+func (h *HistoryDB) Query(q HistoryQuery) ([]CheckRecord, error) {
+	where := []string{"checked_at >= ?"}
+	args := []any{q.Since.UTC()}
+	if q.City != "" {
+		where = append(where, "city_key = ?")
+		args = append(args, strings.ToLower(q.City))
+	}
+	if !q.Until.IsZero() {
+		where = append(where, "checked_at < ?")
+		args = append(args, q.Until.UTC())
+	}
+	if q.AlertsOnly {
+		where = append(where, "exceeds_threshold")
+	}
+	limit := ""
+	if q.Limit > 0 {
+		limit = " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := h.db.Query(h.store.rebind(`SELECT id, checked_at, city, exceeds_threshold, severity, max_wind_gust, wind_gust_threshold, rule, recipients, reminder
+		FROM checks WHERE `+strings.Join(where, " AND ")+` ORDER BY checked_at DESC, id DESC`+limit), args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении базы истории: %w", err)
+	}
+	defer rows.Close()
+
+	records := []CheckRecord{}
+	index := map[int64]int{}
+	for rows.Next() {
+		var r CheckRecord
+		var recipients string
+		if err := rows.Scan(&r.ID, &r.CheckedAt, &r.City, &r.ExceedsThreshold, &r.Severity, &r.MaxWindGust,
+			&r.WindGustThreshold, &r.Rule, &recipients, &r.Reminder); err != nil {
+			return nil, fmt.Errorf("ошибка при чтении базы истории: %w", err)
+		}
+		r.Recipients = parseEmailList(recipients)
+		r.Deliveries = []DeliveryRecord{}
+		index[r.ID] = len(records)
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении базы истории: %w", err)
+	}
+	if len(records) == 0 {
+		return records, nil
+	}
+
+	// Доставка по каналам для найденных проверок
+	for start := 0; start < len(records); start += historyDeliveryBatch {
+		batch := records[start:min(start+historyDeliveryBatch, len(records))]
+		if err := h.queryDeliveries(batch, records, index); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// Чтение доставки для части проверок в records
+func (h *HistoryDB) queryDeliveries(batch, records []CheckRecord, index map[int64]int) error {
+	ids := make([]any, 0, len(batch))
+	for _, r := range batch {
+		ids = append(ids, r.ID)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	deliveries, err := h.db.Query(h.store.rebind(`SELECT check_id, channel, recipient, status, attempt, error, delivered_at
+		FROM deliveries WHERE check_id IN (`+placeholders+`) ORDER BY id`), ids...)
+	if err != nil {
+		return fmt.Errorf("ошибка при чтении базы истории: %w", err)
+	}
+	defer deliveries.Close()
+	for deliveries.Next() {
+		var checkID int64
+		var d DeliveryRecord
+		if err := deliveries.Scan(&checkID, &d.Channel, &d.Recipient, &d.Status, &d.Attempt, &d.Error, &d.DeliveredAt); err != nil {
+			return fmt.Errorf("ошибка при чтении базы истории: %w", err)
+		}
+		records[index[checkID]].Deliveries = append(records[index[checkID]].Deliveries, d)
+	}
+	return deliveries.Err()
+}
+
+func (h *HistoryDB) registerRoutes(mux *http.ServeMux) {
+	if h == nil {
+		return
+	}
+	mux.HandleFunc("/api/history", h.handleHistory)
+}
+
+// GET /api/history?city=Москва&since=2026-10-01&alerts=true&limit=50 - результаты проверок
+// с доставкой по каналам, начиная с самого свежего
+func (h *HistoryDB) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+		return
+	}
+
+	query := HistoryQuery{City: r.URL.Query().Get("city"), Limit: 100}
+	if since := r.URL.Query().Get("since"); since != "" {
+		t, err := time.Parse("2006-01-02", since)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "since: ожидается дата ГГГГ-ММ-ДД")
+			return
+		}
+		query.Since = t
+	}
+	if alerts := r.URL.Query().Get("alerts"); alerts != "" {
+		val, err := strconv.ParseBool(alerts)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "alerts: ожидается true или false")
+			return
+		}
+		query.AlertsOnly = val
+	}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		val, err := strconv.Atoi(limit)
+		if err != nil || val < 1 || val > 1000 {
+			writeError(w, http.StatusBadRequest, "limit: ожидается число от 1 до 1000")
+			return
+		}
+		query.Limit = val
+	}
+
+	records, err := h.Query(query)
+	if err != nil {
+		logErrorf("Ошибка при запросе истории: %v", err)
+		writeError(w, http.StatusInternalServerError, "ошибка при чтении истории")
+		return
+	}
+	writeJSON(w, http.StatusOK, records)
+}
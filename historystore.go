@@ -0,0 +1,196 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// Хранилище базы истории: особенности СУБД, в которой хранятся проверки и доставка.
+// Запросы HistoryDB общие для всех хранилищ и пишутся с параметрами ?.
+type HistoryStore interface {
+	// Подключение к базе
+	open(dsn string) (*sql.DB, error)
+	// Создание таблиц и обновление схемы до текущей версии
+	migrate(db *sql.DB) error
+	// Запрос с параметрами в синтаксисе СУБД
+	rebind(query string) string
+	// Описание базы для журнала без пароля
+	describe(dsn string) string
+}
+
+// Хранилище по значению HISTORY_DB: адрес postgres:// - PostgreSQL (и TimescaleDB), иначе файл SQLite
+func historyStoreFor(dsn string) HistoryStore {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		return postgresHistoryStore{}
+	}
+	return sqliteHistoryStore{}
+}
+
+// Является ли HISTORY_DB адресом сервера, а не файлом
+func isHistoryDBURL(dsn string) bool {
+	_, ok := historyStoreFor(dsn).(postgresHistoryStore)
+	return ok
+}
+
+// Встроенная база SQLite: внешний сервер и CGO не нужны
+type sqliteHistoryStore struct{}
+
+// Схема SQLite: результаты проверок и доставка уведомлений по каналам
+const sqliteHistorySchema = `
+CREATE TABLE IF NOT EXISTS checks (
+	id                  INTEGER PRIMARY KEY AUTOINCREMENT,
+	checked_at          TIMESTAMP NOT NULL,
+	city                TEXT NOT NULL,
+	city_key            TEXT NOT NULL, -- Название пункта в нижнем регистре для поиска
+	exceeds_threshold   INTEGER NOT NULL,
+	severity            TEXT NOT NULL,
+	max_wind_gust       REAL NOT NULL,
+	wind_gust_threshold REAL NOT NULL,
+	rule                TEXT NOT NULL DEFAULT '',
+	recipients          TEXT NOT NULL DEFAULT '',
+	reminder            INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS checks_checked_at ON checks (checked_at);
+CREATE INDEX IF NOT EXISTS checks_city_key ON checks (city_key);
+CREATE TABLE IF NOT EXISTS deliveries (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	check_id     INTEGER NOT NULL REFERENCES checks (id),
+	channel      TEXT NOT NULL,
+	status       TEXT NOT NULL,
+	attempt      INTEGER NOT NULL DEFAULT 1,
+	error        TEXT NOT NULL DEFAULT '',
+	delivered_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS deliveries_check_id ON deliveries (check_id);
+`
+
+// Изменения схемы SQLite после первой версии; номер примененного изменения хранится в PRAGMA user_version
+var sqliteHistoryMigrations = []string{
+	// Получатель уведомления: адрес, номер телефона или подтвердивший предупреждение
+	`ALTER TABLE deliveries ADD COLUMN recipient TEXT NOT NULL DEFAULT ''`,
+}
+
+func (sqliteHistoryStore) open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite допускает одну запись за раз, поэтому запросы выполняются по одному соединению
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// Создание таблиц и применение изменений схемы, еще не примененных к базе
+func (sqliteHistoryStore) migrate(db *sql.DB) error {
+	if _, err := db.Exec(sqliteHistorySchema); err != nil {
+		return err
+	}
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	for ; version < len(sqliteHistoryMigrations); version++ {
+		if _, err := db.Exec(sqliteHistoryMigrations[version]); err != nil {
+			return err
+		}
+		if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sqliteHistoryStore) rebind(query string) string {
+	return query
+}
+
+func (sqliteHistoryStore) describe(path string) string {
+	return path
+}
+
+// Сервер PostgreSQL или TimescaleDB: одна база для нескольких экземпляров сервиса и BI-инструментов
+type postgresHistoryStore struct{}
+
+// Схема PostgreSQL; изменения схемы добавляются в конец как ALTER TABLE ... IF NOT EXISTS
+const postgresHistorySchema = `
+CREATE TABLE IF NOT EXISTS checks (
+	id                  BIGSERIAL PRIMARY KEY,
+	checked_at          TIMESTAMPTZ NOT NULL,
+	city                TEXT NOT NULL,
+	city_key            TEXT NOT NULL,
+	exceeds_threshold   BOOLEAN NOT NULL,
+	severity            TEXT NOT NULL,
+	max_wind_gust       DOUBLE PRECISION NOT NULL,
+	wind_gust_threshold DOUBLE PRECISION NOT NULL,
+	rule                TEXT NOT NULL DEFAULT '',
+	recipients          TEXT NOT NULL DEFAULT '',
+	reminder            BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE INDEX IF NOT EXISTS checks_checked_at ON checks (checked_at);
+CREATE INDEX IF NOT EXISTS checks_city_key ON checks (city_key);
+CREATE TABLE IF NOT EXISTS deliveries (
+	id           BIGSERIAL PRIMARY KEY,
+	check_id     BIGINT NOT NULL REFERENCES checks (id),
+	channel      TEXT NOT NULL,
+	recipient    TEXT NOT NULL DEFAULT '',
+	status       TEXT NOT NULL,
+	attempt      INTEGER NOT NULL DEFAULT 1,
+	error        TEXT NOT NULL DEFAULT '',
+	delivered_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS deliveries_check_id ON deliveries (check_id);
+`
+
+// Ключ блокировки pg_advisory_xact_lock на время создания таблиц
+const postgresHistoryLock = 7_294_113
+
+func (postgresHistoryStore) open(dsn string) (*sql.DB, error) {
+	return sql.Open("postgres", dsn)
+}
+
+// Таблицы создаются в транзакции под блокировкой, чтобы одновременно запущенные
+// экземпляры сервиса не мешали друг другу
+func (postgresHistoryStore) migrate(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", postgresHistoryLock); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(postgresHistorySchema); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Параметры ? заменяются на $1, $2...
+func (postgresHistoryStore) rebind(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (postgresHistoryStore) describe(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "PostgreSQL"
+	}
+	return u.Redacted()
+}
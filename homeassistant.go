@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Описание устройства, объединяющего сущности Home Assistant
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+}
+
+// Конфигурация сущности для MQTT discovery Home Assistant
+type haEntityConfig struct {
+	Name              string   `json:"name"`
+	UniqueID          string   `json:"unique_id"`
+	ObjectID          string   `json:"object_id"`
+	StateTopic        string   `json:"state_topic"`
+	DeviceClass       string   `json:"device_class,omitempty"`
+	StateClass        string   `json:"state_class,omitempty"`
+	UnitOfMeasurement string   `json:"unit_of_measurement,omitempty"`
+	PayloadOn         string   `json:"payload_on,omitempty"`
+	PayloadOff        string   `json:"payload_off,omitempty"`
+	Icon              string   `json:"icon,omitempty"`
+	Device            haDevice `json:"device"`
+}
+
+// Топики обнаружения Home Assistant для состояния предупреждения,
+// максимального порыва ветра, порога и времени следующей проверки. При нескольких
+// пунктах slug - имя пункта в топиках, и каждый пункт становится отдельным устройством.
+func homeAssistantDiscoveryMessages(cfg MQTTConfig, slug, place string) ([]mqttMessage, error) {
+	nodeID := cfg.ClientID
+	deviceName := "Мониторинг порывов ветра"
+	if slug != "" {
+		nodeID += "_" + slug
+		deviceName += ": " + place
+	}
+	device := haDevice{
+		Identifiers:  []string{nodeID},
+		Name:         deviceName,
+		Manufacturer: "WindAlerts",
+		Model:        "OpenWeatherMap",
+	}
+
+	entities := []struct {
+		component string
+		objectID  string
+		config    haEntityConfig
+	}{
+		{"binary_sensor", "alert", haEntityConfig{
+			Name:        "Предупреждение о сильном ветре",
+			DeviceClass: "safety",
+			PayloadOn:   "ON",
+			PayloadOff:  "OFF",
+			Icon:        "mdi:weather-windy",
+		}},
+		{"sensor", "max_gust", haEntityConfig{
+			Name:              "Максимальный порыв ветра",
+			DeviceClass:       "wind_speed",
+			StateClass:        "measurement",
+			UnitOfMeasurement: "m/s",
+		}},
+		{"sensor", "threshold", haEntityConfig{
+			Name:              "Порог порывов ветра",
+			DeviceClass:       "wind_speed",
+			UnitOfMeasurement: "m/s",
+		}},
+		{"sensor", "next_check", haEntityConfig{
+			Name:        "Следующая проверка",
+			DeviceClass: "timestamp",
+			Icon:        "mdi:clock-outline",
+		}},
+	}
+
+	var messages []mqttMessage
+	for _, e := range entities {
+		entity := e.config
+		entity.UniqueID = nodeID + "_" + e.objectID
+		entity.ObjectID = nodeID + "_" + e.objectID
+		entity.StateTopic = cfg.topicPrefix(slug) + "/" + e.objectID
+		entity.Device = device
+
+		payload, err := json.Marshal(entity)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при формировании конфигурации Home Assistant: %w", err)
+		}
+
+		// Конфигурация обнаружения всегда сохраняется на брокере, чтобы Home Assistant
+		// получил ее после собственного перезапуска
+		messages = append(messages, mqttMessage{
+			topic:    fmt.Sprintf("%s/%s/%s/%s/config", cfg.HADiscoveryPrefix, e.component, nodeID, e.objectID),
+			payload:  string(payload),
+			retained: true,
+		})
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// Ключ дедупликации инцидента по городу
+func alertDedupKey(city string) string {
+	return "windalerts-" + strings.ToLower(strings.ReplaceAll(city, " ", "-"))
+}
+
+// Состояние инцидентов по городам для систем дежурства (PagerDuty, Opsgenie)
+type incidentTracker struct {
+	mu sync.Mutex
+	// Открыт ли инцидент по городу; при отсутствии записи (после запуска)
+	// отбой отправляется на всякий случай - системы дежурства игнорируют его для неизвестных ключей
+	open map[string]bool
+}
+
+func newIncidentTracker() *incidentTracker {
+	return &incidentTracker{open: make(map[string]bool)}
+}
+
+// Может ли быть открыт инцидент по городу
+func (t *incidentTracker) mayBeOpen(city string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	open, known := t.open[city]
+	return !known || open
+}
+
+// Открыт ли инцидент по городу; в отличие от mayBeOpen, неизвестное состояние считается закрытым
+func (t *incidentTracker) isOpen(city string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.open[city]
+}
+
+// Запоминание состояния инцидента после успешной отправки события
+func (t *incidentTracker) set(city string, open bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.open[city] = open
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Настройки записи метрик в InfluxDB 2.x (HTTP API /api/v2/write)
+type InfluxDBConfig struct {
+	URL    string // Адрес сервера, например http://influxdb:8086
+	Org    string // Организация
+	Bucket string // Bucket для метрик
+	Token  string // Токен с правом записи в bucket
+}
+
+// Загрузка настроек InfluxDB из переменных окружения
+func loadInfluxDBConfig() InfluxDBConfig {
+	return InfluxDBConfig{
+		URL:    strings.TrimSuffix(os.Getenv("INFLUXDB_URL"), "/"),
+		Org:    os.Getenv("INFLUXDB_ORG"),
+		Bucket: os.Getenv("INFLUXDB_BUCKET"),
+		Token:  os.Getenv("INFLUXDB_TOKEN"),
+	}
+}
+
+// Запись точек прогноза и результата каждой проверки в InfluxDB для графиков Grafana
+type influxDBNotifier struct {
+	config InfluxDBConfig
+}
+
+func newInfluxDBNotifier(config InfluxDBConfig) *influxDBNotifier {
+	return &influxDBNotifier{config: config}
+}
+
+func (n *influxDBNotifier) Name() string {
+	return "influxdb"
+}
+
+// Точки в формате line protocol: порывы ветра по каждой точке прогноза (wind_forecast,
+// время точки прогноза) и результат проверки (wind_check, время проверки)
+func influxLines(report *AlertReport) []string {
+	reports := []*AlertReport{report}
+	if len(report.Locations) > 0 {
+		reports = report.Locations
+	}
+
+	var lines []string
+	for _, r := range reports {
+		city := influxEscapeTag(r.City)
+		for _, point := range r.Points {
+			lines = append(lines, fmt.Sprintf("wind_forecast,city=%s gust=%s,threshold=%s,exceeds=%t %d",
+				city, influxFloat(point.WindGust), influxFloat(r.WindGustThreshold), point.WindGust > r.WindGustThreshold, point.Time.Unix()))
+		}
+
+		fields := fmt.Sprintf("alert=%t,max_gust=%s,threshold=%s,reminder=%t",
+			r.ExceedsThreshold, influxFloat(r.MaxWindGust), influxFloat(r.WindGustThreshold), r.Reminder)
+		if r.Rule != "" {
+			fields += ",rule=" + influxString(r.Rule)
+		}
+		lines = append(lines, fmt.Sprintf("wind_check,city=%s,severity=%s %s %d", city, r.Severity.String(), fields, r.CheckedAt.Unix()))
+	}
+	return lines
+}
+
+// Результат отправляется после каждой проверки, чтобы на графиках были и дни без превышения порога
+func (n *influxDBNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	lines := influxLines(report)
+	query := url.Values{
+		"org":       {n.config.Org},
+		"bucket":    {n.config.Bucket},
+		"precision": {"s"},
+	}
+	headers := map[string]string{
+		"Authorization": "Token " + n.config.Token,
+		"Content-Type":  "text/plain; charset=utf-8",
+	}
+
+	body := strings.NewReader(strings.Join(lines, "\n") + "\n")
+	if _, err := sendRequest(ctx, http.MethodPost, n.config.URL+"/api/v2/write?"+query.Encode(), headers, body); err != nil {
+		return fmt.Errorf("ошибка при записи метрик в InfluxDB: %w", err)
+	}
+
+	log.Printf("Метрики записаны в InfluxDB (%d точек)", len(lines))
+	return nil
+}
+
+// Экранирование значения тега: запятые, пробелы и знаки равенства
+func influxEscapeTag(value string) string {
+	return strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`).Replace(value)
+}
+
+// Строковое поле в кавычках
+func influxString(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// Число с плавающей точкой без экспоненты
+func influxFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Языки писем
+const (
+	languageRU = "ru" // Русский (по умолчанию)
+	languageEN = "en" // Английский
+)
+
+// Единицы скорости ветра в письмах
+const (
+	unitsMS    = "ms"    // Метры в секунду (по умолчанию)
+	unitsKMH   = "kmh"   // Километры в час
+	unitsMPH   = "mph"   // Мили в час
+	unitsKnots = "knots" // Узлы
+	unitsBft   = "bft"   // Баллы по шкале Бофорта
+)
+
+// Нижние границы баллов шкалы Бофорта в м/с
+var beaufortScale = []float64{0, 0.5, 1.6, 3.4, 5.5, 8.0, 10.8, 13.9, 17.2, 20.8, 24.5, 28.5, 32.7}
+
+// Разбор единиц скорости ветра; допускаются обозначения m/s, km/h, kn, beaufort и т.п.
+func parseUnits(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", unitsMS, "m/s", "mps":
+		return unitsMS, nil
+	case unitsKMH, "km/h", "kph":
+		return unitsKMH, nil
+	case unitsMPH, "mi/h":
+		return unitsMPH, nil
+	case unitsKnots, "knot", "kn", "kt", "kts":
+		return unitsKnots, nil
+	case unitsBft, "beaufort":
+		return unitsBft, nil
+	default:
+		return "", fmt.Errorf("неизвестные единицы %q (ожидается ms, kmh, mph, knots или bft)", value)
+	}
+}
+
+// Балл по шкале Бофорта для скорости ветра в м/с
+func beaufort(ms float64) int {
+	force := 0
+	for i, bound := range beaufortScale {
+		if ms >= bound {
+			force = i
+		}
+	}
+	return force
+}
+
+// Перевод скорости ветра из м/с в выбранные единицы
+func convertSpeed(ms float64, units string) float64 {
+	switch units {
+	case unitsKMH:
+		return ms * 3.6
+	case unitsMPH:
+		return ms * 2.236936
+	case unitsKnots:
+		return ms * 1.943844
+	case unitsBft:
+		return float64(beaufort(ms))
+	default:
+		return ms
+	}
+}
+
+// Перевод скорости ветра из выбранных единиц в м/с; балл Бофорта переводится
+// в нижнюю границу балла, чтобы порог в 8 баллов срабатывал с 17.2 м/с
+func toMetersPerSecond(value float64, units string) float64 {
+	switch units {
+	case unitsKMH:
+		return value / 3.6
+	case unitsMPH:
+		return value / 2.236936
+	case unitsKnots:
+		return value / 1.943844
+	case unitsBft:
+		force := int(math.Ceil(value))
+		force = max(0, min(force, len(beaufortScale)-1))
+		return beaufortScale[force]
+	default:
+		return value
+	}
+}
+
+// Скорость ветра в выбранных единицах с обозначением; баллы Бофорта - целым числом
+func formatSpeed(ms float64, units string, precision int) string {
+	return formatSpeedIn(ms, units, languageRU, precision)
+}
+
+// Скорость ветра в выбранных единицах с обозначением на языке письма
+func formatSpeedIn(ms float64, units, language string, precision int) string {
+	if units == unitsBft {
+		return fmt.Sprintf("%d %s", beaufort(ms), speedUnitLabel(units, language))
+	}
+	return fmt.Sprintf("%.*f %s", precision, convertSpeed(ms, units), speedUnitLabel(units, language))
+}
+
+// Функции шаблонов писем: {{speed .MaxWindGust 2}} выводит скорость в м/с из данных
+// в единицах получателя с заданным числом знаков после запятой, {{unit}} - обозначение единиц
+func speedFuncs(units, language string) map[string]any {
+	return map[string]any{
+		"speed": func(ms float64, precision int) string { return formatSpeedIn(ms, units, language, precision) },
+		"unit":  func() string { return speedUnitLabel(units, language) },
+	}
+}
+
+// Обозначение единиц скорости на языке письма
+func speedUnitLabel(units, language string) string {
+	labels := map[string][2]string{
+		unitsMS:    {"м/с", "m/s"},
+		unitsKMH:   {"км/ч", "km/h"},
+		unitsMPH:   {"миль/ч", "mph"},
+		unitsKnots: {"уз", "kn"},
+		unitsBft:   {"Бфт", "Bft"},
+	}
+	label, ok := labels[units]
+	if !ok {
+		label = labels[unitsMS]
+	}
+	if language == languageEN {
+		return label[1]
+	}
+	return label[0]
+}
+
+// Период проверки на языке письма
+func (r *AlertReport) periodTitleIn(language string) string {
+	if language == languageEN {
+		switch r.LookaheadDays {
+		case 0:
+			return "today"
+		case 1:
+			return "today and tomorrow"
+		default:
+			return fmt.Sprintf("in the next %d days", r.LookaheadDays+1)
+		}
+	}
+	switch r.LookaheadDays {
+	case 0:
+		return "сегодня"
+	case 1:
+		return "сегодня и завтра"
+	case 2, 3:
+		return fmt.Sprintf("в ближайшие %d дня", r.LookaheadDays+1)
+	default:
+		return fmt.Sprintf("в ближайшие %d дней", r.LookaheadDays+1)
+	}
+}
+
+// Тема письма с предупреждением на языке получателя
+func alertSubject(report *AlertReport, language string) string {
+	if language == languageEN {
+		if report.Reminder {
+			return "REMINDER: Strong wind " + report.periodTitleIn(language)
+		}
+		return "WARNING: Strong wind " + report.periodTitleIn(language)
+	}
+	if report.Reminder {
+		return "НАПОМИНАНИЕ: Сильный ветер " + report.periodTitleIn(language)
+	}
+	return "ВНИМАНИЕ: Сильный ветер " + report.periodTitleIn(language)
+}
+
+// Шаблоны письма с предупреждением на английском языке
+const emailHTMLTemplateTextEN = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Weather alert</title>
+</head>
+<body style="margin: 0; padding: 0; background-color: #f4f4f4; font-family: Arial, sans-serif;">
+    <table border="0" cellpadding="0" cellspacing="0" width="100%" bgcolor="#f4f4f4" style="background-color: #f4f4f4;">
+        <tr>
+            <td align="center" style="padding: 20px 0;">
+                <table border="0" cellpadding="0" cellspacing="0" width="600" style="background-color: #ffffff; border-radius: 8px; max-width: 600px; width: 100%;">
+                    <tr>
+                        <td style="padding: 20px;">
+                            <h1 style="color: #d9534f; font-size: 24px; text-align: center; margin-top: 0; margin-bottom: 20px;">{{if .Reminder}}Reminder{{else}}Warning!{{end}}</h1>
+                            {{if .Name}}<p style="font-size: 16px; line-height: 1.5; color: #333333;">Hello, {{.Name}}!</p>{{end}}
+                            {{if .Locations}}<p style="font-size: 16px; line-height: 1.5; color: #333333;">Strong wind gusts above the safe threshold are expected {{.Period}}:</p>
+                            {{range .Locations}}<p style="font-size: 16px; line-height: 1.5; color: #333333; margin-bottom: 5px;"><b>{{.Name}}</b>: up to <b style="color: #d9534f;">{{speed .MaxWindGust 1}}</b> (threshold {{speed .WindGustThreshold 1}})</p>
+                            <ul style="font-size: 16px; line-height: 1.5; color: #333333;">
+                                {{range .Forecasts}}<li><b>{{.Time}}</b>: {{speed .WindGust 1}}</li>
+                                {{end}}
+                            </ul>
+                            {{end}}{{else}}<p style="font-size: 16px; line-height: 1.5; color: #333333;">Strong wind gusts of <b style="color: #d9534f;">{{speed .MaxWindGust 1}}</b> are expected {{.Period}}, exceeding the safe threshold of <b style="color: #d9534f;">{{speed .WindGustThreshold 1}}</b>.</p>
+                            {{if .Forecasts}}<p style="font-size: 16px; line-height: 1.5; color: #333333; margin-bottom: 5px;">Times of strong gusts:</p>
+                            <ul style="font-size: 16px; line-height: 1.5; color: #333333;">
+                                {{range .Forecasts}}<li><b>{{.Time}}</b>: {{speed .WindGust 1}}</li>
+                                {{end}}
+                            </ul>{{end}}{{end}}
+                            {{if .Chart}}<p><img src="cid:forecast-chart.png" alt="Wind gust forecast chart" width="640" style="display: block; max-width: 100%; height: auto; border: 0;"></p>{{end}}
+                            <p style="font-size: 16px; line-height: 1.5; color: #333333;">Please <b style="color: #d9534f;">keep the office windows closed</b> during the day.</p>
+                            {{if .AckURL}}<p style="text-align: center;"><a href="{{.AckURL}}" style="display: inline-block; padding: 10px 20px; background-color: #d9534f; color: #ffffff; text-decoration: none; border-radius: 4px;">Acknowledge</a></p>{{end}}
+                            <p style="font-size: 14px; line-height: 1.5; color: #777777; text-align: center;">This is an automatic notification from the weather monitoring system.</p>
+                        </td>
+                    </tr>
+                </table>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>`
+
+const emailPlainTextTemplateEN = `{{if .Reminder}}Reminder based on the updated forecast{{else}}Warning!{{end}}
+{{if .Name}}
+Hello, {{.Name}}!
+{{end}}
+{{if .Locations}}Strong wind gusts above the safe threshold are expected {{.Period}}:
+{{range .Locations}}
+{{.Name}}: up to {{speed .MaxWindGust 1}} (threshold {{speed .WindGustThreshold 1}}){{range .Forecasts}}
+- {{.Time}}: {{speed .WindGust 1}}{{end}}
+{{end}}{{else}}Strong wind gusts of {{speed .MaxWindGust 1}} are expected {{.Period}}, exceeding the safe threshold of {{speed .WindGustThreshold 1}}.
+{{if .Forecasts}}
+Times of strong gusts:{{range .Forecasts}}
+- {{.Time}}: {{speed .WindGust 1}}{{end}}
+{{end}}{{end}}
+Please keep the office windows closed during the day.
+{{if .AckURL}}
+Acknowledge the alert: {{.AckURL}}
+{{end}}
+This is an automatic notification from the weather monitoring system.`
+
+// Разбор языка сообщений
+func parseLanguage(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", languageRU, "rus", "russian":
+		return languageRU, nil
+	case languageEN, "eng", "english":
+		return languageEN, nil
+	default:
+		return "", fmt.Errorf("неизвестный язык %q (ожидается ru или en)", value)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+// Адреса LINE Messaging API для отправки сообщений
+const (
+	linePushURL      = "https://api.line.me/v2/bot/message/push"
+	lineBroadcastURL = "https://api.line.me/v2/bot/message/broadcast"
+)
+
+// Настройки отправки уведомлений в LINE.
+// LINE Notify закрыт 31.03.2025, поэтому используется Messaging API с токеном канала.
+type LINEConfig struct {
+	ChannelAccessToken string   // Долгосрочный токен доступа канала (channel access token)
+	To                 []string // Идентификаторы пользователей, групп или комнат; пустой список - рассылка всем подписчикам
+}
+
+// Загрузка настроек LINE из переменных окружения
+func loadLINEConfig() LINEConfig {
+	return LINEConfig{
+		ChannelAccessToken: os.Getenv("LINE_CHANNEL_ACCESS_TOKEN"),
+		To:                 parseList(os.Getenv("LINE_TO")),
+	}
+}
+
+// Отправка предупреждения сообщениями LINE
+type lineNotifier struct {
+	config LINEConfig
+}
+
+func newLINENotifier(config LINEConfig) *lineNotifier {
+	return &lineNotifier{config: config}
+}
+
+func (n *lineNotifier) Name() string {
+	return "line"
+}
+
+type lineTextMessage struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Запрос push (конкретному получателю) или broadcast (всем подписчикам)
+type linePushRequest struct {
+	To       string            `json:"to,omitempty"`
+	Messages []lineTextMessage `json:"messages"`
+}
+
+func (n *lineNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	messages := []lineTextMessage{{Type: "text", Text: report.text("⚠️ " + formatAlertText(report))}}
+	headers := map[string]string{"Authorization": "Bearer " + n.config.ChannelAccessToken}
+
+	if len(n.config.To) == 0 {
+		if _, err := sendJSON(ctx, http.MethodPost, lineBroadcastURL, headers, linePushRequest{Messages: messages}); err != nil {
+			return fmt.Errorf("ошибка при рассылке сообщения в LINE: %w", err)
+		}
+		log.Println("Предупреждение разослано подписчикам LINE")
+		return nil
+	}
+
+	var failed int
+	for _, to := range n.config.To {
+		if _, err := sendJSON(ctx, http.MethodPost, linePushURL, headers, linePushRequest{To: to, Messages: messages}); err != nil {
+			logErrorf("Ошибка при отправке сообщения LINE получателю %s: %v", to, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("не доставлено сообщений LINE: %d из %d", failed, len(n.config.To))
+	}
+
+	log.Printf("Предупреждение отправлено в LINE (%d получателей)", len(n.config.To))
+	return nil
+}
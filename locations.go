@@ -0,0 +1,346 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Способы рассылки предупреждений по нескольким пунктам (LOCATIONS_REPORT)
+const (
+	locationsSeparate = "separate" // Отдельное предупреждение по каждому пункту
+	locationsCombined = "combined" // Одно сводное письмо по всем пунктам получателя
+)
+
+// Контролируемый пункт: город или координаты со своим порогом и получателями
+type Location struct {
+	Name       string   `json:"name,omitempty"`       // Название в уведомлениях (по умолчанию city)
+	City       string   `json:"city,omitempty"`       // Город для Geocoding API
+	Lat        *float64 `json:"lat,omitempty"`        // Широта; вместе с lon заменяет поиск по городу
+	Lon        *float64 `json:"lon,omitempty"`        // Долгота
+	Threshold  float64  `json:"threshold,omitempty"`  // Порог порывов ветра (по умолчанию WIND_GUST_THRESHOLD)
+	Recipients []string `json:"recipients,omitempty"` // Получатели (по умолчанию EMAIL_TO)
+	// Время ежедневной проверки ЧЧ:ММ по часовому поясу пункта (по умолчанию NOTIFICATION_HOUR:NOTIFICATION_MIN)
+	NotificationTime string `json:"notification_time,omitempty"`
+	CheckWindow      string `json:"check_window,omitempty"` // Окно проверки ЧЧ:ММ-ЧЧ:ММ (по умолчанию CHECK_WINDOW)
+	Timezone         string `json:"timezone,omitempty"`     // Часовой пояс IANA; без него определяется по прогнозу пункта
+
+	clock     *CityClock   // Часовой пояс пункта
+	scheduled bool         // Задано свое время проверки
+	hour      int          // Час проверки пункта
+	minute    int          // Минуты проверки пункта
+	window    *CheckWindow // Свое окно проверки; nil - общее
+}
+
+// Название пункта в уведомлениях
+func (l *Location) title() string {
+	if l.Name != "" {
+		return l.Name
+	}
+	return l.City
+}
+
+// Проверяется ли пункт по своему расписанию, а не в общее время
+func (l *Location) ownSchedule() bool {
+	return l.scheduled
+}
+
+// Настройки проверки нескольких пунктов
+type LocationsConfig struct {
+	List   []Location
+	Report string // Способ рассылки: separate или combined
+}
+
+// Загрузка списка пунктов из JSON-файла LOCATIONS_FILE и способа рассылки из LOCATIONS_REPORT
+func loadLocationsConfig() (LocationsConfig, error) {
+	cfg := LocationsConfig{Report: locationsSeparate}
+
+	switch report := strings.ToLower(strings.TrimSpace(os.Getenv("LOCATIONS_REPORT"))); report {
+	case "":
+	case locationsSeparate, locationsCombined:
+		cfg.Report = report
+	default:
+		log.Printf("Неизвестный способ рассылки LOCATIONS_REPORT=%s, используется %s", report, locationsSeparate)
+	}
+
+	list, err := readLocationsFile(os.Getenv("LOCATIONS_FILE"))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.List = list
+	return cfg, nil
+}
+
+// Чтение и проверка файла пунктов; пустой путь - пунктов нет
+func readLocationsFile(path string) ([]Location, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении файла пунктов: %w", err)
+	}
+	var list []Location
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе файла пунктов: %w", err)
+	}
+
+	names := map[string]bool{}
+	for i := range list {
+		loc := &list[i]
+		if loc.City == "" && (loc.Lat == nil || loc.Lon == nil) {
+			return nil, fmt.Errorf("пункт %d: не указан город или координаты", i+1)
+		}
+		if loc.title() == "" {
+			return nil, fmt.Errorf("пункт %d: не указано название", i+1)
+		}
+		if names[loc.title()] {
+			return nil, fmt.Errorf("пункт %q указан несколько раз", loc.title())
+		}
+		names[loc.title()] = true
+		loc.Recipients = parseEmailList(strings.Join(loc.Recipients, ","))
+
+		// TIMEZONE относится к основному городу: часовой пояс пункта задается в файле
+		// или определяется по смещению из ответа прогноза для пункта
+		loc.clock = &CityClock{loc: time.Local}
+		if loc.Timezone != "" {
+			tz, err := time.LoadLocation(loc.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("пункт %q: timezone: %w", loc.title(), err)
+			}
+			loc.clock = &CityClock{loc: tz, fixed: true}
+		}
+		if loc.NotificationTime != "" {
+			minutes, err := parseClock(loc.NotificationTime)
+			if err != nil {
+				return nil, fmt.Errorf("пункт %q: notification_time: %w", loc.title(), err)
+			}
+			loc.scheduled, loc.hour, loc.minute = true, minutes/60, minutes%60
+		}
+		if loc.CheckWindow != "" {
+			window, err := parseCheckWindow(loc.CheckWindow)
+			if err != nil {
+				return nil, fmt.Errorf("пункт %q: check_window: %w", loc.title(), err)
+			}
+			loc.window = &window
+		}
+	}
+	return list, nil
+}
+
+// Конфигурация проверки одного пункта: координаты, порог, получатели, часовой пояс,
+// время и окно проверки пункта
+func (c *Config) forLocation(loc Location) *Config {
+	locConfig := *c
+	locConfig.City = loc.City
+	locConfig.PlaceName = loc.title()
+	locConfig.Coords = nil
+	if loc.Lat != nil && loc.Lon != nil {
+		locConfig.Coords = &GeoLocation{Name: loc.title(), Lat: *loc.Lat, Lon: *loc.Lon}
+	}
+	if loc.Threshold > 0 {
+		// Уровни опасности сдвигаются вместе с порогом пункта
+		shift := loc.Threshold - c.WindGustThreshold
+		locConfig.WindGustThreshold = loc.Threshold
+		locConfig.Severity = SeverityConfig{
+			OrangeThreshold: c.Severity.OrangeThreshold + shift,
+			RedThreshold:    c.Severity.RedThreshold + shift,
+		}
+	}
+	if len(loc.Recipients) > 0 {
+		locConfig.EmailTo = loc.Recipients
+	}
+	// Отдельное время доставки (RECIPIENT_TIMES) действует только для основного города
+	locConfig.RecipientSlots = nil
+	if loc.clock != nil {
+		locConfig.Clock = loc.clock
+	}
+	if loc.scheduled {
+		locConfig.NotificationHour, locConfig.NotificationMin = loc.hour, loc.minute
+	}
+	if loc.window != nil {
+		locConfig.CheckWindow = *loc.window
+	}
+	return &locConfig
+}
+
+// Конфигурация с пунктами, отобранными функцией keep
+func (c *Config) withLocations(keep func(loc *Location) bool) *Config {
+	filtered := *c
+	filtered.Locations.List = nil
+	for i := range c.Locations.List {
+		if keep(&c.Locations.List[i]) {
+			filtered.Locations.List = append(filtered.Locations.List, c.Locations.List[i])
+		}
+	}
+	return &filtered
+}
+
+// Конфигурация пункта по его названию; для основного города возвращается сама конфигурация
+func (c *Config) locationConfig(name string) *Config {
+	for _, loc := range c.Locations.List {
+		if loc.title() == name {
+			return c.forLocation(loc)
+		}
+	}
+	return c
+}
+
+// Название контролируемого пункта в уведомлениях
+func (c *Config) placeName() string {
+	if c.PlaceName != "" {
+		return c.PlaceName
+	}
+	return c.City
+}
+
+// Проверка всех пунктов за один запуск и рассылка отдельных или сводного предупреждения;
+// false - прогноз не получен хотя бы для одного пункта
+func checkLocationsAndAlert(config *Config, dispatcher *Dispatcher) bool {
+	var reports []*AlertReport
+	ok := true
+	for _, loc := range config.Locations.List {
+		locConfig := config.forLocation(loc)
+		report := evaluateWeather(locConfig)
+		if report == nil {
+			config.Audit.recordForecastError(locConfig.placeName())
+			ok = false
+			continue
+		}
+		// Следующая проверка пункта со своим временем планируется по его часовому поясу,
+		// остальных пунктов - по часовому поясу сервиса
+		if !loc.ownSchedule() {
+			report.NextCheck = getNextSendTime(config)
+		}
+
+		if report.ExceedsThreshold {
+			log.Printf("%s: порывы ветра превышают пороговое значение (уровень опасности: %s)", report.City, report.Severity.Title())
+		} else {
+			log.Printf("%s: порывы ветра в норме", report.City)
+		}
+		reports = append(reports, report)
+	}
+	if len(reports) == 0 {
+		return false
+	}
+
+	if config.Locations.Report == locationsCombined {
+		dispatcher.Dispatch(combineReports(reports))
+		return ok
+	}
+	for _, report := range reports {
+		dispatcher.Dispatch(report)
+	}
+	return ok
+}
+
+// Сводный отчет по нескольким пунктам: уровень опасности и порывы берутся по самому
+// опасному пункту, получатели объединяются по пунктам с превышением порога
+func combineReports(reports []*AlertReport) *AlertReport {
+	combined := &AlertReport{
+		CheckedAt:     reports[0].CheckedAt,
+		NextCheck:     reports[0].NextCheck,
+		LookaheadDays: reports[0].LookaheadDays,
+		Locations:     reports,
+		Units:         reports[0].Units,
+		Language:      reports[0].Language,
+	}
+
+	var names []string
+	worst := reports[0]
+	seen := map[string]bool{}
+	for _, report := range reports {
+		names = append(names, report.City)
+		if moreDangerous(report, worst) {
+			worst = report
+		}
+		if !report.ExceedsThreshold {
+			continue
+		}
+		combined.ExceedsThreshold = true
+		for _, recipient := range report.Recipients {
+			if key := strings.ToLower(recipient); !seen[key] {
+				seen[key] = true
+				combined.Recipients = append(combined.Recipients, recipient)
+			}
+		}
+	}
+
+	combined.City = strings.Join(names, ", ")
+	combined.Severity = worst.Severity
+	combined.MaxWindGust = worst.MaxWindGust
+	combined.WindGustThreshold = worst.WindGustThreshold
+	return combined
+}
+
+// Опаснее ли обстановка в пункте: сравнивается уровень опасности, затем превышение порога
+func moreDangerous(a, b *AlertReport) bool {
+	if a.Severity != b.Severity {
+		return a.Severity > b.Severity
+	}
+	return a.MaxWindGust-a.WindGustThreshold > b.MaxWindGust-b.WindGustThreshold
+}
+
+// Пункты с превышением порога из сводного отчета
+func (r *AlertReport) alertedLocations() []*AlertReport {
+	var alerted []*AlertReport
+	for _, location := range r.Locations {
+		if location.ExceedsThreshold {
+			alerted = append(alerted, location)
+		}
+	}
+	return alerted
+}
+
+// Части сводного письма: каждый получатель получает одно письмо только по своим пунктам
+func splitByRecipient(report *AlertReport, recipients []string) []*AlertReport {
+	type group struct {
+		recipients []string
+		locations  []*AlertReport
+	}
+	groups := map[string]*group{}
+
+	for _, recipient := range recipients {
+		var locations []*AlertReport
+		var names []string
+		for _, location := range report.alertedLocations() {
+			for _, r := range location.Recipients {
+				if strings.EqualFold(r, recipient) {
+					locations = append(locations, location)
+					names = append(names, location.City)
+					break
+				}
+			}
+		}
+		if len(locations) == 0 {
+			continue
+		}
+
+		key := strings.Join(names, "\n")
+		if groups[key] == nil {
+			groups[key] = &group{locations: locations}
+		}
+		groups[key].recipients = append(groups[key].recipients, recipient)
+	}
+
+	var parts []*AlertReport
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		g := groups[key]
+		part := *report
+		part.Locations = g.locations
+		part.Recipients = g.recipients
+		part.City = strings.ReplaceAll(key, "\n", ", ")
+		parts = append(parts, &part)
+	}
+	return parts
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Настройки записи журнала в файл для установок без journald и драйвера журналов контейнера
+type LogFileConfig struct {
+	File           string        // Файл журнала (LOG_FILE); пустая строка - вывод в stderr
+	MaxSizeMB      int           // Размер файла в мегабайтах, после которого он сменяется новым
+	RotateInterval time.Duration // Смена файла по времени; 0 - только по размеру
+	MaxBackups     int           // Сколько старых файлов хранить; 0 - все
+	MaxAgeDays     int           // Сколько дней хранить старые файлы; 0 - без ограничения
+	Compress       bool          // Сжатие старых файлов gzip
+}
+
+// Загрузка настроек файла журнала из переменных окружения
+func loadLogFileConfig() LogFileConfig {
+	cfg := LogFileConfig{
+		File:       os.Getenv("LOG_FILE"),
+		MaxSizeMB:  100,
+		MaxBackups: 7,
+	}
+
+	if envSize := os.Getenv("LOG_MAX_SIZE"); envSize != "" {
+		if val, err := strconv.Atoi(envSize); err == nil && val >= 1 {
+			cfg.MaxSizeMB = val
+		} else {
+			logWarnf("Ошибка парсинга LOG_MAX_SIZE: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envInterval := os.Getenv("LOG_ROTATE_INTERVAL"); envInterval != "" {
+		if val, err := time.ParseDuration(envInterval); err == nil && val >= 0 {
+			cfg.RotateInterval = val
+		} else {
+			logWarnf("Ошибка парсинга LOG_ROTATE_INTERVAL: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envBackups := os.Getenv("LOG_MAX_BACKUPS"); envBackups != "" {
+		if val, err := strconv.Atoi(envBackups); err == nil && val >= 0 {
+			cfg.MaxBackups = val
+		} else {
+			logWarnf("Ошибка парсинга LOG_MAX_BACKUPS: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envAge := os.Getenv("LOG_MAX_AGE"); envAge != "" {
+		if val, err := strconv.Atoi(envAge); err == nil && val >= 0 {
+			cfg.MaxAgeDays = val
+		} else {
+			logWarnf("Ошибка парсинга LOG_MAX_AGE: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envCompress := os.Getenv("LOG_COMPRESS"); envCompress != "" {
+		if val, err := strconv.ParseBool(envCompress); err == nil {
+			cfg.Compress = val
+		} else {
+			logWarnf("Ошибка парсинга LOG_COMPRESS: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	return cfg
+}
+
+// Файл журнала с ротацией: при превышении размера или по истечении LOG_ROTATE_INTERVAL
+// текущий файл переименовывается с отметкой времени, а запись продолжается в новый
+type LogFile struct {
+	logger *lumberjack.Logger
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// Перенаправление журнала сервиса в файл
+func openLogFile(config LogFileConfig) *LogFile {
+	f := &LogFile{
+		logger: &lumberjack.Logger{
+			Filename:   config.File,
+			MaxSize:    config.MaxSizeMB,
+			MaxBackups: config.MaxBackups,
+			MaxAge:     config.MaxAgeDays,
+			LocalTime:  true,
+			Compress:   config.Compress,
+		},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	log.Printf("Журнал записывается в %s", config.File)
+	setLogOutput(f.logger)
+
+	go f.rotate(config.RotateInterval)
+	return f
+}
+
+// Смена файла каждые interval; завершается при закрытии журнала
+func (f *LogFile) rotate(interval time.Duration) {
+	defer close(f.done)
+	if interval <= 0 {
+		<-f.stop
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := f.logger.Rotate(); err != nil {
+				logErrorf("Ошибка при смене файла журнала: %v", err)
+			}
+		case <-f.stop:
+			return
+		}
+	}
+}
+
+// Возврат вывода журнала в stderr и закрытие файла
+func (f *LogFile) Close() error {
+	close(f.stop)
+	<-f.done
+	setLogOutput(os.Stderr)
+	return f.logger.Close()
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	maillog "github.com/wneessen/go-mail/log"
+)
+
+// Уровень подробности журнала (LOG_LEVEL)
+type logLevel int32
+
+// Нулевое значение - info, чтобы до загрузки конфигурации журнал выводился как обычно
+const (
+	levelDebug logLevel = iota - 1
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var logLevelNames = map[string]logLevel{
+	"debug": levelDebug,
+	"info":  levelInfo,
+	"warn":  levelWarn,
+	"error": levelError,
+}
+
+// Текущий уровень журнала; меняется при перезагрузке конфигурации
+var currentLogLevel atomic.Int32
+
+// Сообщения уровней debug, warn и error выводятся с отметкой уровня мимо фильтра стандартного журнала
+var leveledLog = log.New(os.Stderr, "", log.LstdFlags)
+
+// Загрузка уровня журнала из переменной окружения
+func loadLogLevel() logLevel {
+	envLevel := os.Getenv("LOG_LEVEL")
+	if envLevel == "" {
+		return levelInfo
+	}
+	level, ok := logLevelNames[strings.ToLower(envLevel)]
+	if !ok {
+		logWarnf("Ошибка парсинга LOG_LEVEL: ожидается debug, info, warn или error, получено %q, используется значение по умолчанию", envLevel)
+		return levelInfo
+	}
+	return level
+}
+
+func setLogLevel(level logLevel) {
+	currentLogLevel.Store(int32(level))
+}
+
+// Выводятся ли сообщения уровня level
+func logEnabled(level logLevel) bool {
+	return level >= logLevel(currentLogLevel.Load())
+}
+
+// Стандартный журнал выводит сообщения уровня info: при LOG_LEVEL=warn и error они отбрасываются
+type infoLogWriter struct {
+	out io.Writer
+}
+
+func (w infoLogWriter) Write(p []byte) (int, error) {
+	if !logEnabled(levelInfo) {
+		return len(p), nil
+	}
+	return w.out.Write(p)
+}
+
+// Подключение фильтра уровня к стандартному журналу
+func initLogging() {
+	setLogOutput(os.Stderr)
+}
+
+// Вывод журнала всех уровней в w: stderr или файл журнала (LOG_FILE)
+func setLogOutput(w io.Writer) {
+	log.SetOutput(infoLogWriter{out: w})
+	leveledLog.SetOutput(w)
+}
+
+func logDebugf(format string, v ...interface{}) {
+	if logEnabled(levelDebug) {
+		leveledLog.Output(2, "DEBUG "+fmt.Sprintf(format, v...))
+	}
+}
+
+func logWarnf(format string, v ...interface{}) {
+	if logEnabled(levelWarn) {
+		leveledLog.Output(2, "WARN "+fmt.Sprintf(format, v...))
+	}
+}
+
+func logErrorf(format string, v ...interface{}) {
+	if logEnabled(levelError) {
+		leveledLog.Output(2, "ERROR "+fmt.Sprintf(format, v...))
+	}
+}
+
+// Сообщение о неустранимой ошибке выводится при любом уровне журнала, после чего сервис завершается
+func logFatalf(format string, v ...interface{}) {
+	leveledLog.Output(2, "FATAL "+fmt.Sprintf(format, v...))
+	os.Exit(1)
+}
+
+// Журнал go-mail: диалог с SMTP-сервером выводится только при LOG_LEVEL=debug,
+// потому что содержит адреса получателей и заголовки писем
+type mailLogger struct{}
+
+func (mailLogger) message(l maillog.Log) string {
+	direction := "C <-- S:"
+	if l.Direction == maillog.DirClientToServer {
+		direction = "C --> S:"
+	}
+	return "SMTP " + direction + " " + fmt.Sprintf(l.Format, l.Messages...)
+}
+
+func (m mailLogger) Debugf(l maillog.Log) {
+	logDebugf("%s", m.message(l))
+}
+
+func (m mailLogger) Infof(l maillog.Log) {
+	log.Print(m.message(l))
+}
+
+func (m mailLogger) Warnf(l maillog.Log) {
+	logWarnf("%s", m.message(l))
+}
+
+func (m mailLogger) Errorf(l maillog.Log) {
+	logErrorf("%s", m.message(l))
+}
@@ -4,17 +4,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"text/template"
 	"time"
 
-	"github.com/joho/godotenv"
 	"github.com/wneessen/go-mail"
 )
 
@@ -24,10 +28,22 @@ type WindGustForecast struct {
 	WindGust float64
 }
 
+// Режимы работы сервиса
+const (
+	modeWind   = "wind"   // Предупреждение о сильных порывах ветра
+	modeDrone  = "drone"  // Подбор окон для полетов БПЛА
+	modeSchool = "school" // Рекомендация по прогулкам для детских учреждений
+)
+
+// Максимальное число дней вперед: прогноз OpenWeatherMap (5 day / 3 hour) покрывает текущий день и еще четыре
+const maxLookaheadDays = 4
+
 // Конфигурация приложения
 type Config struct {
 	OpenWeatherAPIKey string
 	City              string
+	PlaceName         string       // Название пункта в уведомлениях (по умолчанию City)
+	Coords            *GeoLocation // Координаты пункта, если заданы явно вместо города
 	EmailFrom         string
 	EmailTo           []string
 	SMTPServer        string
@@ -35,14 +51,113 @@ type Config struct {
 	SMTPUser          string
 	SMTPPassword      string
 	WindGustThreshold float64 // Пороговое значение порывов ветра в м/с
-	NotificationHour  int     // Час отправки уведомления
-	NotificationMin   int     // Минуты отправки уведомления
+	Severity          SeverityConfig
+	NotificationHour  int         // Час отправки уведомления
+	NotificationMin   int         // Минуты отправки уведомления
+	CheckWindow       CheckWindow // Часть суток, прогноз на которую оценивается
+	LookaheadDays     int         // Сколько дней после текущего включать в проверку
+	Mode              string      // Режим работы: wind (по умолчанию), drone или school
+	HTTPAddr          string      // Адрес необязательного HTTP-сервера, например :8080
+	EventsFile        string      // Файл с разовыми проверками для мероприятий
+	HistoryFile       string      // Файл истории выпущенных предупреждений
+	HistoryDB         string      // Файл SQLite или адрес PostgreSQL с историей проверок и доставки уведомлений
+	PauseUntil        string      // Дата, до которой рассылка приостановлена (PAUSE_UNTIL)
+	PauseFile         string      // Файл состояния приостановки рассылки
+	RunStateFile      string      // Файл состояния плановых проверок для выполнения пропущенной проверки
+	SubscriptionsFile string      // Файл получателей, добавленных и измененных через /api/recipients
+	Store             StoreConfig // Хранение состояния: JSON-файлы или bbolt (STORE_BACKEND)
+	TemplatesDir      string      // Каталог шаблонов сообщений каналов
+	Clock             *CityClock  // Часовой пояс города
+	MQTT              MQTTConfig
+	Matrix            MatrixConfig
+	WhatsApp          WhatsAppConfig
+	PagerDuty         PagerDutyConfig
+	Opsgenie          OpsgenieConfig
+	GoogleChat        GoogleChatConfig
+	Signal            SignalConfig
+	VK                VKConfig
+	FCM               FCMConfig
+	Maker             MakerConfig
+	XMPP              XMPPConfig
+	RocketChat        RocketChatConfig
+	Zulip             ZulipConfig
+	SNS               SNSConfig
+	Twilio            TwilioConfig
+	LINE              LINEConfig
+	Viber             ViberConfig
+	NodeRED           NodeREDConfig
+	Syslog            SyslogConfig
+	Pushbullet        PushbulletConfig
+	InfluxDB          InfluxDBConfig
+	Routing           RoutingConfig
+	Retry             RetryConfig
+	QuietHours        QuietHoursConfig
+	Escalation        EscalationConfig
+	Unsubscribe       UnsubscribeConfig // Ссылка отписки в письмах (UNSUBSCRIBE_SECRET)
+	Unsubscribed      []string          // Адреса, отписавшиеся по ссылке или исключенные через /api/recipients
+	Settings          SettingsConfig    // Страница редактирования настроек /settings (SETTINGS_PASSWORD)
+	APIKeys           APIKeysConfig     // Ключи API для маршрутов управления (API_KEYS)
+	Feed              FeedConfig
+	DailyCSV          DailyCSVConfig
+	ForecastArchive   ForecastArchiveConfig
+	ForecastCache     *ForecastCache   // Кэш ответов прогноза (FORECAST_CACHE_TTL); nil - отключен
+	Accuracy          *AccuracyTracker // Учет точности прогноза (ACCURACY_FILE); nil - отключен
+	Health            *HealthMonitor   // Состояние для /healthz и /readyz
+	Metrics           *Metrics         // Метрики Prometheus: /metrics и METRICS_TEXTFILE
+	Audit             *AuditLog        // Журнал решений о предупреждениях (AUDIT_FILE); nil - не ведется
+	Ops               *OpsAlerts       // Служебные оповещения о сбоях подряд (OPS_ALERT_*); nil - отключены
+	Dashboard         *Dashboard       // Последние проверки для страницы состояния (DASHBOARD) и API /api/v1
+	Drone             DroneConfig
+	School            SchoolConfig
+	Preview           PreviewConfig
+	Digest            DigestConfig
+	Poll              PollConfig
+	Heartbeat         HeartbeatConfig
+	LogFile           LogFileConfig
+	Schedule          string        // Стратегия запуска проверок: daily, continuous, cron или once
+	DryRun            bool          // Уведомления выводятся в журнал вместо отправки
+	LogLevel          logLevel      // Подробность журнала (LOG_LEVEL)
+	Preflight         bool          // Проверка ключа OpenWeatherMap и входа на SMTP-сервер при запуске
+	AlertDedup        bool          // Не повторять предупреждение того же уровня по пункту и правилу в течение дня
+	EmailChart        bool          // Встраивать в письмо предупреждения график прогноза (EMAIL_CHART)
+	AlertCooldown     time.Duration // Период после предупреждения, в течение которого оно повторяется только при повышении уровня
+	CronSchedule      string        // Выражение cron для SCHEDULE=cron
+	Blackout          BlackoutConfig
+	RecipientSlots    []deliverySlot // Отдельное время доставки письма для части получателей
+	Reminder          ReminderConfig
+	Locations         LocationsConfig
+	RecipientProfiles []Recipient // Настройки получателей из RECIPIENTS_FILE
+	Units             string      // Единицы скорости ветра для порогов и сообщений (UNITS)
+	Language          string      // Язык уведомлений: ru или en (LANGUAGE)
+	Features          Features    // Флаги необязательных функций (FEATURES)
+	Rules             AlertRules  // Правила предупреждений из RULES_FILE; пустой список - правило порывов ветра
 }
 
 // Структура данных для шаблона электронного письма
 type EmailData struct {
+	Name              string // Имя получателя для обращения
+	MaxWindGust       float64
+	WindGustThreshold float64
+	AckURL            string // Ссылка для подтверждения получения
+	Period            string // Период проверки: "сегодня", "сегодня и завтра" и т.д.
+	Reminder          bool   // Напоминание перед началом сильного ветра
+	Forecasts         []ForecastLine
+	Locations         []LocationLine // Пункты с превышением порога в сводном письме
+	Chart             bool           // Встроенный график прогноза (EMAIL_CHART)
+}
+
+// Пункт сводного письма
+type LocationLine struct {
+	Name              string
 	MaxWindGust       float64
 	WindGustThreshold float64
+	Forecasts         []ForecastLine
+}
+
+// Строка списка сильных порывов в письме
+type ForecastLine struct {
+	Time     string
+	WindGust float64
 }
 
 // Шаблон для HTML письма
@@ -135,9 +250,23 @@ const emailHTMLTemplateText = `<!DOCTYPE html>
                 <table border="0" cellpadding="0" cellspacing="0" width="600" class="container" style="background-color: #ffffff; border-radius: 8px; box-shadow: 0 0 10px rgba(0, 0, 0, 0.1); max-width: 600px; width: 100%;">
                     <tr>
                         <td class="content" style="padding: 20px;">
-                            <h1 style="color: #d9534f; font-size: 24px; text-align: center; margin-top: 0; margin-bottom: 20px;">Внимание!</h1>
-                            <p style="font-size: 16px; line-height: 1.5; color: #333333; margin-top: 0; margin-bottom: 15px;">Сегодня ожидаются <span class="highlight" style="font-weight: bold; color: #d9534f;">сильные порывы ветра ({{printf "%.2f" .MaxWindGust}} м/с)</span>, что превышает безопасный порог (<span class="highlight" style="font-weight: bold; color: #d9534f;">{{printf "%.2f" .WindGustThreshold}} м/с</span>).</p>
+                            <h1 style="color: #d9534f; font-size: 24px; text-align: center; margin-top: 0; margin-bottom: 20px;">{{if .Reminder}}Напоминание{{else}}Внимание!{{end}}</h1>
+                            {{if .Name}}<p style="font-size: 16px; line-height: 1.5; color: #333333; margin-top: 0; margin-bottom: 15px;">Здравствуйте, {{.Name}}!</p>{{end}}
+                            {{if .Locations}}<p style="font-size: 16px; line-height: 1.5; color: #333333; margin-top: 0; margin-bottom: 15px;">{{.Period}} ожидаются <span class="highlight" style="font-weight: bold; color: #d9534f;">сильные порывы ветра</span>, превышающие безопасный порог:</p>
+                            {{range .Locations}}<p style="font-size: 16px; line-height: 1.5; color: #333333; margin-top: 0; margin-bottom: 5px;"><b>{{.Name}}</b>: до <span class="highlight" style="font-weight: bold; color: #d9534f;">{{speed .MaxWindGust 2}}</span> (порог {{speed .WindGustThreshold 2}})</p>
+                            <ul style="font-size: 16px; line-height: 1.5; color: #333333; margin-top: 0; margin-bottom: 15px;">
+                                {{range .Forecasts}}<li><b>{{.Time}}</b>: {{speed .WindGust 2}}</li>
+                                {{end}}
+                            </ul>
+                            {{end}}{{else}}<p style="font-size: 16px; line-height: 1.5; color: #333333; margin-top: 0; margin-bottom: 15px;">{{.Period}} ожидаются <span class="highlight" style="font-weight: bold; color: #d9534f;">сильные порывы ветра ({{speed .MaxWindGust 2}})</span>, что превышает безопасный порог (<span class="highlight" style="font-weight: bold; color: #d9534f;">{{speed .WindGustThreshold 2}}</span>).</p>
+                            {{if .Forecasts}}<p style="font-size: 16px; line-height: 1.5; color: #333333; margin-top: 0; margin-bottom: 5px;">Время сильных порывов:</p>
+                            <ul style="font-size: 16px; line-height: 1.5; color: #333333; margin-top: 0; margin-bottom: 15px;">
+                                {{range .Forecasts}}<li><b>{{.Time}}</b>: {{speed .WindGust 2}}</li>
+                                {{end}}
+                            </ul>{{end}}{{end}}
+                            {{if .Chart}}<p style="margin-top: 0; margin-bottom: 15px;"><img src="cid:forecast-chart.png" alt="График порывов ветра по прогнозу" width="640" style="display: block; max-width: 100%; height: auto; border: 0;"></p>{{end}}
                             <p style="font-size: 16px; line-height: 1.5; color: #333333; margin-top: 0; margin-bottom: 15px;">Рекомендуется <span class="highlight" style="font-weight: bold; color: #d9534f;">не открывать окна в офисе</span> в течение дня.</p>
+                            {{if .AckURL}}<p style="font-size: 16px; line-height: 1.5; color: #333333; margin-top: 0; margin-bottom: 15px; text-align: center;"><a href="{{.AckURL}}" style="display: inline-block; padding: 10px 20px; background-color: #d9534f; color: #ffffff; text-decoration: none; border-radius: 4px;">Подтвердить получение</a></p>{{end}}
                             <div class="footer" style="margin-top: 20px; font-size: 14px; color: #777777; text-align: center;">
                                 <p style="font-size: 14px; line-height: 1.5; color: #777777; margin-top: 0; margin-bottom: 15px;">Это автоматическое уведомление от системы мониторинга погоды.</p>
                             </div>
@@ -158,29 +287,74 @@ const emailHTMLTemplateText = `<!DOCTYPE html>
 </html>`
 
 // Шаблон для текстового письма
-const emailPlainTextTemplate = `Внимание!
-
-Сегодня ожидаются сильные порывы ветра ({{printf "%.2f" .MaxWindGust}} м/с), что превышает безопасный порог ({{printf "%.2f" .WindGustThreshold}} м/с).
-
+const emailPlainTextTemplate = `{{if .Reminder}}Напоминание по обновленному прогнозу{{else}}Внимание!{{end}}
+{{if .Name}}
+Здравствуйте, {{.Name}}!
+{{end}}
+{{if .Locations}}{{.Period}} ожидаются сильные порывы ветра, превышающие безопасный порог:
+{{range .Locations}}
+{{.Name}}: до {{speed .MaxWindGust 2}} (порог {{speed .WindGustThreshold 2}}){{range .Forecasts}}
+- {{.Time}}: {{speed .WindGust 2}}{{end}}
+{{end}}{{else}}{{.Period}} ожидаются сильные порывы ветра ({{speed .MaxWindGust 2}}), что превышает безопасный порог ({{speed .WindGustThreshold 2}}).
+{{if .Forecasts}}
+Время сильных порывов:{{range .Forecasts}}
+- {{.Time}}: {{speed .WindGust 2}}{{end}}
+{{end}}{{end}}
 Рекомендуется не открывать окна в офисе в течение дня.
-
+{{if .AckURL}}
+Подтвердите получение предупреждения: {{.AckURL}}
+{{end}}
 Это автоматическое уведомление от системы мониторинга погоды.`
 
 // Структуры для парсинга ответа от OpenWeatherMap API
 type WeatherResponse struct {
 	List []DailyForecast `json:"list"`
+	City struct {
+		Name     string `json:"name"`
+		Timezone int    `json:"timezone"` // Смещение от UTC в секундах
+	} `json:"city"`
+
+	loc *time.Location // Часовой пояс города
+}
+
+// Текущее время в часовом поясе города
+func (w *WeatherResponse) now() time.Time {
+	if w.loc == nil {
+		return time.Now()
+	}
+	return time.Now().In(w.loc)
 }
 
 type DailyForecast struct {
 	Dt   int64 `json:"dt"`
 	Main struct {
-		Temp float64 `json:"temp"`
+		Temp     float64 `json:"temp"`
+		Humidity float64 `json:"humidity"`
 	} `json:"main"`
 	Wind struct {
 		Speed float64 `json:"speed"`
 		Gust  float64 `json:"gust"`
 	} `json:"wind"`
-	Weather []WeatherDesc `json:"weather"`
+	Weather    []WeatherDesc `json:"weather"`
+	Visibility int           `json:"visibility"` // Видимость в метрах
+	Pop        float64       `json:"pop"`        // Вероятность осадков (0-1)
+	Rain       struct {
+		ThreeHours float64 `json:"3h"`
+	} `json:"rain"`
+	Snow struct {
+		ThreeHours float64 `json:"3h"`
+	} `json:"snow"`
+
+	loc *time.Location // Часовой пояс города
+}
+
+// Время прогноза в часовом поясе города
+func (f DailyForecast) Time() time.Time {
+	t := time.Unix(f.Dt, 0)
+	if f.loc != nil {
+		t = t.In(f.loc)
+	}
+	return t
 }
 
 type WeatherDesc struct {
@@ -197,15 +371,8 @@ type GeoLocation struct {
 	State   string  `json:"state"`
 }
 
-// Загрузка конфигурации из переменных окружения
-func loadConfig() (*Config, error) {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("Предупреждение: Файл .env не найден, используются переменные окружения системы")
-	}
-
-	// Получение списка адресов из строки, разделенной запятыми или точкой с запятой
-	emailToStr := os.Getenv("EMAIL_TO")
+// Получение списка адресов из строки, разделенной запятыми или точкой с запятой
+func parseEmailList(emailToStr string) []string {
 	var emailTo []string
 	if emailToStr != "" {
 		// Поддержка разделителей "," или ";"
@@ -231,6 +398,58 @@ func loadConfig() (*Config, error) {
 		}
 		emailTo = cleanEmailTo
 	}
+	return emailTo
+}
+
+// Загрузка конфигурации из переменных окружения
+func loadConfig() (*Config, error) {
+	missing, err := loadConfigFiles()
+	if err != nil {
+		return nil, fmt.Errorf("CONFIG_FILE: %w", err)
+	}
+	// Слои .env по умолчанию необязательны, предупреждение выводится, только если нет ни одного
+	if lookupEnv("CONFIG_FILE") != "" {
+		for _, path := range missing {
+			log.Printf("Предупреждение: Файл конфигурации %s не найден и пропущен", path)
+		}
+	} else if len(missing) == len(configFilePaths()) {
+		log.Printf("Предупреждение: Файл %s не найден, используются переменные окружения системы", defaultConfigFile)
+	}
+	applyEnvNamespace()
+	if err := checkConfigVersion(); err != nil {
+		return nil, err
+	}
+	if errs := loadSecretFiles(); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if err := applyProfile(); err != nil {
+		return nil, err
+	}
+	if errs := decryptConfigValues(); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if strictConfig() {
+		if err := checkStrictConfig(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Получение списка адресов из строки, разделенной запятыми или точкой с запятой
+	emailTo := parseEmailList(os.Getenv("EMAIL_TO"))
+
+	// Единицы скорости ветра для порогов и сообщений
+	units, err := parseUnits(os.Getenv("UNITS"))
+	if err != nil {
+		logWarnf("Ошибка парсинга UNITS: %v, используется значение по умолчанию", err)
+		units = unitsMS
+	}
+
+	// Язык уведомлений
+	language, err := parseLanguage(os.Getenv("LANGUAGE"))
+	if err != nil {
+		logWarnf("Ошибка парсинга LANGUAGE: %v, используется значение по умолчанию", err)
+		language = languageRU
+	}
 
 	// Настройки порога ветра и времени уведомления с значениями по умолчанию
 	windGustThreshold := 15.0 // По умолчанию 15 м/с
@@ -240,9 +459,9 @@ func loadConfig() (*Config, error) {
 	// Загрузка значений из переменных окружения, если они указаны
 	if envThreshold := os.Getenv("WIND_GUST_THRESHOLD"); envThreshold != "" {
 		if val, err := strconv.ParseFloat(envThreshold, 64); err == nil {
-			windGustThreshold = val
+			windGustThreshold = toMetersPerSecond(val, units)
 		} else {
-			log.Printf("Ошибка парсинга WIND_GUST_THRESHOLD: %v, используется значение по умолчанию", err)
+			logWarnf("Ошибка парсинга WIND_GUST_THRESHOLD: %v, используется значение по умолчанию", err)
 		}
 	}
 
@@ -250,7 +469,7 @@ func loadConfig() (*Config, error) {
 		if val, err := strconv.Atoi(envHour); err == nil && val >= 0 && val < 24 {
 			notificationHour = val
 		} else {
-			log.Printf("Ошибка парсинга NOTIFICATION_HOUR: %v, используется значение по умолчанию", err)
+			logWarnf("Ошибка парсинга NOTIFICATION_HOUR: %v, используется значение по умолчанию", err)
 		}
 	}
 
@@ -258,10 +477,100 @@ func loadConfig() (*Config, error) {
 		if val, err := strconv.Atoi(envMin); err == nil && val >= 0 && val < 60 {
 			notificationMin = val
 		} else {
-			log.Printf("Ошибка парсинга NOTIFICATION_MIN: %v, используется значение по умолчанию", err)
+			logWarnf("Ошибка парсинга NOTIFICATION_MIN: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	lookaheadDays := 0 // По умолчанию только текущий день
+	if envDays := os.Getenv("LOOKAHEAD_DAYS"); envDays != "" {
+		if val, err := strconv.Atoi(envDays); err == nil && val >= 0 {
+			lookaheadDays = val
+		} else {
+			logWarnf("Ошибка парсинга LOOKAHEAD_DAYS: %v, используется значение по умолчанию", err)
+		}
+	}
+	if lookaheadDays > maxLookaheadDays {
+		log.Printf("LOOKAHEAD_DAYS=%d превышает горизонт прогноза, используется %d", lookaheadDays, maxLookaheadDays)
+		lookaheadDays = maxLookaheadDays
+	}
+
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("MODE")))
+	switch mode {
+	case "":
+		mode = modeWind
+	case modeWind, modeDrone, modeSchool:
+	default:
+		log.Printf("Неизвестный режим работы MODE=%s, используется режим %s", mode, modeWind)
+		mode = modeWind
+	}
+
+	poll := loadPollConfig()
+
+	dryRun := false
+	if envDryRun := os.Getenv("DRY_RUN"); envDryRun != "" {
+		if val, err := strconv.ParseBool(envDryRun); err == nil {
+			dryRun = val
+		} else {
+			logWarnf("Ошибка парсинга DRY_RUN: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	preflight := false
+	if envPreflight := os.Getenv("PREFLIGHT"); envPreflight != "" {
+		if val, err := strconv.ParseBool(envPreflight); err == nil {
+			preflight = val
+		} else {
+			logWarnf("Ошибка парсинга PREFLIGHT: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	alertDedup := true
+	if envDedup := os.Getenv("ALERT_DEDUP"); envDedup != "" {
+		if val, err := strconv.ParseBool(envDedup); err == nil {
+			alertDedup = val
+		} else {
+			logWarnf("Ошибка парсинга ALERT_DEDUP: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	var emailChart bool
+	if envChart := os.Getenv("EMAIL_CHART"); envChart != "" {
+		if val, err := strconv.ParseBool(envChart); err == nil {
+			emailChart = val
+		} else {
+			logWarnf("Ошибка парсинга EMAIL_CHART: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	var alertCooldown time.Duration
+	if envCooldown := os.Getenv("ALERT_COOLDOWN"); envCooldown != "" {
+		if val, err := time.ParseDuration(envCooldown); err == nil && val >= 0 {
+			alertCooldown = val
+		} else {
+			logWarnf("Ошибка парсинга ALERT_COOLDOWN: %v, подавление повторов по времени отключено", err)
 		}
 	}
 
+	locations, err := loadLocationsConfig()
+	if err != nil {
+		return nil, fmt.Errorf("LOCATIONS_FILE: %w", err)
+	}
+
+	profiles, err := loadRecipientProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("RECIPIENTS_FILE: %w", err)
+	}
+
+	rules, err := loadAlertRules(units)
+	if err != nil {
+		return nil, fmt.Errorf("RULES_FILE: %w", err)
+	}
+
+	accuracy, err := newAccuracyTracker(loadAccuracyConfig())
+	if err != nil {
+		return nil, fmt.Errorf("ACCURACY_FILE: %w", err)
+	}
+
 	config := &Config{
 		OpenWeatherAPIKey: os.Getenv("OPENWEATHER_API_KEY"),
 		City:              os.Getenv("CITY"),
@@ -272,15 +581,136 @@ func loadConfig() (*Config, error) {
 		SMTPUser:          os.Getenv("SMTP_USER"),
 		SMTPPassword:      os.Getenv("SMTP_PASSWORD"),
 		WindGustThreshold: windGustThreshold,
+		Severity:          loadSeverityConfig(windGustThreshold, units),
 		NotificationHour:  notificationHour,
 		NotificationMin:   notificationMin,
+		CheckWindow:       loadCheckWindow(),
+		LookaheadDays:     lookaheadDays,
+		Mode:              mode,
+		HTTPAddr:          os.Getenv("HTTP_ADDR"),
+		EventsFile:        os.Getenv("EVENTS_FILE"),
+		HistoryFile:       os.Getenv("HISTORY_FILE"),
+		HistoryDB:         os.Getenv("HISTORY_DB"),
+		ForecastArchive:   loadForecastArchiveConfig(),
+		ForecastCache:     loadForecastCache(),
+		Accuracy:          accuracy,
+		Health:            newHealthMonitor(loadHealthConfig()),
+		Metrics:           loadMetrics(),
+		Audit:             loadAuditLog(dryRun),
+		Ops:               newOpsAlerts(loadOpsAlertConfig()),
+		Dashboard:         loadDashboard(),
+		PauseUntil:        os.Getenv("PAUSE_UNTIL"),
+		PauseFile:         os.Getenv("PAUSE_FILE"),
+		RunStateFile:      os.Getenv("RUN_STATE_FILE"),
+		SubscriptionsFile: os.Getenv("SUBSCRIPTIONS_FILE"),
+		Store:             loadStoreConfig(),
+		TemplatesDir:      templateDir(os.Getenv),
+		Clock:             loadCityClock(),
+		MQTT:              loadMQTTConfig(),
+		Matrix:            loadMatrixConfig(),
+		WhatsApp:          loadWhatsAppConfig(),
+		PagerDuty:         loadPagerDutyConfig(),
+		Opsgenie:          loadOpsgenieConfig(),
+		GoogleChat:        loadGoogleChatConfig(),
+		Signal:            loadSignalConfig(),
+		VK:                loadVKConfig(),
+		FCM:               loadFCMConfig(),
+		Maker:             loadMakerConfig(),
+		XMPP:              loadXMPPConfig(),
+		RocketChat:        loadRocketChatConfig(),
+		Zulip:             loadZulipConfig(),
+		SNS:               loadSNSConfig(),
+		Twilio:            loadTwilioConfig(),
+		LINE:              loadLINEConfig(),
+		Viber:             loadViberConfig(),
+		NodeRED:           loadNodeREDConfig(),
+		Syslog:            loadSyslogConfig(),
+		Pushbullet:        loadPushbulletConfig(),
+		InfluxDB:          loadInfluxDBConfig(),
+		Routing:           loadRoutingConfig(),
+		Retry:             loadRetryConfig(),
+		QuietHours:        loadQuietHoursConfig(),
+		Escalation:        loadEscalationConfig(),
+		Unsubscribe:       loadUnsubscribeConfig(),
+		Settings:          loadSettingsConfig(),
+		APIKeys:           loadAPIKeysConfig(),
+		Feed:              loadFeedConfig(),
+		DailyCSV:          loadDailyCSVConfig(),
+		Drone:             loadDroneConfig(units),
+		School:            loadSchoolConfig(),
+		Preview:           loadPreviewConfig(),
+		Digest:            loadDigestConfig(),
+		Poll:              poll,
+		Schedule:          loadScheduleMode(poll, dryRun),
+		DryRun:            dryRun,
+		LogLevel:          loadLogLevel(),
+		LogFile:           loadLogFileConfig(),
+		Heartbeat:         loadHeartbeatConfig(),
+		Preflight:         preflight,
+		AlertDedup:        alertDedup,
+		EmailChart:        emailChart,
+		AlertCooldown:     alertCooldown,
+		CronSchedule:      os.Getenv("CRON_SCHEDULE"),
+		Blackout:          loadBlackoutConfig(),
+		RecipientSlots:    loadRecipientSlots(),
+		Reminder:          loadReminderConfig(),
+		Locations:         locations,
+		RecipientProfiles: profiles,
+		Units:             units,
+		Language:          language,
+		Features:          loadFeatures(),
+		Rules:             rules,
+	}
+	config.Feed.Units, config.Feed.Language = units, language
+	if language == languageEN && os.Getenv("TWILIO_VOICE_LANGUAGE") == "" {
+		config.Twilio.VoiceLanguage = "en-US"
+	}
+
+	// Пороги пунктов и получателей задаются в единицах UNITS; получатели без своих единиц и языка используют UNITS и LANGUAGE
+	for i := range config.Locations.List {
+		config.Locations.List[i].Threshold = toMetersPerSecond(config.Locations.List[i].Threshold, units)
+	}
+	for i := range config.RecipientProfiles {
+		profile := &config.RecipientProfiles[i]
+		profile.Threshold = toMetersPerSecond(profile.Threshold, units)
+		if profile.Units == "" {
+			profile.Units = units
+		}
+		if profile.Language == "" {
+			profile.Language = language
+		}
+	}
+
+	// Без EMAIL_TO общая рассылка идет получателям из RECIPIENTS_FILE, выбравшим электронную почту;
+	// номера получателей, выбравших SMS или звонок, добавляются к номерам Twilio
+	if len(config.EmailTo) == 0 {
+		config.EmailTo = recipientEmails(profiles)
+	}
+	config.Twilio.SMSTo = append(config.Twilio.SMSTo, recipientPhones(profiles, "sms")...)
+	config.Twilio.CallTo = append(config.Twilio.CallTo, recipientPhones(profiles, "call")...)
+
+	if len(config.Locations.List) > 0 {
+		// Без CITY часовой пояс и прогноз на завтра используют первый пункт
+		if config.City == "" {
+			first := config.forLocation(config.Locations.List[0])
+			config.City, config.PlaceName, config.Coords = first.City, first.PlaceName, first.Coords
+		}
+		// Отдельное время доставки задается пунктам (notification_time), а не получателям
+		if len(config.RecipientSlots) > 0 {
+			return nil, fmt.Errorf("RECIPIENT_TIMES не поддерживается при проверке нескольких пунктов (LOCATIONS_FILE): " +
+				"задайте время проверки пункта (notification_time) или уберите RECIPIENT_TIMES")
+		}
+	}
+
+	if config.Schedule == scheduleContinuous && config.Poll.Interval == 0 {
+		config.Poll.Interval = defaultPollInterval
 	}
 
 	// Проверка обязательных полей
 	if config.OpenWeatherAPIKey == "" {
 		return nil, fmt.Errorf("не указан API ключ для OpenWeatherMap")
 	}
-	if config.City == "" {
+	if config.City == "" && config.Coords == nil {
 		return nil, fmt.Errorf("не указан город для проверки погоды")
 	}
 	if len(config.EmailTo) == 0 {
@@ -295,6 +725,10 @@ func loadConfig() (*Config, error) {
 
 // Получение координат города с помощью Geocoding API
 func getGeoCoordinates(config *Config) (*GeoLocation, error) {
+	if config.Coords != nil {
+		return config.Coords, nil
+	}
+
 	url := fmt.Sprintf("http://api.openweathermap.org/geo/1.0/direct?q=%s&limit=1&appid=%s",
 		config.City, config.OpenWeatherAPIKey)
 
@@ -321,15 +755,51 @@ func getGeoCoordinates(config *Config) (*GeoLocation, error) {
 	return &locations[0], nil
 }
 
-// Получение данных о погоде по координатам
+// Получение данных о погоде по координатам; в течение FORECAST_CACHE_TTL используется сохраненный ответ
 func getWeatherData(config *Config) (*WeatherResponse, error) {
+	_, body, fresh, err := config.ForecastCache.get(config, time.Now(), func() (*GeoLocation, int, []byte, error) {
+		location, status, body, err := fetchForecast(config)
+		// Результат запроса учитывается проверкой готовности (/readyz)
+		fetchErr := err
+		if err == nil && status != http.StatusOK {
+			fetchErr = fmt.Errorf("OpenWeatherMap вернул статус %d", status)
+		}
+		config.Health.forecastFetched(time.Now(), fetchErr)
+		config.Ops.forecastFetched(config.placeName(), fetchErr)
+		return location, status, body, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var weatherData WeatherResponse
+	if err := json.Unmarshal(body, &weatherData); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе JSON: %w", err)
+	}
+
+	// Время прогноза переводится в часовой пояс города, а не сервера
+	config.Clock.updateOffset(weatherData.City.Timezone)
+	weatherData.loc = config.Clock.Location()
+	for i := range weatherData.List {
+		weatherData.List[i].loc = weatherData.loc
+	}
+	// Прогноз из кэша уже учтен при получении
+	if fresh {
+		config.Accuracy.RecordForecast(config.placeName(), &weatherData, config.WindGustThreshold, time.Now())
+	}
+
+	return &weatherData, nil
+}
+
+// Запрос прогноза к OpenWeatherMap: координаты пункта, код ответа и тело ответа
+func fetchForecast(config *Config) (*GeoLocation, int, []byte, error) {
 	// Получаем координаты города
 	location, err := getGeoCoordinates(config)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при получении координат: %w", err)
+		return nil, 0, nil, fmt.Errorf("ошибка при получении координат: %w", err)
 	}
 
-	log.Printf("Получены координаты для %s: широта %.4f, долгота %.4f",
+	logDebugf("Получены координаты для %s: широта %.4f, долгота %.4f",
 		location.Name, location.Lat, location.Lon)
 
 	// Используем координаты для запроса прогноза погоды
@@ -338,34 +808,102 @@ func getWeatherData(config *Config) (*WeatherResponse, error) {
 
 	resp, err := http.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при запросе к API: %w", err)
+		return nil, 0, nil, fmt.Errorf("ошибка при запросе к API: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при чтении ответа: %w", err)
+		return nil, 0, nil, fmt.Errorf("ошибка при чтении ответа: %w", err)
 	}
+	config.ForecastArchive.save(config.placeName(), location, resp.StatusCode, body, time.Now())
+	return location, resp.StatusCode, body, nil
+}
 
-	var weatherData WeatherResponse
-	if err := json.Unmarshal(body, &weatherData); err != nil {
-		return nil, fmt.Errorf("ошибка при разборе JSON: %w", err)
+// Отправка электронного письма через Microsoft Exchange с использованием библиотеки go-mail
+func sendEmail(config *Config, subject, htmlBody, plainTextBody string) error {
+	return sendEmailTo(config, config.EmailTo, subject, htmlBody, plainTextBody)
+}
+
+// Отправка электронного письма указанным получателям; отписавшиеся адреса пропускаются.
+// Со ссылкой отписки (UNSUBSCRIBE_SECRET) каждый получатель получает отдельное письмо со своей ссылкой.
+func sendEmailTo(config *Config, recipients []string, subject, htmlBody, plainTextBody string, embeds ...emailEmbed) error {
+	recipients = config.withoutUnsubscribed(recipients)
+	if len(recipients) == 0 {
+		return nil
+	}
+	if config.DryRun {
+		log.Printf("[dry-run] Письмо %q для %s не отправлено:\n%s", subject, strings.Join(recipients, ", "), plainTextBody)
+		return nil
 	}
 
-	return &weatherData, nil
+	var messages []*mail.Msg
+	if config.Unsubscribe.enabled() {
+		for _, recipient := range recipients {
+			link := config.Unsubscribe.link(recipient)
+			htmlBody, plainTextBody := unsubscribeFooter(link, config.recipientProfile(recipient).Language, htmlBody, plainTextBody)
+			msg, err := newEmailMsg(config, []string{recipient}, subject, htmlBody, plainTextBody, embeds...)
+			if err != nil {
+				return err
+			}
+			// Отписка в один клик из почтового клиента (RFC 8058)
+			msg.SetGenHeader(mail.HeaderListUnsubscribe, "<"+link+">")
+			msg.SetGenHeader(mail.HeaderListUnsubscribePost, "List-Unsubscribe=One-Click")
+			messages = append(messages, msg)
+		}
+	} else {
+		msg, err := newEmailMsg(config, recipients, subject, htmlBody, plainTextBody, embeds...)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, msg)
+	}
+	return sendMessages(config, messages...)
 }
 
-// Отправка электронного письма через Microsoft Exchange с использованием библиотеки go-mail
-func sendEmail(config *Config, subject, htmlBody, plainTextBody string) error {
-	// Создание нового сообщения
+// Служебное письмо администраторам: без ссылки отписки и без учета отписавшихся
+func sendServiceEmailTo(config *Config, recipients []string, subject, htmlBody, plainTextBody string) error {
+	msg, err := newEmailMsg(config, recipients, subject, htmlBody, plainTextBody)
+	if err != nil {
+		return err
+	}
+	return sendMessages(config, msg)
+}
+
+// Отправка писем за одно подключение к SMTP-серверу
+func sendMessages(config *Config, messages ...*mail.Msg) error {
+	client, err := newSMTPClient(config)
+	if err != nil {
+		return err
+	}
+
+	// Отправка письма с контекстом для возможности отмены при длительных операциях
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := client.DialAndSendWithContext(ctx, messages...); err != nil {
+		return fmt.Errorf("ошибка при отправке письма: %w", err)
+	}
+
+	return nil
+}
+
+// Изображение, встроенное в HTML письма по Content-ID
+type emailEmbed struct {
+	ContentID string
+	Data      []byte
+}
+
+// Создание письма с HTML и текстовой версией
+func newEmailMsg(config *Config, recipients []string, subject, htmlBody, plainTextBody string, embeds ...emailEmbed) (*mail.Msg, error) {
 	msg := mail.NewMsg()
 	if err := msg.FromFormat("Система мониторинга погоды", config.EmailFrom); err != nil {
-		return fmt.Errorf("ошибка при указании отправителя: %w", err)
+		return nil, fmt.Errorf("ошибка при указании отправителя: %w", err)
 	}
 
 	// Добавление получателей
-	if err := msg.To(config.EmailTo...); err != nil {
-		return fmt.Errorf("ошибка при указании получателя %s: %w", config.EmailTo, err)
+	if err := msg.To(recipients...); err != nil {
+		return nil, fmt.Errorf("ошибка при указании получателя %s: %w", recipients, err)
 	}
 
 	// Установка темы письма
@@ -374,17 +912,26 @@ func sendEmail(config *Config, subject, htmlBody, plainTextBody string) error {
 	// Установка HTML тела письма и текстовой альтернативы
 	msg.SetBodyString(mail.TypeTextHTML, htmlBody)
 	msg.AddAlternativeString(mail.TypeTextPlain, plainTextBody)
+	// Content-ID встроенного файла - его имя в угловых скобках
+	for _, embed := range embeds {
+		if err := msg.EmbedReader(embed.ContentID, bytes.NewReader(embed.Data)); err != nil {
+			return nil, fmt.Errorf("ошибка при встраивании %s: %w", embed.ContentID, err)
+		}
+	}
 
 	// Установка кодировки для поддержки кириллицы
 	msg.SetCharset(mail.CharsetUTF8)
+	return msg, nil
+}
 
+// Клиент SMTP с опциями для Microsoft Exchange
+func newSMTPClient(config *Config) (*mail.Client, error) {
 	// Парсинг порта
 	portInt, err := strconv.Atoi(config.SMTPPort)
 	if err != nil {
-		return fmt.Errorf("ошибка при парсинге порта: %w", err)
+		return nil, fmt.Errorf("ошибка при парсинге порта: %w", err)
 	}
 
-	// Создание клиента с различными опциями для Microsoft Exchange
 	client, err := mail.NewClient(config.SMTPServer,
 		mail.WithPort(portInt),
 		mail.WithSMTPAuth(mail.SMTPAuthLogin), // Microsoft Exchange часто требует LOGIN аутентификацию
@@ -392,53 +939,61 @@ func sendEmail(config *Config, subject, htmlBody, plainTextBody string) error {
 		mail.WithPassword(config.SMTPPassword),
 		mail.WithTLSPolicy(mail.TLSOpportunistic), // Пробуем STARTTLS, но продолжаем без него если не поддерживается
 		mail.WithTimeout(30*time.Second),          // Увеличенный таймаут
+		mail.WithLogger(mailLogger{}),
 	)
 	if err != nil {
-		return fmt.Errorf("ошибка при создании клиента: %w", err)
+		return nil, fmt.Errorf("ошибка при создании клиента: %w", err)
 	}
+	// Диалог с SMTP-сервером содержит адреса и заголовки писем, поэтому выводится только при LOG_LEVEL=debug
+	client.SetDebugLog(logEnabled(levelDebug))
+	return client, nil
+}
 
-	// Включаем отладочный режим
-	client.SetDebugLog(true)
+// Отбор записей прогноза, попадающих в окно проверки дня со смещением dayOffset от текущего
+// (0 - сегодня, 1 - завтра)
+func forecastEntriesForTheDay(weatherData *WeatherResponse, window CheckWindow, dayOffset int) []DailyForecast {
+	now := weatherData.now()
+	startOfDay, endOfDay := window.bounds(now.AddDate(0, 0, dayOffset))
 
-	// Отправка письма с контекстом для возможности отмены при длительных операциях
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	var entries []DailyForecast
+	for _, forecast := range weatherData.List {
+		// Преобразуем время прогноза
+		forecastTime := forecast.Time()
 
-	if err := client.DialAndSendWithContext(ctx, msg); err != nil {
-		return fmt.Errorf("ошибка при отправке письма: %w", err)
+		// Проверяем, что прогноз относится к проверяемому дню. Точка ровно в полночь, как и прежде,
+		// в день не входит, а начало заданного окна входит: 09:00 попадает в окно 09:00-18:00
+		afterStart := forecastTime.After(startOfDay) || (window.Start > 0 && forecastTime.Equal(startOfDay))
+		if afterStart && forecastTime.Before(endOfDay) {
+			entries = append(entries, forecast)
+		}
 	}
 
-	return nil
+	return entries
 }
 
-// Проверка прогноза погоды на весь день и поиск сильных порывов ветра
-func checkWeatherForTheDay(weatherData *WeatherResponse, threshold float64) (bool, []WindGustForecast) {
-	now := time.Now()
-	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	endOfDay := startOfDay.Add(19 * time.Hour)
+// Отбор точек прогноза порывов ветра, относящихся к дню со смещением dayOffset от текущего
+func forecastPointsForTheDay(weatherData *WeatherResponse, window CheckWindow, dayOffset int) []WindGustForecast {
+	var points []WindGustForecast
+	for _, forecast := range forecastEntriesForTheDay(weatherData, window, dayOffset) {
+		points = append(points, WindGustForecast{
+			Time:     forecast.Time(),
+			WindGust: forecast.Wind.Gust,
+		})
+	}
 
+	return points
+}
+
+// Проверка прогноза погоды на весь день и поиск сильных порывов ветра
+func checkWeatherForTheDay(points []WindGustForecast, threshold float64) (bool, []WindGustForecast) {
 	var forecasts []WindGustForecast
 	exceedsThreshold := false
 
-	for _, forecast := range weatherData.List {
-		// Преобразуем время прогноза
-		forecastTime := time.Unix(forecast.Dt, 0)
-
-		// Проверяем, что прогноз относится к текущему дню
-		if forecastTime.After(startOfDay) && forecastTime.Before(endOfDay) {
-			windGust := forecast.Wind.Gust
-
-			log.Printf("Прогноз на %s: порывы ветра %.2f м/с\n",
-				forecastTime.Format("15:04"), windGust)
-
-			// Если порывы ветра превышают порог
-			if windGust > threshold {
-				exceedsThreshold = true
-				forecasts = append(forecasts, WindGustForecast{
-					Time:     forecastTime,
-					WindGust: windGust,
-				})
-			}
+	for _, point := range points {
+		// Если порывы ветра превышают порог
+		if point.WindGust > threshold {
+			exceedsThreshold = true
+			forecasts = append(forecasts, point)
 		}
 	}
 
@@ -450,6 +1005,25 @@ func checkWeatherForTheDay(weatherData *WeatherResponse, threshold float64) (boo
 	return exceedsThreshold, forecasts
 }
 
+// Вывод точек прогноза в журнал
+func logForecastPoints(points []WindGustForecast) {
+	for _, point := range points {
+		log.Printf("Прогноз на %s: порывы ветра %.2f м/с\n",
+			point.Time.Format("02.01 15:04"), point.WindGust)
+	}
+}
+
+// Интервалы прогноза с порывами выше порога - для личного порога получателя
+func gustsAbove(points []WindGustForecast, threshold float64) []WindGustForecast {
+	var forecasts []WindGustForecast
+	for _, point := range points {
+		if point.WindGust > threshold {
+			forecasts = append(forecasts, point)
+		}
+	}
+	return forecasts
+}
+
 // Нахождение максимального значения порыва ветра
 func findMaxWindGust(forecasts []WindGustForecast) float64 {
 	if len(forecasts) == 0 {
@@ -467,14 +1041,42 @@ func findMaxWindGust(forecasts []WindGustForecast) float64 {
 }
 
 // Формирование HTML и текстового тела письма с использованием шаблонов
-func generateEmailBodies(maxWindGust, windGustThreshold float64) (string, string, error) {
+// на языке и в единицах получателя
+func generateEmailBodies(report *AlertReport, recipient Recipient) (string, string, error) {
 	data := EmailData{
-		MaxWindGust:       maxWindGust,
-		WindGustThreshold: windGustThreshold,
+		Name:              recipient.Name,
+		MaxWindGust:       report.MaxWindGust,
+		WindGustThreshold: report.WindGustThreshold,
+		AckURL:            report.AckURL,
+		Period:            capitalize(report.periodTitleIn(recipient.Language)),
+		Reminder:          report.Reminder,
+		Chart:             len(report.Chart) > 0,
+	}
+	if recipient.Language == languageEN {
+		data.Period = report.periodTitleIn(languageEN)
+	}
+	for _, f := range report.Forecasts {
+		data.Forecasts = append(data.Forecasts, ForecastLine{Time: report.formatTimeIn(f.Time, recipient.Language), WindGust: f.WindGust})
+	}
+	for _, location := range report.alertedLocations() {
+		line := LocationLine{Name: location.City, MaxWindGust: location.MaxWindGust, WindGustThreshold: location.WindGustThreshold}
+		for _, f := range location.Forecasts {
+			line.Forecasts = append(line.Forecasts, ForecastLine{Time: location.formatTimeIn(f.Time, recipient.Language), WindGust: f.WindGust})
+		}
+		data.Locations = append(data.Locations, line)
 	}
 
+	funcs := speedFuncs(recipient.Units, recipient.Language)
+	if recipient.Language == languageEN {
+		return renderEmailBodies(emailHTMLTemplateTextEN, emailPlainTextTemplateEN, data, funcs)
+	}
+	return renderEmailBodies(emailHTMLTemplateText, emailPlainTextTemplate, data, funcs)
+}
+
+// Заполнение HTML и текстового шаблонов письма данными
+func renderEmailBodies(htmlTemplateText, plainTextTemplateText string, data interface{}, funcs map[string]any) (string, string, error) {
 	// Создание HTML-тела письма
-	htmlTemplate, err := template.New("emailHTML").Parse(emailHTMLTemplateText)
+	htmlTemplate, err := template.New("emailHTML").Funcs(funcs).Parse(htmlTemplateText)
 	if err != nil {
 		return "", "", fmt.Errorf("ошибка при парсинге HTML шаблона: %w", err)
 	}
@@ -485,7 +1087,7 @@ func generateEmailBodies(maxWindGust, windGustThreshold float64) (string, string
 	}
 
 	// Создание текстового тела письма
-	textTemplate, err := template.New("emailText").Parse(emailPlainTextTemplate)
+	textTemplate, err := template.New("emailText").Funcs(funcs).Parse(plainTextTemplateText)
 	if err != nil {
 		return "", "", fmt.Errorf("ошибка при парсинге текстового шаблона: %w", err)
 	}
@@ -498,98 +1100,459 @@ func generateEmailBodies(maxWindGust, windGustThreshold float64) (string, string
 	return htmlBuffer.String(), textBuffer.String(), nil
 }
 
-// Проверка погоды и отправка предупреждения
-func checkWeatherAndAlert(config *Config) {
+// Проверка погоды и отправка предупреждения; false - прогноз не получен
+func checkWeatherAndAlert(config *Config, dispatcher *Dispatcher) bool {
+	if len(config.Locations.List) > 0 {
+		return checkLocationsAndAlert(config, dispatcher)
+	}
+
+	report := evaluateWeather(config)
+	if report == nil {
+		config.Audit.recordForecastError(config.placeName())
+		return false
+	}
+
+	if report.ExceedsThreshold {
+		log.Printf("Порывы ветра превышают пороговое значение в течение дня (уровень опасности: %s), отправляю предупреждение...",
+			report.Severity.Title())
+	} else {
+		log.Println("Порывы ветра в норме на весь день, предупреждение не требуется")
+	}
+
+	dispatcher.Dispatch(report)
+	return true
+}
+
+// Получение прогноза и оценка порывов ветра; при ошибке возвращает nil
+func evaluateWeather(config *Config) *AlertReport {
 	log.Println("Запуск проверки погодных условий...")
 
 	weatherData, err := getWeatherData(config)
 	if err != nil {
-		log.Printf("Ошибка при получении данных о погоде: %v\n", err)
-		return
+		logErrorf("Ошибка при получении данных о погоде: %v\n", err)
+		return nil
 	}
 
 	// Проверка наличия данных
 	if len(weatherData.List) == 0 {
 		log.Println("Нет данных о погоде в ответе API")
-		return
+		return nil
 	}
 
-	// Проверяем весь день на наличие сильных порывов ветра
-	exceedsThreshold, forecasts := checkWeatherForTheDay(weatherData, config.WindGustThreshold)
-
-	if exceedsThreshold {
-		log.Println("Порывы ветра превышают пороговое значение в течение дня, отправляю предупреждение...")
+	// Проверяем весь день (и следующие дни при LOOKAHEAD_DAYS) на наличие сильных порывов ветра
+	var points []WindGustForecast
+	for day := 0; day <= config.LookaheadDays; day++ {
+		points = append(points, forecastPointsForTheDay(weatherData, config.CheckWindow, day)...)
+	}
+	logForecastPoints(points)
+
+	maxWindGust := findMaxWindGust(points)
+	report := &AlertReport{
+		City:              config.placeName(),
+		CheckedAt:         config.Clock.Now(),
+		NextCheck:         getNextSendTime(config),
+		Severity:          severityFor(maxWindGust, config.WindGustThreshold, config.Severity),
+		MaxWindGust:       maxWindGust,
+		WindGustThreshold: config.WindGustThreshold,
+		Points:            points,
+		LookaheadDays:     config.LookaheadDays,
+		Recipients:        config.defaultRecipients(),
+		Units:             config.Units,
+		Language:          config.Language,
+	}
 
-		// Получаем максимальную силу ветра за день
-		maxWindGust := findMaxWindGust(forecasts)
+	// Правила оцениваются по порядку, предупреждение выпускает первое сработавшее
+	for _, rule := range config.alertRules() {
+		forecasts, value := rule.evaluate(weatherData, config)
+		if len(forecasts) == 0 {
+			continue
+		}
+		report.ExceedsThreshold = true
+		report.Forecasts = forecasts
+		report.Rule = rule.Name
+		report.RuleMetric = rule.Metric
+		report.RuleValue = value
+		report.Template = rule.Template
+		switch {
+		case rule.severity != SeverityNone:
+			report.Severity = rule.severity
+		case report.Severity == SeverityNone:
+			// Правило не по порывам ветра сработало при слабом ветре
+			report.Severity = SeverityYellow
+		}
+		report.Reason = ruleReason(rule, value, rule.thresholdFor(config), report.Severity)
+		if len(config.Rules) > 0 {
+			log.Printf("Сработало правило %s: %s %s %.2f", rule.Name, rule.Metric, rule.Comparator, value)
+		}
+		break
+	}
+	if !report.ExceedsThreshold {
+		report.Reason = calmReason(report, len(config.Rules))
+	}
 
-		subject := "ВНИМАНИЕ: Сильный ветер сегодня"
+	config.Metrics.recordReport(report)
+	config.Dashboard.recordReport(report)
+	return report
+}
 
-		// Формирование HTML и текстовой версий письма с использованием шаблонов
-		htmlBody, plainTextBody, err := generateEmailBodies(maxWindGust, config.WindGustThreshold)
-		if err != nil {
-			log.Printf("Ошибка при формировании письма: %v\n", err)
-			return
+// Выполнение проверки в соответствии с режимом работы; false - проверка не удалась.
+// Проверка, пропущенная в день без уведомлений или во время паузы, считается успешной.
+func runCheck(config *Config, dispatcher *Dispatcher) bool {
+	config.Health.checked(time.Now())
+	if blackedOut(config, "проверка") {
+		if dispatcher.store.leading() {
+			config.Audit.recordBlackout(config)
 		}
+		return true
+	}
+	if standby(dispatcher.store, "проверка") {
+		return true
+	}
 
-		if err := sendEmail(config, subject, htmlBody, plainTextBody); err != nil {
-			log.Printf("Ошибка при отправке предупреждения: %v\n", err)
-		} else {
-			log.Println("Предупреждение успешно отправлено")
-		}
-	} else {
-		log.Println("Порывы ветра в норме на весь день, предупреждение не требуется")
+	// В режимах без истории проверок приостановка отменяет запуск целиком
+	if config.Mode != modeWind && dispatcher.pause.Paused() {
+		log.Println("Рассылка приостановлена, проверка пропущена")
+		return true
+	}
+
+	switch config.Mode {
+	case modeDrone:
+		return checkFlightWindowsAndNotify(config)
+	case modeSchool:
+		return checkOutdoorActivityAndNotify(config)
+	default:
+		return checkWeatherAndAlert(config, dispatcher)
 	}
 }
 
 // Получение следующего времени отправки
 func getNextSendTime(config *Config) time.Time {
-	now := time.Now()
-	nextSend := time.Date(now.Year(), now.Month(), now.Day(), config.NotificationHour, config.NotificationMin, 0, 0, now.Location())
+	return nextDailyTime(config.Clock.Now(), config.NotificationHour, config.NotificationMin)
+}
+
+// Ближайшее наступление ежедневного времени hour:minute после указанного момента
+func nextDailyTime(now time.Time, hour, minute int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
 
-	// Если уже позже времени отправки, переходим на следующий день
-	if now.After(nextSend) {
-		nextSend = nextSend.Add(24 * time.Hour)
+	// Если уже позже, переходим на следующий календарный день
+	// (не на 24 часа, чтобы при переходе на летнее время отправка не сдвигалась на час)
+	if now.After(next) {
+		next = time.Date(now.Year(), now.Month(), now.Day()+1, hour, minute, 0, 0, now.Location())
 	}
 
-	return nextSend
+	return next
+}
+
+// Интервал, с которым пересчитывается время следующей отправки во время ожидания
+const scheduleRecheckInterval = time.Minute
+
+// Ожидание ежедневного времени hour:minute в часовом поясе города. Таймеры Go отсчитывают
+// монотонное время, поэтому ожидание разбито на короткие интервалы, после каждого из которых
+// время пересчитывается по часам: так учитываются переходы на летнее время, смена часового пояса
+// города и перевод системных часов. name - название запуска для журнала.
+func waitForDailyTime(ctx context.Context, clock *CityClock, hour, minute int, name string) bool {
+	return waitUntil(ctx, clock, func(now time.Time) time.Time { return nextDailyTime(now, hour, minute) }, name)
+}
+
+// Ожидание времени, вычисляемого функцией next по текущему времени города
+func waitUntil(ctx context.Context, clock *CityClock, next func(now time.Time) time.Time, name string) bool {
+	nextSend := next(clock.Now())
+	log.Printf("Следующая %s запланирована на %s (через %s)",
+		name, nextSend.Format("2006-01-02 15:04:05 MST"), time.Until(nextSend).Round(time.Second))
+
+	for {
+		now := clock.Now()
+		if !now.Before(nextSend) {
+			return true
+		}
+
+		if recomputed := next(now); !recomputed.Equal(nextSend) {
+			log.Printf("Время запуска (%s) изменилось: %s (через %s)",
+				name, recomputed.Format("2006-01-02 15:04:05 MST"), recomputed.Sub(now).Round(time.Second))
+			nextSend = recomputed
+		}
+
+		timer := time.NewTimer(min(nextSend.Sub(now), scheduleRecheckInterval))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		}
+	}
 }
 
 func main() {
+	initLogging()
+
+	// Проверка конфигурации без запуска сервиса: windalerts validate [флаги]
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(os.Args[2:]))
+	}
+	// Самодиагностика окружения: windalerts doctor [флаги]
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		os.Exit(runDoctor(os.Args[2:], os.Stdout))
+	}
+	// Доставка предупреждений по получателям из базы истории: windalerts status [флаги]
+	if len(os.Args) > 1 && os.Args[1] == "status" {
+		os.Exit(runStatus(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	// Выгрузка предупреждений и максимумов по дням из базы истории: windalerts history export [флаги]
+	if len(os.Args) > 1 && os.Args[1] == "history" {
+		os.Exit(runHistoryCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	// Решения о предупреждениях из журнала аудита: windalerts audit [флаги]
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		os.Exit(runAuditCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	// Образец конфигурации, JSON Schema, шифрование значений и миграция: windalerts config sample|schema|keygen|encrypt|migrate
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfigCommand(os.Args[2:]))
+	}
+
+	// Флаги командной строки переопределяют переменные окружения и .env
+	if err := applyFlags(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		logFatalf("Ошибка в аргументах командной строки: %v", err)
+	}
+
 	log.Println("Запуск сервиса мониторинга порывов ветра...")
 
-	// Загрузка конфигурации
+	// Сигналы остановки (Ctrl+C, systemd, Kubernetes) прерывают ожидание плановых запусков
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var background sync.WaitGroup
+
+	// Загрузка конфигурации; хранилище запоминает окружение процесса до чтения .env
+	store := newConfigStore()
 	config, err := loadConfig()
 	if err != nil {
-		log.Fatalf("Ошибка при загрузке конфигурации: %v", err)
+		logFatalf("Ошибка при загрузке конфигурации: %v", err)
 	}
+	store.Store(config)
 
-	log.Printf("Загружена конфигурация: порог ветра = %.2f м/s, время отправки = %02d:%02d",
-		config.WindGustThreshold, config.NotificationHour, config.NotificationMin)
+	// Журнал в файл с ротацией; файл и ротация не меняются при перезагрузке конфигурации
+	if config.LogFile.File != "" {
+		logFile := openLogFile(config.LogFile)
+		defer logFile.Close()
+	}
 
-	// Запускаем первую проверку сразу при старте (но уведомление отправляем только если сейчас время отправки)
-	now := time.Now()
-	if now.Hour() == config.NotificationHour && now.Minute() >= config.NotificationMin && now.Minute() < config.NotificationMin+5 {
-		// Запускаем проверку только если мы находимся в 5-минутном окне после времени отправки
-		checkWeatherAndAlert(config)
-	} else {
-		log.Printf("Первая проверка будет выполнена в %02d:%02d", config.NotificationHour, config.NotificationMin)
+	// Учетные данные проверяются до начала работы, а не во время первой рассылки
+	if config.Preflight {
+		if err := runPreflight(ctx, config); err != nil {
+			logFatalf("Предварительная проверка не пройдена:\n%v", err)
+		}
 	}
 
-	// Основной цикл программы
-	for {
-		// Получаем время следующей отправки
-		nextSend := getNextSendTime(config)
+	log.Printf("Загружена конфигурация: режим = %s, порог ветра = %.2f м/s, время отправки = %02d:%02d, окно проверки = %s",
+		config.Mode, config.WindGustThreshold, config.NotificationHour, config.NotificationMin, config.CheckWindow)
+
+	stateStore, err := openStateStore(config.Store)
+	if err != nil {
+		logFatalf("Ошибка при открытии хранилища состояния: %v", err)
+	}
+	defer stateStore.Close()
+	config.Ops.attach(store, stateStore)
+
+	// Получатели, добавленные и исключенные через /api/recipients, дополняют EMAIL_TO и RECIPIENTS_FILE
+	subscriptions, err := loadSubscriptions(stateStore, config.SubscriptionsFile)
+	if err != nil {
+		logFatalf("Ошибка при загрузке подписок: %v", err)
+	}
+	subscriptions.attach(store)
+
+	history, err := loadAlertHistory(stateStore, config.HistoryFile)
+	if err != nil {
+		logFatalf("Ошибка при загрузке истории предупреждений: %v", err)
+	}
+	historyDB, err := openHistoryDB(config.HistoryDB)
+	if err != nil {
+		logFatalf("Ошибка при открытии базы истории: %v", err)
+	}
+	defer historyDB.Close()
+
+	// Время отправки и «текущий день» считаются в часовом поясе города
+	resolveCityTimezone(config)
+
+	runState, err := loadRunState(stateStore, config.RunStateFile)
+	if err != nil {
+		logFatalf("Ошибка при загрузке состояния проверок: %v", err)
+	}
+
+	notifiers := buildNotifiers(store, history)
+
+	// Недоставленные уведомления повторяются в фоне, очередь переживает перезапуск
+	retries, err := newRetryQueue(config.Retry, func() QuietHoursConfig { return store.Load().QuietHours }, config.Clock, notifiers, historyDB, stateStore, config.Metrics, config.Ops)
+	if err != nil {
+		logFatalf("Ошибка при загрузке очереди повторной доставки: %v", err)
+	}
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		retries.Run(ctx)
+	}()
+
+	templates, err := loadMessageTemplates(config.TemplatesDir)
+	if err != nil {
+		logFatalf("Ошибка при загрузке шаблонов сообщений: %v", err)
+	}
+
+	// Цепочка эскалации при отсутствии подтверждения
+	escalation, err := newEscalator(config.Escalation, stateStore)
+	if err != nil {
+		logFatalf("Ошибка при загрузке состояния эскалации: %v", err)
+	}
+
+	// Приостановка рассылки: PAUSE_UNTIL, сигнал SIGUSR1 или /api/pause
+	pause, err := newPauseControl(store, stateStore, config.PauseFile)
+	if err != nil {
+		logFatalf("Ошибка при загрузке состояния приостановки: %v", err)
+	}
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		pause.handleSignals(ctx)
+	}()
+
+	// Повторная проверка перед началом сильного ветра
+	reminders := newReminders(config.Reminder, func(place string) *AlertReport {
+		config := store.Load()
+		if blackedOut(config, "повторная проверка") || standby(stateStore, "повторная проверка") {
+			return nil
+		}
+		report := evaluateWeather(config.locationConfig(place))
+		if report != nil {
+			report.NextCheck = getNextSendTime(config)
+		}
+		return report
+	})
+
+	dispatcher := newDispatcher(store, notifiers, templates, retries, escalation, pause, reminders, historyDB, runState, stateStore)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		escalation.Run(ctx)
+	}()
+
+	// Разовые проверки для мероприятий выполняются отдельно от ежедневной проверки
+	events, err := newEventScheduler(config, stateStore)
+	if err != nil {
+		logFatalf("Ошибка при загрузке мероприятий: %v", err)
+	}
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		events.Run(ctx)
+	}()
 
-		// Вычисляем время ожидания до следующей отправки
-		waitDuration := nextSend.Sub(time.Now())
-		log.Printf("Следующая проверка запланирована на %s (через %s)",
-			nextSend.Format("2006-01-02 15:04:05"), waitDuration.String())
+	scheduler, err := newScheduler(store, dispatcher, runState)
+	if err != nil {
+		logFatalf("Ошибка при настройке расписания: %v", err)
+	}
+	log.Printf("Расписание проверок: %s", scheduler.Name())
+
+	// Необязательный HTTP-сервер
+	var server *http.Server
+	if config.HTTPAddr != "" {
+		mux := http.NewServeMux()
+		events.registerRoutes(mux)
+		registerFeedRoutes(mux, history, config.Feed)
+		registerCalendarRoute(mux, store, history)
+		escalation.registerRoutes(mux)
+		pause.registerRoutes(mux)
+		subscriptions.registerRoutes(mux)
+		registerSettingsRoutes(mux, store)
+		historyDB.registerRoutes(mux)
+		config.Health.registerRoutes(mux, store)
+		config.Metrics.registerRoutes(mux)
+		config.Dashboard.registerRoutes(mux, store, scheduler, history, pause, notifiers)
+		registerChartRoutes(mux, store)
+		if !config.APIKeys.Configured {
+			logWarnf("API_KEYS не задана: маршруты управления на %s открыты всем, кто может подключиться к порту; "+
+				"изменения через /api/alerts и /api/recipients отклоняются", config.HTTPAddr)
+		}
+		server = startHTTPServer(config.HTTPAddr, requireAPIKeys(store, mux))
+	}
 
-		// Ждем до следующего времени отправки
-		time.Sleep(waitDuration)
+	// Проверки живости и готовности и метрики; отдельный адрес позволяет не открывать остальные маршруты
+	var healthServer *http.Server
+	if config.Health.config.Addr != "" {
+		mux := http.NewServeMux()
+		config.Health.registerRoutes(mux, store)
+		config.Metrics.registerRoutes(mux)
+		// Профилирование только на служебном адресе: профили раскрывают внутреннее устройство процесса
+		if config.Health.config.Pprof {
+			registerPprofRoutes(mux)
+			log.Printf("Профилирование pprof доступно по адресу %s/debug/pprof/", config.Health.config.Addr)
+		}
+		healthServer = startHTTPServer(config.Health.config.Addr, mux)
+	}
+	if config.HTTPAddr != "" || healthServer != nil {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			config.Health.runProbes(ctx, store)
+		}()
+	}
 
-		// Выполняем проверку и отправку
-		checkWeatherAndAlert(config)
+	// Вечерний предварительный прогноз на завтра
+	if config.Preview.Enabled && config.Mode == modeWind {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			runPreviewSchedule(ctx, store, pause, stateStore)
+		}()
 	}
+
+	// Еженедельная сводка по истории проверок
+	if config.Digest.Enabled && config.Mode == modeWind {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			runDigestSchedule(ctx, store, history, stateStore)
+		}()
+	}
+
+	// Сравнение прогноза с фактической погодой
+	if config.Accuracy != nil {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			runAccuracyTracking(ctx, store, config.Accuracy)
+		}()
+	}
+
+	// Перезагрузка конфигурации по SIGHUP и при изменении .env
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		store.Watch(ctx)
+	}()
+
+	// Ожидание прерывается сигналом завершения, а начатая проверка
+	// с отправкой уведомлений всегда доводится до конца
+	scheduler.Run(ctx)
+	stop()
+
+	log.Println("Останавливаю сервис...")
+	config.Dashboard.closeStreams()
+	for _, srv := range []*http.Server{server, healthServer} {
+		if srv == nil {
+			continue
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logErrorf("Ошибка при остановке HTTP-сервера: %v", err)
+		}
+		cancel()
+	}
+
+	// Дожидаемся завершения фоновых отправок и сохраняем состояние
+	reminders.Stop()
+	background.Wait()
+	retries.Flush()
+	escalation.Flush()
+	log.Println("Сервис остановлен")
 }
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// Настройки веб-хука для IFTTT Maker / Zapier
+type MakerConfig struct {
+	WebhookURL string // Полный адрес веб-хука (например, Zapier Catch Hook)
+	IFTTTKey   string // Ключ IFTTT Webhooks, если адрес не указан явно
+	Event      string // Имя события
+}
+
+// Загрузка настроек веб-хука из переменных окружения
+func loadMakerConfig() MakerConfig {
+	cfg := MakerConfig{
+		WebhookURL: os.Getenv("MAKER_WEBHOOK_URL"),
+		IFTTTKey:   os.Getenv("IFTTT_KEY"),
+		Event:      os.Getenv("MAKER_EVENT"),
+	}
+
+	if cfg.Event == "" {
+		cfg.Event = "wind_alert"
+	}
+
+	return cfg
+}
+
+// Адрес веб-хука: явно указанный или сформированный для IFTTT
+func (c MakerConfig) endpoint() string {
+	if c.WebhookURL != "" {
+		return c.WebhookURL
+	}
+	return fmt.Sprintf("https://maker.ifttt.com/trigger/%s/with/key/%s",
+		url.PathEscape(c.Event), url.PathEscape(c.IFTTTKey))
+}
+
+// Запуск автоматизаций IFTTT / Zapier по предупреждению
+type makerNotifier struct {
+	config MakerConfig
+}
+
+func newMakerNotifier(config MakerConfig) *makerNotifier {
+	return &makerNotifier{config: config}
+}
+
+func (n *makerNotifier) Name() string {
+	return "maker"
+}
+
+// Тело запроса в формате IFTTT Webhooks (value1-value3); Zapier принимает его как есть
+type makerPayload struct {
+	Event    string `json:"event"`
+	Value1   string `json:"value1"` // Город
+	Value2   string `json:"value2"` // Максимальный порыв ветра в единицах UNITS
+	Value3   string `json:"value3"` // Пороговое значение в единицах UNITS
+	Severity string `json:"severity"`
+}
+
+func (n *makerNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	payload := makerPayload{
+		Event:    n.config.Event,
+		Value1:   report.City,
+		Value2:   strconv.FormatFloat(convertSpeed(report.MaxWindGust, report.Units), 'f', 1, 64),
+		Value3:   strconv.FormatFloat(convertSpeed(report.WindGustThreshold, report.Units), 'f', 1, 64),
+		Severity: report.Severity.String(),
+	}
+
+	if _, err := sendJSON(ctx, http.MethodPost, n.config.endpoint(), nil, payload); err != nil {
+		return fmt.Errorf("ошибка при вызове веб-хука: %w", err)
+	}
+
+	log.Printf("Веб-хук %s вызван", n.config.Event)
+	return nil
+}
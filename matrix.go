@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Настройки отправки уведомлений в Matrix
+type MatrixConfig struct {
+	HomeserverURL string // Адрес homeserver, например https://matrix.example.org
+	AccessToken   string
+	RoomID        string // Идентификатор комнаты, например !abcdef:example.org
+}
+
+// Загрузка настроек Matrix из переменных окружения
+func loadMatrixConfig() MatrixConfig {
+	return MatrixConfig{
+		HomeserverURL: strings.TrimSuffix(os.Getenv("MATRIX_HOMESERVER_URL"), "/"),
+		AccessToken:   os.Getenv("MATRIX_ACCESS_TOKEN"),
+		RoomID:        os.Getenv("MATRIX_ROOM_ID"),
+	}
+}
+
+// Отправка предупреждения в комнату Matrix
+type matrixNotifier struct {
+	config MatrixConfig
+}
+
+func newMatrixNotifier(config MatrixConfig) *matrixNotifier {
+	return &matrixNotifier{config: config}
+}
+
+func (n *matrixNotifier) Name() string {
+	return "matrix"
+}
+
+// Сообщение m.room.message с HTML-форматированием
+type matrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format"`
+	FormattedBody string `json:"formatted_body"`
+}
+
+func (n *matrixNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	// Идентификатор транзакции защищает от дублирования при повторной отправке
+	txnID := fmt.Sprintf("windalerts-%d", time.Now().UnixNano())
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		n.config.HomeserverURL, url.PathEscape(n.config.RoomID), txnID)
+
+	message := matrixMessage{
+		MsgType:       "m.text",
+		Body:          report.text(formatAlertText(report)),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: formatMatrixHTML(report),
+	}
+	if report.MessageHTML != "" {
+		message.FormattedBody = report.MessageHTML
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + n.config.AccessToken}
+	if _, err := sendJSON(ctx, http.MethodPut, endpoint, headers, message); err != nil {
+		return fmt.Errorf("ошибка при отправке сообщения в Matrix: %w", err)
+	}
+
+	log.Println("Предупреждение отправлено в Matrix")
+	return nil
+}
+
+// HTML-версия предупреждения для клиентов Matrix
+func formatMatrixHTML(report *AlertReport) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, report.tr("<h3>⚠️ Внимание! %s</h3>", "<h3>⚠️ Warning! %s</h3>"), html.EscapeString(report.City))
+	fmt.Fprintf(&sb, report.tr("<p>%s ожидаются <b>сильные порывы ветра (%s)</b>, что превышает безопасный порог (<b>%s</b>).</p>",
+		"<p>%s <b>strong wind gusts (%s)</b> are expected, exceeding the safe threshold (<b>%s</b>).</p>"),
+		capitalize(report.periodTitle()), report.speed(report.MaxWindGust, 2), report.speed(report.WindGustThreshold, 2))
+	if len(report.Forecasts) > 0 {
+		sb.WriteString("<ul>")
+		for _, f := range report.Forecasts {
+			fmt.Fprintf(&sb, "<li>%s: %s</li>", report.formatTime(f.Time), report.speed(f.WindGust, 2))
+		}
+		sb.WriteString("</ul>")
+	}
+	sb.WriteString(report.tr("<p>Рекомендуется <b>не открывать окна в офисе</b> в течение дня.</p>", "<p>Please <b>keep the office windows closed</b> during the day.</p>"))
+	if report.AckURL != "" {
+		fmt.Fprintf(&sb, report.tr("<p><a href=\"%s\">Подтвердить получение</a></p>", "<p><a href=\"%s\">Acknowledge</a></p>"), html.EscapeString(report.AckURL))
+	}
+	return sb.String()
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Последний результат проверки пункта для метрик
+type cityMetrics struct {
+	checkedAt        time.Time
+	maxWindGust      float64
+	threshold        float64
+	severity         Severity
+	exceedsThreshold bool
+}
+
+// Границы интервалов гистограммы времени доставки, секунды
+var deliveryDurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Доставка уведомлений через канал: счетчики и гистограмма времени отправки
+type channelMetrics struct {
+	sent, failed int
+	buckets      []int // Число отправок не дольше соответствующей границы deliveryDurationBuckets
+	count        int
+	sum          float64
+}
+
+// Метрики Prometheus: результаты проверок и доставка уведомлений по каналам.
+// Отдаются по адресу /metrics и, если задан METRICS_TEXTFILE, записываются в файл .prom
+// для textfile collector node_exporter на хостах, с которых Prometheus не может
+// обратиться к HTTP-серверу сервиса. Значения хранятся в памяти с момента запуска.
+// Если задан STATSD_HOST, события дополнительно отправляются в StatsD.
+type Metrics struct {
+	textfile string
+	statsd   *statsdClient // Отправка тех же событий в StatsD (STATSD_HOST); nil - не отправляются
+
+	mu          sync.Mutex
+	lastRun     time.Time
+	lastSuccess time.Time
+	runOK       bool
+	leading     bool
+	cities      map[string]*cityMetrics
+	channels    map[string]*channelMetrics
+}
+
+// Создание метрик с настройками из переменной окружения
+func loadMetrics() *Metrics {
+	path := os.Getenv("METRICS_TEXTFILE")
+	if path != "" && filepath.Ext(path) != ".prom" {
+		logWarnf("METRICS_TEXTFILE: node_exporter читает только файлы с расширением .prom, %s не будет собран", path)
+	}
+	return &Metrics{
+		textfile: path,
+		statsd:   newStatsdClient(loadStatsDConfig()),
+		cities:   make(map[string]*cityMetrics),
+		channels: make(map[string]*channelMetrics),
+	}
+}
+
+// Учет результата проверки пункта; сводный отчет учитывается по пунктам
+func (m *Metrics) recordReport(report *AlertReport) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cities[report.City] = &cityMetrics{
+		checkedAt:        report.CheckedAt,
+		maxWindGust:      report.MaxWindGust,
+		threshold:        report.WindGustThreshold,
+		severity:         report.Severity,
+		exceedsThreshold: report.ExceedsThreshold,
+	}
+	m.writeLocked()
+
+	city := statsdTag("city", report.City)
+	m.statsd.gauge("max_wind_gust", report.MaxWindGust, city)
+	m.statsd.gauge("wind_gust_threshold", report.WindGustThreshold, city)
+	m.statsd.gauge("alert_active", float64(boolValue(report.ExceedsThreshold)), city)
+	m.statsd.gauge("alert_severity", float64(report.Severity), city)
+}
+
+// Учет плановой проверки: ok - проверка выполнена или пропущена по расписанию,
+// leading - этот экземпляр рассылает уведомления
+func (m *Metrics) recordRun(now time.Time, ok, leading bool) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastRun, m.runOK, m.leading = now, ok, leading
+	if ok {
+		m.lastSuccess = now
+	}
+	m.writeLocked()
+
+	result := "success"
+	if !ok {
+		result = "failure"
+	}
+	m.statsd.count("runs", 1, statsdTag("result", result))
+	m.statsd.gauge("sending_instance", float64(boolValue(leading)))
+}
+
+// Учет попытки доставки уведомления через канал
+func (m *Metrics) recordDelivery(channel string, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.channels[channel]
+	if !ok {
+		c = &channelMetrics{buckets: make([]int, len(deliveryDurationBuckets))}
+		m.channels[channel] = c
+	}
+	if err != nil {
+		c.failed++
+	} else {
+		c.sent++
+	}
+	seconds := duration.Seconds()
+	for i, le := range deliveryDurationBuckets {
+		if seconds <= le {
+			c.buckets[i]++
+		}
+	}
+	c.count++
+	c.sum += seconds
+	m.writeLocked()
+
+	result := "sent"
+	if err != nil {
+		result = "failed"
+	}
+	m.statsd.count("notifications", 1, statsdTag("channel", channel), statsdTag("result", result))
+	m.statsd.timing("notification.duration", duration, statsdTag("channel", channel))
+}
+
+// Метрики в текстовом формате Prometheus
+func (m *Metrics) render() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.renderLocked()
+}
+
+func (m *Metrics) renderLocked() []byte {
+	var buf bytes.Buffer
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	gauge := func(name, help string) {
+		metric(name, "gauge", help)
+	}
+
+	if !m.lastRun.IsZero() {
+		gauge("windalerts_last_run_timestamp_seconds", "Время последней плановой проверки (Unix)")
+		fmt.Fprintf(&buf, "windalerts_last_run_timestamp_seconds %d\n", m.lastRun.Unix())
+		gauge("windalerts_last_run_success", "Успешна ли последняя плановая проверка")
+		fmt.Fprintf(&buf, "windalerts_last_run_success %d\n", boolValue(m.runOK))
+		gauge("windalerts_sending_instance", "Рассылает ли уведомления этот экземпляр сервиса")
+		fmt.Fprintf(&buf, "windalerts_sending_instance %d\n", boolValue(m.leading))
+	}
+	if !m.lastSuccess.IsZero() {
+		gauge("windalerts_last_success_timestamp_seconds", "Время последней успешной плановой проверки (Unix)")
+		fmt.Fprintf(&buf, "windalerts_last_success_timestamp_seconds %d\n", m.lastSuccess.Unix())
+	}
+
+	cities := make([]string, 0, len(m.cities))
+	for city := range m.cities {
+		cities = append(cities, city)
+	}
+	sort.Strings(cities)
+	cityGauge := func(name, help string, value func(c *cityMetrics) string) {
+		if len(cities) == 0 {
+			return
+		}
+		gauge(name, help)
+		for _, city := range cities {
+			fmt.Fprintf(&buf, "%s{city=\"%s\"} %s\n", name, prometheusLabel(city), value(m.cities[city]))
+		}
+	}
+	cityGauge("windalerts_check_timestamp_seconds", "Время последней проверки прогноза по пункту (Unix)", func(c *cityMetrics) string {
+		return fmt.Sprint(c.checkedAt.Unix())
+	})
+	cityGauge("windalerts_max_wind_gust_meters_per_second", "Максимальный порыв ветра по прогнозу за проверяемый период, м/с", func(c *cityMetrics) string {
+		return fmt.Sprintf("%.2f", c.maxWindGust)
+	})
+	cityGauge("windalerts_wind_gust_threshold_meters_per_second", "Порог порывов ветра, м/с", func(c *cityMetrics) string {
+		return fmt.Sprintf("%.2f", c.threshold)
+	})
+	cityGauge("windalerts_alert_active", "Превышен ли порог по последнему прогнозу", func(c *cityMetrics) string {
+		return fmt.Sprint(boolValue(c.exceedsThreshold))
+	})
+	cityGauge("windalerts_alert_severity", "Уровень опасности: 0 - нет, 1 - желтый, 2 - оранжевый, 3 - красный", func(c *cityMetrics) string {
+		return fmt.Sprint(int(c.severity))
+	})
+
+	channels := make([]string, 0, len(m.channels))
+	for channel := range m.channels {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	if len(channels) > 0 {
+		metric("windalerts_notifications_total", "counter", "Попытки доставки уведомлений по каналам и результату")
+		for _, channel := range channels {
+			c, label := m.channels[channel], prometheusLabel(channel)
+			fmt.Fprintf(&buf, "windalerts_notifications_total{channel=\"%s\",result=\"sent\"} %d\n", label, c.sent)
+			fmt.Fprintf(&buf, "windalerts_notifications_total{channel=\"%s\",result=\"failed\"} %d\n", label, c.failed)
+		}
+		metric("windalerts_notification_duration_seconds", "histogram", "Время отправки уведомления через канал, с")
+		for _, channel := range channels {
+			c, label := m.channels[channel], prometheusLabel(channel)
+			for i, le := range deliveryDurationBuckets {
+				fmt.Fprintf(&buf, "windalerts_notification_duration_seconds_bucket{channel=\"%s\",le=\"%g\"} %d\n", label, le, c.buckets[i])
+			}
+			fmt.Fprintf(&buf, "windalerts_notification_duration_seconds_bucket{channel=\"%s\",le=\"+Inf\"} %d\n", label, c.count)
+			fmt.Fprintf(&buf, "windalerts_notification_duration_seconds_sum{channel=\"%s\"} %g\n", label, c.sum)
+			fmt.Fprintf(&buf, "windalerts_notification_duration_seconds_count{channel=\"%s\"} %d\n", label, c.count)
+		}
+	}
+	return buf.Bytes()
+}
+
+// Запись файла METRICS_TEXTFILE через временный файл в том же каталоге: node_exporter не должен прочитать его наполовину
+func (m *Metrics) writeLocked() {
+	if m.textfile == "" {
+		return
+	}
+
+	tmp := m.textfile + ".tmp"
+	if err := os.WriteFile(tmp, m.renderLocked(), 0o644); err != nil {
+		logErrorf("Ошибка при записи метрик в %s: %v", m.textfile, err)
+		return
+	}
+	if err := os.Rename(tmp, m.textfile); err != nil {
+		logErrorf("Ошибка при записи метрик в %s: %v", m.textfile, err)
+	}
+}
+
+// Маршрут /metrics для Prometheus
+func (m *Metrics) registerRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(m.render())
+	})
+}
+
+// Значение логической метрики
+func boolValue(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Экранирование значения метки в формате Prometheus
+func prometheusLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Версия формата конфигурации (CONFIG_VERSION). Конфигурация без версии считается версией 1.
+//
+//	1 - переменные без префикса (CITY, SMTP_PORT), в том числе заданные только в окружении
+//	2 - переменные с префиксом WINDALERTS_ (WINDALERTS_CITY)
+const configVersion = 2
+
+// Шаг миграции конфигурации с версии from на from+1
+type configMigration struct {
+	from    int
+	title   string
+	migrate func(values map[string]string) (renames map[string]string, notes []string)
+}
+
+// Миграции по порядку версий
+var configMigrations = []configMigration{
+	{from: 1, title: "префикс WINDALERTS_ для имен переменных", migrate: migrateEnvPrefix},
+}
+
+// Версия 1 -> 2: параметрам, секретам из файлов (_FILE) и значениям профилей (<ПРОФИЛЬ>__<ИМЯ>)
+// добавляется префикс WINDALERTS_. Посторонние переменные не изменяются.
+func migrateEnvPrefix(values map[string]string) (map[string]string, []string) {
+	renames := map[string]string{}
+	var notes []string
+	for _, name := range sortedKeys(values) {
+		if strings.HasPrefix(name, envPrefix) || !isConfigVariable(name) {
+			continue
+		}
+		if _, exists := values[envPrefix+name]; exists {
+			renames[name] = ""
+			notes = append(notes, fmt.Sprintf("%s удалена: уже задана %s%s", name, envPrefix, name))
+			continue
+		}
+		renames[name] = envPrefix + name
+		notes = append(notes, fmt.Sprintf("%s -> %s%s", name, envPrefix, name))
+	}
+	return renames, notes
+}
+
+// Является ли переменная параметром сервиса: имя параметра, <ИМЯ>_FILE или <ПРОФИЛЬ>__<ИМЯ>
+func isConfigVariable(name string) bool {
+	if _, profileName, ok := strings.Cut(name, profileSeparator); ok {
+		name = profileName
+	}
+	name = strings.TrimSuffix(name, "_FILE")
+	if _, ok := configAliases[name]; ok {
+		return true
+	}
+	for _, known := range configEnvVars {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Версия конфигурации из набора переменных
+func configVersionOf(values map[string]string) (int, error) {
+	raw, ok := values[envPrefix+"CONFIG_VERSION"]
+	if !ok {
+		raw, ok = values["CONFIG_VERSION"]
+	}
+	if !ok || strings.TrimSpace(raw) == "" {
+		return 1, nil
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("CONFIG_VERSION: ожидается номер версии, получено %q", raw)
+	}
+	if version > configVersion {
+		return 0, fmt.Errorf("CONFIG_VERSION: версия %d новее поддерживаемой (%d), обновите программу", version, configVersion)
+	}
+	return version, nil
+}
+
+// Проверка версии загруженной конфигурации при запуске: устаревшая версия работает,
+// но в журнал выводится подсказка о миграции
+func checkConfigVersion() error {
+	version, err := configVersionOf(map[string]string{"CONFIG_VERSION": lookupEnv("CONFIG_VERSION")})
+	if err != nil {
+		return err
+	}
+	if version < configVersion {
+		log.Printf("Конфигурация версии %d устарела (текущая %d): выполните windalerts config migrate (или config migrate --env для переменных окружения)", version, configVersion)
+	}
+	return nil
+}
+
+// Строка присваивания в файле .env: необязательный export, имя, значение
+var envAssignment = regexp.MustCompile(`^(\s*(?:export\s+)?)([A-Za-z_][A-Za-z0-9_]*)(\s*=.*)$`)
+
+// Миграция файла .env до текущей версии с сохранением комментариев и порядка строк.
+// Возвращает новое содержимое и описание выполненных изменений.
+func migrateConfigFile(data string, values map[string]string) (string, []string, error) {
+	version, err := configVersionOf(values)
+	if err != nil {
+		return "", nil, err
+	}
+	if version == configVersion {
+		return data, nil, nil
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	var report []string
+	for _, m := range configMigrations {
+		if m.from < version {
+			continue
+		}
+		renames, notes := m.migrate(values)
+		report = append(report, fmt.Sprintf("Версия %d -> %d: %s", m.from, m.from+1, m.title))
+		for _, note := range notes {
+			report = append(report, "  "+note)
+		}
+
+		var migrated []string
+		for _, line := range lines {
+			match := envAssignment.FindStringSubmatch(line)
+			if match == nil {
+				migrated = append(migrated, line)
+				continue
+			}
+			newName, renamed := renames[match[2]]
+			switch {
+			case !renamed:
+				migrated = append(migrated, line)
+			case newName != "":
+				migrated = append(migrated, match[1]+newName+match[3])
+			}
+		}
+		lines = migrated
+
+		next := map[string]string{}
+		for name, value := range values {
+			if newName, renamed := renames[name]; renamed {
+				if newName != "" {
+					next[newName] = value
+				}
+				continue
+			}
+			next[name] = value
+		}
+		values = next
+	}
+
+	// Номер версии записывается первой строкой, прежний номер удаляется
+	var out []string
+	out = append(out, fmt.Sprintf("%sCONFIG_VERSION=%d", envPrefix, configVersion))
+	for _, line := range lines {
+		if match := envAssignment.FindStringSubmatch(line); match != nil &&
+			(match[2] == "CONFIG_VERSION" || match[2] == envPrefix+"CONFIG_VERSION") {
+			continue
+		}
+		out = append(out, line)
+	}
+	report = append(report, fmt.Sprintf("Установлена версия конфигурации %d", configVersion))
+	return strings.Join(out, "\n") + "\n", report, nil
+}
+
+// Файл .env из параметров, заданных в окружении процесса: для конфигураций без файла
+func configFileFromEnv() (string, map[string]string) {
+	values := map[string]string{}
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if isConfigVariable(strings.TrimPrefix(name, envPrefix)) || name == "CONFIG_VERSION" || name == envPrefix+"CONFIG_VERSION" {
+			values[name] = value
+		}
+	}
+
+	var b strings.Builder
+	for _, name := range sortedKeys(values) {
+		fmt.Fprintf(&b, "%s=%s\n", name, quoteEnvValue(values[name]))
+	}
+	return b.String(), values
+}
+
+// Команда config migrate: windalerts config migrate [--write] [файл] или --env для окружения
+func runConfigMigrate(args []string, stdout, stderr io.Writer) int {
+	path, write, fromEnv := defaultConfigFile, false, false
+	for _, arg := range args {
+		switch arg {
+		case "--write", "-w":
+			write = true
+		case "--env":
+			fromEnv = true
+		default:
+			if strings.HasPrefix(arg, "-") {
+				fmt.Fprintf(stderr, "Неизвестный флаг %s\n", arg)
+				return 2
+			}
+			path = arg
+		}
+	}
+	if fromEnv && write {
+		fmt.Fprintln(stderr, "Флаг --write не применим к --env: перенаправьте вывод в файл")
+		return 2
+	}
+
+	var data string
+	var values map[string]string
+	if fromEnv {
+		data, values = configFileFromEnv()
+	} else {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "Ошибка при чтении %s: %v\n", path, err)
+			return 1
+		}
+		data = string(raw)
+		if values, err = readConfigFile(path); err != nil {
+			fmt.Fprintf(stderr, "Ошибка при разборе %s: %v\n", path, err)
+			return 1
+		}
+	}
+
+	migrated, report, err := migrateConfigFile(data, values)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if len(report) == 0 {
+		fmt.Fprintf(stderr, "Конфигурация уже соответствует версии %d, изменения не требуются\n", configVersion)
+		if !write {
+			fmt.Fprint(stdout, migrated)
+		}
+		return 0
+	}
+	for _, line := range report {
+		fmt.Fprintln(stderr, line)
+	}
+
+	if !write {
+		fmt.Fprint(stdout, migrated)
+		return 0
+	}
+	// Прежний файл сохраняется рядом, чтобы миграцию можно было отменить
+	perm := os.FileMode(0o600)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := os.WriteFile(path+".bak", []byte(data), perm); err != nil {
+		fmt.Fprintf(stderr, "Ошибка при сохранении резервной копии: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(path, []byte(migrated), perm); err != nil {
+		fmt.Fprintf(stderr, "Ошибка при записи %s: %v\n", path, err)
+		return 1
+	}
+	fmt.Fprintf(stderr, "Файл %s обновлен, прежняя версия сохранена в %s.bak\n", path, path)
+	return 0
+}
+
+// Имена переменных по алфавиту
+func sortedKeys(values map[string]string) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// Настройки публикации в MQTT брокер
+type MQTTConfig struct {
+	Broker      string // Адрес брокера, например tcp://localhost:1883
+	ClientID    string
+	User        string
+	Password    string
+	TopicPrefix string // Префикс топиков, например windalerts
+	QoS         byte   // Уровень QoS (0, 1 или 2)
+	Retained    bool   // Флаг retained для публикуемых сообщений
+
+	HADiscovery       bool   // Публикация топиков обнаружения Home Assistant
+	HADiscoveryPrefix string // Префикс топиков обнаружения (по умолчанию homeassistant)
+}
+
+// Загрузка настроек MQTT из переменных окружения
+func loadMQTTConfig() MQTTConfig {
+	cfg := MQTTConfig{
+		Broker:      os.Getenv("MQTT_BROKER"),
+		ClientID:    os.Getenv("MQTT_CLIENT_ID"),
+		User:        os.Getenv("MQTT_USER"),
+		Password:    os.Getenv("MQTT_PASSWORD"),
+		TopicPrefix: os.Getenv("MQTT_TOPIC_PREFIX"),
+		QoS:         0,
+		Retained:    true, // По умолчанию состояние сохраняется на брокере
+
+		HADiscoveryPrefix: os.Getenv("MQTT_HA_DISCOVERY_PREFIX"),
+	}
+
+	if cfg.HADiscoveryPrefix == "" {
+		cfg.HADiscoveryPrefix = "homeassistant"
+	}
+
+	if cfg.ClientID == "" {
+		cfg.ClientID = "windalerts"
+	}
+	if cfg.TopicPrefix == "" {
+		cfg.TopicPrefix = "windalerts"
+	}
+	cfg.TopicPrefix = strings.TrimSuffix(cfg.TopicPrefix, "/")
+
+	if envQoS := os.Getenv("MQTT_QOS"); envQoS != "" {
+		if val, err := strconv.Atoi(envQoS); err == nil && val >= 0 && val <= 2 {
+			cfg.QoS = byte(val)
+		} else {
+			logWarnf("Ошибка парсинга MQTT_QOS: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envRetained := os.Getenv("MQTT_RETAINED"); envRetained != "" {
+		if val, err := strconv.ParseBool(envRetained); err == nil {
+			cfg.Retained = val
+		} else {
+			logWarnf("Ошибка парсинга MQTT_RETAINED: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envDiscovery := os.Getenv("MQTT_HA_DISCOVERY"); envDiscovery != "" {
+		if val, err := strconv.ParseBool(envDiscovery); err == nil {
+			cfg.HADiscovery = val
+		} else {
+			logWarnf("Ошибка парсинга MQTT_HA_DISCOVERY: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	return cfg
+}
+
+// Сообщение для публикации в MQTT
+type mqttMessage struct {
+	topic    string // Полное имя топика
+	payload  string
+	retained bool
+}
+
+// Публикация состояния предупреждения и метрик прогноза в MQTT
+type mqttNotifier struct {
+	config    MQTTConfig
+	locations bool // Несколько пунктов (LOCATIONS_FILE): состояние каждого публикуется в свои топики
+}
+
+func newMQTTNotifier(config MQTTConfig, locations bool) *mqttNotifier {
+	return &mqttNotifier{config: config, locations: locations}
+}
+
+func (n *mqttNotifier) Name() string {
+	return "mqtt"
+}
+
+// Точка прогноза в формате для публикации
+type mqttForecastPoint struct {
+	Time     time.Time `json:"time"`
+	WindGust float64   `json:"wind_gust"`
+}
+
+func (n *mqttNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	opts := mqtt.NewClientOptions().
+		AddBroker(n.config.Broker).
+		SetClientID(n.config.ClientID).
+		SetUsername(n.config.User).
+		SetPassword(n.config.Password).
+		SetConnectTimeout(10 * time.Second).
+		SetAutoReconnect(false)
+
+	client := mqtt.NewClient(opts)
+	if err := waitToken(ctx, client.Connect()); err != nil {
+		return fmt.Errorf("ошибка при подключении к MQTT брокеру: %w", err)
+	}
+	defer client.Disconnect(250)
+
+	// Сводный отчет публикуется по каждому пункту
+	reports := []*AlertReport{report}
+	if len(report.Locations) > 0 {
+		reports = report.Locations
+	}
+
+	var messages []mqttMessage
+	for _, r := range reports {
+		slug := ""
+		if n.locations {
+			slug = mqttSlug(r.City)
+		}
+		states, err := n.stateMessages(r, slug)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, states...)
+	}
+
+	for _, m := range messages {
+		token := client.Publish(m.topic, n.config.QoS, m.retained, m.payload)
+		if err := waitToken(ctx, token); err != nil {
+			return fmt.Errorf("ошибка при публикации в топик %s: %w", m.topic, err)
+		}
+	}
+
+	log.Printf("Состояние опубликовано в MQTT (%s/...)", n.config.TopicPrefix)
+	return nil
+}
+
+// Префикс топиков пункта: <префикс>/<пункт> или <префикс> без нескольких пунктов
+func (c MQTTConfig) topicPrefix(slug string) string {
+	if slug == "" {
+		return c.TopicPrefix
+	}
+	return c.TopicPrefix + "/" + slug
+}
+
+// Сообщения о состоянии одного пункта; slug - имя пункта в топиках, пусто - без нескольких пунктов
+func (n *mqttNotifier) stateMessages(report *AlertReport, slug string) ([]mqttMessage, error) {
+	state := "OFF"
+	if report.ExceedsThreshold {
+		state = "ON"
+	}
+
+	points := make([]mqttForecastPoint, 0, len(report.Points))
+	for _, p := range report.Points {
+		points = append(points, mqttForecastPoint{Time: p.Time, WindGust: p.WindGust})
+	}
+	forecastJSON, err := json.Marshal(points)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при формировании JSON прогноза: %w", err)
+	}
+
+	// Топики обнаружения публикуются перед состоянием, чтобы сущности Home Assistant
+	// были созданы к моменту получения значений
+	var messages []mqttMessage
+	if n.config.HADiscovery {
+		discovery, err := homeAssistantDiscoveryMessages(n.config, slug, report.City)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, discovery...)
+	}
+
+	nextCheck := ""
+	if !report.NextCheck.IsZero() {
+		nextCheck = report.NextCheck.Format(time.RFC3339)
+	}
+
+	states := []struct {
+		topic   string
+		payload string
+	}{
+		{"alert", state},
+		{"max_gust", strconv.FormatFloat(report.MaxWindGust, 'f', 2, 64)},
+		{"threshold", strconv.FormatFloat(report.WindGustThreshold, 'f', 2, 64)},
+		{"last_check", report.CheckedAt.Format(time.RFC3339)},
+		{"next_check", nextCheck},
+		{"forecast", string(forecastJSON)},
+	}
+	for _, m := range states {
+		messages = append(messages, mqttMessage{
+			topic:    n.config.topicPrefix(slug) + "/" + m.topic,
+			payload:  m.payload,
+			retained: n.config.Retained,
+		})
+	}
+	return messages, nil
+}
+
+// Транслитерация кириллицы в именах пунктов для топиков MQTT
+var slugTranslit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// Имя пункта в топиках MQTT и идентификаторах Home Assistant: латиница, цифры и "_",
+// например "Офис Москва" - ofis_moskva
+func mqttSlug(name string) string {
+	var sb strings.Builder
+	separate := false
+	for _, r := range strings.ToLower(name) {
+		part, ok := slugTranslit[r]
+		if !ok && (r >= 'a' && r <= 'z' || r >= '0' && r <= '9') {
+			part, ok = string(r), true
+		}
+		if !ok {
+			separate = sb.Len() > 0
+			continue
+		}
+		if separate && part != "" {
+			sb.WriteByte('_')
+			separate = false
+		}
+		sb.WriteString(part)
+	}
+	if sb.Len() == 0 {
+		return "location"
+	}
+	return sb.String()
+}
+
+// Ожидание завершения операции MQTT с учетом контекста
+func waitToken(ctx context.Context, token mqtt.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
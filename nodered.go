@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Версия схемы события Node-RED; увеличивается только при несовместимых изменениях
+const nodeREDSchemaVersion = 1
+
+// Настройки отправки событий в Node-RED (узел http in)
+type NodeREDConfig struct {
+	URL      string // Адрес узла http in, например http://nodered:1880/wind-alert
+	User     string // Пользователь httpNodeAuth (необязательно)
+	Password string
+}
+
+// Загрузка настроек Node-RED из переменных окружения
+func loadNodeREDConfig() NodeREDConfig {
+	return NodeREDConfig{
+		URL:      os.Getenv("NODERED_URL"),
+		User:     os.Getenv("NODERED_USER"),
+		Password: os.Getenv("NODERED_PASSWORD"),
+	}
+}
+
+// Отправка результата каждой проверки в поток Node-RED
+type nodeREDNotifier struct {
+	config NodeREDConfig
+}
+
+func newNodeREDNotifier(config NodeREDConfig) *nodeREDNotifier {
+	return &nodeREDNotifier{config: config}
+}
+
+func (n *nodeREDNotifier) Name() string {
+	return "nodered"
+}
+
+// Событие для Node-RED со стабильной схемой
+type nodeREDEvent struct {
+	SchemaVersion int               `json:"schema_version"`
+	City          string            `json:"city"`
+	Alert         bool              `json:"alert"`    // Превышен ли порог
+	Severity      string            `json:"severity"` // none, yellow, orange, red
+	MaxGust       float64           `json:"max_gust"`
+	Threshold     float64           `json:"threshold"`
+	Window        *nodeREDWindow    `json:"window"` // Интервал сильных порывов; null, если порог не превышен
+	Forecasts     []nodeREDForecast `json:"forecasts"`
+	CheckedAt     time.Time         `json:"checked_at"`
+	AckURL        string            `json:"ack_url,omitempty"`
+}
+
+type nodeREDWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type nodeREDForecast struct {
+	Time time.Time `json:"time"`
+	Gust float64   `json:"gust"`
+}
+
+// Формирование события из результата проверки
+func newNodeREDEvent(report *AlertReport) nodeREDEvent {
+	event := nodeREDEvent{
+		SchemaVersion: nodeREDSchemaVersion,
+		City:          report.City,
+		Alert:         report.ExceedsThreshold,
+		Severity:      report.Severity.String(),
+		MaxGust:       report.MaxWindGust,
+		Threshold:     report.WindGustThreshold,
+		Forecasts:     []nodeREDForecast{},
+		CheckedAt:     report.CheckedAt,
+		AckURL:        report.AckURL,
+	}
+
+	for _, f := range report.Points {
+		event.Forecasts = append(event.Forecasts, nodeREDForecast{Time: f.Time, Gust: f.WindGust})
+	}
+
+	if len(report.Forecasts) > 0 {
+		event.Window = &nodeREDWindow{
+			Start: report.Forecasts[0].Time,
+			End:   report.Forecasts[len(report.Forecasts)-1].Time.Add(forecastStep),
+		}
+	}
+
+	return event
+}
+
+// Событие отправляется и при отсутствии превышения, чтобы поток мог снять предупреждение
+func (n *nodeREDNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	var headers map[string]string
+	if n.config.User != "" {
+		headers = map[string]string{"Authorization": basicAuth(n.config.User, n.config.Password)}
+	}
+
+	if _, err := sendJSON(ctx, http.MethodPost, n.config.URL, headers, newNodeREDEvent(report)); err != nil {
+		return fmt.Errorf("ошибка при отправке события в Node-RED: %w", err)
+	}
+
+	log.Println("Событие отправлено в Node-RED")
+	return nil
+}
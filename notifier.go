@@ -0,0 +1,720 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Результат проверки прогноза, передаваемый во все каналы уведомлений
+type AlertReport struct {
+	City              string
+	CheckedAt         time.Time
+	NextCheck         time.Time // Время следующей плановой проверки
+	ExceedsThreshold  bool
+	Severity          Severity           // Уровень опасности по максимальному порыву ветра
+	MaxWindGust       float64            // Максимальный порыв ветра за проверяемый период
+	WindGustThreshold float64            // Пороговое значение порывов ветра в м/с
+	Forecasts         []WindGustForecast // Точки прогноза, превышающие порог
+	Points            []WindGustForecast // Все точки прогноза за проверяемый период
+	LookaheadDays     int                // Число дней после текущего, включенных в проверку
+	Message           string             // Текст из шаблона канала (TEMPLATE_DIR)
+	MessageHTML       string             // HTML-версия из шаблона канала
+	Subject           string             // Тема из шаблона канала
+	AckURL            string             // Ссылка для подтверждения получения предупреждения
+	Chart             []byte             // PNG-график прогноза для письма (EMAIL_CHART)
+	Recipients        []string           // Получатели письма по активной конфигурации или группы с отдельным временем доставки
+	Reminder          bool               // Напоминание по обновленному прогнозу перед началом сильного ветра
+	Locations         []*AlertReport     // Отчеты по пунктам в сводном предупреждении (LOCATIONS_REPORT=combined)
+	Units             string             // Единицы скорости ветра в сообщениях (UNITS)
+	Language          string             // Язык сообщений (LANGUAGE)
+	Rule              string             // Сработавшее правило предупреждения (RULES_FILE)
+	RuleMetric        string             // Показатель сработавшего правила
+	RuleValue         float64            // Самое неблагоприятное значение показателя правила
+	Template          string             // Набор шаблонов сработавшего правила
+	HistoryID         int64              // Идентификатор проверки в базе истории (HISTORY_DB)
+	Reason            string             // Объяснение решения о предупреждении для журнала аудита (AUDIT_FILE)
+}
+
+// Скорость ветра для текста сообщения в единицах UNITS
+func (r *AlertReport) speed(ms float64, precision int) string {
+	return formatSpeedIn(ms, r.Units, r.Language, precision)
+}
+
+// Текст сообщения на языке LANGUAGE
+func (r *AlertReport) tr(ru, en string) string {
+	if r.Language == languageEN {
+		return en
+	}
+	return ru
+}
+
+// Название уровня опасности на языке LANGUAGE
+func (r *AlertReport) severityTitle() string {
+	return r.Severity.TitleIn(r.Language)
+}
+
+// Текст сообщения: из шаблона канала, если он задан, иначе стандартный
+func (r *AlertReport) text(fallback string) string {
+	if r.Message != "" {
+		return r.Message
+	}
+	return fallback
+}
+
+// Период проверки для текста сообщения на языке LANGUAGE: "сегодня", "сегодня и завтра" или "в ближайшие N дней"
+func (r *AlertReport) periodTitle() string {
+	return r.periodTitleIn(r.Language)
+}
+
+// Время точки прогноза: при проверке нескольких дней добавляется дата
+func (r *AlertReport) formatTime(t time.Time) string {
+	return r.formatTimeIn(t, r.Language)
+}
+
+// Время точки прогноза в формате даты языка сообщения
+func (r *AlertReport) formatTimeIn(t time.Time, language string) string {
+	if r.LookaheadDays == 0 {
+		return t.Format("15:04")
+	}
+	if language == languageEN {
+		return t.Format("Jan 2 15:04")
+	}
+	return t.Format("02.01 15:04")
+}
+
+// Строка с заглавной буквы
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// Канал доставки уведомлений
+type Notifier interface {
+	// Имя канала для журналирования
+	Name() string
+	// Обработка результата проверки. Канал сам решает, реагировать ли
+	// на проверки без превышения порога (например, публикация состояния в MQTT).
+	Notify(ctx context.Context, report *AlertReport) error
+}
+
+// Канал с адресными получателями (почта, SMS, звонки): доставка записывается
+// в базу истории отдельно по каждому получателю
+type recipientNotifier interface {
+	// Получатели, которым канал отправит уведомление по результату проверки
+	deliveryRecipients(report *AlertReport) []string
+}
+
+// Получатели уведомления в канале; nil - канал без адресных получателей
+func deliveryRecipients(notifier Notifier, report *AlertReport) []string {
+	if n, ok := notifier.(recipientNotifier); ok {
+		return n.deliveryRecipients(report)
+	}
+	return nil
+}
+
+// Ошибка доставки части получателей канала с причиной по каждому из них
+type recipientErrors struct {
+	message string
+	failed  map[string]error
+}
+
+func (e *recipientErrors) Error() string {
+	return e.message
+}
+
+// Формирование списка активных каналов уведомлений по конфигурации
+func buildNotifiers(store *ConfigStore, history *AlertHistory) []Notifier {
+	config := store.Load()
+	// История записывается первой, чтобы лента включала текущее предупреждение
+	notifiers := []Notifier{history, &emailNotifier{configs: store, alerted: newIncidentTracker()}}
+
+	if config.Feed.File != "" {
+		notifiers = append(notifiers, &feedFileNotifier{config: config.Feed, history: history})
+	}
+	if config.DailyCSV.File != "" {
+		notifiers = append(notifiers, newDailyCSVNotifier(config.DailyCSV))
+	}
+
+	if config.MQTT.Broker != "" {
+		notifiers = append(notifiers, newMQTTNotifier(config.MQTT, len(config.Locations.List) > 0))
+	}
+	if config.Matrix.HomeserverURL != "" {
+		notifiers = append(notifiers, newMatrixNotifier(config.Matrix))
+	}
+	if config.WhatsApp.AccessToken != "" {
+		notifiers = append(notifiers, newWhatsAppNotifier(config.WhatsApp))
+	}
+	if config.PagerDuty.RoutingKey != "" {
+		notifiers = append(notifiers, newPagerDutyNotifier(config.PagerDuty))
+	}
+	if config.Opsgenie.APIKey != "" {
+		notifiers = append(notifiers, newOpsgenieNotifier(config.Opsgenie))
+	}
+	if config.GoogleChat.WebhookURL != "" {
+		notifiers = append(notifiers, newGoogleChatNotifier(config.GoogleChat))
+	}
+	if config.Signal.APIURL != "" {
+		notifiers = append(notifiers, newSignalNotifier(config.Signal))
+	}
+	if config.VK.AccessToken != "" {
+		notifiers = append(notifiers, newVKNotifier(config.VK))
+	}
+	if config.FCM.ServiceAccountFile != "" {
+		if fcm, err := newFCMNotifier(config.FCM); err == nil {
+			notifiers = append(notifiers, fcm)
+		} else {
+			log.Printf("Канал fcm отключен: %v", err)
+		}
+	}
+	if config.Maker.WebhookURL != "" || config.Maker.IFTTTKey != "" {
+		notifiers = append(notifiers, newMakerNotifier(config.Maker))
+	}
+	if config.XMPP.User != "" {
+		notifiers = append(notifiers, newXMPPNotifier(config.XMPP))
+	}
+	if config.RocketChat.WebhookURL != "" {
+		notifiers = append(notifiers, newRocketChatNotifier(config.RocketChat))
+	}
+	if config.Zulip.Site != "" {
+		notifiers = append(notifiers, newZulipNotifier(config.Zulip))
+	}
+	if config.SNS.TopicARN != "" {
+		if snsNotifier, err := newSNSNotifier(config.SNS); err == nil {
+			notifiers = append(notifiers, snsNotifier)
+		} else {
+			log.Printf("Канал sns отключен: %v", err)
+		}
+	}
+	if config.Twilio.AccountSID != "" && len(config.Twilio.SMSTo) > 0 {
+		notifiers = append(notifiers, newTwilioSMSNotifier(config.Twilio))
+	}
+	if config.Twilio.AccountSID != "" && len(config.Twilio.CallTo) > 0 {
+		notifiers = append(notifiers, newTwilioVoiceNotifier(config.Twilio))
+	}
+	if config.LINE.ChannelAccessToken != "" {
+		notifiers = append(notifiers, newLINENotifier(config.LINE))
+	}
+	if config.Viber.AuthToken != "" && len(config.Viber.Receivers) > 0 {
+		notifiers = append(notifiers, newViberNotifier(config.Viber))
+	}
+	if config.NodeRED.URL != "" {
+		notifiers = append(notifiers, newNodeREDNotifier(config.NodeRED))
+	}
+	if config.Syslog.Addr != "" {
+		if syslog, err := newSyslogNotifier(config.Syslog); err == nil {
+			notifiers = append(notifiers, syslog)
+		} else {
+			log.Printf("Канал syslog отключен: %v", err)
+		}
+	}
+	if config.Pushbullet.AccessToken != "" {
+		notifiers = append(notifiers, newPushbulletNotifier(config.Pushbullet))
+	}
+	if config.InfluxDB.URL != "" {
+		notifiers = append(notifiers, newInfluxDBNotifier(config.InfluxDB))
+	}
+
+	return notifiers
+}
+
+// Рассылка результата проверки по каналам с учетом правил маршрутизации
+type Dispatcher struct {
+	configs    *ConfigStore // Текущая конфигурация: маршрутизация, правила, периоды тишины и подавление повторов меняются без перезапуска
+	notifiers  []Notifier
+	clock      *CityClock
+	templates  *MessageTemplates
+	retries    *RetryQueue // Очередь повторной и отложенной доставки
+	escalation *Escalator
+	pause      *PauseControl
+	reminders  *Reminders
+	historyDB  *HistoryDB // База истории проверок и доставки; nil - не используется
+	runState   *RunState  // Отметки об отправленных за день предупреждениях
+	store      StateStore // Хранилище состояния; с общим состоянием рассылает один экземпляр
+	metrics    *Metrics   // Счетчики и время доставки по каналам
+	audit      *AuditLog  // Журнал решений о предупреждениях (AUDIT_FILE)
+	ops        *OpsAlerts // Служебные оповещения о сбоях доставки подряд
+}
+
+func newDispatcher(configs *ConfigStore, notifiers []Notifier, templates *MessageTemplates, retries *RetryQueue, escalation *Escalator, pause *PauseControl, reminders *Reminders, historyDB *HistoryDB, runState *RunState, store StateStore) *Dispatcher {
+	config := configs.Load()
+	config.Routing.warnUnknownChannels(notifiers)
+	config.Rules.warnUnknownChannels(notifiers)
+	d := &Dispatcher{
+		configs:    configs,
+		notifiers:  notifiers,
+		clock:      config.Clock,
+		templates:  templates,
+		retries:    retries,
+		escalation: escalation,
+		pause:      pause,
+		reminders:  reminders,
+		historyDB:  historyDB,
+		runState:   runState,
+		store:      store,
+		metrics:    config.Metrics,
+		audit:      config.Audit,
+		ops:        config.Ops,
+	}
+	escalation.escalate = d.deliverTo
+	escalation.acknowledged = historyDB.RecordAck
+	reminders.dispatch = d.Dispatch
+	return d
+}
+
+// Рассылка результата проверки по каналам
+func (d *Dispatcher) Dispatch(report *AlertReport) {
+	// С общим состоянием (STORE_BACKEND=redis) результат записывает и рассылает один экземпляр
+	if standby(d.store, "рассылка") {
+		return
+	}
+
+	config := d.configs.Load()
+
+	// Каждый результат проверки сохраняется в базу истории, в пробном запуске история не изменяется
+	if !config.DryRun {
+		report.HistoryID = d.historyDB.RecordCheck(report)
+	}
+
+	// Во время приостановки результат проверки только записывается в историю
+	if d.pause.Paused() {
+		log.Println("Рассылка приостановлена, уведомления не отправляются")
+		d.audit.recordReport(report, "рассылка приостановлена")
+		for _, notifier := range d.notifiers {
+			if history, ok := notifier.(*AlertHistory); ok {
+				d.deliver(history, report)
+			}
+		}
+		return
+	}
+
+	if config.DryRun && report.ExceedsThreshold {
+		log.Printf("[dry-run] Текст предупреждения:\n%s", formatAlertText(report))
+	}
+
+	// Предупреждение, уже отправленное сегодня или в пределах ALERT_COOLDOWN, не повторяется
+	// после перезапуска или при следующем опросе; в историю результат записывается как обычно
+	if sentAt, dup := d.duplicate(report, alertStateKey(report)); dup {
+		log.Printf("%s: предупреждение (правило %s, уровень %s) уже отправлено в %s, повторно не рассылается",
+			report.City, report.Rule, report.Severity.Title(), sentAt.Format("02.01 15:04"))
+		d.audit.recordReport(report, "предупреждение того же уровня уже отправлено в "+sentAt.Format("02.01 15:04"))
+		for _, notifier := range d.notifiers {
+			if history, ok := notifier.(*AlertHistory); ok {
+				d.deliver(history, report)
+			}
+		}
+		return
+	}
+
+	d.audit.recordReport(report, "")
+
+	// Напоминание не отслеживается повторно: подтверждается исходное предупреждение
+	if d.escalation.tracking() && !report.Reminder && !config.DryRun {
+		report.AckURL = d.escalation.Track(report)
+	}
+
+	for _, notifier := range d.notifiers {
+		// Каналы эскалации получают предупреждение, только если его не подтвердили вовремя
+		if report.ExceedsThreshold && d.escalation.holds(notifier.Name()) {
+			continue
+		}
+		d.deliver(notifier, report)
+	}
+
+	if !report.Reminder {
+		d.reminders.Schedule(report)
+	}
+	d.markSent(report, alertStateKey(report))
+}
+
+// Отправлялось ли такое же предупреждение сегодня или в пределах ALERT_COOLDOWN;
+// напоминания и результаты без превышения порога повторами не считаются
+func (d *Dispatcher) duplicate(report *AlertReport, key string) (time.Time, bool) {
+	config := d.configs.Load()
+	if !suppresses(config, report) {
+		return time.Time{}, false
+	}
+	return d.runState.alertSent(key, d.clock.Now(), report.Severity, config.AlertDedup, config.AlertCooldown)
+}
+
+// Отметка об отправленном предупреждении
+func (d *Dispatcher) markSent(report *AlertReport, key string) {
+	config := d.configs.Load()
+	if !suppresses(config, report) {
+		return
+	}
+	d.runState.RecordAlert(key, d.clock.Now(), report.Severity, config.AlertCooldown)
+}
+
+// Подлежит ли результат проверки подавлению повторов
+func suppresses(config *Config, report *AlertReport) bool {
+	return (config.AlertDedup || config.AlertCooldown > 0) && !config.DryRun && report.ExceedsThreshold && !report.Reminder
+}
+
+// Доставка результата проверки в канал по имени
+func (d *Dispatcher) deliverTo(channel string, report *AlertReport) {
+	for _, notifier := range d.notifiers {
+		if notifier.Name() == channel {
+			d.deliver(notifier, report)
+			return
+		}
+	}
+	log.Printf("Канал %s не настроен", channel)
+}
+
+// Доставка результата проверки в канал с учетом маршрутизации, шаблонов и периодов тишины
+func (d *Dispatcher) deliver(notifier Notifier, report *AlertReport) {
+	config := d.configs.Load()
+	if !config.Routing.allows(notifier.Name(), report.Severity) || !config.Rules.allows(notifier.Name(), report) {
+		return
+	}
+	if config.DryRun {
+		log.Printf("[dry-run] Канал %s: уведомление не отправлено", notifier.Name())
+		return
+	}
+
+	channelReport, err := d.templates.apply(notifier.Name(), report)
+	if err != nil {
+		logErrorf("Ошибка шаблона канала %s: %v, используется стандартный текст", notifier.Name(), err)
+	}
+
+	if until, quiet := config.QuietHours.deferUntil(notifier.Name(), d.clock.Now()); quiet {
+		d.historyDB.RecordDelivery(report, notifier.Name(), deliveryRecipients(notifier, channelReport), deliveryDeferred, 0, nil)
+		d.retries.Defer(notifier.Name(), channelReport, until)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	started := time.Now()
+	err = notifier.Notify(ctx, channelReport)
+	d.metrics.recordDelivery(notifier.Name(), time.Since(started), err)
+	d.ops.delivered(notifier.Name(), err)
+	cancel()
+
+	if err != nil {
+		logErrorf("Ошибка при отправке уведомления через %s: %v\n", notifier.Name(), err)
+		d.historyDB.RecordDelivery(report, notifier.Name(), deliveryRecipients(notifier, channelReport), deliveryFailed, 1, err)
+		d.retries.Enqueue(notifier.Name(), channelReport, err)
+	} else {
+		d.historyDB.RecordDelivery(report, notifier.Name(), deliveryRecipients(notifier, channelReport), deliverySent, 1, nil)
+		d.retries.Resolve(notifier.Name(), channelReport.City)
+	}
+}
+
+// Уведомление по электронной почте через Microsoft Exchange
+type emailNotifier struct {
+	configs *ConfigStore     // Текущая конфигурация: получатели и их настройки меняются без перезапуска
+	alerted *incidentTracker // Города, по которым отправлено предупреждение, - для письма об отбое
+}
+
+func (n *emailNotifier) Name() string {
+	return "email"
+}
+
+// Получатели письма о превышении порога: письма об отбое в базе истории записываются на канал
+func (n *emailNotifier) deliveryRecipients(report *AlertReport) []string {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+	if len(report.Recipients) > 0 {
+		return report.Recipients
+	}
+	return n.configs.Load().defaultRecipients()
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	config := n.configs.Load()
+	recipients := report.Recipients
+	if len(recipients) == 0 {
+		recipients = config.defaultRecipients()
+	}
+
+	// Адреса, которым письмо не доставлено: повторная доставка отправляет его только им
+	failed := map[string]error{}
+	// У пунктов свои получатели, поэтому сводный отчет рассылается и без общих получателей
+	if len(report.Locations) > 0 {
+		n.notifyCombined(report, recipients, failed)
+		return emailDeliveryError(failed, max(len(recipients), len(failed)))
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+	if !report.ExceedsThreshold {
+		n.sendAllClear(report, recipients, failed)
+		if len(failed) > 0 {
+			return emailDeliveryError(failed, len(recipients))
+		}
+		// Письмо отправляется только при превышении порога - общего или личного порога получателя
+		if !config.lowerPersonalThreshold(report.WindGustThreshold) {
+			return nil
+		}
+	}
+
+	place := ""
+	if len(config.Locations.List) > 0 {
+		place = report.City
+	}
+	n.send(report, place, recipients, failed)
+	if len(failed) > 0 {
+		return emailDeliveryError(failed, len(recipients))
+	}
+	if report.ExceedsThreshold && !report.Reminder {
+		n.alerted.set(report.City, true)
+	}
+	return nil
+}
+
+// Сводное предупреждение: каждый получатель получает одно письмо по своим пунктам,
+// а письмо об отбое отправляется по каждому пункту, где ветер стих, его получателям
+func (n *emailNotifier) notifyCombined(report *AlertReport, recipients []string, failed map[string]error) {
+	for _, location := range report.Locations {
+		if !location.ExceedsThreshold && len(location.Recipients) > 0 {
+			n.sendAllClear(location, location.Recipients, failed)
+		}
+	}
+	if !report.ExceedsThreshold {
+		return
+	}
+
+	for _, part := range splitByRecipient(report, recipients) {
+		n.send(part, part.City, part.Recipients, failed)
+	}
+	if report.Reminder {
+		return
+	}
+	// Предупреждение по пункту считается отправленным, если письмо дошло до всех его получателей
+	for _, location := range report.alertedLocations() {
+		delivered := true
+		for _, recipient := range location.Recipients {
+			if _, ok := failed[recipient]; ok {
+				delivered = false
+				break
+			}
+		}
+		if delivered {
+			n.alerted.set(location.City, true)
+		}
+	}
+}
+
+// Ошибка доставки части писем; nil - все письма доставлены
+func emailDeliveryError(failed map[string]error, total int) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	addresses := make([]string, 0, len(failed))
+	for address := range failed {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return &recipientErrors{
+		message: fmt.Sprintf("не доставлено писем: %d из %d получателей: %v", len(failed), total, failed[addresses[0]]),
+		failed:  failed,
+	}
+}
+
+// Письмо об отбое (FEATURES=all_clear_emails): ветер стих после отправленного с момента запуска
+// предупреждения. Для сводного письма вызывается по каждому пункту отдельно. Получатели, для которых по личному порогу предупреждение продолжается, его не получают.
+func (n *emailNotifier) sendAllClear(report *AlertReport, recipients []string, failed map[string]error) {
+	config := n.configs.Load()
+	if !config.Features.Enabled(featureAllClearEmails) || report.Reminder || len(report.Locations) > 0 || !n.alerted.isOpen(report.City) {
+		return
+	}
+
+	sent := true
+	for _, group := range config.recipientGroups(recipients) {
+		if group.profile.personalize(report) != nil {
+			continue
+		}
+
+		r := *report
+		r.Language, r.Units = group.profile.Language, group.profile.Units
+		subject := r.tr("ОТБОЙ: Сильного ветра ", "ALL CLEAR: No strong wind ") + r.periodTitle() + r.tr(" не ожидается", "")
+		if len(config.Locations.List) > 0 {
+			subject += ": " + r.City
+		}
+		text := fmt.Sprintf(r.tr("Отбой предупреждения. %s: %s порывы ветра по прогнозу не превысят безопасный порог %s (максимум %s).",
+			"All clear. %s: wind gusts %s are no longer expected to exceed the safe threshold of %s (maximum %s)."),
+			r.City, r.periodTitle(), r.speed(r.WindGustThreshold, 2), r.speed(r.MaxWindGust, 2))
+		htmlBody := "<!DOCTYPE html>\n<html>\n<body>\n<p>" + html.EscapeString(text) + "</p>\n</body>\n</html>\n"
+
+		if err := sendEmailTo(config, group.emails, subject, htmlBody, text); err != nil {
+			failGroup(failed, group.emails, err)
+			sent = false
+			continue
+		}
+		log.Printf("Письмо об отбое отправлено (%d получателей)", len(group.emails))
+	}
+	if sent {
+		n.alerted.set(report.City, false)
+	}
+}
+
+// Отметка адресов группы, которой не удалось отправить письмо
+func failGroup(failed map[string]error, emails []string, err error) {
+	for _, email := range emails {
+		failed[email] = err
+	}
+}
+
+// Формирование писем по шаблонам и отправка получателям: получатели с одинаковыми
+// языком, единицами и личным порогом получают одно письмо. Адреса групп, которым
+// письмо не удалось отправить, записываются в failed.
+func (n *emailNotifier) send(report *AlertReport, place string, recipients []string, failed map[string]error) {
+	config := n.configs.Load()
+	for _, group := range config.recipientGroups(recipients) {
+		personal := group.profile.personalize(report)
+		if personal == nil {
+			continue
+		}
+		if config.EmailChart {
+			withChart := *personal
+			withChart.Units, withChart.Language = group.profile.Units, group.profile.Language
+			withChart.Chart = emailChart(&withChart)
+			personal = &withChart
+		}
+
+		subject := alertSubject(personal, group.profile.Language)
+		if place != "" {
+			subject += ": " + place
+		}
+		htmlBody, plainTextBody, err := generateEmailBodies(personal, group.profile)
+		if err != nil {
+			failGroup(failed, group.emails, err)
+			continue
+		}
+		// Пользовательские шаблоны (TEMPLATE_DIR) написаны на языке LANGUAGE
+		if group.profile.Language == config.Language {
+			if personal.Subject != "" {
+				subject = personal.Subject
+			}
+			if personal.MessageHTML != "" {
+				htmlBody = personal.MessageHTML
+			}
+			plainTextBody = personal.text(plainTextBody)
+		}
+
+		// График встраивается, только если на него ссылается HTML письма: шаблон канала может его не содержать
+		var embeds []emailEmbed
+		if len(personal.Chart) > 0 && strings.Contains(htmlBody, "cid:"+chartContentID) {
+			embeds = append(embeds, emailEmbed{ContentID: chartContentID, Data: personal.Chart})
+		}
+		if err := sendEmailTo(config, group.emails, subject, htmlBody, plainTextBody, embeds...); err != nil {
+			failGroup(failed, group.emails, err)
+			continue
+		}
+		log.Printf("Предупреждение успешно отправлено (%d получателей)", len(group.emails))
+	}
+}
+
+// Краткий текст предупреждения для мессенджеров
+func formatAlertText(report *AlertReport) string {
+	var sb strings.Builder
+	if report.Reminder {
+		sb.WriteString(report.tr("Напоминание по обновленному прогнозу. ", "Reminder based on the updated forecast. "))
+	}
+	if len(report.Locations) > 0 {
+		fmt.Fprintf(&sb, report.tr("Внимание! %s ожидаются сильные порывы ветра:", "Warning! %s strong wind gusts are expected:"), capitalize(report.periodTitle()))
+		for _, location := range report.alertedLocations() {
+			fmt.Fprintf(&sb, report.tr("\n- %s: %s (порог %s)", "\n- %s: %s (threshold %s)"), location.City, report.speed(location.MaxWindGust, 2), report.speed(location.WindGustThreshold, 2))
+		}
+		sb.WriteString(report.tr("\nРекомендуется не открывать окна в офисе в течение дня.", "\nPlease keep the office windows closed during the day."))
+		if report.AckURL != "" {
+			sb.WriteString(report.tr("\nПодтвердите получение: ", "\nAcknowledge: ") + report.AckURL)
+		}
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, report.tr("Внимание! %s: %s ожидаются сильные порывы ветра (%s), что превышает безопасный порог (%s).",
+		"Warning! %s: strong wind gusts of %[3]s are expected %[2]s, exceeding the safe threshold of %[4]s."),
+		report.City, report.periodTitle(), report.speed(report.MaxWindGust, 2), report.speed(report.WindGustThreshold, 2))
+	if len(report.Forecasts) > 0 {
+		sb.WriteString(report.tr("\nВремя сильных порывов:", "\nTimes of strong gusts:"))
+		for _, f := range report.Forecasts {
+			fmt.Fprintf(&sb, "\n- %s: %s", report.formatTime(f.Time), report.speed(f.WindGust, 2))
+		}
+	}
+	sb.WriteString(report.tr("\nРекомендуется не открывать окна в офисе в течение дня.", "\nPlease keep the office windows closed during the day."))
+	if report.AckURL != "" {
+		sb.WriteString(report.tr("\nПодтвердите получение: ", "\nAcknowledge: ") + report.AckURL)
+	}
+	return sb.String()
+}
+
+// Разбор списка значений, разделенных запятыми или точкой с запятой
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Отправка JSON-запроса во внешний сервис с проверкой кода ответа
+func sendJSON(ctx context.Context, method, url string, headers map[string]string, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при формировании JSON: %w", err)
+	}
+
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headers["Content-Type"] = "application/json"
+	return sendRequest(ctx, method, url, headers, bytes.NewReader(body))
+}
+
+// Отправка формы (application/x-www-form-urlencoded) во внешний сервис с проверкой кода ответа
+func sendForm(ctx context.Context, endpoint string, headers map[string]string, form url.Values) ([]byte, error) {
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headers["Content-Type"] = "application/x-www-form-urlencoded"
+	return sendRequest(ctx, http.MethodPost, endpoint, headers, strings.NewReader(form.Encode()))
+}
+
+// Значение заголовка Authorization для базовой аутентификации
+func basicAuth(user, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+}
+
+// Выполнение HTTP-запроса с проверкой кода ответа
+func sendRequest(ctx context.Context, method, url string, headers map[string]string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании запроса: %w", err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при выполнении запроса: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении ответа: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return respBody, fmt.Errorf("сервис вернул статус %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	return respBody, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Настройки служебных оповещений о сбоях: получение прогноза или отправка через канал
+// не удается несколько раз подряд. Адресаты служебных оповещений не связаны с получателями
+// предупреждений о ветре.
+type OpsAlertConfig struct {
+	After      int      // Число ошибок подряд, после которого отправляется оповещение (OPS_ALERT_AFTER)
+	EmailTo    []string // Адреса администраторов (OPS_ALERT_EMAIL_TO)
+	WebhookURL string   // Входящий вебхук чата администраторов, поле text (OPS_ALERT_WEBHOOK_URL)
+}
+
+// Загрузка настроек служебных оповещений из переменных окружения
+func loadOpsAlertConfig() OpsAlertConfig {
+	cfg := OpsAlertConfig{
+		After:      3,
+		EmailTo:    parseEmailList(os.Getenv("OPS_ALERT_EMAIL_TO")),
+		WebhookURL: os.Getenv("OPS_ALERT_WEBHOOK_URL"),
+	}
+
+	if envAfter := os.Getenv("OPS_ALERT_AFTER"); envAfter != "" {
+		if val, err := strconv.Atoi(envAfter); err == nil && val >= 1 {
+			cfg.After = val
+		} else {
+			logWarnf("Ошибка парсинга OPS_ALERT_AFTER: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	return cfg
+}
+
+// Включены ли служебные оповещения
+func (c OpsAlertConfig) enabled() bool {
+	return len(c.EmailTo) > 0 || c.WebhookURL != ""
+}
+
+// Служебные оповещения: считает ошибки подряд по источнику (прогноз или канал доставки),
+// при достижении OPS_ALERT_AFTER сообщает администраторам о сбое, а после первой удачной
+// попытки - о восстановлении. Счетчики хранятся в памяти и сбрасываются при перезапуске.
+type OpsAlerts struct {
+	config  OpsAlertConfig
+	configs *ConfigStore // Текущая конфигурация для отправки писем
+	state   StateStore   // С общим состоянием оповещает только рассылающий экземпляр
+
+	mu       sync.Mutex
+	failures map[string]int  // Ошибки подряд по источнику
+	alerted  map[string]bool // Отправлено оповещение о сбое, восстановление еще не сообщено
+}
+
+func newOpsAlerts(config OpsAlertConfig) *OpsAlerts {
+	if !config.enabled() {
+		return nil
+	}
+	return &OpsAlerts{
+		config:   config,
+		failures: make(map[string]int),
+		alerted:  make(map[string]bool),
+	}
+}
+
+// Подключение к конфигурации и хранилищу состояния после их создания при запуске
+func (o *OpsAlerts) attach(configs *ConfigStore, state StateStore) {
+	if o == nil {
+		return
+	}
+	o.configs, o.state = configs, state
+}
+
+// Учет результата получения прогноза OpenWeatherMap для пункта
+func (o *OpsAlerts) forecastFetched(place string, err error) {
+	o.record("forecast:"+place, "получение прогноза OpenWeatherMap для "+place, err)
+}
+
+// Учет результата отправки уведомления через канал
+func (o *OpsAlerts) delivered(channel string, err error) {
+	o.record("channel:"+channel, "отправка уведомлений через "+channel, err)
+}
+
+// Учет попытки: source - источник ошибок, title - действие для текста оповещения
+func (o *OpsAlerts) record(source, title string, err error) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err == nil {
+		failures := o.failures[source]
+		delete(o.failures, source)
+		if o.alerted[source] {
+			delete(o.alerted, source)
+			go o.send("WindAlerts: восстановлено - "+title,
+				fmt.Sprintf("%s снова выполняется успешно (перед этим ошибок подряд: %d).", capitalize(title), failures))
+		}
+		return
+	}
+
+	o.failures[source]++
+	if o.failures[source] != o.config.After {
+		return
+	}
+	o.alerted[source] = true
+	go o.send(fmt.Sprintf("WindAlerts: сбой - %s (ошибок подряд: %d)", title, o.config.After),
+		fmt.Sprintf("%s не удается (ошибок подряд: %d), предупреждения о ветре могут не доходить до получателей.\nПоследняя ошибка: %v", capitalize(title), o.config.After, err))
+}
+
+// Отправка оповещения администраторам по почте и в вебхук
+func (o *OpsAlerts) send(subject, text string) {
+	if o.configs == nil {
+		return
+	}
+	config := o.configs.Load()
+	if o.state != nil && !o.state.leading() {
+		return
+	}
+	hostname, _ := os.Hostname()
+	text = fmt.Sprintf("%s\n\nХост: %s\nВремя: %s", text, hostname, config.Clock.Now().Format("02.01.2006 15:04 MST"))
+	if config.DryRun {
+		log.Printf("[dry-run] Служебное оповещение не отправлено: %s", subject)
+		return
+	}
+
+	sent := false
+	if len(o.config.EmailTo) > 0 {
+		htmlBody := "<p>" + strings.ReplaceAll(html.EscapeString(text), "\n", "<br>") + "</p>"
+		if err := sendServiceEmailTo(config, o.config.EmailTo, subject, htmlBody, text+"\n"); err != nil {
+			logErrorf("Ошибка при отправке служебного оповещения по почте: %v", err)
+		} else {
+			sent = true
+		}
+	}
+	if o.config.WebhookURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if _, err := sendJSON(ctx, http.MethodPost, o.config.WebhookURL, nil, map[string]string{"text": subject + "\n" + text}); err != nil {
+			logErrorf("Ошибка при отправке служебного оповещения в вебхук: %v", err)
+		} else {
+			sent = true
+		}
+	}
+	if sent {
+		log.Printf("Служебное оповещение отправлено: %s", subject)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Настройки интеграции с Opsgenie
+type OpsgenieConfig struct {
+	APIKey      string   // Ключ API-интеграции Opsgenie
+	APIURL      string   // Адрес API (https://api.opsgenie.com или https://api.eu.opsgenie.com)
+	Team        string   // Команда, на которую маршрутизируется предупреждение
+	Tags        []string // Дополнительные теги
+	MinSeverity Severity // Минимальный уровень опасности для создания предупреждения
+}
+
+// Загрузка настроек Opsgenie из переменных окружения
+func loadOpsgenieConfig() OpsgenieConfig {
+	cfg := OpsgenieConfig{
+		APIKey:      os.Getenv("OPSGENIE_API_KEY"),
+		APIURL:      strings.TrimSuffix(os.Getenv("OPSGENIE_API_URL"), "/"),
+		Team:        os.Getenv("OPSGENIE_TEAM"),
+		Tags:        parseList(os.Getenv("OPSGENIE_TAGS")),
+		MinSeverity: SeverityRed,
+	}
+
+	if cfg.APIURL == "" {
+		cfg.APIURL = "https://api.opsgenie.com"
+	}
+
+	if envSeverity := os.Getenv("OPSGENIE_MIN_SEVERITY"); envSeverity != "" {
+		if val, err := parseSeverity(envSeverity); err == nil && val != SeverityNone {
+			cfg.MinSeverity = val
+		} else {
+			logWarnf("Ошибка парсинга OPSGENIE_MIN_SEVERITY: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	return cfg
+}
+
+// Соответствие уровней опасности приоритетам Opsgenie
+func opsgeniePriority(s Severity) string {
+	switch s {
+	case SeverityRed:
+		return "P1"
+	case SeverityOrange:
+		return "P2"
+	case SeverityYellow:
+		return "P3"
+	default:
+		return "P5"
+	}
+}
+
+// Создание и закрытие предупреждений Opsgenie
+type opsgenieNotifier struct {
+	config    OpsgenieConfig
+	incidents *incidentTracker
+}
+
+func newOpsgenieNotifier(config OpsgenieConfig) *opsgenieNotifier {
+	return &opsgenieNotifier{config: config, incidents: newIncidentTracker()}
+}
+
+func (n *opsgenieNotifier) Name() string {
+	return "opsgenie"
+}
+
+// Запрос на создание предупреждения Opsgenie
+type opsgenieAlert struct {
+	Message     string              `json:"message"`
+	Alias       string              `json:"alias"`
+	Description string              `json:"description"`
+	Responders  []opsgenieResponder `json:"responders,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Details     map[string]string   `json:"details,omitempty"`
+	Entity      string              `json:"entity,omitempty"`
+	Source      string              `json:"source"`
+	Priority    string              `json:"priority"`
+}
+
+type opsgenieResponder struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Запрос на закрытие предупреждения Opsgenie
+type opsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note"`
+}
+
+func (n *opsgenieNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	alias := alertDedupKey(report.City)
+	headers := map[string]string{"Authorization": "GenieKey " + n.config.APIKey}
+
+	if report.Severity >= n.config.MinSeverity {
+		alert := opsgenieAlert{
+			Message: fmt.Sprintf(report.tr("%s: порывы ветра до %s (уровень опасности: %s)", "%s: wind gusts up to %s (severity: %s)"),
+				report.City, report.speed(report.MaxWindGust, 1), report.severityTitle()),
+			Alias:       alias,
+			Description: formatAlertText(report),
+			Tags: append([]string{
+				"windalerts",
+				"city:" + report.City,
+				fmt.Sprintf("max_gust:%.1f", report.MaxWindGust),
+				"severity:" + report.Severity.String(),
+			}, n.config.Tags...),
+			Details: map[string]string{
+				"city":          report.City,
+				"max_wind_gust": fmt.Sprintf("%.2f", report.MaxWindGust),
+				"threshold":     fmt.Sprintf("%.2f", report.WindGustThreshold),
+				"severity":      report.Severity.String(),
+			},
+			Entity:   report.City,
+			Source:   "windalerts",
+			Priority: opsgeniePriority(report.Severity),
+		}
+		if n.config.Team != "" {
+			alert.Responders = []opsgenieResponder{{Name: n.config.Team, Type: "team"}}
+		}
+
+		if _, err := sendJSON(ctx, http.MethodPost, n.config.APIURL+"/v2/alerts", headers, alert); err != nil {
+			return fmt.Errorf("ошибка при создании предупреждения Opsgenie: %w", err)
+		}
+
+		n.incidents.set(report.City, true)
+		log.Printf("Предупреждение создано в Opsgenie (%s)", alias)
+		return nil
+	}
+
+	// Закрытие отправляется только если предупреждение могло быть открыто
+	if !n.incidents.mayBeOpen(report.City) {
+		return nil
+	}
+
+	endpoint := fmt.Sprintf("%s/v2/alerts/%s/close?identifierType=alias", n.config.APIURL, url.PathEscape(alias))
+	body := opsgenieClose{Source: "windalerts", Note: "Порывы ветра опустились ниже порога"}
+	if _, err := sendJSON(ctx, http.MethodPost, endpoint, headers, body); err != nil {
+		return fmt.Errorf("ошибка при закрытии предупреждения Opsgenie: %w", err)
+	}
+
+	n.incidents.set(report.City, false)
+	log.Printf("Предупреждение закрыто в Opsgenie (%s)", alias)
+	return nil
+}
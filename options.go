@@ -0,0 +1,285 @@
+package main
+
+// Типы значений параметров конфигурации
+const (
+	optString   = "string"   // Произвольная строка
+	optInt      = "integer"  // Целое число
+	optNumber   = "number"   // Число с дробной частью
+	optBool     = "boolean"  // true или false
+	optDuration = "duration" // Длительность в формате Go: 15m, 1h30m
+	optClock    = "clock"    // Время суток ЧЧ:ММ
+	optList     = "list"     // Список через запятую или точку с запятой
+	optEnum     = "enum"     // Одно из перечисленных значений
+)
+
+// Описание параметра конфигурации: переменная окружения, тип, значение по умолчанию и пояснение.
+// Из этого списка строятся флаги командной строки, пример конфигурации и JSON Schema.
+type configOption struct {
+	Name      string
+	Type      string
+	Help      string
+	Default   string   // Значение по умолчанию для справки; пусто - не задано
+	Example   string   // Пример значения для образца конфигурации
+	Enum      []string // Допустимые значения для optEnum
+	Min, Max  float64  // Диапазон для optInt и optNumber, если Bounded
+	Bounded   bool
+	Required  bool
+	Essential bool // Раскомментирован в образце конфигурации, хотя и не обязателен
+	Secret    bool // Значение лучше передавать через <ИМЯ>_FILE
+}
+
+// Группа параметров для образца конфигурации
+type configOptionGroup struct {
+	Title   string
+	Options []configOption
+}
+
+// Диапазон значений числового параметра
+func bounded(opt configOption, min, max float64) configOption {
+	opt.Min, opt.Max, opt.Bounded = min, max, true
+	return opt
+}
+
+var severityNames = []string{"yellow", "orange", "red"}
+
+// Все параметры конфигурации по группам
+var configOptionGroups = []configOptionGroup{
+	{"Основные настройки", []configOption{
+		{Name: "OPENWEATHER_API_KEY", Type: optString, Help: "ключ API OpenWeatherMap", Required: true, Secret: true, Example: "your_api_key"},
+		{Name: "CITY", Type: optString, Help: "город для проверки погоды (Город,Код_страны); не обязателен при LOCATIONS_FILE", Essential: true, Example: "Moscow,RU"},
+		{Name: "MODE", Type: optEnum, Help: "режим работы", Default: modeWind, Enum: []string{modeWind, modeDrone, modeSchool}},
+		{Name: "TIMEZONE", Type: optString, Help: "часовой пояс города (IANA); по умолчанию определяется по прогнозу", Example: "Europe/Moscow"},
+		{Name: "HTTP_ADDR", Type: optString, Help: "адрес HTTP-сервера; если не указан, сервер не запускается", Example: ":8080"},
+		{Name: "LOG_LEVEL", Type: optEnum, Help: "подробность журнала; debug включает диалог с SMTP-сервером", Default: "info", Enum: []string{"debug", "info", "warn", "error"}},
+		{Name: "LOG_FILE", Type: optString, Help: "файл журнала с ротацией вместо вывода в stderr (для установок без journald)", Example: "/var/log/windalerts/windalerts.log"},
+		bounded(configOption{Name: "LOG_MAX_SIZE", Type: optInt, Help: "размер файла журнала в мегабайтах, после которого начинается новый файл", Default: "100"}, 1, 100000),
+		{Name: "LOG_ROTATE_INTERVAL", Type: optDuration, Help: "начинать новый файл журнала с этим интервалом независимо от размера (0 - только по размеру)", Example: "24h"},
+		bounded(configOption{Name: "LOG_MAX_BACKUPS", Type: optInt, Help: "сколько старых файлов журнала хранить (0 - все)", Default: "7"}, 0, 10000),
+		bounded(configOption{Name: "LOG_MAX_AGE", Type: optInt, Help: "сколько дней хранить старые файлы журнала (0 - без ограничения)", Default: "0"}, 0, 36500),
+		{Name: "LOG_COMPRESS", Type: optBool, Help: "сжимать старые файлы журнала gzip", Default: "false"},
+		{Name: "DRY_RUN", Type: optBool, Help: "пробный запуск: уведомления только выводятся в журнал", Default: "false"},
+		{Name: "FEATURES", Type: optList, Help: "флаги необязательных функций: имя или имя=true|false (all_clear_emails, continuous_mode)", Example: featureAllClearEmails},
+		{Name: "STRICT_CONFIG", Type: optBool, Help: "не запускаться при неизвестных параметрах и значениях, которые не удалось разобрать", Default: "false"},
+		{Name: "PREFLIGHT", Type: optBool, Help: "проверить ключ OpenWeatherMap и вход на SMTP-сервер при запуске", Default: "false"},
+		{Name: "PROFILE", Type: optString, Help: "именованный профиль: значения <ПРОФИЛЬ>__<ИМЯ> заменяют общие", Example: "dacha"},
+		{Name: "CONFIG_VERSION", Type: optInt, Help: "версия формата конфигурации; без нее конфигурация считается версией 1 (config migrate)", Essential: true, Example: "2"},
+		{Name: "CONFIG_FILE", Type: optList, Help: "файлы конфигурации или адреса HTTP(S) через запятую, следующий переопределяет предыдущие; задается в окружении или флагом (по умолчанию .env, .env.<PROFILE>, .env.local, .env.<PROFILE>.local)", Example: "/etc/windalerts/windalerts.env"},
+		{Name: "CONFIG_REFRESH_INTERVAL", Type: optDuration, Help: "интервал опроса удаленного файла конфигурации", Default: "5m"},
+		{Name: "CONFIG_AUTH_TOKEN", Type: optString, Help: "токен Bearer для загрузки удаленного файла конфигурации", Secret: true},
+		{Name: "CONFIG_AGE_KEY", Type: optString, Help: "секретный ключ age для расшифровки значений ENC[age:...]", Secret: true},
+	}},
+	{"Электронная почта", []configOption{
+		{Name: "EMAIL_FROM", Type: optString, Help: "адрес отправителя", Required: true, Example: "alerts@example.org"},
+		{Name: "EMAIL_TO", Type: optList, Help: "адреса получателей; не обязателен при RECIPIENTS_FILE", Essential: true, Example: "office@example.org"},
+		{Name: "RECIPIENTS_FILE", Type: optString, Help: "JSON-файл с настройками получателей", Example: "recipients.json"},
+		{Name: "SUBSCRIPTIONS_FILE", Type: optString, Help: "JSON-файл получателей, добавленных, измененных и исключенных через /api/recipients", Example: "subscriptions.json"},
+		{Name: "EMAIL_CHART", Type: optBool, Help: "встраивать в письмо предупреждения PNG-график порывов ветра с линией порога", Default: "false"},
+		{Name: "UNSUBSCRIBE_SECRET", Type: optString, Help: "ключ подписи ссылок отписки в письмах; ссылка ведет на PUBLIC_URL/unsubscribe", Secret: true},
+		{Name: "SMTP_SERVER", Type: optString, Help: "адрес SMTP сервера", Required: true, Example: "mail.example.org"},
+		bounded(configOption{Name: "SMTP_PORT", Type: optInt, Help: "порт SMTP сервера", Required: true, Example: "587"}, 1, 65535),
+		{Name: "SMTP_USER", Type: optString, Help: "имя пользователя SMTP", Essential: true, Example: "alerts"},
+		{Name: "SMTP_PASSWORD", Type: optString, Help: "пароль SMTP", Essential: true, Secret: true},
+	}},
+	{"Пороги и проверка", []configOption{
+		{Name: "WIND_GUST_THRESHOLD", Type: optNumber, Help: "порог порывов ветра в единицах UNITS (по умолчанию 15 м/с)"},
+		{Name: "LANGUAGE", Type: optEnum, Help: "язык уведомлений", Default: languageRU, Enum: []string{languageRU, languageEN}},
+		{Name: "UNITS", Type: optEnum, Help: "единицы скорости ветра для порогов и сообщений", Default: unitsMS, Enum: []string{unitsMS, unitsKMH, unitsMPH, unitsKnots, unitsBft}},
+		{Name: "WIND_GUST_ORANGE_THRESHOLD", Type: optNumber, Help: "порог оранжевого уровня в единицах UNITS (по умолчанию порог + 5 м/с)"},
+		{Name: "WIND_GUST_RED_THRESHOLD", Type: optNumber, Help: "порог красного уровня в единицах UNITS (по умолчанию порог + 10 м/с)"},
+		bounded(configOption{Name: "NOTIFICATION_HOUR", Type: optInt, Help: "час отправки уведомления", Default: "9"}, 0, 23),
+		bounded(configOption{Name: "NOTIFICATION_MIN", Type: optInt, Help: "минуты отправки уведомления", Default: "0"}, 0, 59),
+		{Name: "CHECK_WINDOW", Type: optString, Help: "часть суток для проверки, ЧЧ:ММ-ЧЧ:ММ", Default: "00:00-19:00"},
+		bounded(configOption{Name: "LOOKAHEAD_DAYS", Type: optInt, Help: "сколько дней после текущего включать в проверку", Default: "0"}, 0, maxLookaheadDays),
+		{Name: "RULES_FILE", Type: optString, Help: "JSON-файл с упорядоченным списком правил предупреждений (по умолчанию порывы выше WIND_GUST_THRESHOLD)", Example: "rules.json"},
+		{Name: "LOCATIONS_FILE", Type: optString, Help: "JSON-файл со списком пунктов", Example: "locations.json"},
+		{Name: "LOCATIONS_REPORT", Type: optEnum, Help: "рассылка по нескольким пунктам", Default: locationsSeparate, Enum: []string{locationsSeparate, locationsCombined}},
+	}},
+	{"Расписание", []configOption{
+		{Name: "SCHEDULE", Type: optEnum, Help: "стратегия запуска проверок", Default: scheduleDaily, Enum: []string{scheduleDaily, scheduleContinuous, scheduleCron, scheduleOnce}},
+		{Name: "CRON_SCHEDULE", Type: optString, Help: "выражение cron для SCHEDULE=cron", Example: "0 6,9 * * 1-5"},
+		{Name: "POLL_INTERVAL", Type: optDuration, Help: "интервал опроса в непрерывном режиме", Example: "1h"},
+		{Name: "POLL_INTERVAL_NEAR", Type: optDuration, Help: "интервал опроса вблизи порога", Default: "15m"},
+		{Name: "POLL_NEAR_RATIO", Type: optNumber, Help: "близость к порогу как доля от него", Default: "0.2"},
+		{Name: "RUN_STATE_FILE", Type: optString, Help: "файл времени последней плановой проверки и отправленных за день предупреждений", Example: "runstate.json"},
+		{Name: "ALERT_DEDUP", Type: optBool, Help: "не повторять предупреждение того же уровня по пункту и правилу в течение дня", Default: "true"},
+		{Name: "ALERT_COOLDOWN", Type: optDuration, Help: "период после предупреждения, в течение которого оно повторяется только при повышении уровня опасности", Example: "6h"},
+		{Name: "PAUSE_UNTIL", Type: optString, Help: "дата возобновления рассылки: ГГГГ-ММ-ДД или ГГГГ-ММ-ДД ЧЧ:ММ", Example: "2026-11-10"},
+		{Name: "PAUSE_FILE", Type: optString, Help: "JSON-файл состояния приостановки рассылки, чтобы она сохранялась после перезапуска", Example: "pause.json"},
+		{Name: "BLACKOUT_DATES", Type: optList, Help: "дни без уведомлений: даты и диапазоны ГГГГ-ММ-ДД..ГГГГ-ММ-ДД", Example: "2026-12-31,2027-01-01..2027-01-08"},
+		{Name: "BLACKOUT_ICAL", Type: optString, Help: "календарь .ics с днями без уведомлений", Example: "holidays.ics"},
+		{Name: "RECIPIENT_TIMES", Type: optString, Help: "отдельное время доставки: адрес=ЧЧ:ММ;...", Example: "shift@example.org=06:00"},
+		{Name: "REMINDER_LEAD", Type: optDuration, Help: "за сколько до сильного ветра повторить проверку", Example: "1h"},
+		{Name: "HEARTBEAT_URL", Type: optString, Help: "адрес, запрашиваемый после каждой успешной плановой проверки (healthchecks.io, Uptime Kuma)", Secret: true, Example: "https://hc-ping.com/your-uuid"},
+		{Name: "HEARTBEAT_FAIL_URL", Type: optString, Help: "адрес, запрашиваемый после неудачной плановой проверки", Secret: true, Example: "https://hc-ping.com/your-uuid/fail"},
+		{Name: "OPS_ALERT_EMAIL_TO", Type: optString, Help: "адреса администраторов для служебных оповещений о повторяющихся сбоях", Example: "admin@example.com"},
+		{Name: "OPS_ALERT_WEBHOOK_URL", Type: optString, Help: "входящий вебхук чата администраторов (Slack, Mattermost, Rocket.Chat) для служебных оповещений", Secret: true, Example: "https://hooks.slack.com/services/T000/B000/XXXX"},
+		bounded(configOption{Name: "OPS_ALERT_AFTER", Type: optInt, Help: "число ошибок подряд при получении прогноза или отправке через канал, после которого отправляется служебное оповещение", Default: "3"}, 1, 1000),
+		{Name: "PREVIEW_TIME", Type: optClock, Help: "время вечерней проверки прогноза на завтра", Example: "20:00"},
+		{Name: "PREVIEW_EMAIL_TO", Type: optList, Help: "получатели прогноза на завтра (по умолчанию EMAIL_TO)"},
+		{Name: "DIGEST_TIME", Type: optClock, Help: "время отправки еженедельной сводки", Example: "09:00"},
+		{Name: "DIGEST_WEEKDAY", Type: optEnum, Help: "день недели сводки", Default: "mon", Enum: []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}},
+		{Name: "DIGEST_EMAIL_TO", Type: optList, Help: "получатели сводки (по умолчанию EMAIL_TO)"},
+	}},
+	{"Хранилища и HTTP API", []configOption{
+		{Name: "EVENTS_FILE", Type: optString, Help: "JSON-файл мероприятий", Example: "events.json"},
+		{Name: "HISTORY_FILE", Type: optString, Help: "JSON-файл истории предупреждений", Example: "history.json"},
+		{Name: "HISTORY_DB", Type: optString, Help: "файл SQLite или адрес postgres:// базы со всеми результатами проверок и статусом доставки уведомлений", Example: "history.db"},
+		{Name: "AUDIT_FILE", Type: optString, Help: "файл JSON Lines, в который дописывается каждое решение о предупреждении с объяснением (просмотр: windalerts audit)", Example: "audit.jsonl"},
+		{Name: "STORE_BACKEND", Type: optEnum, Help: "хранение состояния сервиса: json - отдельные файлы *_FILE, bolt - один файл bbolt без CGO, redis - общее состояние нескольких экземпляров", Default: "json", Enum: []string{storeJSON, storeBolt, storeRedis}},
+		{Name: "STORE_FILE", Type: optString, Help: "файл bbolt с состоянием сервиса при STORE_BACKEND=bolt", Default: "weather-state.db"},
+		{Name: "REDIS_URL", Type: optString, Help: "адрес Redis при STORE_BACKEND=redis", Secret: true, Example: "redis://:password@redis:6379/0"},
+		{Name: "REDIS_PREFIX", Type: optString, Help: "префикс ключей Redis", Default: "windalerts:"},
+		{Name: "REDIS_LEADER_TTL", Type: optDuration, Help: "срок права рассылки: через столько после сбоя рассылающего экземпляра ее продолжит другой", Default: "15s"},
+		{Name: "FORECAST_ARCHIVE_DIR", Type: optString, Help: "каталог, в который сохраняется каждый полученный ответ прогноза", Example: "forecasts"},
+		{Name: "FORECAST_ARCHIVE_RETENTION", Type: optDuration, Help: "срок хранения снимков прогноза (по умолчанию без удаления)", Example: "720h"},
+		{Name: "FORECAST_CACHE_TTL", Type: optDuration, Help: "срок, в течение которого проверки и HTTP API используют уже полученный прогноз пункта (0 - без кэша)", Default: "0"},
+		{Name: "DAILY_CSV_FILE", Type: optString, Help: "CSV-журнал: строка на день и пункт с максимальным порывом, порогом и отметкой о предупреждении", Example: "wind-daily.csv"},
+		{Name: "DAILY_CSV_DELIMITER", Type: optEnum, Help: "разделитель полей журнала CSV (при ; дробная часть через запятую)", Default: ",", Enum: []string{",", ";", "tab"}},
+		{Name: "ACCURACY_FILE", Type: optString, Help: "JSON-файл сравнения прогноза максимального порыва с фактической погодой", Example: "accuracy.json"},
+		{Name: "ACCURACY_INTERVAL", Type: optDuration, Help: "период опроса фактической погоды", Default: "1h"},
+		bounded(configOption{Name: "ACCURACY_DAYS", Type: optInt, Help: "число дней скользящей статистики точности прогноза", Default: "30"}, 1, 365),
+		{Name: "ACCURACY_STATION_URL", Type: optString, Help: "метеостанция основного города: JSON с полем wind_gust (м/с) вместо текущей погоды OpenWeatherMap", Example: "http://station.local/current.json"},
+		{Name: "TEMPLATE_DIR", Type: optString, Help: "каталог шаблонов сообщений каналов (прежнее имя TEMPLATES_DIR тоже поддерживается)", Example: "templates"},
+		{Name: "FEED_FILE", Type: optString, Help: "файл ленты предупреждений", Example: "feed.xml"},
+		{Name: "FEED_FORMAT", Type: optEnum, Help: "формат ленты", Default: "rss", Enum: []string{"rss", "atom", "json"}},
+		{Name: "FEED_LINK", Type: optString, Help: "публичный адрес сервиса для ссылок в ленте", Example: "https://weather.example.org"},
+		{Name: "HEALTH_ADDR", Type: optString, Help: "адрес отдельного HTTP-сервера только с /healthz, /readyz и /metrics для проб Kubernetes и Prometheus", Example: ":8081"},
+		{Name: "HEALTH_MAX_CHECK_AGE", Type: optDuration, Help: "время без проверок по расписанию, после которого /readyz отвечает 503 (по умолчанию 25h, в непрерывном режиме - два POLL_INTERVAL)", Example: "2h"},
+		{Name: "HEALTH_PROBE_INTERVAL", Type: optDuration, Help: "интервал проверки доступности OpenWeatherMap и SMTP-сервера для /readyz (0 - не проверять)", Default: "5m"},
+		{Name: "DASHBOARD", Type: optBool, Help: "страница состояния по адресу / на HTTP_ADDR: прогноз, последние проверки и следующая проверка", Default: "false"},
+		{Name: "SETTINGS_USER", Type: optString, Help: "имя пользователя страницы настроек /settings", Default: defaultSettingsUser},
+		{Name: "SETTINGS_PASSWORD", Type: optString, Help: "пароль страницы настроек /settings на HTTP_ADDR: пороги, расписание и получатели; без пароля страница отключена", Secret: true},
+		{Name: "API_KEYS", Type: optString, Help: "ключи API маршрутов управления: имя=ключ:области[:ГГГГ-ММ-ДД];... (области trigger, subscriptions, config или *)", Secret: true},
+		{Name: "PPROF", Type: optBool, Help: "профилирование net/http/pprof по адресу /debug/pprof/ на HEALTH_ADDR", Default: "false"},
+		{Name: "METRICS_TEXTFILE", Type: optString, Help: "файл .prom с метриками последней проверки для textfile collector node_exporter", Example: "/var/lib/node_exporter/textfile/windalerts.prom"},
+		{Name: "STATSD_HOST", Type: optString, Help: "адрес агента StatsD/DogStatsD (Datadog, Telegraf), которому по UDP отправляются метрики", Example: "127.0.0.1"},
+		bounded(configOption{Name: "STATSD_PORT", Type: optInt, Help: "UDP-порт агента StatsD", Default: "8125"}, 1, 65535),
+		{Name: "STATSD_PREFIX", Type: optString, Help: "префикс имен метрик StatsD", Default: "windalerts."},
+		{Name: "STATSD_TAGS", Type: optList, Help: "общие теги DogStatsD ключ:значение через запятую", Example: "env:prod,service:windalerts"},
+	}},
+	{"Доставка уведомлений", []configOption{
+		{Name: "ROUTING_RULES", Type: optString, Help: "каналы по уровням опасности: уровень=канал1,канал2;...", Example: "yellow=email;red=email,sms,call"},
+		{Name: "QUIET_HOURS", Type: optString, Help: "периоды тишины: канал=ЧЧ:ММ-ЧЧ:ММ;...", Example: "sms=22:00-07:00"},
+		{Name: "RETRY_QUEUE_FILE", Type: optString, Help: "JSON-файл очереди повторной доставки", Example: "retry.json"},
+		{Name: "RETRY_INITIAL_DELAY", Type: optDuration, Help: "пауза перед первой повторной попыткой", Default: "1m"},
+		{Name: "RETRY_MAX_PERIOD", Type: optDuration, Help: "сколько повторять доставку после первой ошибки (0 - не повторять)", Default: "6h"},
+		{Name: "ESCALATION_CHANNELS", Type: optList, Help: "каналы эскалации", Example: "sms,call"},
+		{Name: "ESCALATION_DELAY", Type: optDuration, Help: "время ожидания подтверждения", Default: "15m"},
+		{Name: "ESCALATION_FILE", Type: optString, Help: "JSON-файл состояния эскалации", Example: "escalation.json"},
+		{Name: "PUBLIC_URL", Type: optString, Help: "публичный адрес для ссылок подтверждения и отписки (по умолчанию FEED_LINK)"},
+	}},
+	{"Режимы drone и school", []configOption{
+		{Name: "DRONE_MAX_GUST", Type: optNumber, Help: "максимальные порывы для полетов в единицах UNITS (по умолчанию 10 м/с)"},
+		{Name: "DRONE_MIN_VISIBILITY", Type: optNumber, Help: "минимальная видимость в метрах", Default: "5000"},
+		{Name: "SCHOOL_EMAIL_TO", Type: optList, Help: "адреса администраторов (по умолчанию EMAIL_TO)"},
+		{Name: "SCHOOL_AGE_GROUPS", Type: optString, Help: "пороги групп: Название:мин_температура:макс_индекс_жары:макс_УФ;..."},
+		{Name: "SCHOOL_MAX_PRECIPITATION", Type: optNumber, Help: "осадки в мм за 3 часа, при которых прогулка отменяется", Default: "1.0"},
+		bounded(configOption{Name: "SCHOOL_START_HOUR", Type: optInt, Help: "начало прогулочного времени", Default: "9"}, 0, 23),
+		bounded(configOption{Name: "SCHOOL_END_HOUR", Type: optInt, Help: "конец прогулочного времени", Default: "18"}, 1, 24),
+	}},
+	{"MQTT", []configOption{
+		{Name: "MQTT_BROKER", Type: optString, Help: "адрес брокера", Example: "tcp://192.168.1.10:1883"},
+		{Name: "MQTT_CLIENT_ID", Type: optString, Help: "идентификатор клиента", Default: "windalerts"},
+		{Name: "MQTT_USER", Type: optString, Help: "пользователь брокера"},
+		{Name: "MQTT_PASSWORD", Type: optString, Help: "пароль брокера", Secret: true},
+		{Name: "MQTT_TOPIC_PREFIX", Type: optString, Help: "префикс топиков", Default: "windalerts"},
+		bounded(configOption{Name: "MQTT_QOS", Type: optInt, Help: "уровень QoS", Default: "0"}, 0, 2),
+		{Name: "MQTT_RETAINED", Type: optBool, Help: "публиковать с флагом retained", Default: "true"},
+		{Name: "MQTT_HA_DISCOVERY", Type: optBool, Help: "топики обнаружения Home Assistant", Default: "false"},
+		{Name: "MQTT_HA_DISCOVERY_PREFIX", Type: optString, Help: "префикс топиков обнаружения", Default: "homeassistant"},
+	}},
+	{"Мессенджеры", []configOption{
+		{Name: "MATRIX_HOMESERVER_URL", Type: optString, Help: "адрес homeserver Matrix", Example: "https://matrix.example.org"},
+		{Name: "MATRIX_ACCESS_TOKEN", Type: optString, Help: "токен доступа бота Matrix", Secret: true},
+		{Name: "MATRIX_ROOM_ID", Type: optString, Help: "идентификатор комнаты Matrix", Example: "!abcdef:example.org"},
+		{Name: "WHATSAPP_ACCESS_TOKEN", Type: optString, Help: "токен доступа WhatsApp Business", Secret: true},
+		{Name: "WHATSAPP_API_VERSION", Type: optString, Help: "версия Graph API", Default: "v19.0"},
+		{Name: "WHATSAPP_PHONE_NUMBER_ID", Type: optString, Help: "идентификатор номера отправителя WhatsApp"},
+		{Name: "WHATSAPP_TEMPLATE", Type: optString, Help: "имя шаблона WhatsApp", Default: "wind_gust_alert"},
+		{Name: "WHATSAPP_TEMPLATE_LANGUAGE", Type: optString, Help: "код языка шаблона WhatsApp", Default: "ru"},
+		{Name: "WHATSAPP_TO", Type: optList, Help: "номера получателей WhatsApp", Example: "79001234567"},
+		{Name: "GOOGLE_CHAT_WEBHOOK_URL", Type: optString, Help: "адрес веб-хука Google Chat", Secret: true},
+		{Name: "SIGNAL_API_URL", Type: optString, Help: "адрес signal-cli-rest-api", Example: "http://localhost:8080"},
+		{Name: "SIGNAL_NUMBER", Type: optString, Help: "номер отправителя Signal", Example: "+79001234567"},
+		{Name: "SIGNAL_GROUP_ID", Type: optString, Help: "идентификатор группы Signal"},
+		{Name: "VK_ACCESS_TOKEN", Type: optString, Help: "ключ доступа сообщества ВКонтакте", Secret: true},
+		{Name: "VK_API_VERSION", Type: optString, Help: "версия VK API", Default: "5.199"},
+		{Name: "VK_PEER_IDS", Type: optList, Help: "идентификаторы получателей ВКонтакте"},
+		{Name: "XMPP_SERVER", Type: optString, Help: "сервер XMPP host:port (по умолчанию домен из XMPP_USER)"},
+		{Name: "XMPP_USER", Type: optString, Help: "JID отправителя", Example: "alerts@jabber.example.org"},
+		{Name: "XMPP_PASSWORD", Type: optString, Help: "пароль XMPP", Secret: true},
+		{Name: "XMPP_RECIPIENTS", Type: optList, Help: "JID получателей"},
+		{Name: "XMPP_DIRECT_TLS", Type: optBool, Help: "прямое TLS-подключение вместо STARTTLS", Default: "false"},
+		{Name: "ROCKETCHAT_WEBHOOK_URL", Type: optString, Help: "адрес веб-хука Rocket.Chat", Secret: true},
+		{Name: "ZULIP_SITE", Type: optString, Help: "адрес сервера Zulip", Example: "https://chat.example.org"},
+		{Name: "ZULIP_BOT_EMAIL", Type: optString, Help: "адрес бота Zulip"},
+		{Name: "ZULIP_API_KEY", Type: optString, Help: "API-ключ бота Zulip", Secret: true},
+		{Name: "ZULIP_STREAM", Type: optString, Help: "канал Zulip"},
+		{Name: "ZULIP_TOPIC", Type: optString, Help: "тема Zulip (по умолчанию CITY)"},
+		{Name: "LINE_CHANNEL_ACCESS_TOKEN", Type: optString, Help: "токен доступа канала LINE", Secret: true},
+		{Name: "LINE_TO", Type: optList, Help: "получатели LINE; если не указаны - все подписчики"},
+		{Name: "VIBER_AUTH_TOKEN", Type: optString, Help: "токен бота Viber", Secret: true},
+		{Name: "VIBER_RECEIVERS", Type: optList, Help: "идентификаторы подписчиков Viber"},
+		{Name: "VIBER_SENDER_NAME", Type: optString, Help: "имя отправителя Viber", Default: "WindAlerts"},
+		{Name: "PUSHBULLET_ACCESS_TOKEN", Type: optString, Help: "токен доступа Pushbullet", Secret: true},
+		{Name: "PUSHBULLET_DEVICES", Type: optList, Help: "устройства Pushbullet"},
+		{Name: "PUSHBULLET_CHANNEL", Type: optString, Help: "тег канала Pushbullet"},
+	}},
+	{"Push, SMS и интеграции", []configOption{
+		{Name: "FCM_SERVICE_ACCOUNT_FILE", Type: optString, Help: "JSON-ключ сервисного аккаунта Firebase"},
+		{Name: "FCM_TOKENS", Type: optList, Help: "токены устройств FCM"},
+		{Name: "FCM_TOPIC", Type: optString, Help: "тема FCM, если токены не указаны", Default: "windalerts"},
+		{Name: "IFTTT_KEY", Type: optString, Help: "ключ IFTTT Webhooks", Secret: true},
+		{Name: "MAKER_EVENT", Type: optString, Help: "имя события IFTTT/Zapier", Default: "wind_alert"},
+		{Name: "MAKER_WEBHOOK_URL", Type: optString, Help: "адрес веб-хука (например, Zapier)", Secret: true},
+		{Name: "SNS_REGION", Type: optString, Help: "регион AWS (по умолчанию из ARN)"},
+		{Name: "SNS_TOPIC_ARN", Type: optString, Help: "ARN темы AWS SNS"},
+		{Name: "TWILIO_ACCOUNT_SID", Type: optString, Help: "SID учетной записи Twilio"},
+		{Name: "TWILIO_AUTH_TOKEN", Type: optString, Help: "токен Twilio", Secret: true},
+		{Name: "TWILIO_FROM", Type: optString, Help: "номер Twilio отправителя"},
+		{Name: "TWILIO_SMS_TO", Type: optList, Help: "номера для SMS"},
+		{Name: "TWILIO_CALL_TO", Type: optList, Help: "номера для голосового звонка"},
+		{Name: "TWILIO_CALL_MIN_SEVERITY", Type: optEnum, Help: "минимальный уровень для звонка", Default: "red", Enum: severityNames},
+		{Name: "TWILIO_VOICE_LANGUAGE", Type: optString, Help: "язык синтеза речи (при LANGUAGE=en по умолчанию en-US)", Default: "ru-RU"},
+		{Name: "NODERED_URL", Type: optString, Help: "адрес узла http in Node-RED"},
+		{Name: "NODERED_USER", Type: optString, Help: "пользователь Node-RED"},
+		{Name: "NODERED_PASSWORD", Type: optString, Help: "пароль Node-RED", Secret: true},
+		{Name: "SYSLOG_ADDR", Type: optString, Help: "сервер syslog host:port"},
+		{Name: "SYSLOG_PROTOCOL", Type: optEnum, Help: "протокол syslog", Default: "udp", Enum: []string{"udp", "tcp", "tls"}},
+		{Name: "SYSLOG_TLS_CA", Type: optString, Help: "сертификат CA сервера syslog"},
+		{Name: "SYSLOG_FACILITY", Type: optString, Help: "facility syslog", Default: "local0"},
+		{Name: "SYSLOG_APP_NAME", Type: optString, Help: "имя приложения в syslog", Default: "windalerts"},
+		{Name: "INFLUXDB_URL", Type: optString, Help: "адрес InfluxDB 2.x для метрик прогноза и проверок", Example: "http://influxdb:8086"},
+		{Name: "INFLUXDB_ORG", Type: optString, Help: "организация InfluxDB"},
+		{Name: "INFLUXDB_BUCKET", Type: optString, Help: "bucket InfluxDB", Example: "weather"},
+		{Name: "INFLUXDB_TOKEN", Type: optString, Help: "токен InfluxDB с правом записи в bucket", Secret: true},
+	}},
+	{"Инциденты", []configOption{
+		{Name: "PAGERDUTY_ROUTING_KEY", Type: optString, Help: "integration key PagerDuty", Secret: true},
+		{Name: "PAGERDUTY_SOURCE", Type: optString, Help: "источник события PagerDuty", Default: "windalerts"},
+		{Name: "PAGERDUTY_MIN_SEVERITY", Type: optEnum, Help: "минимальный уровень для инцидента PagerDuty", Default: "red", Enum: severityNames},
+		{Name: "OPSGENIE_API_KEY", Type: optString, Help: "ключ API Opsgenie", Secret: true},
+		{Name: "OPSGENIE_API_URL", Type: optString, Help: "адрес API Opsgenie", Default: "https://api.opsgenie.com"},
+		{Name: "OPSGENIE_MIN_SEVERITY", Type: optEnum, Help: "минимальный уровень для предупреждения Opsgenie", Default: "red", Enum: severityNames},
+		{Name: "OPSGENIE_TAGS", Type: optList, Help: "дополнительные теги Opsgenie"},
+		{Name: "OPSGENIE_TEAM", Type: optString, Help: "команда Opsgenie"},
+	}},
+}
+
+// Переменные окружения всех параметров конфигурации
+var configEnvVars = configOptionNames()
+
+// Прежние имена параметров, которые по-прежнему читаются: прежнее имя -> текущее
+var configAliases = map[string]string{
+	"TEMPLATES_DIR": "TEMPLATE_DIR",
+}
+
+func configOptionNames() []string {
+	var names []string
+	for _, group := range configOptionGroups {
+		for _, opt := range group.Options {
+			names = append(names, opt.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+// Адрес PagerDuty Events API v2
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// Настройки интеграции с PagerDuty
+type PagerDutyConfig struct {
+	RoutingKey  string   // Integration key сервиса PagerDuty
+	MinSeverity Severity // Минимальный уровень опасности для создания инцидента
+	Source      string   // Источник события в PagerDuty
+}
+
+// Загрузка настроек PagerDuty из переменных окружения
+func loadPagerDutyConfig() PagerDutyConfig {
+	cfg := PagerDutyConfig{
+		RoutingKey:  os.Getenv("PAGERDUTY_ROUTING_KEY"),
+		MinSeverity: SeverityRed,
+		Source:      os.Getenv("PAGERDUTY_SOURCE"),
+	}
+
+	if cfg.Source == "" {
+		cfg.Source = "windalerts"
+	}
+
+	if envSeverity := os.Getenv("PAGERDUTY_MIN_SEVERITY"); envSeverity != "" {
+		if val, err := parseSeverity(envSeverity); err == nil && val != SeverityNone {
+			cfg.MinSeverity = val
+		} else {
+			logWarnf("Ошибка парсинга PAGERDUTY_MIN_SEVERITY: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	return cfg
+}
+
+// Соответствие уровней опасности уровням PagerDuty
+func pagerDutySeverity(s Severity) string {
+	switch s {
+	case SeverityRed:
+		return "critical"
+	case SeverityOrange:
+		return "error"
+	case SeverityYellow:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// Создание и закрытие инцидентов PagerDuty
+type pagerDutyNotifier struct {
+	config    PagerDutyConfig
+	incidents *incidentTracker
+}
+
+func newPagerDutyNotifier(config PagerDutyConfig) *pagerDutyNotifier {
+	return &pagerDutyNotifier{config: config, incidents: newIncidentTracker()}
+}
+
+func (n *pagerDutyNotifier) Name() string {
+	return "pagerduty"
+}
+
+// Событие PagerDuty Events API v2
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Component     string                 `json:"component,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+func (n *pagerDutyNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	dedupKey := alertDedupKey(report.City)
+
+	event := pagerDutyEvent{
+		RoutingKey: n.config.RoutingKey,
+		DedupKey:   dedupKey,
+	}
+
+	if report.Severity >= n.config.MinSeverity {
+		event.EventAction = "trigger"
+		event.Payload = &pagerDutyPayload{
+			Summary: fmt.Sprintf(report.tr("%s: порывы ветра до %s (уровень опасности: %s)", "%s: wind gusts up to %s (severity: %s)"),
+				report.City, report.speed(report.MaxWindGust, 1), report.severityTitle()),
+			Source:    n.config.Source,
+			Severity:  pagerDutySeverity(report.Severity),
+			Component: report.City,
+			CustomDetails: map[string]interface{}{
+				"city":          report.City,
+				"max_wind_gust": report.MaxWindGust,
+				"threshold":     report.WindGustThreshold,
+				"severity":      report.Severity.String(),
+			},
+		}
+	} else {
+		// Отбой отправляется только если инцидент мог быть открыт
+		if !n.incidents.mayBeOpen(report.City) {
+			return nil
+		}
+		event.EventAction = "resolve"
+	}
+
+	if _, err := sendJSON(ctx, http.MethodPost, pagerDutyEventsURL, nil, event); err != nil {
+		return fmt.Errorf("ошибка при отправке события в PagerDuty: %w", err)
+	}
+
+	n.incidents.set(report.City, event.EventAction == "trigger")
+
+	log.Printf("Событие %s отправлено в PagerDuty (%s)", event.EventAction, dedupKey)
+	return nil
+}
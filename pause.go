@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Приостановка рассылки (например, на время ремонта здания). Проверки продолжают
+// выполняться и записываться в историю, но уведомления в каналы не отправляются.
+// Состояние сохраняется в хранилище (PAUSE_FILE при STORE_BACKEND=json) и переживает перезапуск.
+type PauseControl struct {
+	mu          sync.Mutex
+	configs     *ConfigStore
+	clock       *CityClock
+	store       StateStore
+	file        string
+	paused      bool
+	until       time.Time // Время автоматического возобновления; нулевое - до ручного возобновления
+	configUntil string    // Последнее примененное значение PAUSE_UNTIL
+}
+
+// Состояние приостановки для API
+type PauseStatus struct {
+	Paused bool       `json:"paused"`
+	Until  *time.Time `json:"until,omitempty"`
+}
+
+// Сохраняемое состояние приостановки
+type pauseState struct {
+	Paused      bool      `json:"paused"`
+	Until       time.Time `json:"until"`
+	ConfigUntil string    `json:"pause_until,omitempty"` // PAUSE_UNTIL, уже примененная к состоянию
+}
+
+// Создание управления приостановкой с загрузкой сохраненного состояния. PAUSE_UNTIL применяется,
+// только если изменилась с прошлого запуска: досрочное возобновление через API сохраняется.
+func newPauseControl(configs *ConfigStore, store StateStore, file string) (*PauseControl, error) {
+	p := &PauseControl{configs: configs, clock: configs.Load().Clock, store: store, file: file}
+	store.onLeading(func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.refresh()
+	})
+
+	data, err := store.read(statePause, file)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении состояния приостановки: %w", err)
+	}
+	if data != nil {
+		var state pauseState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("ошибка при разборе состояния приостановки: %w", err)
+		}
+		p.paused, p.until, p.configUntil = state.Paused, state.Until, state.ConfigUntil
+		if p.paused && (p.until.IsZero() || p.until.After(p.clock.Now())) {
+			log.Printf("Восстановлена приостановка рассылки %s", p.describe())
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.applyConfig()
+	return p, nil
+}
+
+// Срок приостановки для журнала
+func (p *PauseControl) describe() string {
+	if p.until.IsZero() {
+		return "до ручного возобновления"
+	}
+	return "до " + p.until.In(p.clock.Location()).Format("02.01.2006 15:04")
+}
+
+// Применение PAUSE_UNTIL, изменившейся при перезагрузке конфигурации; вызывается с захваченной блокировкой.
+// Удаление PAUSE_UNTIL снимает приостановку, заданную ею, но не приостановку через API или сигнал.
+func (p *PauseControl) applyConfig() {
+	value := strings.TrimSpace(p.configs.Load().PauseUntil)
+	if value == p.configUntil {
+		return
+	}
+	previous := p.configUntil
+	p.configUntil = value
+
+	if value == "" {
+		if until, err := parsePauseUntil(previous, p.clock.Location()); err == nil && p.paused && p.until.Equal(until) {
+			p.paused, p.until = false, time.Time{}
+			log.Println("PAUSE_UNTIL удалена из конфигурации, рассылка возобновлена")
+		}
+	} else if until, err := parsePauseUntil(value, p.clock.Location()); err != nil {
+		logWarnf("Ошибка парсинга PAUSE_UNTIL: %v, рассылка не приостановлена", err)
+	} else if until.After(p.clock.Now()) {
+		p.paused, p.until = true, until
+		log.Printf("Рассылка приостановлена %s", p.describe())
+	}
+	p.persist()
+}
+
+// Загрузка состояния, измененного другим экземпляром сервиса: /api/pause может прийти
+// на любой из них. Вызывается с захваченной блокировкой.
+func (p *PauseControl) refresh() {
+	data, err := p.store.read(statePause, p.file)
+	if err != nil {
+		logErrorf("Ошибка при чтении состояния приостановки: %v", err)
+		return
+	}
+	if data == nil {
+		return
+	}
+	var state pauseState
+	if err := json.Unmarshal(data, &state); err != nil {
+		logErrorf("Ошибка при разборе состояния приостановки: %v", err)
+		return
+	}
+	p.paused, p.until, p.configUntil = state.Paused, state.Until, state.ConfigUntil
+}
+
+// Сохранение состояния с журналированием ошибки; вызывается с захваченной блокировкой
+func (p *PauseControl) persist() {
+	if !p.store.persistent(p.file) {
+		return
+	}
+	data, err := json.MarshalIndent(pauseState{Paused: p.paused, Until: p.until, ConfigUntil: p.configUntil}, "", "  ")
+	if err == nil {
+		err = p.store.write(statePause, p.file, data)
+	}
+	if err != nil {
+		logErrorf("Ошибка при сохранении состояния приостановки: %v", err)
+	}
+}
+
+// Разбор даты возобновления: "2006-01-02" (с начала дня) или "2006-01-02 15:04"
+func parsePauseUntil(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("ожидается дата ГГГГ-ММ-ДД или ГГГГ-ММ-ДД ЧЧ:ММ, получено %q", value)
+}
+
+// Проверка, приостановлена ли рассылка; по наступлении даты возобновления пауза снимается
+func (p *PauseControl) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.store.shared() {
+		p.refresh()
+	}
+	p.applyConfig()
+	if p.paused && !p.until.IsZero() && !p.clock.Now().Before(p.until) {
+		p.paused, p.until = false, time.Time{}
+		log.Println("Срок приостановки истек, рассылка возобновлена")
+		p.persist()
+	}
+	return p.paused
+}
+
+// Приостановка рассылки до указанного времени (нулевое - до ручного возобновления)
+func (p *PauseControl) Pause(until time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.paused, p.until = true, until
+	p.persist()
+	log.Printf("Рассылка приостановлена %s", p.describe())
+}
+
+// Возобновление рассылки
+func (p *PauseControl) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.paused, p.until = false, time.Time{}
+	p.persist()
+	log.Println("Рассылка возобновлена")
+}
+
+// Текущее состояние приостановки
+func (p *PauseControl) Status() PauseStatus {
+	paused := p.Paused()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := PauseStatus{Paused: paused}
+	if paused && !p.until.IsZero() {
+		until := p.until
+		status.Until = &until
+	}
+	return status
+}
+
+// Переключение приостановки по сигналу SIGUSR1; завершается при отмене ctx
+func (p *PauseControl) handleSignals(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-signals:
+			if p.Paused() {
+				p.Resume()
+			} else {
+				p.Pause(time.Time{})
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *PauseControl) registerRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/pause", p.handlePause)
+}
+
+// GET /api/pause - состояние, POST /api/pause - приостановка (тело {"until": "2006-01-02"} необязательно),
+// DELETE /api/pause - возобновление
+func (p *PauseControl) handlePause(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var body struct {
+			Until string `json:"until"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeError(w, http.StatusBadRequest, "некорректный JSON: "+err.Error())
+				return
+			}
+		}
+
+		var until time.Time
+		if body.Until != "" {
+			var err error
+			if until, err = parsePauseUntil(body.Until, p.clock.Location()); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			if !until.After(p.clock.Now()) {
+				writeError(w, http.StatusBadRequest, "дата возобновления уже наступила")
+				return
+			}
+		}
+		p.Pause(until)
+	case http.MethodDelete:
+		p.Resume()
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p.Status())
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+)
+
+// Предварительная проверка при запуске (PREFLIGHT=true): ключ OpenWeatherMap проверяется
+// запросом к Geocoding API, SMTP-сервер - подключением и аутентификацией без отправки письма.
+// Ошибки учетных данных обнаруживаются сразу, а не во время плановой рассылки.
+func runPreflight(ctx context.Context, config *Config) error {
+	var errs []error
+	if err := preflightOpenWeather(ctx, config); err != nil {
+		errs = append(errs, err)
+	} else {
+		log.Println("Предварительная проверка: ключ OpenWeatherMap принят")
+	}
+
+	// В пробном запуске письма не отправляются, поэтому SMTP не проверяется
+	if config.DryRun {
+		return errors.Join(errs...)
+	}
+	if err := preflightSMTP(ctx, config); err != nil {
+		errs = append(errs, err)
+	} else {
+		log.Printf("Предварительная проверка: вход на SMTP-сервер %s:%s выполнен", config.SMTPServer, config.SMTPPort)
+	}
+	return errors.Join(errs...)
+}
+
+// Проверка ключа OpenWeatherMap: запрос координат города или названия места по координатам
+func preflightOpenWeather(ctx context.Context, config *Config) error {
+	query := url.Values{"limit": {"1"}, "appid": {config.OpenWeatherAPIKey}}
+	endpoint := "https://api.openweathermap.org/geo/1.0/direct?"
+	if config.Coords != nil {
+		endpoint = "https://api.openweathermap.org/geo/1.0/reverse?"
+		query.Set("lat", fmt.Sprintf("%.4f", config.Coords.Lat))
+		query.Set("lon", fmt.Sprintf("%.4f", config.Coords.Lon))
+	} else {
+		query.Set("q", config.City)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("OpenWeatherMap: ошибка при создании запроса: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// Адрес запроса содержит ключ, поэтому в сообщение попадает только причина ошибки
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("OpenWeatherMap: сервер недоступен: %w. Проверьте доступ к api.openweathermap.org (DNS, прокси, межсетевой экран)", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return errors.New("OPENWEATHER_API_KEY: ключ отклонен OpenWeatherMap (401). Проверьте ключ в личном кабинете; новый ключ активируется в течение нескольких часов после регистрации")
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return errors.New("OPENWEATHER_API_KEY: превышен лимит запросов тарифа (429). Уменьшите частоту проверок или смените тариф")
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("OpenWeatherMap: неожиданный статус %d", resp.StatusCode)
+	}
+
+	if config.Coords == nil {
+		var locations []GeoLocation
+		if err := json.NewDecoder(resp.Body).Decode(&locations); err != nil {
+			return fmt.Errorf("OpenWeatherMap: ошибка при разборе ответа: %w", err)
+		}
+		if len(locations) == 0 {
+			return fmt.Errorf("CITY: город %q не найден OpenWeatherMap. Укажите город в формате Город,Код_страны или координаты в LOCATIONS_FILE", config.City)
+		}
+	}
+	return nil
+}
+
+// Проверка SMTP: подключение, STARTTLS и аутентификация без отправки письма
+func preflightSMTP(ctx context.Context, config *Config) error {
+	client, err := newSMTPClient(config)
+	if err != nil {
+		return fmt.Errorf("SMTP: %w", err)
+	}
+
+	if err := client.DialWithContext(ctx); err != nil {
+		var protoErr *textproto.Error
+		var netErr net.Error
+		switch {
+		case errors.As(err, &protoErr) && (protoErr.Code == 535 || protoErr.Code == 534 || protoErr.Code == 530):
+			return fmt.Errorf("SMTP_USER/SMTP_PASSWORD: сервер %s отклонил вход пользователя %s: %w. Проверьте имя пользователя и пароль", config.SMTPServer, config.SMTPUser, err)
+		case errors.As(err, &netErr):
+			return fmt.Errorf("SMTP_SERVER/SMTP_PORT: не удалось подключиться к %s:%s: %w. Проверьте адрес, порт и доступ к сети", config.SMTPServer, config.SMTPPort, err)
+		default:
+			return fmt.Errorf("SMTP: ошибка при подключении к %s:%s: %w", config.SMTPServer, config.SMTPPort, err)
+		}
+	}
+	if err := client.Close(); err != nil {
+		log.Printf("Предварительная проверка: ошибка при закрытии соединения SMTP: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+)
+
+// Настройки вечернего предварительного прогноза на завтра
+type PreviewConfig struct {
+	Enabled bool
+	Hour    int      // Час отправки в часовом поясе города
+	Minute  int      // Минуты отправки
+	EmailTo []string // Получатели (по умолчанию EMAIL_TO)
+}
+
+// Загрузка настроек предварительного прогноза из переменных окружения
+func loadPreviewConfig() PreviewConfig {
+	cfg := PreviewConfig{
+		EmailTo: parseEmailList(os.Getenv("PREVIEW_EMAIL_TO")),
+	}
+
+	if envTime := os.Getenv("PREVIEW_TIME"); envTime != "" {
+		if minutes, err := parseClock(envTime); err == nil {
+			cfg.Enabled = true
+			cfg.Hour, cfg.Minute = minutes/60, minutes%60
+		} else {
+			logWarnf("Ошибка парсинга PREVIEW_TIME: %v, предварительный прогноз отключен", err)
+		}
+	}
+
+	return cfg
+}
+
+// Структура данных для шаблона письма с прогнозом на завтра
+type PreviewEmailData struct {
+	Date              string
+	Severity          Severity
+	MaxWindGust       float64
+	WindGustThreshold float64
+	Forecasts         []WindGustForecast
+}
+
+// Шаблон для HTML письма с прогнозом на завтра
+const previewEmailHTMLTemplateText = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Прогноз на завтра</title>
+</head>
+<body style="margin: 0; padding: 0; background-color: #f4f4f4; font-family: Arial, sans-serif;">
+    <table border="0" cellpadding="0" cellspacing="0" width="100%" bgcolor="#f4f4f4" style="background-color: #f4f4f4;">
+        <tr>
+            <td align="center" style="padding: 20px 0;">
+                <table border="0" cellpadding="0" cellspacing="0" width="600" style="background-color: #ffffff; border-radius: 8px; max-width: 600px; width: 100%;">
+                    <tr>
+                        <td style="padding: 20px;">
+                            <h1 style="color: {{.Severity.Color}}; font-size: 24px; text-align: center; margin-top: 0; margin-bottom: 20px;">Завтра, {{.Date}}, ожидается сильный ветер</h1>
+                            <p style="font-size: 16px; line-height: 1.5; color: #333333;">По предварительному прогнозу порывы ветра достигнут <b style="color: {{.Severity.Color}};">{{speed .MaxWindGust 2}}</b> при безопасном пороге {{speed .WindGustThreshold 2}} (уровень опасности: {{.Severity.Title}}).</p>
+                            <ul style="font-size: 16px; line-height: 1.5; color: #333333;">
+                                {{range .Forecasts}}<li>{{.Time.Format "15:04"}}: {{speed .WindGust 2}}</li>
+                                {{end}}
+                            </ul>
+                            <p style="font-size: 16px; line-height: 1.5; color: #333333;">Утром прогноз будет уточнен и при необходимости придет предупреждение.</p>
+                            <p style="font-size: 14px; line-height: 1.5; color: #777777; text-align: center;">Это автоматическое уведомление от системы мониторинга погоды.</p>
+                        </td>
+                    </tr>
+                </table>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>`
+
+// Шаблон для текстового письма с прогнозом на завтра
+const previewEmailPlainTextTemplate = `Завтра, {{.Date}}, ожидается сильный ветер
+
+По предварительному прогнозу порывы ветра достигнут {{speed .MaxWindGust 2}} при безопасном пороге {{speed .WindGustThreshold 2}} (уровень опасности: {{.Severity.Title}}).
+{{range .Forecasts}}
+- {{.Time.Format "15:04"}}: {{speed .WindGust 2}}{{end}}
+
+Утром прогноз будет уточнен и при необходимости придет предупреждение.
+
+Это автоматическое уведомление от системы мониторинга погоды.`
+
+// Ежедневный вечерний запуск предварительного прогноза
+func runPreviewSchedule(ctx context.Context, store *ConfigStore, pause *PauseControl, state StateStore) {
+	initial := store.Load()
+	for waitForDailyTime(ctx, initial.Clock, initial.Preview.Hour, initial.Preview.Minute, "проверка прогноза на завтра") {
+		config := store.Load()
+		if blackedOut(config, "проверка прогноза на завтра") || standby(state, "проверка прогноза на завтра") {
+			continue
+		}
+		if pause.Paused() {
+			log.Println("Рассылка приостановлена, проверка прогноза на завтра пропущена")
+			continue
+		}
+		checkTomorrowAndNotify(config)
+	}
+}
+
+// Проверка прогноза на завтра и отправка предварительного предупреждения
+func checkTomorrowAndNotify(config *Config) {
+	log.Println("Запуск проверки прогноза на завтра...")
+
+	weatherData, err := getWeatherData(config)
+	if err != nil {
+		logErrorf("Ошибка при получении данных о погоде: %v\n", err)
+		return
+	}
+
+	points := forecastPointsForTheDay(weatherData, config.CheckWindow, 1)
+	if len(points) == 0 {
+		log.Println("Нет данных о погоде на завтра в ответе API")
+		return
+	}
+
+	logForecastPoints(points)
+	exceedsThreshold, forecasts := checkWeatherForTheDay(points, config.WindGustThreshold)
+	if !exceedsThreshold {
+		log.Println("Порывы ветра завтра в норме, предварительное предупреждение не требуется")
+		return
+	}
+
+	maxWindGust := findMaxWindGust(points)
+	data := PreviewEmailData{
+		Date:              points[0].Time.Format("02.01.2006"),
+		Severity:          severityFor(maxWindGust, config.WindGustThreshold, config.Severity),
+		MaxWindGust:       maxWindGust,
+		WindGustThreshold: config.WindGustThreshold,
+		Forecasts:         forecasts,
+	}
+
+	htmlBody, plainTextBody, err := renderEmailBodies(previewEmailHTMLTemplateText, previewEmailPlainTextTemplate, data, speedFuncs(config.Units, languageRU))
+	if err != nil {
+		logErrorf("Ошибка при формировании письма: %v\n", err)
+		return
+	}
+
+	recipients := config.Preview.EmailTo
+	if len(recipients) == 0 {
+		recipients = config.EmailTo
+	}
+
+	subject := "Прогноз: завтра сильный ветер"
+	if err := sendEmailTo(config, recipients, subject, htmlBody, plainTextBody); err != nil {
+		logErrorf("Ошибка при отправке прогноза на завтра: %v\n", err)
+	} else {
+		log.Println("Прогноз на завтра успешно отправлен")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Разделитель имени профиля и имени переменной: DACHA__CITY задает CITY для профиля dacha
+const profileSeparator = "__"
+
+// Подставленные из профиля значения; при перезагрузке конфигурации профиль применяется заново
+var profileOverrides = map[string]envOverride{}
+
+// Переменные, заданные флагами командной строки: профиль их не переопределяет
+var flagVars = map[string]bool{}
+
+// Применение именованного профиля PROFILE: для каждой переменной конфигурации значение
+// <ПРОФИЛЬ>__<ИМЯ> заменяет общее значение. Так один файл .env описывает несколько
+// сценариев (office, dacha, marina) со своими пунктами, правилами и получателями.
+func applyProfile() error {
+	// Значения прежнего профиля сбрасываются, если их не обновила перезагрузка .env
+	restoreEnv(profileOverrides)
+
+	profile := strings.TrimSpace(os.Getenv("PROFILE"))
+	if profile == "" {
+		return nil
+	}
+
+	prefix := profilePrefix(profile)
+	applied := 0
+	for _, name := range configEnvVars {
+		value, ok := os.LookupEnv(prefix + name)
+		if !ok {
+			continue
+		}
+		applied++
+		if flagVars[name] {
+			continue
+		}
+		overrideEnv(profileOverrides, name, value)
+	}
+	if applied == 0 && !profileFileExists(profile) {
+		return fmt.Errorf("профиль %s не найден: нет переменных %s<ИМЯ>", profile, prefix)
+	}
+
+	log.Printf("Используется профиль %s (переопределено параметров: %d)", profile, applied)
+	return nil
+}
+
+// Префикс переменных профиля
+func profilePrefix(profile string) string {
+	return strings.ToUpper(strings.ReplaceAll(profile, "-", "_")) + profileSeparator
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+// Адрес Pushbullet API для отправки push-уведомлений
+const pushbulletPushesURL = "https://api.pushbullet.com/v2/pushes"
+
+// Настройки отправки push-уведомлений через Pushbullet
+type PushbulletConfig struct {
+	AccessToken string   // Токен доступа из настроек учетной записи
+	Devices     []string // Идентификаторы устройств (device_iden)
+	Channel     string   // Тег канала для рассылки подписчикам
+}
+
+// Загрузка настроек Pushbullet из переменных окружения
+func loadPushbulletConfig() PushbulletConfig {
+	return PushbulletConfig{
+		AccessToken: os.Getenv("PUSHBULLET_ACCESS_TOKEN"),
+		Devices:     parseList(os.Getenv("PUSHBULLET_DEVICES")),
+		Channel:     os.Getenv("PUSHBULLET_CHANNEL"),
+	}
+}
+
+// Отправка предупреждения push-уведомлением Pushbullet
+type pushbulletNotifier struct {
+	config PushbulletConfig
+}
+
+func newPushbulletNotifier(config PushbulletConfig) *pushbulletNotifier {
+	return &pushbulletNotifier{config: config}
+}
+
+func (n *pushbulletNotifier) Name() string {
+	return "pushbullet"
+}
+
+// Push-уведомление: note - текст, link - текст со ссылкой подтверждения
+type pushbulletPush struct {
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Body       string `json:"body"`
+	URL        string `json:"url,omitempty"`
+	DeviceIden string `json:"device_iden,omitempty"`
+	ChannelTag string `json:"channel_tag,omitempty"`
+}
+
+func (n *pushbulletNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	base := pushbulletPush{
+		Type:  "note",
+		Title: report.tr("⚠️ Сильный ветер ", "⚠️ Strong wind ") + report.periodTitle(),
+		Body:  report.text(formatAlertText(report)),
+	}
+	if report.AckURL != "" {
+		base.Type = "link"
+		base.URL = report.AckURL
+	}
+
+	// Без указания устройств и канала уведомление приходит на все устройства учетной записи
+	var targets []pushbulletPush
+	for _, device := range n.config.Devices {
+		push := base
+		push.DeviceIden = device
+		targets = append(targets, push)
+	}
+	if n.config.Channel != "" {
+		push := base
+		push.ChannelTag = n.config.Channel
+		targets = append(targets, push)
+	}
+	if len(targets) == 0 {
+		targets = append(targets, base)
+	}
+
+	headers := map[string]string{"Access-Token": n.config.AccessToken}
+
+	var failed int
+	for _, push := range targets {
+		if _, err := sendJSON(ctx, http.MethodPost, pushbulletPushesURL, headers, push); err != nil {
+			logErrorf("Ошибка при отправке уведомления Pushbullet: %v", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("не доставлено уведомлений Pushbullet: %d из %d", failed, len(targets))
+	}
+
+	log.Printf("Предупреждение отправлено в Pushbullet (%d получателей)", len(targets))
+	return nil
+}
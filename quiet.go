@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Период тишины канала в минутах от начала суток; может переходить через полночь
+type quietWindow struct {
+	start int
+	end   int
+}
+
+// Настройки периодов тишины: канал -> период, в который уведомления откладываются
+type QuietHoursConfig map[string]quietWindow
+
+// Загрузка периодов тишины из переменной QUIET_HOURS
+// в формате "sms=22:00-07:00;call=23:00-06:00"
+func loadQuietHoursConfig() QuietHoursConfig {
+	envQuiet := os.Getenv("QUIET_HOURS")
+	if envQuiet == "" {
+		return nil
+	}
+
+	cfg, err := parseQuietHours(envQuiet)
+	if err != nil {
+		logWarnf("Ошибка парсинга QUIET_HOURS: %v, периоды тишины отключены", err)
+		return nil
+	}
+	return cfg
+}
+
+// Разбор периодов тишины
+func parseQuietHours(value string) (QuietHoursConfig, error) {
+	cfg := QuietHoursConfig{}
+	for _, rule := range strings.Split(value, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		channel, window, ok := strings.Cut(rule, "=")
+		if !ok {
+			return nil, fmt.Errorf("ожидается формат канал=ЧЧ:ММ-ЧЧ:ММ, получено %q", rule)
+		}
+		from, to, ok := strings.Cut(window, "-")
+		if !ok {
+			return nil, fmt.Errorf("ожидается период ЧЧ:ММ-ЧЧ:ММ, получено %q", window)
+		}
+
+		start, err := parseClock(from)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(to)
+		if err != nil {
+			return nil, err
+		}
+
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		if channel == "email" {
+			log.Printf("Предупреждение: период тишины для канала email игнорируется")
+			continue
+		}
+		cfg[channel] = quietWindow{start: start, end: end}
+	}
+	return cfg, nil
+}
+
+// Разбор времени ЧЧ:ММ в минуты от начала суток
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("некорректное время %q", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Попадает ли момент в период тишины
+func (w quietWindow) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if w.start <= w.end {
+		return minute >= w.start && minute < w.end
+	}
+	return minute >= w.start || minute < w.end
+}
+
+// Окончание периода тишины, в который попадает момент
+func (w quietWindow) endAfter(t time.Time) time.Time {
+	end := time.Date(t.Year(), t.Month(), t.Day(), w.end/60, w.end%60, 0, 0, t.Location())
+	if !end.After(t) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+// Время, до которого откладывается уведомление канала; false - канал доступен сейчас
+func (c QuietHoursConfig) deferUntil(channel string, t time.Time) (time.Time, bool) {
+	w, ok := c[channel]
+	if !ok || !w.contains(t) {
+		return time.Time{}, false
+	}
+	return w.endAfter(t), true
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Отдельное время доставки письма для группы получателей
+type deliverySlot struct {
+	Hour       int
+	Minute     int
+	Recipients []string
+}
+
+// Загрузка времени доставки по получателям из RECIPIENT_TIMES
+// в формате "shift@corp.ru=06:00;office@corp.ru=09:00"
+func loadRecipientSlots() []deliverySlot {
+	envTimes := os.Getenv("RECIPIENT_TIMES")
+	if envTimes == "" {
+		return nil
+	}
+
+	slots, err := parseRecipientSlots(envTimes)
+	if err != nil {
+		logWarnf("Ошибка парсинга RECIPIENT_TIMES: %v, письма отправляются всем получателям в общее время", err)
+		return nil
+	}
+	return slots
+}
+
+// Разбор времени доставки; получатели с одинаковым временем объединяются в одну рассылку
+func parseRecipientSlots(value string) ([]deliverySlot, error) {
+	byTime := map[int][]string{}
+	for _, rule := range parseList(value) {
+		recipient, clock, ok := strings.Cut(rule, "=")
+		if !ok {
+			return nil, fmt.Errorf("ожидается формат адрес=ЧЧ:ММ, получено %q", rule)
+		}
+		minutes, err := parseClock(clock)
+		if err != nil {
+			return nil, err
+		}
+		byTime[minutes] = append(byTime[minutes], strings.TrimSpace(recipient))
+	}
+
+	var slots []deliverySlot
+	for minutes, recipients := range byTime {
+		slots = append(slots, deliverySlot{Hour: minutes / 60, Minute: minutes % 60, Recipients: recipients})
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].Hour*60+slots[i].Minute < slots[j].Hour*60+slots[j].Minute
+	})
+	return slots, nil
+}
+
+// Получатели общей рассылки: EMAIL_TO без адресов, для которых задано свое время
+func (c *Config) defaultRecipients() []string {
+	if len(c.RecipientSlots) == 0 {
+		return c.EmailTo
+	}
+
+	own := map[string]bool{}
+	for _, slot := range c.RecipientSlots {
+		for _, recipient := range slot.Recipients {
+			own[strings.ToLower(recipient)] = true
+		}
+	}
+
+	var recipients []string
+	for _, recipient := range c.EmailTo {
+		if !own[strings.ToLower(recipient)] {
+			recipients = append(recipients, recipient)
+		}
+	}
+	return recipients
+}
+
+// Ежедневная проверка и отправка письма группе получателей в ее время; завершается при отмене ctx
+func runRecipientSchedule(ctx context.Context, store *ConfigStore, dispatcher *Dispatcher, slot deliverySlot) {
+	name := fmt.Sprintf("рассылка для %s", strings.Join(slot.Recipients, ", "))
+	for waitForDailyTime(ctx, store.Load().Clock, slot.Hour, slot.Minute, name) {
+		config := store.Load()
+		if blackedOut(config, name) || standby(dispatcher.store, name) {
+			continue
+		}
+		if dispatcher.pause.Paused() {
+			log.Printf("Рассылка приостановлена, %s пропущена", name)
+			continue
+		}
+
+		report := evaluateWeather(config)
+		if report == nil {
+			continue
+		}
+		if !report.ExceedsThreshold {
+			log.Printf("Порывы ветра в норме, %s не требуется", name)
+			continue
+		}
+
+		report.Recipients = slot.Recipients
+		report.NextCheck = nextDailyTime(config.Clock.Now(), slot.Hour, slot.Minute)
+		key := alertStateKey(report) + "|" + strings.ToLower(strings.Join(slot.Recipients, ","))
+		if _, dup := dispatcher.duplicate(report, key); dup {
+			log.Printf("Предупреждение уже отправлено сегодня, %s не требуется", name)
+			continue
+		}
+		dispatcher.deliverTo("email", report)
+		dispatcher.markSent(report, key)
+	}
+}
+
+// Каналы, которые получатель может выбрать в RECIPIENTS_FILE
+var recipientChannels = []string{"email", "sms", "call"}
+
+// Настройки получателя: язык, единицы, личный порог и предпочитаемые каналы
+type Recipient struct {
+	Email     string   `json:"email"`
+	Name      string   `json:"name,omitempty"`      // Имя для обращения в письме
+	Language  string   `json:"language,omitempty"`  // Язык письма: ru или en (по умолчанию LANGUAGE)
+	Units     string   `json:"units,omitempty"`     // Единицы скорости: ms, kmh, mph, knots, bft (по умолчанию UNITS)
+	Threshold float64  `json:"threshold,omitempty"` // Личный порог порывов ветра (в единицах UNITS, после загрузки - в м/с)
+	Channels  []string `json:"channels,omitempty"`  // Каналы: email (по умолчанию), sms, call
+	Phone     string   `json:"phone,omitempty"`     // Номер для sms и call
+}
+
+// Загрузка настроек получателей из JSON-файла RECIPIENTS_FILE
+func loadRecipientProfiles() ([]Recipient, error) {
+	return readRecipientProfiles(os.Getenv("RECIPIENTS_FILE"))
+}
+
+// Чтение и проверка файла получателей; пустой путь - настроек получателей нет
+func readRecipientProfiles(path string) ([]Recipient, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении файла получателей: %w", err)
+	}
+	var profiles []Recipient
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе файла получателей: %w", err)
+	}
+
+	for i := range profiles {
+		if err := profiles[i].normalize(); err != nil {
+			return nil, fmt.Errorf("получатель %d: %w", i+1, err)
+		}
+	}
+	return profiles, nil
+}
+
+// Проверка настроек получателя и приведение языка, единиц и каналов к каноническому виду
+func (p *Recipient) normalize() error {
+	if p.Email == "" && p.Phone == "" {
+		return fmt.Errorf("не указан адрес или номер телефона")
+	}
+	if p.Language != "" {
+		language, err := parseLanguage(p.Language)
+		if err != nil {
+			return err
+		}
+		p.Language = language
+	}
+	if p.Units != "" {
+		units, err := parseUnits(p.Units)
+		if err != nil {
+			return err
+		}
+		p.Units = units
+	}
+	for j, channel := range p.Channels {
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		if !slices.Contains(recipientChannels, channel) {
+			return fmt.Errorf("неизвестный канал %q (ожидается email, sms или call)", channel)
+		}
+		if channel != "email" && p.Phone == "" {
+			return fmt.Errorf("для канала %s не указан номер телефона", channel)
+		}
+		if channel == "email" && p.Email == "" {
+			return fmt.Errorf("для канала email не указан адрес")
+		}
+		p.Channels[j] = channel
+	}
+	return nil
+}
+
+// Выбран ли получателем канал; без списка каналов используется электронная почта
+func (r Recipient) wants(channel string) bool {
+	if len(r.Channels) == 0 {
+		return channel == "email" && r.Email != ""
+	}
+	return slices.Contains(r.Channels, channel)
+}
+
+// Настройки получателя по адресу; для адресов без настроек - значения по умолчанию
+func (c *Config) recipientProfile(email string) Recipient {
+	for _, profile := range c.RecipientProfiles {
+		if strings.EqualFold(profile.Email, email) {
+			return profile
+		}
+	}
+	return Recipient{Email: email, Language: c.Language, Units: c.Units}
+}
+
+// Есть ли получатели с личным порогом ниже общего: им письмо нужно и без превышения общего порога
+func (c *Config) lowerPersonalThreshold(threshold float64) bool {
+	for _, profile := range c.RecipientProfiles {
+		if profile.Threshold > 0 && profile.Threshold < threshold && profile.wants("email") {
+			return true
+		}
+	}
+	return false
+}
+
+// Результат проверки с учетом личного порога получателя; nil, если для него порог не превышен.
+// В сводном предупреждении по нескольким пунктам действуют пороги пунктов.
+func (r Recipient) personalize(report *AlertReport) *AlertReport {
+	if r.Threshold <= 0 || r.Threshold == report.WindGustThreshold || len(report.Locations) > 0 || len(report.Points) == 0 {
+		if !report.ExceedsThreshold {
+			return nil
+		}
+		return report
+	}
+
+	// Точки прогноза уже записаны в журнал при проверке, повторно они не выводятся
+	forecasts := gustsAbove(report.Points, r.Threshold)
+	if len(forecasts) == 0 {
+		return nil
+	}
+	personal := *report
+	personal.ExceedsThreshold = true
+	personal.WindGustThreshold = r.Threshold
+	personal.Forecasts = forecasts
+	return &personal
+}
+
+// Получатели с одинаковыми настройками письма
+type recipientGroup struct {
+	profile Recipient
+	emails  []string
+}
+
+// Разбиение адресов на группы с одинаковым письмом; получатели, отказавшиеся
+// от электронной почты, пропускаются, письма с обращением по имени отправляются отдельно
+func (c *Config) recipientGroups(emails []string) []recipientGroup {
+	var groups []recipientGroup
+	index := map[string]int{}
+	for _, email := range emails {
+		profile := c.recipientProfile(email)
+		if !profile.wants("email") {
+			continue
+		}
+
+		key := fmt.Sprintf("%s|%s|%g", profile.Language, profile.Units, profile.Threshold)
+		if profile.Name != "" {
+			key += "|" + strings.ToLower(email)
+		}
+		if i, ok := index[key]; ok {
+			groups[i].emails = append(groups[i].emails, email)
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, recipientGroup{profile: profile, emails: []string{email}})
+	}
+	return groups
+}
+
+// Адреса для общей рассылки по умолчанию из настроек получателей, выбравших электронную почту
+func recipientEmails(profiles []Recipient) []string {
+	var emails []string
+	for _, profile := range profiles {
+		if profile.wants("email") {
+			emails = append(emails, profile.Email)
+		}
+	}
+	return emails
+}
+
+// Номера получателей, выбравших канал sms или call
+func recipientPhones(profiles []Recipient, channel string) []string {
+	var phones []string
+	for _, profile := range profiles {
+		if profile.wants(channel) {
+			phones = append(phones, profile.Phone)
+		}
+	}
+	return phones
+}
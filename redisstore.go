@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Продление права рассылки, только если ключ все еще принадлежит этому экземпляру
+var redisRenewLeader = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// Освобождение права рассылки при остановке, чтобы другой экземпляр не ждал истечения срока
+var redisReleaseLeader = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Сервер Redis: состояние, общее для нескольких экземпляров сервиса, и выбор экземпляра,
+// который рассылает уведомления. Право рассылки - ключ <REDIS_PREFIX>leader с идентификатором
+// экземпляра и сроком REDIS_LEADER_TTL, который владелец продлевает каждую треть срока.
+type redisStateStore struct {
+	client *redis.Client
+	config StoreConfig
+	id     string // Идентификатор экземпляра: имя хоста и номер процесса
+
+	leader    atomic.Bool
+	mu        sync.Mutex
+	callbacks []func()
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Подключение к Redis и первая попытка получить право рассылки
+func openRedisStateStore(config StoreConfig) (StateStore, error) {
+	if config.RedisURL == "" {
+		return nil, fmt.Errorf("не указан REDIS_URL для STORE_BACKEND=redis")
+	}
+	options, err := redis.ParseURL(config.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка в REDIS_URL: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	s := &redisStateStore{
+		client: redis.NewClient(options),
+		config: config,
+		id:     fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		done:   make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		s.client.Close()
+		return nil, fmt.Errorf("ошибка при подключении к Redis: %w", err)
+	}
+	log.Printf("Состояние сервиса хранится в Redis %s (экземпляр %s)", redactedRedisURL(config.RedisURL), s.id)
+
+	s.campaign(context.Background())
+	electCtx, stop := context.WithCancel(context.Background())
+	s.cancel = stop
+	go s.elect(electCtx)
+	return s, nil
+}
+
+// Адрес Redis без пароля для журнала
+func redactedRedisURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "Redis"
+	}
+	return u.Redacted()
+}
+
+func (s *redisStateStore) key(name string) string {
+	return s.config.RedisPrefix + name
+}
+
+func (s *redisStateStore) read(key, file string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	data, err := s.client.Get(ctx, s.key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (s *redisStateStore) write(key, file string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return s.client.Set(ctx, s.key(key), data, 0).Err()
+}
+
+func (s *redisStateStore) persistent(file string) bool {
+	return true
+}
+
+func (s *redisStateStore) shared() bool {
+	return true
+}
+
+func (s *redisStateStore) leading() bool {
+	return s.leader.Load()
+}
+
+func (s *redisStateStore) onLeading(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.callbacks = append(s.callbacks, fn)
+}
+
+// Получение или продление права рассылки каждую треть REDIS_LEADER_TTL; завершается при отмене ctx
+func (s *redisStateStore) elect(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.LeaderTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.campaign(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Попытка получить право рассылки или продлить его. При ошибке Redis экземпляр
+// отказывается от рассылки: иначе после истечения срока уведомления могли бы
+// отправлять два экземпляра одновременно.
+func (s *redisStateStore) campaign(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.LeaderTTL/3)
+	defer cancel()
+
+	key := s.key("leader")
+	var leading bool
+	var err error
+	if s.leader.Load() {
+		var renewed int64
+		renewed, err = redisRenewLeader.Run(ctx, s.client, []string{key}, s.id, s.config.LeaderTTL.Milliseconds()).Int64()
+		leading = err == nil && renewed == 1
+	} else {
+		leading, err = s.client.SetNX(ctx, key, s.id, s.config.LeaderTTL).Result()
+	}
+	if err != nil && ctx.Err() == nil {
+		logErrorf("Ошибка при выборе экземпляра для рассылки: %v", err)
+	}
+
+	switch was := s.leader.Swap(leading); {
+	case leading && !was:
+		log.Printf("Экземпляр %s рассылает уведомления", s.id)
+		s.mu.Lock()
+		callbacks := append([]func(){}, s.callbacks...)
+		s.mu.Unlock()
+		for _, fn := range callbacks {
+			fn()
+		}
+	case !leading && was:
+		log.Printf("Экземпляр %s больше не рассылает уведомления", s.id)
+	}
+}
+
+// Остановка выбора и освобождение права рассылки
+func (s *redisStateStore) Close() error {
+	s.cancel()
+	<-s.done
+
+	if s.leader.Swap(false) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := redisReleaseLeader.Run(ctx, s.client, []string{s.key("leader")}, s.id).Err(); err != nil {
+			logErrorf("Ошибка при освобождении права рассылки: %v", err)
+		}
+		cancel()
+	}
+	return s.client.Close()
+}
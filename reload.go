@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Интервал проверки времени изменения локального файла конфигурации
+const envFileCheckInterval = 5 * time.Second
+
+// Активная конфигурация с атомарной заменой при перезагрузке. Плановые запуски
+// берут конфигурацию из хранилища перед каждой проверкой, поэтому новый порог
+// и получатели действуют уже со следующего запуска.
+type ConfigStore struct {
+	current atomic.Pointer[Config]
+
+	mu        sync.Mutex           // Перезагрузки выполняются по одной
+	paths     []string             // Файлы конфигурации (CONFIG_FILE): локальные пути или адреса HTTP(S)
+	protected map[string]bool      // Переменные окружения процесса и флаги: файлы конфигурации их не переопределяют
+	fromFile  map[string]bool      // Переменные, заданные из файлов конфигурации
+	modTimes  map[string]time.Time // Время изменения локальных файлов при последней загрузке
+
+	base          *Config        // Загруженная конфигурация без изменений получателей через /api/recipients
+	subscriptions *Subscriptions // Получатели, добавленные и измененные через API
+}
+
+// Создание хранилища; вызывается до loadConfig, чтобы запомнить переменные окружения процесса
+func newConfigStore() *ConfigStore {
+	s := &ConfigStore{paths: configFilePaths(), protected: map[string]bool{}, fromFile: map[string]bool{}}
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		s.protected[name] = true
+	}
+	if values, _, err := readConfigFiles(s.paths); err == nil {
+		for name := range values {
+			if !s.protected[name] {
+				s.fromFile[name] = true
+			}
+		}
+	}
+	s.modTimes = s.fileModTimes()
+	return s
+}
+
+// Текущая конфигурация
+func (s *ConfigStore) Load() *Config {
+	return s.current.Load()
+}
+
+// Установка начальной конфигурации
+func (s *ConfigStore) Store(config *Config) {
+	s.base = config
+	s.current.Store(s.subscriptions.apply(config))
+	setLogLevel(config.LogLevel)
+}
+
+// Подключение подписок после открытия хранилища состояния
+func (s *ConfigStore) attachSubscriptions(subscriptions *Subscriptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscriptions = subscriptions
+	s.current.Store(subscriptions.apply(s.base))
+}
+
+// Применение изменившихся подписок к загруженной конфигурации
+func (s *ConfigStore) applySubscriptions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current.Store(s.subscriptions.apply(s.base))
+}
+
+// Перезагрузка конфигурации: файлы конфигурации перечитываются, новая конфигурация проверяется
+// и заменяет текущую только при успешной загрузке
+func (s *ConfigStore) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, _, err := readConfigFiles(s.paths)
+	if err != nil {
+		return err
+	}
+	s.modTimes = s.fileModTimes()
+
+	// Удаленные из файла переменные сбрасываются, измененные обновляются
+	for name := range s.fromFile {
+		if _, ok := values[name]; !ok {
+			os.Unsetenv(name)
+			delete(s.fromFile, name)
+		}
+	}
+	for name, value := range values {
+		if s.protected[name] {
+			continue
+		}
+		os.Setenv(name, value)
+		s.fromFile[name] = true
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	old := s.Load()
+	restart := restartRequired(old, config)
+	// Часовой пояс уже определен при запуске и используется всеми компонентами
+	config.Clock = old.Clock
+	for i := range config.Locations.List {
+		loc := &config.Locations.List[i]
+		for _, prev := range old.Locations.List {
+			// Часовой пояс пункта, определенный по прогнозу, сохраняется, если он не задан в файле заново
+			if prev.title() == loc.title() && prev.Timezone == loc.Timezone {
+				loc.clock = prev.clock
+			}
+		}
+	}
+	// Учет точности прогноза продолжается с данными и настройками, загруженными при запуске
+	config.Accuracy = old.Accuracy
+	// Сохраненные прогнозы остаются действительными; новый FORECAST_CACHE_TTL применяется после перезапуска
+	config.ForecastCache = old.ForecastCache
+	// Время последней проверки и результаты проверок доступности относятся к процессу, а не к конфигурации
+	config.Health = old.Health
+	// Метрики продолжают накапливаться в файле, заданном при запуске
+	config.Metrics = old.Metrics
+	// Журнал аудита дописывается в файл, заданный при запуске
+	config.Audit = old.Audit
+	// Счетчики ошибок подряд сохраняются; новые адресаты служебных оповещений применяются после перезапуска
+	config.Ops = old.Ops
+	// Страница состояния показывает результаты проверок, выполненных до перезагрузки
+	config.Dashboard = old.Dashboard
+	s.base = config
+	// Получатели, добавленные и исключенные через API, сохраняются поверх новых EMAIL_TO и RECIPIENTS_FILE
+	config = s.subscriptions.apply(config)
+	s.current.Store(config)
+	setLogLevel(config.LogLevel)
+
+	log.Printf("Конфигурация перезагружена: порог ветра = %s, получатели = %s, время отправки = %02d:%02d",
+		formatSpeed(config.WindGustThreshold, config.Units, 2), strings.Join(config.EmailTo, ", "), config.NotificationHour, config.NotificationMin)
+	if len(restart) > 0 {
+		logWarnf("Изменены настройки, которые применяются только после перезапуска: %s", strings.Join(restart, ", "))
+	}
+	return nil
+}
+
+// Измененные настройки, которые действуют только после перезапуска: каналы уведомлений
+// и фоновые задачи создаются при запуске. Маршрутизация, правила, периоды тишины,
+// подавление повторов и пробный запуск читаются из текущей конфигурации при каждой рассылке.
+func restartRequired(old, config *Config) []string {
+	settings := []struct {
+		name     string
+		old, new any
+	}{
+		{"MQTT_*", old.MQTT, config.MQTT},
+		{"MATRIX_*", old.Matrix, config.Matrix},
+		{"WHATSAPP_*", old.WhatsApp, config.WhatsApp},
+		{"PAGERDUTY_*", old.PagerDuty, config.PagerDuty},
+		{"OPSGENIE_*", old.Opsgenie, config.Opsgenie},
+		{"GOOGLE_CHAT_WEBHOOK_URL", old.GoogleChat, config.GoogleChat},
+		{"SIGNAL_*", old.Signal, config.Signal},
+		{"VK_*", old.VK, config.VK},
+		{"FCM_*", old.FCM, config.FCM},
+		{"MAKER_*, IFTTT_KEY", old.Maker, config.Maker},
+		{"XMPP_*", old.XMPP, config.XMPP},
+		{"ROCKETCHAT_WEBHOOK_URL", old.RocketChat, config.RocketChat},
+		{"ZULIP_*", old.Zulip, config.Zulip},
+		{"SNS_*", old.SNS, config.SNS},
+		{"TWILIO_*", old.Twilio, config.Twilio},
+		{"LINE_*", old.LINE, config.LINE},
+		{"VIBER_*", old.Viber, config.Viber},
+		{"NODERED_*", old.NodeRED, config.NodeRED},
+		{"SYSLOG_*", old.Syslog, config.Syslog},
+		{"PUSHBULLET_*", old.Pushbullet, config.Pushbullet},
+		{"INFLUXDB_*", old.InfluxDB, config.InfluxDB},
+		{"FEED_*", old.Feed, config.Feed},
+		{"DAILY_CSV_*", old.DailyCSV, config.DailyCSV},
+		{"RETRY_*", old.Retry, config.Retry},
+		{"ESCALATION_*", old.Escalation, config.Escalation},
+		{"TEMPLATE_DIR", old.TemplatesDir, config.TemplatesDir},
+		{"SCHEDULE", old.Schedule, config.Schedule},
+		{"CRON_SCHEDULE", old.CronSchedule, config.CronSchedule},
+		{"RECIPIENT_TIMES", old.RecipientSlots, config.RecipientSlots},
+		{"LOCATIONS_FILE: notification_time", locationTimes(old), locationTimes(config)},
+		{"EVENTS_FILE", old.EventsFile, config.EventsFile},
+		{"HTTP_ADDR", old.HTTPAddr, config.HTTPAddr},
+	}
+
+	var changed []string
+	for _, setting := range settings {
+		if !reflect.DeepEqual(setting.old, setting.new) {
+			changed = append(changed, setting.name)
+		}
+	}
+	return changed
+}
+
+// Время проверки пунктов со своим расписанием
+func locationTimes(config *Config) map[string]int {
+	times := map[string]int{}
+	for _, loc := range config.Locations.List {
+		if loc.ownSchedule() {
+			times[loc.title()] = loc.hour*60 + loc.minute
+		}
+	}
+	return times
+}
+
+// Перезагрузка по сигналу SIGHUP и при изменении файлов конфигурации; завершается при отмене ctx.
+// Локальные файлы проверяются по времени изменения, удаленные запрашиваются с интервалом
+// CONFIG_REFRESH_INTERVAL условным запросом по ETag.
+func (s *ConfigStore) Watch(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	fileTicker := time.NewTicker(envFileCheckInterval)
+	defer fileTicker.Stop()
+	remoteTicker := time.NewTicker(configRefreshInterval())
+	defer remoteTicker.Stop()
+
+	for {
+		select {
+		case <-signals:
+			log.Println("Получен сигнал SIGHUP, перезагружаю конфигурацию...")
+			// По сигналу удаленные файлы запрашиваются сразу, не дожидаясь очередного опроса
+			s.remoteChanged(ctx)
+		case <-fileTicker.C:
+			if !s.filesChanged() {
+				continue
+			}
+		case <-remoteTicker.C:
+			if !s.remoteChanged(ctx) {
+				continue
+			}
+		case <-ctx.Done():
+			return
+		}
+
+		if err := s.Reload(); err != nil {
+			logErrorf("Ошибка при перезагрузке конфигурации: %v, продолжаю работу с прежней конфигурацией", err)
+		}
+	}
+}
+
+// Запрос удаленных файлов конфигурации; возвращает true, если хотя бы один изменился
+func (s *ConfigStore) remoteChanged(ctx context.Context) bool {
+	changed := false
+	for _, path := range s.paths {
+		if !isRemoteConfig(path) {
+			continue
+		}
+		updated, err := remoteConfig(path).refresh(ctx)
+		if err != nil {
+			logErrorf("Ошибка при загрузке конфигурации %s: %v, продолжаю работу с прежней конфигурацией", path, err)
+			continue
+		}
+		if updated {
+			log.Printf("Конфигурация %s обновлена на сервере, перезагружаю конфигурацию...", path)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Проверка, изменился ли, появился или исчез локальный файл конфигурации после последней загрузки
+func (s *ConfigStore) filesChanged() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	modTimes := s.fileModTimes()
+	for _, path := range s.paths {
+		if !modTimes[path].Equal(s.modTimes[path]) {
+			log.Printf("Файл %s изменен, перезагружаю конфигурацию...", path)
+			return true
+		}
+	}
+	return false
+}
+
+// Время изменения локальных файлов конфигурации; отсутствующие и удаленные файлы не включаются
+func (s *ConfigStore) fileModTimes() map[string]time.Time {
+	modTimes := map[string]time.Time{}
+	for _, path := range s.paths {
+		if isRemoteConfig(path) {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil {
+			modTimes[path] = info.ModTime()
+		}
+	}
+	return modTimes
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Настройки напоминания перед началом сильного ветра
+type ReminderConfig struct {
+	Lead time.Duration // За сколько до первого превышения порога повторить проверку; 0 - отключено
+}
+
+// Загрузка настроек напоминания из переменной REMINDER_LEAD (например, 1h)
+func loadReminderConfig() ReminderConfig {
+	var cfg ReminderConfig
+
+	if envLead := os.Getenv("REMINDER_LEAD"); envLead != "" {
+		if val, err := time.ParseDuration(envLead); err == nil && val > 0 {
+			cfg.Lead = val
+		} else {
+			logWarnf("Ошибка парсинга REMINDER_LEAD: %v, напоминание отключено", err)
+		}
+	}
+
+	return cfg
+}
+
+// Повторная проверка незадолго до начала сильного ветра с напоминанием по обновленному прогнозу
+type Reminders struct {
+	config   ReminderConfig
+	evaluate func(place string) *AlertReport // Получение свежего прогноза для пункта
+	dispatch func(report *AlertReport)       // Рассылка напоминания; задается диспетчером
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer // Запланированные напоминания по пунктам
+}
+
+func newReminders(config ReminderConfig, evaluate func(place string) *AlertReport) *Reminders {
+	return &Reminders{config: config, evaluate: evaluate, timers: map[string]*time.Timer{}}
+}
+
+// Планирование напоминания по предупреждению; более раннее напоминание для того же пункта заменяется
+func (r *Reminders) Schedule(report *AlertReport) {
+	if r.config.Lead == 0 || !report.ExceedsThreshold {
+		return
+	}
+	// Для сводного предупреждения напоминание планируется по каждому пункту
+	if len(report.Locations) > 0 {
+		for _, location := range report.alertedLocations() {
+			r.Schedule(location)
+		}
+		return
+	}
+	if len(report.Forecasts) == 0 {
+		return
+	}
+
+	at := report.Forecasts[0].Time.Add(-r.config.Lead)
+	if !at.After(time.Now()) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	place := report.City
+	if timer := r.timers[place]; timer != nil {
+		timer.Stop()
+	}
+	r.timers[place] = time.AfterFunc(time.Until(at), func() { r.recheck(place) })
+	log.Printf("Повторная проверка перед началом сильного ветра (%s) запланирована на %s", place, at.In(report.CheckedAt.Location()).Format("02.01.2006 15:04"))
+}
+
+// Отмена запланированного напоминания при завершении сервиса
+func (r *Reminders) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for place, timer := range r.timers {
+		timer.Stop()
+		delete(r.timers, place)
+	}
+}
+
+// Повторная проверка и рассылка напоминания, если порог по-прежнему превышен
+func (r *Reminders) recheck(place string) {
+	log.Printf("Повторная проверка перед началом сильного ветра (%s)...", place)
+
+	report := r.evaluate(place)
+	if report == nil {
+		return
+	}
+	if !report.ExceedsThreshold {
+		log.Println("По обновленному прогнозу порывы ветра в норме, напоминание не требуется")
+		return
+	}
+
+	report.Reminder = true
+	r.dispatch(report)
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Максимальная пауза между повторными попытками доставки
+const maxRetryDelay = time.Hour
+
+// Настройки повторной доставки уведомлений
+type RetryConfig struct {
+	File         string        // Файл очереди повторной доставки (пустая строка - только в памяти)
+	InitialDelay time.Duration // Пауза перед первой повторной попыткой, далее удваивается
+	MaxPeriod    time.Duration // Сколько времени повторять доставку после первой ошибки
+}
+
+// Загрузка настроек повторной доставки из переменных окружения
+func loadRetryConfig() RetryConfig {
+	cfg := RetryConfig{
+		File:         os.Getenv("RETRY_QUEUE_FILE"),
+		InitialDelay: time.Minute,
+		MaxPeriod:    6 * time.Hour,
+	}
+
+	if envDelay := os.Getenv("RETRY_INITIAL_DELAY"); envDelay != "" {
+		val, err := time.ParseDuration(envDelay)
+		if err == nil && val <= 0 {
+			err = fmt.Errorf("пауза должна быть положительной")
+		}
+		if err == nil {
+			cfg.InitialDelay = val
+		} else {
+			logWarnf("Ошибка парсинга RETRY_INITIAL_DELAY: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envPeriod := os.Getenv("RETRY_MAX_PERIOD"); envPeriod != "" {
+		if val, err := time.ParseDuration(envPeriod); err == nil {
+			cfg.MaxPeriod = val
+		} else {
+			logWarnf("Ошибка парсинга RETRY_MAX_PERIOD: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	return cfg
+}
+
+// Недоставленное уведомление, ожидающее повторной попытки
+type pendingDelivery struct {
+	Channel     string       `json:"channel"`
+	Report      *AlertReport `json:"report"`
+	Attempts    int          `json:"attempts"`
+	FailedAt    time.Time    `json:"failed_at"` // Время первой ошибки или окончания периода тишины
+	NextAttempt time.Time    `json:"next_attempt"`
+	LastError   string       `json:"last_error"`
+}
+
+// Очередь повторной доставки уведомлений с экспоненциальной паузой между попытками
+type RetryQueue struct {
+	config    RetryConfig
+	quiet     func() QuietHoursConfig // Текущие периоды тишины; nil - не используются
+	clock     *CityClock
+	notifiers map[string]Notifier
+	historyDB *HistoryDB // База истории для записи результатов повторной доставки
+	metrics   *Metrics
+	ops       *OpsAlerts // Служебные оповещения о сбоях доставки подряд
+	store     StateStore
+
+	mu      sync.Mutex
+	pending []*pendingDelivery
+	wake    chan struct{}
+}
+
+// Создание очереди повторной доставки с загрузкой сохраненных уведомлений
+func newRetryQueue(config RetryConfig, quiet func() QuietHoursConfig, clock *CityClock, notifiers []Notifier, historyDB *HistoryDB, store StateStore, metrics *Metrics, ops *OpsAlerts) (*RetryQueue, error) {
+	q := &RetryQueue{
+		config:    config,
+		quiet:     quiet,
+		clock:     clock,
+		historyDB: historyDB,
+		metrics:   metrics,
+		ops:       ops,
+		store:     store,
+		notifiers: make(map[string]Notifier),
+		wake:      make(chan struct{}, 1),
+	}
+	for _, n := range notifiers {
+		q.notifiers[n.Name()] = n
+	}
+	store.onLeading(q.reload)
+
+	data, err := store.read(stateRetry, config.File)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении очереди повторной доставки: %w", err)
+	}
+	if data == nil {
+		return q, nil
+	}
+
+	if err := json.Unmarshal(data, &q.pending); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе очереди повторной доставки: %w", err)
+	}
+	if len(q.pending) > 0 {
+		log.Printf("Загружено недоставленных уведомлений: %d", len(q.pending))
+	}
+	return q, nil
+}
+
+// Окончание периода тишины канала, если он идет сейчас
+func (q *RetryQueue) quietUntil(channel string) (time.Time, bool) {
+	if q.quiet == nil {
+		return time.Time{}, false
+	}
+	return q.quiet().deferUntil(channel, q.clock.Now())
+}
+
+// Загрузка очереди, сохраненной экземпляром, который рассылал уведомления до этого
+func (q *RetryQueue) reload() {
+	data, err := q.store.read(stateRetry, q.config.File)
+	if err != nil {
+		logErrorf("Ошибка при чтении очереди повторной доставки: %v", err)
+		return
+	}
+
+	var pending []*pendingDelivery
+	if data != nil {
+		if err := json.Unmarshal(data, &pending); err != nil {
+			logErrorf("Ошибка при разборе очереди повторной доставки: %v", err)
+			return
+		}
+	}
+	q.mu.Lock()
+	q.pending = pending
+	q.mu.Unlock()
+	q.notify()
+}
+
+// Сохранение очереди в хранилище; при общем состоянии очередь ведет только рассылающий экземпляр
+func (q *RetryQueue) save() error {
+	if !q.store.persistent(q.config.File) || !q.store.leading() {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(q.pending, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка при формировании JSON: %w", err)
+	}
+
+	if err := q.store.write(stateRetry, q.config.File, data); err != nil {
+		return fmt.Errorf("ошибка при записи очереди повторной доставки: %w", err)
+	}
+	return nil
+}
+
+// Пробуждение цикла очереди после изменения списка
+func (q *RetryQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Удаление ожидающих уведомлений канала по пункту; вызывается с захваченной блокировкой.
+// Уведомления по другим пунктам того же канала остаются в очереди.
+func (q *RetryQueue) dropLocked(channel, city string) bool {
+	kept := q.pending[:0]
+	for _, p := range q.pending {
+		if p.Channel != channel || p.Report.City != city {
+			kept = append(kept, p)
+		}
+	}
+	dropped := len(kept) != len(q.pending)
+	q.pending = kept
+	return dropped
+}
+
+// Постановка недоставленного уведомления в очередь.
+// Более старое уведомление того же канала по тому же пункту заменяется: повторять устаревший прогноз не нужно.
+func (q *RetryQueue) Enqueue(channel string, report *AlertReport, err error) {
+	if q.config.MaxPeriod <= 0 {
+		return
+	}
+
+	now := time.Now()
+
+	q.mu.Lock()
+	q.dropLocked(channel, report.City)
+	q.pending = append(q.pending, &pendingDelivery{
+		Channel:     channel,
+		Report:      undelivered(report, err),
+		Attempts:    1,
+		FailedAt:    now,
+		NextAttempt: now.Add(q.config.InitialDelay),
+		LastError:   err.Error(),
+	})
+	if err := q.save(); err != nil {
+		logErrorf("Ошибка при сохранении очереди повторной доставки: %v", err)
+	}
+	q.mu.Unlock()
+
+	log.Printf("Уведомление через %s поставлено в очередь повторной доставки", channel)
+	q.notify()
+}
+
+// Отложенная доставка уведомления после окончания периода тишины канала
+func (q *RetryQueue) Defer(channel string, report *AlertReport, until time.Time) {
+	q.mu.Lock()
+	q.dropLocked(channel, report.City)
+	q.pending = append(q.pending, &pendingDelivery{
+		Channel:     channel,
+		Report:      report,
+		FailedAt:    until,
+		NextAttempt: until,
+	})
+	if err := q.save(); err != nil {
+		logErrorf("Ошибка при сохранении очереди повторной доставки: %v", err)
+	}
+	q.mu.Unlock()
+
+	log.Printf("Уведомление через %s отложено до %s (период тишины)", channel, until.Format("2006-01-02 15:04"))
+	q.notify()
+}
+
+// Отмена повторной доставки после успешной отправки более свежего уведомления по пункту
+func (q *RetryQueue) Resolve(channel, city string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.dropLocked(channel, city) {
+		if err := q.save(); err != nil {
+			logErrorf("Ошибка при сохранении очереди повторной доставки: %v", err)
+		}
+	}
+}
+
+// Ближайшее время повторной попытки; экземпляр, который не рассылает уведомления, попыток не делает
+func (q *RetryQueue) nextAttempt() (time.Time, bool) {
+	if !q.store.leading() {
+		return time.Time{}, false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var next time.Time
+	for _, p := range q.pending {
+		if next.IsZero() || p.NextAttempt.Before(next) {
+			next = p.NextAttempt
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// Сохранение очереди перед завершением сервиса
+func (q *RetryQueue) Flush() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.save(); err != nil {
+		logErrorf("Ошибка при сохранении очереди повторной доставки: %v", err)
+	}
+}
+
+// Основной цикл повторной доставки; завершается при отмене ctx
+func (q *RetryQueue) Run(ctx context.Context) {
+	for {
+		var timer <-chan time.Time
+		if next, ok := q.nextAttempt(); ok {
+			timer = time.After(time.Until(next))
+		}
+
+		select {
+		case <-timer:
+			q.retryDue()
+		case <-q.wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Повторная отправка уведомлений, время которых наступило
+func (q *RetryQueue) retryDue() {
+	now := time.Now()
+
+	q.mu.Lock()
+	var due []*pendingDelivery
+	for _, p := range q.pending {
+		if !p.NextAttempt.After(now) {
+			due = append(due, p)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, p := range due {
+		// Повторная попытка, попавшая в период тишины, переносится на его окончание
+		if until, quiet := q.quietUntil(p.Channel); quiet {
+			q.mu.Lock()
+			p.NextAttempt = until
+			if p.Attempts == 0 {
+				p.FailedAt = until
+			}
+			if err := q.save(); err != nil {
+				logErrorf("Ошибка при сохранении очереди повторной доставки: %v", err)
+			}
+			q.mu.Unlock()
+			continue
+		}
+
+		notifier, ok := q.notifiers[p.Channel]
+		var err error
+		var recipients []string
+		if ok {
+			recipients = deliveryRecipients(notifier, p.Report)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			started := time.Now()
+			err = notifier.Notify(ctx, p.Report)
+			q.metrics.recordDelivery(p.Channel, time.Since(started), err)
+			q.ops.delivered(p.Channel, err)
+			cancel()
+		} else {
+			err = fmt.Errorf("канал %s не настроен", p.Channel)
+		}
+
+		q.mu.Lock()
+		p.Attempts++
+		switch {
+		case err == nil && p.Attempts == 1:
+			log.Printf("Отложенное уведомление через %s доставлено", p.Channel)
+			q.historyDB.RecordDelivery(p.Report, p.Channel, recipients, deliverySent, p.Attempts, nil)
+			q.remove(p)
+		case err == nil:
+			log.Printf("Уведомление через %s доставлено с попытки %d", p.Channel, p.Attempts)
+			q.historyDB.RecordDelivery(p.Report, p.Channel, recipients, deliveryRetried, p.Attempts, nil)
+			q.remove(p)
+		case !ok || time.Since(p.FailedAt) >= q.config.MaxPeriod:
+			log.Printf("Уведомление через %s не доставлено за %d попыток, последняя ошибка: %v", p.Channel, p.Attempts, err)
+			q.historyDB.RecordDelivery(p.Report, p.Channel, recipients, deliveryDropped, p.Attempts, err)
+			q.remove(p)
+		default:
+			q.historyDB.RecordDelivery(p.Report, p.Channel, recipients, deliveryFailed, p.Attempts, err)
+			p.Report = undelivered(p.Report, err)
+			p.LastError = err.Error()
+			p.NextAttempt = time.Now().Add(retryDelay(q.config.InitialDelay, p.Attempts))
+			log.Printf("Повторная попытка %d через %s не удалась: %v, следующая в %s",
+				p.Attempts, p.Channel, err, p.NextAttempt.Format("15:04:05"))
+		}
+		if err := q.save(); err != nil {
+			logErrorf("Ошибка при сохранении очереди повторной доставки: %v", err)
+		}
+		q.mu.Unlock()
+	}
+}
+
+// Уведомление для повторной доставки: если канал доставил его части получателей
+// (recipientErrors), повторно оно отправляется только остальным
+func undelivered(report *AlertReport, err error) *AlertReport {
+	var partial *recipientErrors
+	if !errors.As(err, &partial) || len(partial.failed) == 0 {
+		return report
+	}
+	r := *report
+	r.Recipients = make([]string, 0, len(partial.failed))
+	for recipient := range partial.failed {
+		r.Recipients = append(r.Recipients, recipient)
+	}
+	sort.Strings(r.Recipients)
+
+	// В сводном отчете получатели сужаются и по пунктам: письма об отбое отправляются получателям пунктов
+	if len(r.Locations) > 0 {
+		r.Locations = make([]*AlertReport, 0, len(report.Locations))
+		for _, location := range report.Locations {
+			l := *location
+			l.Recipients = nil
+			for _, recipient := range location.Recipients {
+				if _, ok := partial.failed[recipient]; ok {
+					l.Recipients = append(l.Recipients, recipient)
+				}
+			}
+			r.Locations = append(r.Locations, &l)
+		}
+	}
+	return &r
+}
+
+// Удаление уведомления из очереди; вызывается с захваченной блокировкой
+func (q *RetryQueue) remove(target *pendingDelivery) {
+	for i, p := range q.pending {
+		if p == target {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// Пауза перед следующей попыткой: удваивается после каждой ошибки, но не более часа
+func retryDelay(initial time.Duration, attempts int) time.Duration {
+	delay := initial
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
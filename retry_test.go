@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// Канал, который возвращает заданные ошибки по очереди и запоминает адресатов каждой попытки
+type stubNotifier struct {
+	name     string
+	errs     []error
+	attempts [][]string
+}
+
+func (n *stubNotifier) Name() string { return n.name }
+
+func (n *stubNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	n.attempts = append(n.attempts, report.Recipients)
+	if len(n.errs) == 0 {
+		return nil
+	}
+	err := n.errs[0]
+	n.errs = n.errs[1:]
+	return err
+}
+
+func newTestRetryQueue(t *testing.T, notifiers ...Notifier) *RetryQueue {
+	t.Helper()
+	config := RetryConfig{InitialDelay: time.Minute, MaxPeriod: time.Hour}
+	q, err := newRetryQueue(config, nil, &CityClock{loc: time.UTC}, notifiers, nil, jsonStateStore{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+// Содержимое очереди в виде "канал/пункт"
+func pendingKeys(q *RetryQueue) []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	keys := []string{}
+	for _, p := range q.pending {
+		keys = append(keys, p.Channel+"/"+p.Report.City)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestRetryQueuePerLocation(t *testing.T) {
+	failure := errors.New("сбой")
+	moscow := &AlertReport{City: "Москва"}
+	kazan := &AlertReport{City: "Казань"}
+	later := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name  string
+		apply func(q *RetryQueue)
+		want  []string
+	}{
+		{
+			name: "пункты одного канала хранятся отдельно",
+			apply: func(q *RetryQueue) {
+				q.Enqueue("email", moscow, failure)
+				q.Enqueue("email", kazan, failure)
+			},
+			want: []string{"email/Казань", "email/Москва"},
+		},
+		{
+			name: "новое уведомление по пункту заменяет старое",
+			apply: func(q *RetryQueue) {
+				q.Enqueue("email", moscow, failure)
+				q.Enqueue("email", &AlertReport{City: "Москва", MaxWindGust: 20}, failure)
+			},
+			want: []string{"email/Москва"},
+		},
+		{
+			name: "каналы не влияют друг на друга",
+			apply: func(q *RetryQueue) {
+				q.Enqueue("email", moscow, failure)
+				q.Enqueue("telegram", moscow, failure)
+				q.Resolve("telegram", "Москва")
+			},
+			want: []string{"email/Москва"},
+		},
+		{
+			name: "доставка по одному пункту не снимает другой",
+			apply: func(q *RetryQueue) {
+				q.Enqueue("email", moscow, failure)
+				q.Enqueue("email", kazan, failure)
+				q.Resolve("email", "Казань")
+			},
+			want: []string{"email/Москва"},
+		},
+		{
+			name: "отложенное уведомление заменяет ошибку по тому же пункту",
+			apply: func(q *RetryQueue) {
+				q.Enqueue("sms", moscow, failure)
+				q.Defer("sms", moscow, later)
+				q.Defer("sms", kazan, later)
+			},
+			want: []string{"sms/Казань", "sms/Москва"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestRetryQueue(t)
+			tt.apply(q)
+			if got := pendingKeys(q); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("в очереди %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryQueueDisabled(t *testing.T) {
+	q := newTestRetryQueue(t)
+	q.config.MaxPeriod = 0
+	q.Enqueue("email", &AlertReport{City: "Москва"}, errors.New("сбой"))
+	if got := pendingKeys(q); len(got) != 0 {
+		t.Errorf("при RETRY_MAX_PERIOD=0 в очереди %v", got)
+	}
+}
+
+func TestUndelivered(t *testing.T) {
+	report := &AlertReport{City: "Москва", Recipients: []string{"a@example.com", "b@example.com", "c@example.com"}}
+	partial := &recipientErrors{message: "сбой", failed: map[string]error{
+		"c@example.com": errors.New("550"),
+		"a@example.com": errors.New("421"),
+	}}
+
+	tests := []struct {
+		name string
+		err  error
+		want []string
+	}{
+		{"общая ошибка", errors.New("сервер недоступен"), report.Recipients},
+		{"часть получателей", partial, []string{"a@example.com", "c@example.com"}},
+		{"обернутая ошибка", fmt.Errorf("email: %w", partial), []string{"a@example.com", "c@example.com"}},
+		{"без списка получателей", &recipientErrors{message: "сбой"}, report.Recipients},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := undelivered(report, tt.err)
+			if !reflect.DeepEqual(got.Recipients, tt.want) {
+				t.Errorf("получатели %v, ожидалось %v", got.Recipients, tt.want)
+			}
+			if len(report.Recipients) != 3 {
+				t.Errorf("исходный отчет изменен: %v", report.Recipients)
+			}
+		})
+	}
+}
+
+func TestFCMTargetsAfterPartialFailure(t *testing.T) {
+	n := &fcmNotifier{config: FCMConfig{Topic: "windalerts", Tokens: []string{"device-1", "device-2"}}}
+	all := []string{"topic:windalerts", "device-1", "device-2"}
+
+	tests := []struct {
+		name       string
+		recipients []string
+		want       []string
+	}{
+		{"первая доставка", []string{"a@example.com"}, all},
+		{"без получателей", nil, all},
+		{"повтор для устройства", []string{"device-2"}, []string{"device-2"}},
+		{"повтор для темы", []string{"topic:windalerts"}, []string{"topic:windalerts"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := n.targets(&AlertReport{Recipients: tt.recipients})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("адресаты %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDueSendsToFailedRecipients(t *testing.T) {
+	notifier := &stubNotifier{name: "email", errs: []error{
+		&recipientErrors{message: "сбой", failed: map[string]error{"b@example.com": errors.New("421")}},
+	}}
+	q := newTestRetryQueue(t, notifier)
+
+	report := &AlertReport{City: "Москва", Recipients: []string{"a@example.com", "b@example.com"}}
+	q.Enqueue("email", report, &recipientErrors{message: "сбой", failed: map[string]error{
+		"a@example.com": errors.New("421"),
+		"b@example.com": errors.New("421"),
+	}})
+
+	for i := 0; i < 2; i++ {
+		q.mu.Lock()
+		for _, p := range q.pending {
+			p.NextAttempt = time.Now()
+		}
+		q.mu.Unlock()
+		q.retryDue()
+	}
+
+	want := [][]string{{"a@example.com", "b@example.com"}, {"b@example.com"}}
+	if !reflect.DeepEqual(notifier.attempts, want) {
+		t.Errorf("попытки %v, ожидалось %v", notifier.attempts, want)
+	}
+	if got := pendingKeys(q); len(got) != 0 {
+		t.Errorf("после доставки в очереди %v", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		initial  time.Duration
+		attempts int
+		want     time.Duration
+	}{
+		{time.Minute, 1, time.Minute},
+		{time.Minute, 2, 2 * time.Minute},
+		{time.Minute, 4, 8 * time.Minute},
+		{time.Minute, 7, maxRetryDelay},
+		{time.Minute, 100, maxRetryDelay},
+		{2 * time.Hour, 1, maxRetryDelay},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.initial, tt.attempts), func(t *testing.T) {
+			if got := retryDelay(tt.initial, tt.attempts); got != tt.want {
+				t.Errorf("пауза %s, ожидалась %s", got, tt.want)
+			}
+		})
+	}
+}
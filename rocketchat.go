@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Настройки отправки уведомлений в Rocket.Chat
+type RocketChatConfig struct {
+	WebhookURL string // Адрес входящего веб-хука (Incoming WebHook)
+}
+
+// Загрузка настроек Rocket.Chat из переменных окружения
+func loadRocketChatConfig() RocketChatConfig {
+	return RocketChatConfig{
+		WebhookURL: os.Getenv("ROCKETCHAT_WEBHOOK_URL"),
+	}
+}
+
+// Отправка предупреждения во входящий веб-хук Rocket.Chat
+type rocketChatNotifier struct {
+	config RocketChatConfig
+}
+
+func newRocketChatNotifier(config RocketChatConfig) *rocketChatNotifier {
+	return &rocketChatNotifier{config: config}
+}
+
+func (n *rocketChatNotifier) Name() string {
+	return "rocketchat"
+}
+
+// Сообщение входящего веб-хука Rocket.Chat
+type rocketChatMessage struct {
+	Text        string                 `json:"text"`
+	Attachments []rocketChatAttachment `json:"attachments"`
+}
+
+type rocketChatAttachment struct {
+	Title     string            `json:"title"`
+	TitleLink string            `json:"title_link,omitempty"` // Ссылка подтверждения получения
+	Text      string            `json:"text,omitempty"`
+	Color     string            `json:"color"`
+	Fields    []rocketChatField `json:"fields,omitempty"`
+}
+
+type rocketChatField struct {
+	Short bool   `json:"short"`
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+func (n *rocketChatNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	var times []string
+	for _, f := range report.Forecasts {
+		times = append(times, fmt.Sprintf("%s: %s", report.formatTime(f.Time), report.speed(f.WindGust, 2)))
+	}
+
+	message := rocketChatMessage{
+		Text: report.text(fmt.Sprintf(report.tr(":warning: *Внимание!* %s: %s ожидаются сильные порывы ветра", ":warning: *Warning!* %s: strong wind gusts are expected %s"), report.City, report.periodTitle())),
+		Attachments: []rocketChatAttachment{{
+			Title:     report.tr("Уровень опасности: ", "Severity: ") + report.severityTitle(),
+			TitleLink: report.AckURL,
+			Text:      strings.Join(times, "\n"),
+			// Цвет полосы вложения соответствует уровню опасности
+			Color: report.Severity.Color(),
+			Fields: []rocketChatField{
+				{Short: true, Title: report.tr("Максимальный порыв", "Maximum gust"), Value: report.speed(report.MaxWindGust, 2)},
+				{Short: true, Title: report.tr("Безопасный порог", "Safe threshold"), Value: report.speed(report.WindGustThreshold, 2)},
+			},
+		}},
+	}
+
+	if _, err := sendJSON(ctx, http.MethodPost, n.config.WebhookURL, nil, message); err != nil {
+		return fmt.Errorf("ошибка при отправке сообщения в Rocket.Chat: %w", err)
+	}
+
+	log.Println("Предупреждение отправлено в Rocket.Chat")
+	return nil
+}
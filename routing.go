@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Правила маршрутизации: список каналов для каждого уровня опасности.
+// Пустые правила - предупреждения уходят во все каналы.
+type RoutingConfig map[Severity][]string
+
+// Загрузка правил маршрутизации из переменной ROUTING_RULES
+// в формате "yellow=email;orange=email,rocketchat;red=email,sms,call"
+func loadRoutingConfig() RoutingConfig {
+	envRules := os.Getenv("ROUTING_RULES")
+	if envRules == "" {
+		return nil
+	}
+
+	rules, err := parseRoutingRules(envRules)
+	if err != nil {
+		logWarnf("Ошибка парсинга ROUTING_RULES: %v, маршрутизация отключена", err)
+		return nil
+	}
+	return rules
+}
+
+// Разбор правил маршрутизации
+func parseRoutingRules(value string) (RoutingConfig, error) {
+	rules := RoutingConfig{}
+	for _, rule := range strings.Split(value, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		severityName, channels, ok := strings.Cut(rule, "=")
+		if !ok {
+			return nil, fmt.Errorf("ожидается формат уровень=канал1,канал2, получено %q", rule)
+		}
+
+		severity, err := parseSeverity(severityName)
+		if err != nil {
+			return nil, err
+		}
+		if severity == SeverityNone {
+			return nil, fmt.Errorf("для уровня none маршрутизация не задается")
+		}
+
+		for _, channel := range strings.Split(channels, ",") {
+			if channel = strings.ToLower(strings.TrimSpace(channel)); channel != "" {
+				rules[severity] = append(rules[severity], channel)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// Упоминается ли канал хотя бы в одном правиле
+func (r RoutingConfig) routed(channel string) bool {
+	for _, channels := range r {
+		for _, c := range channels {
+			if c == channel {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Должен ли канал получить результат проверки с указанным уровнем опасности.
+// Каналы, не упомянутые в правилах (история, MQTT, лента), получают все результаты;
+// результаты без превышения порога уходят во все каналы, чтобы системы дежурства могли закрыть инциденты.
+func (r RoutingConfig) allows(channel string, severity Severity) bool {
+	if len(r) == 0 || severity == SeverityNone || !r.routed(channel) {
+		return true
+	}
+
+	for _, c := range r[severity] {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// Проверка, что правила ссылаются на настроенные каналы
+func (r RoutingConfig) warnUnknownChannels(notifiers []Notifier) {
+	known := make(map[string]bool)
+	for _, n := range notifiers {
+		known[n.Name()] = true
+	}
+
+	for severity, channels := range r {
+		for _, c := range channels {
+			if !known[c] {
+				log.Printf("Предупреждение: правило маршрутизации для уровня %s ссылается на ненастроенный канал %s", severity, c)
+			}
+		}
+	}
+}
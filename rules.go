@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Показатели прогноза, по которым срабатывают правила предупреждений
+const (
+	metricWindGust   = "wind_gust"  // Порывы ветра, в единицах UNITS
+	metricWindSpeed  = "wind_speed" // Средняя скорость ветра, в единицах UNITS
+	metricTemp       = "temp"       // Температура воздуха, °C
+	metricHumidity   = "humidity"   // Относительная влажность, %
+	metricPop        = "pop"        // Вероятность осадков, %
+	metricVisibility = "visibility" // Видимость, м
+)
+
+var alertMetrics = []string{metricWindGust, metricWindSpeed, metricTemp, metricHumidity, metricPop, metricVisibility}
+
+// Правило предупреждения из RULES_FILE: показатель прогноза сравнивается с порогом
+// в окне суток; сработавшее правило задает уровень опасности, каналы и шаблоны сообщения
+type AlertRule struct {
+	Name       string   `json:"name"`
+	Metric     string   `json:"metric"`               // Показатель: wind_gust, wind_speed, temp, humidity, pop, visibility
+	Comparator string   `json:"comparator,omitempty"` // Сравнение: >, >=, <, <= (по умолчанию >)
+	Threshold  *float64 `json:"threshold,omitempty"`  // Порог; для wind_gust по умолчанию WIND_GUST_THRESHOLD
+	Window     string   `json:"window,omitempty"`     // Часть суток ЧЧ:ММ-ЧЧ:ММ (по умолчанию CHECK_WINDOW)
+	Severity   string   `json:"severity,omitempty"`   // Уровень опасности (по умолчанию по силе порывов)
+	Channels   []string `json:"channels,omitempty"`   // Каналы предупреждения (по умолчанию все)
+	Template   string   `json:"template,omitempty"`   // Шаблоны <template>.<канал>.tmpl в TEMPLATE_DIR
+
+	threshold float64      // Порог в единицах показателя (скорость ветра - в м/с)
+	window    *CheckWindow // Окно правила; nil - CHECK_WINDOW
+	severity  Severity
+}
+
+// Упорядоченный список правил: предупреждение выпускает первое сработавшее
+type AlertRules []AlertRule
+
+// Правило по умолчанию: порывы ветра выше WIND_GUST_THRESHOLD в окне CHECK_WINDOW
+func defaultAlertRule(config *Config) AlertRule {
+	return AlertRule{Name: "wind_gust", Metric: metricWindGust, Comparator: ">", threshold: config.WindGustThreshold}
+}
+
+// Загрузка правил из JSON-файла RULES_FILE; пороги скорости ветра задаются в единицах UNITS
+func loadAlertRules(units string) (AlertRules, error) {
+	return readAlertRules(os.Getenv("RULES_FILE"), units)
+}
+
+// Чтение и проверка файла правил; пустой путь - правил нет
+func readAlertRules(path, units string) (AlertRules, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении файла правил: %w", err)
+	}
+	var rules AlertRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе файла правил: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("файл правил %s не содержит правил", path)
+	}
+
+	names := map[string]bool{}
+	for i := range rules {
+		rule := &rules[i]
+		if err := rule.prepare(units); err != nil {
+			return nil, fmt.Errorf("правило %d: %w", i+1, err)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("правило %q указано несколько раз", rule.Name)
+		}
+		names[rule.Name] = true
+	}
+	return rules, nil
+}
+
+// Проверка полей правила и перевод порога в единицы показателя
+func (r *AlertRule) prepare(units string) error {
+	if r.Name == "" {
+		return fmt.Errorf("не указано название (name)")
+	}
+	r.Metric = strings.ToLower(strings.TrimSpace(r.Metric))
+	if !containsString(alertMetrics, r.Metric) {
+		return fmt.Errorf("%s: неизвестный показатель %q (допустимо: %s)", r.Name, r.Metric, strings.Join(alertMetrics, ", "))
+	}
+	if r.Comparator == "" {
+		r.Comparator = ">"
+	}
+	switch r.Comparator {
+	case ">", ">=", "<", "<=":
+	default:
+		return fmt.Errorf("%s: неизвестное сравнение %q (допустимо: >, >=, <, <=)", r.Name, r.Comparator)
+	}
+
+	switch {
+	case r.Threshold == nil && r.Metric == metricWindGust:
+		// Порог берется из конфигурации пункта при оценке
+	case r.Threshold == nil:
+		return fmt.Errorf("%s: не указан порог (threshold)", r.Name)
+	case r.Metric == metricWindGust || r.Metric == metricWindSpeed:
+		r.threshold = toMetersPerSecond(*r.Threshold, units)
+	default:
+		r.threshold = *r.Threshold
+	}
+
+	if r.Window != "" {
+		window, err := parseCheckWindow(r.Window)
+		if err != nil {
+			return fmt.Errorf("%s: %w", r.Name, err)
+		}
+		r.window = &window
+	}
+	if r.Severity != "" {
+		severity, err := parseSeverity(r.Severity)
+		if err != nil {
+			return fmt.Errorf("%s: %w", r.Name, err)
+		}
+		r.severity = severity
+	}
+	for i, channel := range r.Channels {
+		r.Channels[i] = strings.ToLower(strings.TrimSpace(channel))
+	}
+	return nil
+}
+
+// Значение показателя правила в точке прогноза
+func (r *AlertRule) value(forecast DailyForecast) float64 {
+	switch r.Metric {
+	case metricWindSpeed:
+		return forecast.Wind.Speed
+	case metricTemp:
+		return forecast.Main.Temp
+	case metricHumidity:
+		return forecast.Main.Humidity
+	case metricPop:
+		return forecast.Pop * 100
+	case metricVisibility:
+		return float64(forecast.Visibility)
+	default:
+		return forecast.Wind.Gust
+	}
+}
+
+// Выполняется ли условие правила для значения
+func (r *AlertRule) matches(value float64) bool {
+	switch r.Comparator {
+	case ">=":
+		return value >= r.threshold
+	case "<":
+		return value < r.threshold
+	case "<=":
+		return value <= r.threshold
+	default:
+		return value > r.threshold
+	}
+}
+
+// Оценка правила по прогнозу: точки, в которых условие выполняется, и самое
+// неблагоприятное значение показателя (максимум для > и >=, минимум для < и <=)
+func (r AlertRule) evaluate(weatherData *WeatherResponse, config *Config) ([]WindGustForecast, float64) {
+	r.threshold = r.thresholdFor(config)
+	window := config.CheckWindow
+	if r.window != nil {
+		window = *r.window
+	}
+
+	var matched []WindGustForecast
+	var extreme float64
+	for day := 0; day <= config.LookaheadDays; day++ {
+		for _, forecast := range forecastEntriesForTheDay(weatherData, window, day) {
+			value := r.value(forecast)
+			if !r.matches(value) {
+				continue
+			}
+			below := strings.HasPrefix(r.Comparator, "<")
+			if len(matched) == 0 || below && value < extreme || !below && value > extreme {
+				extreme = value
+			}
+			matched = append(matched, WindGustForecast{Time: forecast.Time(), WindGust: forecast.Wind.Gust})
+		}
+	}
+	return matched, extreme
+}
+
+// Порог правила; для порывов ветра без явного порога - WIND_GUST_THRESHOLD пункта
+func (r AlertRule) thresholdFor(config *Config) float64 {
+	if r.Threshold == nil && r.Metric == metricWindGust {
+		return config.WindGustThreshold
+	}
+	return r.threshold
+}
+
+// Правила конфигурации или правило порывов ветра по умолчанию
+func (c *Config) alertRules() AlertRules {
+	if len(c.Rules) > 0 {
+		return c.Rules
+	}
+	return AlertRules{defaultAlertRule(c)}
+}
+
+// Получает ли канал предупреждение по правилу: каналы, не упомянутые ни в одном правиле
+// (история, MQTT, лента), получают все результаты, как и при маршрутизации по уровням
+func (rules AlertRules) allows(channel string, report *AlertReport) bool {
+	if report.Rule == "" || !report.ExceedsThreshold {
+		return true
+	}
+	mentioned := false
+	for _, rule := range rules {
+		if containsString(rule.Channels, channel) {
+			mentioned = true
+		}
+		if rule.Name == report.Rule && (len(rule.Channels) == 0 || containsString(rule.Channels, channel)) {
+			return true
+		}
+	}
+	return !mentioned
+}
+
+// Проверка, что правила ссылаются на настроенные каналы
+func (rules AlertRules) warnUnknownChannels(notifiers []Notifier) {
+	known := make(map[string]bool)
+	for _, n := range notifiers {
+		known[n.Name()] = true
+	}
+	for _, rule := range rules {
+		for _, c := range rule.Channels {
+			if !known[c] {
+				log.Printf("Предупреждение: правило %s ссылается на ненастроенный канал %s", rule.Name, c)
+			}
+		}
+	}
+}
+
+// Есть ли строка в списке
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
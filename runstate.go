@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Состояние плановых проверок, сохраняемое между перезапусками
+type RunState struct {
+	mu      sync.Mutex
+	store   StateStore
+	path    string
+	LastRun time.Time            `json:"last_run"`         // Время последней выполненной плановой проверки
+	Alerts  map[string]sentAlert `json:"alerts,omitempty"` // Предупреждения, отправленные за текущий день
+}
+
+// Отметка об отправленном предупреждении по пункту и правилу
+type sentAlert struct {
+	Date     string    `json:"date"`     // День отправки (ГГГГ-ММ-ДД) в часовом поясе города
+	SentAt   time.Time `json:"sent_at"`  // Время отправки, от которого отсчитывается ALERT_COOLDOWN
+	Severity string    `json:"severity"` // Наибольший отправленный уровень опасности
+}
+
+// Загрузка состояния из хранилища (файла RUN_STATE_FILE при STORE_BACKEND=json);
+// без файла состояние хранится только в памяти
+func loadRunState(store StateStore, path string) (*RunState, error) {
+	state := &RunState{store: store, path: path}
+	store.onLeading(state.reload)
+
+	data, err := store.read(stateRun, path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении состояния проверок: %w", err)
+	}
+	if data == nil {
+		return state, nil
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе состояния проверок: %w", err)
+	}
+	return state, nil
+}
+
+// Загрузка отметок, сохраненных экземпляром, который рассылал уведомления до этого
+func (s *RunState) reload() {
+	data, err := s.store.read(stateRun, s.path)
+	if err != nil {
+		logErrorf("Ошибка при чтении состояния проверок: %v", err)
+		return
+	}
+	if data == nil {
+		return
+	}
+
+	var loaded RunState
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		logErrorf("Ошибка при разборе состояния проверок: %v", err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.LastRun, s.Alerts = loaded.LastRun, loaded.Alerts
+}
+
+// Сохранение состояния в хранилище; при общем состоянии его изменяет только рассылающий экземпляр
+func (s *RunState) save() error {
+	if !s.store.persistent(s.path) || !s.store.leading() {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка при формировании JSON: %w", err)
+	}
+
+	if err := s.store.write(stateRun, s.path, data); err != nil {
+		return fmt.Errorf("ошибка при записи состояния проверок: %w", err)
+	}
+	return nil
+}
+
+// Отметка о выполненной плановой проверке
+func (s *RunState) Record(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.LastRun = t
+	if err := s.save(); err != nil {
+		logErrorf("Ошибка при сохранении состояния проверок: %v", err)
+	}
+}
+
+// Пропущенная плановая проверка: последнее наступление времени hour:minute до now,
+// после которого проверка не выполнялась. Возвращает время пропущенной проверки.
+func (s *RunState) missed(now time.Time, hour, minute int) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Без сведений о прошлых запусках пропуск определить нельзя
+	if s.LastRun.IsZero() {
+		return time.Time{}, false
+	}
+
+	scheduled := nextDailyTime(now, hour, minute).AddDate(0, 0, -1)
+	if s.LastRun.Before(scheduled) {
+		return scheduled, true
+	}
+	return time.Time{}, false
+}
+
+// Ключ отметки об отправленном предупреждении: пункт и сработавшее правило
+func alertStateKey(report *AlertReport) string {
+	return strings.ToLower(report.City) + "|" + report.Rule
+}
+
+// Отправлялось ли предупреждение по ключу с тем же или более высоким уровнем опасности
+// в тот же день (sameDay) или в пределах cooldown. Возвращает время прошлой отправки.
+// Повышение уровня не считается повтором, чтобы об усилении ветра сообщалось сразу.
+func (s *RunState) alertSent(key string, now time.Time, severity Severity, sameDay bool, cooldown time.Duration) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sent, ok := s.Alerts[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	if sentSeverity, err := parseSeverity(sent.Severity); err != nil || severity > sentSeverity {
+		return time.Time{}, false
+	}
+	if sameDay && sent.Date == now.Format("2006-01-02") {
+		return sent.SentAt, true
+	}
+	if cooldown > 0 && !sent.SentAt.IsZero() && now.Sub(sent.SentAt) < cooldown {
+		return sent.SentAt, true
+	}
+	return time.Time{}, false
+}
+
+// Отметка об отправленном предупреждении; удаляются отметки прошлых дней,
+// период подавления которых (cooldown) уже истек
+func (s *RunState) RecordAlert(key string, now time.Time, severity Severity, cooldown time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	date := now.Format("2006-01-02")
+	for k, sent := range s.Alerts {
+		if sent.Date != date && now.Sub(sent.SentAt) >= cooldown {
+			delete(s.Alerts, k)
+		}
+	}
+	if s.Alerts == nil {
+		s.Alerts = make(map[string]sentAlert)
+	}
+	s.Alerts[key] = sentAlert{Date: date, SentAt: now, Severity: severity.String()}
+	if err := s.save(); err != nil {
+		logErrorf("Ошибка при сохранении состояния проверок: %v", err)
+	}
+}
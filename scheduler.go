@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Стратегии запуска проверок (SCHEDULE)
+const (
+	scheduleDaily      = "daily"      // Ежедневно в NOTIFICATION_HOUR:NOTIFICATION_MIN
+	scheduleContinuous = "continuous" // Периодический опрос прогноза (POLL_INTERVAL)
+	scheduleCron       = "cron"       // По выражению cron (CRON_SCHEDULE)
+	scheduleOnce       = "once"       // Одна проверка и завершение, запуск по внешнему планировщику
+)
+
+// Интервал опроса в непрерывном режиме, если POLL_INTERVAL не задан
+const defaultPollInterval = time.Hour
+
+// Стратегия запуска проверок
+type Scheduler interface {
+	// Описание расписания для журнала
+	Name() string
+	// Выполнение проверок до отмены ctx; разовый запуск завершается сам
+	Run(ctx context.Context)
+	// Время следующей плановой проверки; нулевое - проверка не запланирована
+	Next(now time.Time) time.Time
+}
+
+// Загрузка стратегии запуска из переменной SCHEDULE; без нее пробный запуск (DRY_RUN)
+// выполняет одну проверку, заданный POLL_INTERVAL включает непрерывный режим,
+// иначе используется ежедневная проверка
+func loadScheduleMode(poll PollConfig, dryRun bool) string {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("SCHEDULE")))
+	switch mode {
+	case "":
+		if dryRun {
+			return scheduleOnce
+		}
+		if poll.Interval > 0 {
+			return scheduleContinuous
+		}
+		return scheduleDaily
+	case scheduleDaily, scheduleContinuous, scheduleCron, scheduleOnce:
+		return mode
+	default:
+		log.Printf("Неизвестное расписание SCHEDULE=%s, используется %s", mode, scheduleDaily)
+		return scheduleDaily
+	}
+}
+
+// Создание стратегии запуска по конфигурации
+func newScheduler(store *ConfigStore, dispatcher *Dispatcher, runState *RunState) (Scheduler, error) {
+	config := store.Load()
+	switch config.Schedule {
+	case scheduleContinuous:
+		if !config.Features.Enabled(featureContinuousMode) {
+			log.Printf("Непрерывный режим выключен флагом FEATURES=%s=false, используется ежедневная проверка", featureContinuousMode)
+			break
+		}
+		if config.Mode != modeWind {
+			log.Printf("Непрерывный режим доступен только в режиме %s, используется ежедневная проверка", modeWind)
+			break
+		}
+		return &continuousScheduler{store: store, dispatcher: dispatcher}, nil
+	case scheduleCron:
+		cron, err := parseCron(config.CronSchedule)
+		if err != nil {
+			return nil, fmt.Errorf("CRON_SCHEDULE: %w", err)
+		}
+		return &cronScheduler{store: store, dispatcher: dispatcher, runState: runState, cron: cron}, nil
+	case scheduleOnce:
+		return &onceScheduler{store: store, dispatcher: dispatcher, runState: runState}, nil
+	}
+
+	return &dailyScheduler{store: store, dispatcher: dispatcher, runState: runState}, nil
+}
+
+// Плановая проверка с отметкой о выполнении для обнаружения пропущенных запусков
+func runScheduledCheck(config *Config, dispatcher *Dispatcher, state *RunState) {
+	ok := runCheck(config, dispatcher)
+	sendHeartbeat(config, dispatcher.store, ok)
+	config.Metrics.recordRun(time.Now(), ok, dispatcher.store.leading())
+	// Пробный запуск не считается выполненной плановой проверкой
+	if !config.DryRun {
+		state.Record(config.Clock.Now())
+	}
+}
+
+// Ежедневная проверка в заданное время с выполнением пропущенной проверки при запуске
+type dailyScheduler struct {
+	store      *ConfigStore
+	dispatcher *Dispatcher
+	runState   *RunState
+}
+
+func (s *dailyScheduler) Name() string {
+	config := s.store.Load()
+	name := fmt.Sprintf("ежедневно в %02d:%02d", config.NotificationHour, config.NotificationMin)
+	for _, loc := range scheduledLocations(config) {
+		name += fmt.Sprintf(", %s - в %02d:%02d (%s)", loc.title(), loc.hour, loc.minute, loc.clock.Location())
+	}
+	return name
+}
+
+// Ближайшее время проверки по активной конфигурации: новое время отправки
+// после перезагрузки учитывается уже в текущем ожидании
+func (s *dailyScheduler) next(now time.Time) time.Time {
+	config := s.store.Load()
+	return nextDailyTime(now, config.NotificationHour, config.NotificationMin)
+}
+
+// Ближайшая проверка с учетом пунктов со своим временем
+func (s *dailyScheduler) Next(now time.Time) time.Time {
+	next := s.next(now)
+	for _, loc := range scheduledLocations(s.store.Load()) {
+		if at := nextDailyTime(now.In(loc.clock.Location()), loc.hour, loc.minute); at.Before(next) {
+			next = at
+		}
+	}
+	return next
+}
+
+// Общая плановая проверка; пункты со своим временем проверяются по своему расписанию
+func (s *dailyScheduler) check(config *Config) {
+	if len(scheduledLocations(config)) == 0 {
+		runScheduledCheck(config, s.dispatcher, s.runState)
+		return
+	}
+	shared := config.withLocations(func(loc *Location) bool { return !loc.ownSchedule() })
+	if len(shared.Locations.List) == 0 {
+		log.Println("У всех пунктов свое время проверки, общая проверка не требуется")
+		return
+	}
+	runScheduledCheck(shared, s.dispatcher, s.runState)
+}
+
+func (s *dailyScheduler) Run(ctx context.Context) {
+	config := s.store.Load()
+
+	// Получатели со своим временем доставки получают письмо по отдельному расписанию
+	var slots sync.WaitGroup
+	if config.Mode == modeWind {
+		for _, slot := range config.RecipientSlots {
+			slots.Add(1)
+			go func(slot deliverySlot) {
+				defer slots.Done()
+				runRecipientSchedule(ctx, s.store, s.dispatcher, slot)
+			}(slot)
+		}
+	}
+	// Пункты со своим временем проверяются по своему расписанию в часовом поясе пункта
+	for _, loc := range scheduledLocations(config) {
+		slots.Add(1)
+		go func(loc Location) {
+			defer slots.Done()
+			runLocationSchedule(ctx, s.store, s.dispatcher, loc)
+		}(loc)
+	}
+	defer slots.Wait()
+
+	// Запускаем первую проверку сразу при старте (но уведомление отправляем только если сейчас время отправки)
+	now := config.Clock.Now()
+	if scheduled, ok := s.runState.missed(now, config.NotificationHour, config.NotificationMin); ok {
+		// Процесс не работал в момент плановой проверки (перезапуск, спящий режим) - выполняем ее сейчас
+		log.Printf("Пропущена проверка, запланированная на %s, выполняю ее сейчас", scheduled.Format("02.01.2006 15:04"))
+		s.check(config)
+	} else if now.Hour() == config.NotificationHour && now.Minute() >= config.NotificationMin && now.Minute() < config.NotificationMin+5 {
+		// Запускаем проверку только если мы находимся в 5-минутном окне после времени отправки
+		s.check(config)
+	} else {
+		log.Printf("Первая проверка будет выполнена в %02d:%02d", config.NotificationHour, config.NotificationMin)
+	}
+
+	for waitUntil(ctx, config.Clock, s.next, "проверка") {
+		s.check(s.store.Load())
+	}
+}
+
+// Пункты со своим временем проверки (notification_time в LOCATIONS_FILE); свое время
+// действует только в режиме wind, остальные режимы проверяют первый пункт в общее время
+func scheduledLocations(config *Config) []Location {
+	if config.Mode != modeWind {
+		return nil
+	}
+	var scheduled []Location
+	for _, loc := range config.Locations.List {
+		if loc.ownSchedule() {
+			scheduled = append(scheduled, loc)
+		}
+	}
+	return scheduled
+}
+
+// Ежедневная проверка пункта в его время по часовому поясу пункта; завершается при отмене ctx.
+// Порог, получатели и окно проверки берутся из активной конфигурации.
+func runLocationSchedule(ctx context.Context, store *ConfigStore, dispatcher *Dispatcher, loc Location) {
+	name := "проверка пункта " + loc.title()
+	// Время проверки отсчитывается по часовому поясу пункта, поэтому он определяется до ожидания
+	resolveCityTimezone(store.Load().forLocation(loc))
+	for waitForDailyTime(ctx, loc.clock, loc.hour, loc.minute, name) {
+		config := store.Load().withLocations(func(l *Location) bool { return l.title() == loc.title() })
+		if len(config.Locations.List) == 0 {
+			log.Printf("Пункт %s больше не указан в LOCATIONS_FILE, %s пропущена", loc.title(), name)
+			continue
+		}
+		ok := runCheck(config, dispatcher)
+		sendHeartbeat(config, dispatcher.store, ok)
+		config.Metrics.recordRun(time.Now(), ok, dispatcher.store.leading())
+	}
+}
+
+// Проверки по выражению cron в часовом поясе города
+type cronScheduler struct {
+	store      *ConfigStore
+	dispatcher *Dispatcher
+	runState   *RunState
+	cron       *CronSchedule
+}
+
+func (s *cronScheduler) Name() string {
+	return fmt.Sprintf("по расписанию cron %q", s.cron)
+}
+
+func (s *cronScheduler) Next(now time.Time) time.Time {
+	return s.cron.Next(now)
+}
+
+func (s *cronScheduler) Run(ctx context.Context) {
+	for waitUntil(ctx, s.store.Load().Clock, s.cron.Next, "проверка") {
+		runScheduledCheck(s.store.Load(), s.dispatcher, s.runState)
+	}
+}
+
+// Разовая проверка для запуска из внешнего планировщика (cron, systemd timer, Kubernetes CronJob)
+type onceScheduler struct {
+	store      *ConfigStore
+	dispatcher *Dispatcher
+	runState   *RunState
+}
+
+func (s *onceScheduler) Name() string {
+	return "разовая проверка"
+}
+
+func (s *onceScheduler) Next(time.Time) time.Time {
+	return time.Time{}
+}
+
+func (s *onceScheduler) Run(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
+	runScheduledCheck(s.store.Load(), s.dispatcher, s.runState)
+}
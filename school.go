@@ -0,0 +1,393 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Запас в градусах, при котором прогулка разрешается с ограничениями
+const schoolTemperatureMargin = 3.0
+
+// Варианты рекомендации по прогулкам
+const (
+	walkYes        = "да"
+	walkRestricted = "с ограничениями"
+	walkNo         = "нет"
+)
+
+// Пороговые значения для возрастной группы
+type AgeGroupLimits struct {
+	Name         string
+	MinWindChill float64 // Минимальная ощущаемая температура на ветру, °C
+	MaxHeatIndex float64 // Максимальный индекс жары, °C
+	MaxUV        float64 // Максимальный УФ-индекс без ограничений
+}
+
+// Настройки профиля для школ и детских садов
+type SchoolConfig struct {
+	AgeGroups        []AgeGroupLimits
+	EmailTo          []string // Адреса администраторов (по умолчанию EMAIL_TO)
+	MaxPrecipitation float64  // Осадки в мм за 3 часа, при превышении прогулка отменяется
+	StartHour        int      // Начало прогулочного времени
+	EndHour          int      // Окончание прогулочного времени
+}
+
+// Погодные условия за прогулочное время
+type OutdoorConditions struct {
+	MinWindChill  float64
+	MaxHeatIndex  float64
+	UV            float64 // УФ-индекс (отрицательное значение - данных нет)
+	Precipitation float64 // Максимум осадков за 3 часа, мм
+	Thunderstorm  bool
+}
+
+// Рекомендация для возрастной группы
+type AgeGroupAdvice struct {
+	Group    string
+	Decision string
+	Reasons  []string
+}
+
+// Структура данных для шаблона письма с рекомендацией по прогулкам
+type SchoolEmailData struct {
+	Date       string
+	Conditions OutdoorConditions
+	Advice     []AgeGroupAdvice
+}
+
+// Шаблон для HTML письма с рекомендацией по прогулкам
+const schoolEmailHTMLTemplateText = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Прогулки</title>
+</head>
+<body style="margin: 0; padding: 0; background-color: #f4f4f4; font-family: Arial, sans-serif;">
+    <table border="0" cellpadding="0" cellspacing="0" width="100%" bgcolor="#f4f4f4" style="background-color: #f4f4f4;">
+        <tr>
+            <td align="center" style="padding: 20px 0;">
+                <table border="0" cellpadding="0" cellspacing="0" width="600" style="background-color: #ffffff; border-radius: 8px; max-width: 600px; width: 100%;">
+                    <tr>
+                        <td style="padding: 20px;">
+                            <h1 style="color: #337ab7; font-size: 24px; text-align: center; margin-top: 0; margin-bottom: 20px;">Прогулки на {{.Date}}</h1>
+                            <p style="font-size: 14px; line-height: 1.5; color: #333333;">Ощущаемая температура на ветру: от {{printf "%.1f" .Conditions.MinWindChill}} °C; индекс жары: до {{printf "%.1f" .Conditions.MaxHeatIndex}} °C; УФ-индекс: {{if ge .Conditions.UV 0.0}}{{printf "%.1f" .Conditions.UV}}{{else}}нет данных{{end}}; осадки: до {{printf "%.1f" .Conditions.Precipitation}} мм за 3 ч{{if .Conditions.Thunderstorm}}, ожидается гроза{{end}}.</p>
+                            <table border="1" cellpadding="6" cellspacing="0" width="100%" style="border-collapse: collapse; font-size: 14px; color: #333333;">
+                                <tr style="background-color: #eeeeee;"><th align="left">Группа</th><th align="left">Прогулки</th><th align="left">Причины</th></tr>
+                                {{range .Advice}}<tr>
+                                    <td>{{.Group}}</td>
+                                    <td style="font-weight: bold; color: {{if eq .Decision "да"}}#3c763d{{else if eq .Decision "нет"}}#d9534f{{else}}#f0ad4e{{end}};">{{.Decision}}</td>
+                                    <td>{{range $i, $r := .Reasons}}{{if $i}}; {{end}}{{$r}}{{end}}</td>
+                                </tr>
+                                {{end}}
+                            </table>
+                            <p style="font-size: 14px; line-height: 1.5; color: #777777; text-align: center; margin-top: 20px;">Это автоматическое уведомление от системы мониторинга погоды.</p>
+                        </td>
+                    </tr>
+                </table>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>`
+
+// Шаблон для текстового письма с рекомендацией по прогулкам
+const schoolEmailPlainTextTemplate = `Прогулки на {{.Date}}
+
+Ощущаемая температура на ветру: от {{printf "%.1f" .Conditions.MinWindChill}} °C
+Индекс жары: до {{printf "%.1f" .Conditions.MaxHeatIndex}} °C
+УФ-индекс: {{if ge .Conditions.UV 0.0}}{{printf "%.1f" .Conditions.UV}}{{else}}нет данных{{end}}
+Осадки: до {{printf "%.1f" .Conditions.Precipitation}} мм за 3 ч{{if .Conditions.Thunderstorm}}, ожидается гроза{{end}}
+{{range .Advice}}
+{{.Group}} - прогулки: {{.Decision}}{{range .Reasons}}
+  - {{.}}{{end}}
+{{end}}
+Это автоматическое уведомление от системы мониторинга погоды.`
+
+// Группы по умолчанию: ясли, младший и старший дошкольный возраст
+var defaultAgeGroups = []AgeGroupLimits{
+	{Name: "Ясли (до 3 лет)", MinWindChill: -10, MaxHeatIndex: 27, MaxUV: 3},
+	{Name: "Младшие группы (3-5 лет)", MinWindChill: -15, MaxHeatIndex: 28, MaxUV: 5},
+	{Name: "Старшие группы (5-7 лет)", MinWindChill: -20, MaxHeatIndex: 30, MaxUV: 6},
+}
+
+// Загрузка настроек профиля для детских учреждений из переменных окружения
+func loadSchoolConfig() SchoolConfig {
+	cfg := SchoolConfig{
+		AgeGroups:        defaultAgeGroups,
+		EmailTo:          parseEmailList(os.Getenv("SCHOOL_EMAIL_TO")),
+		MaxPrecipitation: 1.0,
+		StartHour:        9,
+		EndHour:          18,
+	}
+
+	// Формат: "Название:мин_температура:макс_индекс_жары:макс_УФ;..."
+	if envGroups := os.Getenv("SCHOOL_AGE_GROUPS"); envGroups != "" {
+		if groups, err := parseAgeGroups(envGroups); err == nil {
+			cfg.AgeGroups = groups
+		} else {
+			logWarnf("Ошибка парсинга SCHOOL_AGE_GROUPS: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envPrecipitation := os.Getenv("SCHOOL_MAX_PRECIPITATION"); envPrecipitation != "" {
+		if val, err := strconv.ParseFloat(envPrecipitation, 64); err == nil && val >= 0 {
+			cfg.MaxPrecipitation = val
+		} else {
+			logWarnf("Ошибка парсинга SCHOOL_MAX_PRECIPITATION: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envStart := os.Getenv("SCHOOL_START_HOUR"); envStart != "" {
+		if val, err := strconv.Atoi(envStart); err == nil && val >= 0 && val < 24 {
+			cfg.StartHour = val
+		} else {
+			logWarnf("Ошибка парсинга SCHOOL_START_HOUR: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envEnd := os.Getenv("SCHOOL_END_HOUR"); envEnd != "" {
+		if val, err := strconv.Atoi(envEnd); err == nil && val > 0 && val <= 24 {
+			cfg.EndHour = val
+		} else {
+			logWarnf("Ошибка парсинга SCHOOL_END_HOUR: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	return cfg
+}
+
+// Разбор описания возрастных групп
+func parseAgeGroups(value string) ([]AgeGroupLimits, error) {
+	var groups []AgeGroupLimits
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("ожидается формат Название:мин_температура:макс_индекс_жары:макс_УФ, получено %q", item)
+		}
+
+		var limits [3]float64
+		for i, part := range parts[1:] {
+			val, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return nil, fmt.Errorf("группа %q: %w", parts[0], err)
+			}
+			limits[i] = val
+		}
+
+		groups = append(groups, AgeGroupLimits{
+			Name:         strings.TrimSpace(parts[0]),
+			MinWindChill: limits[0],
+			MaxHeatIndex: limits[1],
+			MaxUV:        limits[2],
+		})
+	}
+
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("не указано ни одной группы")
+	}
+	return groups, nil
+}
+
+// Ощущаемая температура на ветру (формула Environment Canada), скорость ветра в м/с
+func windChill(temp, windSpeed float64) float64 {
+	speedKmh := windSpeed * 3.6
+	if temp > 10 || speedKmh <= 4.8 {
+		return temp
+	}
+	v := math.Pow(speedKmh, 0.16)
+	return 13.12 + 0.6215*temp - 11.37*v + 0.3965*temp*v
+}
+
+// Индекс жары (формула Ротфуса), температура в °C, влажность в %
+func heatIndex(temp, humidity float64) float64 {
+	if temp < 27 {
+		return temp
+	}
+	t := temp*9/5 + 32
+	h := humidity
+	hi := -42.379 + 2.04901523*t + 10.14333127*h - 0.22475541*t*h -
+		0.00683783*t*t - 0.05481717*h*h + 0.00122874*t*t*h +
+		0.00085282*t*h*h - 0.00000199*t*t*h*h
+	return (hi - 32) * 5 / 9
+}
+
+// Расчет погодных условий за прогулочное время
+func outdoorConditions(entries []DailyForecast, cfg SchoolConfig) (OutdoorConditions, bool) {
+	conditions := OutdoorConditions{
+		MinWindChill: math.Inf(1),
+		MaxHeatIndex: math.Inf(-1),
+		UV:           -1,
+	}
+
+	found := false
+	for _, forecast := range entries {
+		hour := forecast.Time().Hour()
+		if hour < cfg.StartHour || hour >= cfg.EndHour {
+			continue
+		}
+		found = true
+
+		conditions.MinWindChill = math.Min(conditions.MinWindChill, windChill(forecast.Main.Temp, forecast.Wind.Speed))
+		conditions.MaxHeatIndex = math.Max(conditions.MaxHeatIndex, heatIndex(forecast.Main.Temp, forecast.Main.Humidity))
+		conditions.Precipitation = math.Max(conditions.Precipitation, forecast.Rain.ThreeHours+forecast.Snow.ThreeHours)
+		for _, w := range forecast.Weather {
+			if w.Main == "Thunderstorm" {
+				conditions.Thunderstorm = true
+			}
+		}
+	}
+
+	return conditions, found
+}
+
+// Рекомендация по прогулкам для возрастной группы
+func adviseAgeGroup(group AgeGroupLimits, conditions OutdoorConditions, cfg SchoolConfig) AgeGroupAdvice {
+	advice := AgeGroupAdvice{Group: group.Name, Decision: walkYes}
+
+	forbid := func(reason string) {
+		advice.Decision = walkNo
+		advice.Reasons = append(advice.Reasons, reason)
+	}
+	restrict := func(reason string) {
+		if advice.Decision == walkYes {
+			advice.Decision = walkRestricted
+		}
+		advice.Reasons = append(advice.Reasons, reason)
+	}
+
+	switch {
+	case conditions.MinWindChill < group.MinWindChill:
+		forbid(fmt.Sprintf("ощущаемая температура %.1f °C ниже %.1f °C", conditions.MinWindChill, group.MinWindChill))
+	case conditions.MinWindChill < group.MinWindChill+schoolTemperatureMargin:
+		restrict(fmt.Sprintf("ощущаемая температура %.1f °C близка к пределу, сократить время прогулки", conditions.MinWindChill))
+	}
+
+	switch {
+	case conditions.MaxHeatIndex > group.MaxHeatIndex:
+		forbid(fmt.Sprintf("индекс жары %.1f °C выше %.1f °C", conditions.MaxHeatIndex, group.MaxHeatIndex))
+	case conditions.MaxHeatIndex > group.MaxHeatIndex-schoolTemperatureMargin:
+		restrict(fmt.Sprintf("индекс жары %.1f °C близок к пределу, гулять в тени и пить воду", conditions.MaxHeatIndex))
+	}
+
+	if conditions.UV > group.MaxUV {
+		restrict(fmt.Sprintf("УФ-индекс %.1f выше %.1f, головные уборы и тень", conditions.UV, group.MaxUV))
+	}
+
+	switch {
+	case conditions.Thunderstorm:
+		forbid("ожидается гроза")
+	case conditions.Precipitation > cfg.MaxPrecipitation:
+		forbid(fmt.Sprintf("сильные осадки (%.1f мм за 3 ч)", conditions.Precipitation))
+	case conditions.Precipitation > 0:
+		restrict("возможны осадки, прогулка под навесом")
+	}
+
+	return advice
+}
+
+// Получение дневного УФ-индекса через One Call API 3.0.
+// Требует отдельной подписки OpenWeatherMap, поэтому при ошибке УФ-индекс не учитывается.
+func getUVIndex(config *Config) (float64, error) {
+	location, err := getGeoCoordinates(config)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при получении координат: %w", err)
+	}
+
+	url := fmt.Sprintf("https://api.openweathermap.org/data/3.0/onecall?lat=%.4f&lon=%.4f&exclude=current,minutely,hourly,alerts&units=metric&appid=%s",
+		location.Lat, location.Lon, config.OpenWeatherAPIKey)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при запросе к One Call API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при чтении ответа: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("One Call API вернул статус %s", resp.Status)
+	}
+
+	var oneCall struct {
+		Daily []struct {
+			UVI float64 `json:"uvi"`
+		} `json:"daily"`
+	}
+	if err := json.Unmarshal(body, &oneCall); err != nil {
+		return 0, fmt.Errorf("ошибка при разборе JSON: %w", err)
+	}
+
+	if len(oneCall.Daily) == 0 {
+		return 0, fmt.Errorf("нет данных об УФ-индексе")
+	}
+
+	return oneCall.Daily[0].UVI, nil
+}
+
+// Формирование рекомендации по прогулкам и отправка администраторам; false - при ошибке
+func checkOutdoorActivityAndNotify(config *Config) bool {
+	log.Println("Запуск расчета рекомендации по прогулкам...")
+
+	weatherData, err := getWeatherData(config)
+	if err != nil {
+		logErrorf("Ошибка при получении данных о погоде: %v\n", err)
+		return false
+	}
+
+	conditions, found := outdoorConditions(forecastEntriesForTheDay(weatherData, config.CheckWindow, 0), config.School)
+	if !found {
+		log.Println("Нет данных о погоде на прогулочное время в ответе API")
+		return false
+	}
+
+	if uv, err := getUVIndex(config); err == nil {
+		conditions.UV = uv
+	} else {
+		log.Printf("УФ-индекс недоступен и не будет учтен: %v", err)
+	}
+
+	data := SchoolEmailData{
+		Date:       config.Clock.Now().Format("02.01.2006"),
+		Conditions: conditions,
+	}
+	for _, group := range config.School.AgeGroups {
+		advice := adviseAgeGroup(group, conditions, config.School)
+		log.Printf("%s: прогулки - %s", advice.Group, advice.Decision)
+		data.Advice = append(data.Advice, advice)
+	}
+
+	htmlBody, plainTextBody, err := renderEmailBodies(schoolEmailHTMLTemplateText, schoolEmailPlainTextTemplate, data, speedFuncs(config.Units, languageRU))
+	if err != nil {
+		logErrorf("Ошибка при формировании письма: %v\n", err)
+		return false
+	}
+
+	recipients := config.School.EmailTo
+	if len(recipients) == 0 {
+		recipients = config.EmailTo
+	}
+
+	subject := "Прогулки сегодня: рекомендация по погоде"
+	if err := sendEmailTo(config, recipients, subject, htmlBody, plainTextBody); err != nil {
+		logErrorf("Ошибка при отправке рекомендации по прогулкам: %v\n", err)
+		return false
+	}
+	log.Println("Рекомендация по прогулкам успешно отправлена")
+	return true
+}
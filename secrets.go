@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// Переменные, значение которых было прочитано из файла (<ИМЯ>_FILE); при перезагрузке
+// конфигурации они перечитываются, чтобы подхватить обновленный секрет
+var secretsFromFiles = map[string]bool{}
+
+// Чтение значений из файлов секретов Docker/Kubernetes: для любой переменной конфигурации
+// можно задать <ИМЯ>_FILE с путем к файлу, например OPENWEATHER_API_KEY_FILE=/run/secrets/owm_key.
+// Завершающий перевод строки отбрасывается.
+func loadSecretFiles() []error {
+	var errs []error
+	for _, name := range configEnvVars {
+		path := os.Getenv(name + "_FILE")
+		if path == "" {
+			// Ссылка на файл удалена из конфигурации - прочитанное ранее значение сбрасывается
+			if secretsFromFiles[name] {
+				os.Unsetenv(name)
+				delete(secretsFromFiles, name)
+			}
+			continue
+		}
+		if _, set := os.LookupEnv(name); set && !secretsFromFiles[name] {
+			// Вариант с префиксом WINDALERTS_ важнее одноименной переменной без префикса
+			valueNamespaced, fileNamespaced := namespaced(name), namespaced(name+"_FILE")
+			if valueNamespaced && !fileNamespaced {
+				continue
+			}
+			if valueNamespaced == fileNamespaced {
+				errs = append(errs, fmt.Errorf("заданы одновременно %s и %s_FILE", name, name))
+				continue
+			}
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s_FILE: ошибка при чтении файла: %w", name, err))
+			continue
+		}
+		os.Setenv(name, strings.TrimRight(string(data), "\r\n"))
+		secretsFromFiles[name] = true
+	}
+	return errs
+}
+
+// Префикс и суффикс зашифрованного значения: ENC[age:<base64>]
+const (
+	encryptedPrefix = "ENC[age:"
+	encryptedSuffix = "]"
+)
+
+// Расшифрованные значения; при перезагрузке возвращаются зашифрованные, чтобы
+// профиль и префикс WINDALERTS_ сбрасывались как обычно
+var decryptedOverrides = map[string]envOverride{}
+
+// Зашифровано ли значение
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix) && strings.HasSuffix(value, encryptedSuffix)
+}
+
+// Расшифровка значений вида ENC[age:...] ключом из CONFIG_AGE_KEY (или CONFIG_AGE_KEY_FILE),
+// поэтому файл конфигурации с паролями можно хранить в репозитории
+func decryptConfigValues() []error {
+	var encrypted []string
+	for _, name := range configEnvVars {
+		if isEncrypted(os.Getenv(name)) {
+			encrypted = append(encrypted, name)
+		}
+	}
+	if len(encrypted) == 0 {
+		return nil
+	}
+
+	key := os.Getenv("CONFIG_AGE_KEY")
+	if key == "" {
+		return []error{fmt.Errorf("значения %s зашифрованы, но не задан CONFIG_AGE_KEY", strings.Join(encrypted, ", "))}
+	}
+	identities, err := age.ParseIdentities(strings.NewReader(key))
+	if err != nil {
+		return []error{fmt.Errorf("CONFIG_AGE_KEY: %w", err)}
+	}
+
+	var errs []error
+	for _, name := range encrypted {
+		value, err := decryptValue(os.Getenv(name), identities)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: ошибка при расшифровке: %w", name, err))
+			continue
+		}
+		overrideEnv(decryptedOverrides, name, value)
+	}
+	return errs
+}
+
+// Расшифровка одного значения ENC[age:...]
+func decryptValue(value string, identities []age.Identity) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(value, encryptedPrefix), encryptedSuffix))
+	if err != nil {
+		return "", fmt.Errorf("некорректный base64: %w", err)
+	}
+	r, err := age.Decrypt(bytes.NewReader(data), identities...)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Шифрование значения для получателей age
+func encryptValue(value string, recipients []age.Recipient) (string, error) {
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipients...)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.WriteString(w, value); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(buf.Bytes()) + encryptedSuffix, nil
+}
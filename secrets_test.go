@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestDecryptValue(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := encryptValue("пароль SMTP", []age.Recipient{other.Recipient(), identity.Recipient()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isEncrypted(encrypted) {
+		t.Fatalf("значение %q не распознано как зашифрованное", encrypted)
+	}
+	stranger, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Последний символ base64 перед "]" меняется, чтобы повредить тег последнего блока
+	tampered := encrypted[:len(encrypted)-3] + "AA" + encryptedSuffix
+
+	tests := []struct {
+		name       string
+		value      string
+		identities []age.Identity
+		want       string
+		wantErr    bool
+	}{
+		{"ключ получателя", encrypted, []age.Identity{identity}, "пароль SMTP", false},
+		{"ключ второго получателя", encrypted, []age.Identity{other}, "пароль SMTP", false},
+		{"один из нескольких ключей", encrypted, []age.Identity{stranger, identity}, "пароль SMTP", false},
+		{"чужой ключ", encrypted, []age.Identity{stranger}, "", true},
+		{"некорректный base64", encryptedPrefix + "не base64" + encryptedSuffix, []age.Identity{identity}, "", true},
+		{"не age", encryptedPrefix + "aGVsbG8=" + encryptedSuffix, []age.Identity{identity}, "", true},
+		{"поврежденное значение", tampered, []age.Identity{identity}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decryptValue(tt.value, tt.identities)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ошибка %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("расшифровано %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecryptConfigValues(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := encryptValue("секрет", []age.Recipient{identity.Recipient()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := "# public key: " + identity.Recipient().String() + "\n" + identity.String() + "\n"
+
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr string
+	}{
+		{"файл age-keygen", keyFile, "секрет", ""},
+		{"без ключа", "", encrypted, "не задан CONFIG_AGE_KEY"},
+		{"некорректный ключ", "AGE-SECRET-KEY-1QQQ", encrypted, "CONFIG_AGE_KEY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SMTP_PASSWORD", encrypted)
+			t.Setenv("CONFIG_AGE_KEY", tt.key)
+			t.Cleanup(func() { decryptedOverrides = map[string]envOverride{} })
+
+			errs := decryptConfigValues()
+			switch {
+			case tt.wantErr == "" && len(errs) > 0:
+				t.Fatalf("неожиданные ошибки: %v", errs)
+			case tt.wantErr != "" && (len(errs) == 0 || !strings.Contains(errs[0].Error(), tt.wantErr)):
+				t.Fatalf("ошибки %v, ожидалась %q", errs, tt.wantErr)
+			}
+			if got := os.Getenv("SMTP_PASSWORD"); got != tt.want {
+				t.Errorf("SMTP_PASSWORD = %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+}
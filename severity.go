@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Уровень опасности предупреждения
+type Severity int
+
+const (
+	SeverityNone   Severity = iota // Порывы ветра в норме
+	SeverityYellow                 // Превышен основной порог
+	SeverityOrange                 // Превышен оранжевый порог
+	SeverityRed                    // Превышен красный порог
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityYellow:
+		return "yellow"
+	case SeverityOrange:
+		return "orange"
+	case SeverityRed:
+		return "red"
+	default:
+		return "none"
+	}
+}
+
+// Название уровня опасности для сообщений
+func (s Severity) Title() string {
+	switch s {
+	case SeverityYellow:
+		return "желтый"
+	case SeverityOrange:
+		return "оранжевый"
+	case SeverityRed:
+		return "красный"
+	default:
+		return "нет"
+	}
+}
+
+// Название уровня опасности на языке сообщений
+func (s Severity) TitleIn(language string) string {
+	if language != languageEN {
+		return s.Title()
+	}
+	switch s {
+	case SeverityYellow:
+		return "yellow"
+	case SeverityOrange:
+		return "orange"
+	case SeverityRed:
+		return "red"
+	default:
+		return "none"
+	}
+}
+
+// Цвет уровня опасности для оформления сообщений
+func (s Severity) Color() string {
+	switch s {
+	case SeverityYellow:
+		return "#f0c300"
+	case SeverityOrange:
+		return "#f08a00"
+	case SeverityRed:
+		return "#d9534f"
+	default:
+		return "#3c763d"
+	}
+}
+
+// Разбор уровня опасности из строки
+func parseSeverity(value string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "none":
+		return SeverityNone, nil
+	case "yellow":
+		return SeverityYellow, nil
+	case "orange":
+		return SeverityOrange, nil
+	case "red":
+		return SeverityRed, nil
+	default:
+		return SeverityNone, fmt.Errorf("неизвестный уровень опасности %q (ожидается yellow, orange или red)", value)
+	}
+}
+
+// Пороги уровней опасности сверх основного порога WIND_GUST_THRESHOLD
+type SeverityConfig struct {
+	OrangeThreshold float64 // Порог оранжевого уровня в м/с
+	RedThreshold    float64 // Порог красного уровня в м/с
+}
+
+// Загрузка порогов уровней опасности из переменных окружения
+func loadSeverityConfig(windGustThreshold float64, units string) SeverityConfig {
+	cfg := SeverityConfig{
+		OrangeThreshold: windGustThreshold + 5, // По умолчанию на 5 м/с выше основного порога
+		RedThreshold:    windGustThreshold + 10,
+	}
+
+	if envOrange := os.Getenv("WIND_GUST_ORANGE_THRESHOLD"); envOrange != "" {
+		if val, err := strconv.ParseFloat(envOrange, 64); err == nil {
+			cfg.OrangeThreshold = toMetersPerSecond(val, units)
+		} else {
+			logWarnf("Ошибка парсинга WIND_GUST_ORANGE_THRESHOLD: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	if envRed := os.Getenv("WIND_GUST_RED_THRESHOLD"); envRed != "" {
+		if val, err := strconv.ParseFloat(envRed, 64); err == nil {
+			cfg.RedThreshold = toMetersPerSecond(val, units)
+		} else {
+			logWarnf("Ошибка парсинга WIND_GUST_RED_THRESHOLD: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	return cfg
+}
+
+// Определение уровня опасности по максимальному порыву ветра
+func severityFor(maxWindGust, windGustThreshold float64, cfg SeverityConfig) Severity {
+	switch {
+	case maxWindGust > cfg.RedThreshold:
+		return SeverityRed
+	case maxWindGust > cfg.OrangeThreshold:
+		return SeverityOrange
+	case maxWindGust > windGustThreshold:
+		return SeverityYellow
+	default:
+		return SeverityNone
+	}
+}
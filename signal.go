@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Настройки отправки уведомлений в Signal через signal-cli-rest-api
+type SignalConfig struct {
+	APIURL  string // Адрес signal-cli-rest-api, например http://localhost:8080
+	Number  string // Номер отправителя, зарегистрированный в signal-cli
+	GroupID string // Идентификатор группы из /v1/groups (вида group.xxxx)
+}
+
+// Загрузка настроек Signal из переменных окружения
+func loadSignalConfig() SignalConfig {
+	return SignalConfig{
+		APIURL:  strings.TrimSuffix(os.Getenv("SIGNAL_API_URL"), "/"),
+		Number:  os.Getenv("SIGNAL_NUMBER"),
+		GroupID: os.Getenv("SIGNAL_GROUP_ID"),
+	}
+}
+
+// Отправка предупреждения в группу Signal
+type signalNotifier struct {
+	config SignalConfig
+}
+
+func newSignalNotifier(config SignalConfig) *signalNotifier {
+	return &signalNotifier{config: config}
+}
+
+func (n *signalNotifier) Name() string {
+	return "signal"
+}
+
+// Запрос /v2/send signal-cli-rest-api
+type signalMessage struct {
+	Message    string   `json:"message"`
+	Number     string   `json:"number"`
+	Recipients []string `json:"recipients"`
+}
+
+func (n *signalNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	message := signalMessage{
+		Message:    report.text("⚠️ " + formatAlertText(report)),
+		Number:     n.config.Number,
+		Recipients: []string{n.config.GroupID},
+	}
+
+	if _, err := sendJSON(ctx, http.MethodPost, n.config.APIURL+"/v2/send", nil, message); err != nil {
+		return fmt.Errorf("ошибка при отправке сообщения в Signal: %w", err)
+	}
+
+	log.Println("Предупреждение отправлено в Signal")
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// Настройки публикации предупреждений в тему AWS SNS
+type SNSConfig struct {
+	TopicARN string // ARN темы SNS
+	Region   string // Регион AWS (по умолчанию из ARN темы)
+}
+
+// Загрузка настроек SNS из переменных окружения.
+// Учетные данные AWS берутся из стандартной цепочки SDK (переменные окружения, профиль, роль IAM).
+func loadSNSConfig() SNSConfig {
+	cfg := SNSConfig{
+		TopicARN: os.Getenv("SNS_TOPIC_ARN"),
+		Region:   os.Getenv("SNS_REGION"),
+	}
+
+	// ARN имеет вид arn:aws:sns:<регион>:<аккаунт>:<тема>
+	if parts := strings.Split(cfg.TopicARN, ":"); cfg.Region == "" && len(parts) >= 4 {
+		cfg.Region = parts[3]
+	}
+
+	return cfg
+}
+
+// Публикация предупреждений в тему AWS SNS
+type snsNotifier struct {
+	config SNSConfig
+	client *sns.Client
+}
+
+// Создание клиента SNS с учетными данными из окружения
+func newSNSNotifier(config SNSConfig) (*snsNotifier, error) {
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(config.Region))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при загрузке настроек AWS: %w", err)
+	}
+
+	return &snsNotifier{config: config, client: sns.NewFromConfig(awsCfg)}, nil
+}
+
+func (n *snsNotifier) Name() string {
+	return "sns"
+}
+
+func (n *snsNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	subject := alertSubject(report, report.Language)
+	if report.Subject != "" {
+		subject = report.Subject
+	}
+
+	// Атрибуты позволяют подписчикам фильтровать сообщения (filter policy) по уровню опасности и городу
+	input := &sns.PublishInput{
+		TopicArn: aws.String(n.config.TopicARN),
+		Subject:  aws.String(subject),
+		Message:  aws.String(report.text(formatAlertText(report))),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"severity": {DataType: aws.String("String"), StringValue: aws.String(report.Severity.String())},
+			"city":     {DataType: aws.String("String"), StringValue: aws.String(report.City)},
+			"max_gust": {DataType: aws.String("Number"), StringValue: aws.String(strconv.FormatFloat(report.MaxWindGust, 'f', 2, 64))},
+		},
+	}
+
+	output, err := n.client.Publish(ctx, input)
+	if err != nil {
+		return fmt.Errorf("ошибка при публикации в SNS: %w", err)
+	}
+
+	log.Printf("Предупреждение опубликовано в SNS (MessageId: %s)", aws.ToString(output.MessageId))
+	return nil
+}
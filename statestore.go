@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Способы хранения состояния сервиса
+const (
+	storeJSON  = "json"  // Отдельные JSON-файлы (RUN_STATE_FILE, HISTORY_FILE, RETRY_QUEUE_FILE, ESCALATION_FILE, PAUSE_FILE)
+	storeBolt  = "bolt"  // Один файл bbolt (STORE_FILE)
+	storeRedis = "redis" // Сервер Redis, общий для нескольких экземпляров сервиса (REDIS_URL)
+)
+
+// Настройки хранения состояния
+type StoreConfig struct {
+	Backend     string        // json, bolt или redis
+	File        string        // Файл bbolt
+	RedisURL    string        // Адрес Redis, например redis://:пароль@redis:6379/0
+	RedisPrefix string        // Префикс ключей Redis, чтобы несколько сервисов могли использовать одну базу
+	LeaderTTL   time.Duration // Срок, на который экземпляр получает право рассылки
+}
+
+// Загрузка настроек хранения состояния из переменных окружения
+func loadStoreConfig() StoreConfig {
+	cfg := StoreConfig{
+		Backend:     storeJSON,
+		File:        "weather-state.db",
+		RedisURL:    os.Getenv("REDIS_URL"),
+		RedisPrefix: "windalerts:",
+		LeaderTTL:   15 * time.Second,
+	}
+
+	switch envBackend := os.Getenv("STORE_BACKEND"); envBackend {
+	case "", storeJSON:
+	case storeBolt, "bbolt":
+		cfg.Backend = storeBolt
+	case storeRedis:
+		cfg.Backend = storeRedis
+	default:
+		logWarnf("Ошибка парсинга STORE_BACKEND: ожидается json, bolt или redis, получено %q, используется значение по умолчанию", envBackend)
+	}
+	if envFile := os.Getenv("STORE_FILE"); envFile != "" {
+		cfg.File = envFile
+	}
+	if envPrefix, ok := os.LookupEnv("REDIS_PREFIX"); ok {
+		cfg.RedisPrefix = envPrefix
+	}
+
+	if envTTL := os.Getenv("REDIS_LEADER_TTL"); envTTL != "" {
+		if val, err := time.ParseDuration(envTTL); err == nil && val >= time.Second {
+			cfg.LeaderTTL = val
+		} else {
+			logWarnf("Ошибка парсинга REDIS_LEADER_TTL: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	return cfg
+}
+
+// Ключи состояния в хранилище
+const (
+	stateRun           = "run_state"
+	stateHistory       = "alert_history"
+	stateRetry         = "retry_queue"
+	stateEscalation    = "escalation"
+	stateSubscriptions = "subscriptions"
+	statePause         = "pause"
+)
+
+// Хранилище состояния сервиса: отметки о проверках, история предупреждений, очередь
+// повторной доставки и ожидание подтверждений. Значения хранятся в JSON, как в файлах.
+type StateStore interface {
+	// Чтение значения; nil - значение еще не сохранялось
+	read(key, file string) ([]byte, error)
+	// Запись значения
+	write(key, file string, data []byte) error
+	// Сохраняется ли значение между перезапусками
+	persistent(file string) bool
+	// Используется ли состояние несколькими экземплярами сервиса одновременно
+	shared() bool
+	// Рассылает ли уведомления этот экземпляр; с общим состоянием - только выбранный
+	leading() bool
+	// Вызов fn каждый раз, когда экземпляр получает право рассылки
+	onLeading(fn func())
+	Close() error
+}
+
+// Рассылает ли уведомления другой экземпляр сервиса; name - пропускаемое действие для журнала
+func standby(store StateStore, name string) bool {
+	if store.leading() {
+		return false
+	}
+	log.Printf("Уведомления рассылает другой экземпляр сервиса, %s пропущена", name)
+	return true
+}
+
+// Открытие хранилища по STORE_BACKEND
+func openStateStore(config StoreConfig) (StateStore, error) {
+	if config.Backend == storeRedis {
+		return openRedisStateStore(config)
+	}
+	if config.Backend != storeBolt {
+		return jsonStateStore{}, nil
+	}
+
+	// Ожидание блокировки ограничено, чтобы второй экземпляр сервиса не зависал при запуске
+	db, err := bolt.Open(config.File, 0o644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при открытии %s: %w", config.File, err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltStateBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ошибка при создании хранилища %s: %w", config.File, err)
+	}
+	log.Printf("Состояние сервиса хранится в %s (bbolt)", config.File)
+	return boltStateStore{db: db}, nil
+}
+
+// JSON-файлы, заданные настройками каждого компонента; без файла состояние хранится только в памяти
+type jsonStateStore struct{}
+
+func (jsonStateStore) read(key, file string) ([]byte, error) {
+	if file == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Запись через временный файл, чтобы при сбое не остался обрезанный файл
+func (jsonStateStore) write(key, file string, data []byte) error {
+	if file == "" {
+		return nil
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+func (jsonStateStore) persistent(file string) bool {
+	return file != ""
+}
+
+func (jsonStateStore) shared() bool {
+	return false
+}
+
+func (jsonStateStore) leading() bool {
+	return true
+}
+
+func (jsonStateStore) onLeading(func()) {}
+
+func (jsonStateStore) Close() error {
+	return nil
+}
+
+// Бакет bbolt со значениями состояния по ключам
+var boltStateBucket = []byte("state")
+
+// Встроенная база bbolt на чистом Go: все состояние в одном файле без CGO и SQLite.
+// Пути к JSON-файлам не используются, состояние сохраняется всегда.
+type boltStateStore struct {
+	db *bolt.DB
+}
+
+func (s boltStateStore) read(key, file string) ([]byte, error) {
+	var data []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		// Значение действительно только внутри транзакции, поэтому копируется
+		if v := tx.Bucket(boltStateBucket).Get([]byte(key)); v != nil {
+			data = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	return data, err
+}
+
+func (s boltStateStore) write(key, file string, data []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltStateBucket).Put([]byte(key), data)
+	})
+}
+
+func (boltStateStore) persistent(file string) bool {
+	return true
+}
+
+func (boltStateStore) shared() bool {
+	return false
+}
+
+func (boltStateStore) leading() bool {
+	return true
+}
+
+func (boltStateStore) onLeading(func()) {}
+
+func (s boltStateStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Настройки отправки метрик в StatsD/DogStatsD (агент Datadog, Telegraf)
+type StatsDConfig struct {
+	Host   string   // Адрес агента (STATSD_HOST); пустая строка - метрики не отправляются
+	Port   int      // UDP-порт агента
+	Prefix string   // Префикс имен метрик
+	Tags   []string // Общие теги DogStatsD вида ключ:значение
+}
+
+// Загрузка настроек StatsD из переменных окружения
+func loadStatsDConfig() StatsDConfig {
+	cfg := StatsDConfig{
+		Host:   os.Getenv("STATSD_HOST"),
+		Port:   8125,
+		Prefix: "windalerts.",
+	}
+
+	if envPort := os.Getenv("STATSD_PORT"); envPort != "" {
+		if val, err := strconv.Atoi(envPort); err == nil && val >= 1 && val <= 65535 {
+			cfg.Port = val
+		} else {
+			logWarnf("Ошибка парсинга STATSD_PORT: %v, используется значение по умолчанию", err)
+		}
+	}
+	if envPrefix, ok := os.LookupEnv("STATSD_PREFIX"); ok {
+		cfg.Prefix = envPrefix
+	}
+	for _, tag := range strings.Split(os.Getenv("STATSD_TAGS"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			cfg.Tags = append(cfg.Tags, tag)
+		}
+	}
+
+	return cfg
+}
+
+// Отправка метрик по UDP в формате DogStatsD: имя:значение|тип|#теги.
+// Ошибки отправки не мешают работе сервиса и выводятся в журнал только при LOG_LEVEL=debug.
+type statsdClient struct {
+	conn   net.Conn
+	prefix string
+	tags   []string
+}
+
+func newStatsdClient(config StatsDConfig) *statsdClient {
+	if config.Host == "" {
+		return nil
+	}
+	conn, err := net.Dial("udp", net.JoinHostPort(config.Host, strconv.Itoa(config.Port)))
+	if err != nil {
+		logErrorf("Ошибка при подключении к StatsD %s:%d: %v, метрики не отправляются", config.Host, config.Port, err)
+		return nil
+	}
+	return &statsdClient{conn: conn, prefix: config.Prefix, tags: config.Tags}
+}
+
+func (c *statsdClient) send(name, value, kind string, tags ...string) {
+	if c == nil {
+		return
+	}
+	line := c.prefix + name + ":" + value + "|" + kind
+	if all := append(append([]string{}, c.tags...), tags...); len(all) > 0 {
+		line += "|#" + strings.Join(all, ",")
+	}
+	if _, err := c.conn.Write([]byte(line)); err != nil {
+		logDebugf("Ошибка при отправке метрики в StatsD: %v", err)
+	}
+}
+
+func (c *statsdClient) gauge(name string, value float64, tags ...string) {
+	c.send(name, strconv.FormatFloat(value, 'f', -1, 64), "g", tags...)
+}
+
+func (c *statsdClient) count(name string, value int, tags ...string) {
+	c.send(name, strconv.Itoa(value), "c", tags...)
+}
+
+func (c *statsdClient) timing(name string, d time.Duration, tags ...string) {
+	c.send(name, strconv.FormatInt(d.Milliseconds(), 10), "ms", tags...)
+}
+
+// Тег DogStatsD: запятые и вертикальные черты в значении разделяют теги и поля пакета
+func statsdTag(key, value string) string {
+	return fmt.Sprintf("%s:%s", key, strings.NewReplacer(",", "_", "|", "_", "#", "_").Replace(value))
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Состояние сервиса для интеграций: GET /api/v1/status. Скорость ветра во всех ответах API - в м/с.
+type apiStatus struct {
+	Status      string        `json:"status"` // ok, alert или unknown (проверок еще не было)
+	Severity    string        `json:"severity"`
+	Paused      bool          `json:"paused"`
+	PausedUntil *time.Time    `json:"paused_until,omitempty"`
+	DryRun      bool          `json:"dry_run"`
+	NextCheck   *time.Time    `json:"next_check,omitempty"`
+	AlertsToday int           `json:"alerts_today"`
+	LastAlertAt *time.Time    `json:"last_alert_at,omitempty"`
+	Locations   []apiLocation `json:"locations"`
+}
+
+// Результат последней проверки пункта
+type apiLocation struct {
+	City              string    `json:"city"`
+	CheckedAt         time.Time `json:"checked_at"`
+	ExceedsThreshold  bool      `json:"exceeds_threshold"`
+	Severity          string    `json:"severity"`
+	MaxWindGust       float64   `json:"max_wind_gust"`
+	WindGustThreshold float64   `json:"wind_gust_threshold"`
+	Rule              string    `json:"rule,omitempty"`
+	Reason            string    `json:"reason,omitempty"`
+}
+
+// Оцененные точки прогноза пункта: GET /api/v1/forecast
+type apiForecast struct {
+	City              string             `json:"city"`
+	CheckedAt         time.Time          `json:"checked_at"`
+	WindGustThreshold float64            `json:"wind_gust_threshold"`
+	Points            []apiForecastPoint `json:"points"`
+}
+
+// Точка прогноза порывов ветра
+type apiForecastPoint struct {
+	Time             time.Time `json:"time"`
+	WindGust         float64   `json:"wind_gust"`
+	ExceedsThreshold bool      `json:"exceeds_threshold"`
+}
+
+// Выпущенное предупреждение: GET /api/v1/alerts
+type apiAlert struct {
+	ID                string             `json:"id"`
+	City              string             `json:"city"`
+	IssuedAt          time.Time          `json:"issued_at"`
+	MaxWindGust       float64            `json:"max_wind_gust"`
+	WindGustThreshold float64            `json:"wind_gust_threshold"`
+	Forecasts         []apiForecastPoint `json:"forecasts"`
+}
+
+// Маршруты API /api/v1 для интеграции с внутренними системами и табло; доступны только для чтения
+func (d *Dashboard) registerAPIRoutes(mux *http.ServeMux, store *ConfigStore, scheduler Scheduler, history *AlertHistory, pause *PauseControl) {
+	mux.HandleFunc("/api/v1/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+			return
+		}
+		writeJSON(w, http.StatusOK, d.status(store.Load(), scheduler, history, pause))
+	})
+	mux.HandleFunc("/api/v1/forecast", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+			return
+		}
+		city := r.URL.Query().Get("city")
+		forecasts := []apiForecast{}
+		for _, report := range d.latest() {
+			if city != "" && !strings.EqualFold(report.City, city) {
+				continue
+			}
+			forecasts = append(forecasts, apiForecastOf(report))
+		}
+		if city != "" && len(forecasts) == 0 {
+			writeError(w, http.StatusNotFound, "city: проверок пункта еще не было")
+			return
+		}
+		writeJSON(w, http.StatusOK, forecasts)
+	})
+	mux.HandleFunc("/api/v1/alerts", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+			return
+		}
+		// Дата считается в часовом поясе пункта (TIMEZONE), по умолчанию - сегодня
+		day := store.Load().Clock.Now()
+		if date := r.URL.Query().Get("date"); date != "" {
+			t, err := time.ParseInLocation("2006-01-02", date, day.Location())
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "date: ожидается дата ГГГГ-ММ-ДД")
+				return
+			}
+			day = t
+		}
+		alerts := []apiAlert{}
+		for _, record := range alertsOn(history, day) {
+			alerts = append(alerts, apiAlertOf(record))
+		}
+		writeJSON(w, http.StatusOK, alerts)
+	})
+	mux.HandleFunc("/api/v1/stream", d.handleStream(store, scheduler, history, pause))
+	history.onAdd(d.publishAlert)
+}
+
+// Сводное состояние по последним проверкам пунктов
+func (d *Dashboard) status(config *Config, scheduler Scheduler, history *AlertHistory, pause *PauseControl) apiStatus {
+	now := config.Clock.Now()
+	pauseStatus := pause.Status()
+	status := apiStatus{
+		Status:      "unknown",
+		Severity:    SeverityNone.String(),
+		Paused:      pauseStatus.Paused,
+		PausedUntil: pauseStatus.Until,
+		DryRun:      config.DryRun,
+		Locations:   []apiLocation{},
+	}
+	if t := scheduler.Next(now); !t.IsZero() {
+		status.NextCheck = &t
+	}
+	if today := alertsOn(history, now); len(today) > 0 {
+		status.AlertsToday = len(today)
+		status.LastAlertAt = &today[len(today)-1].IssuedAt
+	}
+
+	reports := d.latest()
+	if len(reports) > 0 {
+		status.Status = "ok"
+	}
+	if worst := mostSevere(reports); worst != nil {
+		status.Status, status.Severity = "alert", worst.Severity.String()
+	}
+	for _, report := range reports {
+		status.Locations = append(status.Locations, apiLocation{
+			City:              report.City,
+			CheckedAt:         report.CheckedAt,
+			ExceedsThreshold:  report.ExceedsThreshold,
+			Severity:          report.Severity.String(),
+			MaxWindGust:       report.MaxWindGust,
+			WindGustThreshold: report.WindGustThreshold,
+			Rule:              report.Rule,
+			Reason:            report.Reason,
+		})
+	}
+	return status
+}
+
+// Прогноз пункта по результату проверки
+func apiForecastOf(report *AlertReport) apiForecast {
+	return apiForecast{
+		City:              report.City,
+		CheckedAt:         report.CheckedAt,
+		WindGustThreshold: report.WindGustThreshold,
+		Points:            apiForecastPoints(report.Points, report.WindGustThreshold),
+	}
+}
+
+// Предупреждение по записи истории
+func apiAlertOf(record AlertRecord) apiAlert {
+	return apiAlert{
+		ID:                record.ID,
+		City:              record.City,
+		IssuedAt:          record.IssuedAt,
+		MaxWindGust:       record.MaxWindGust,
+		WindGustThreshold: record.WindGustThreshold,
+		Forecasts:         apiForecastPoints(record.Forecasts, record.WindGustThreshold),
+	}
+}
+
+// Точки прогноза с отметкой превышения порога
+func apiForecastPoints(points []WindGustForecast, threshold float64) []apiForecastPoint {
+	result := make([]apiForecastPoint, 0, len(points))
+	for _, p := range points {
+		result = append(result, apiForecastPoint{Time: p.Time, WindGust: p.WindGust, ExceedsThreshold: p.WindGust > threshold})
+	}
+	return result
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Команда status: результаты проверок и доставка предупреждений по получателям из базы
+// истории (HISTORY_DB), как /api/history, но без запуска сервиса.
+// Возвращает код завершения: 0 - успешно, 1 - ошибка чтения базы, 2 - неверные аргументы.
+func runStatus(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("windalerts status", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	city := fs.String("city", "", "только указанный пункт (без учета регистра)")
+	since := fs.String("since", "", "не раньше даты ГГГГ-ММ-ДД")
+	all := fs.Bool("all", false, "включая проверки без превышения порога")
+	limit := fs.Int("limit", 20, "число проверок, от 1 до 1000")
+	asJSON := fs.Bool("json", false, "вывод в JSON, как /api/history")
+	path := fs.String("db", "", "файл SQLite или адрес postgres:// базы истории (по умолчанию HISTORY_DB)")
+	profile := fs.String("profile", "", "профиль конфигурации (PROFILE)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Использование: windalerts status [флаги]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "Неожиданные аргументы: %s\n", strings.Join(fs.Args(), " "))
+		return 2
+	}
+
+	query := HistoryQuery{City: *city, AlertsOnly: !*all, Limit: *limit}
+	if *limit < 1 || *limit > 1000 {
+		fmt.Fprintln(stderr, "--limit: ожидается число от 1 до 1000")
+		return 2
+	}
+	if *since != "" {
+		t, err := time.ParseInLocation("2006-01-02", *since, time.Local)
+		if err != nil {
+			fmt.Fprintln(stderr, "--since: ожидается дата ГГГГ-ММ-ДД")
+			return 2
+		}
+		query.Since = t
+	}
+
+	db, err := openHistoryDBForCommand(*path, *profile, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer db.Close()
+
+	records, err := db.Query(query)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(records); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+	writeStatus(stdout, records)
+	return 0
+}
+
+// Загрузка окружения для команды: файлы конфигурации, секреты и профиль, как при запуске сервиса
+func loadCommandEnv(profile string, stderr io.Writer) error {
+	if profile != "" {
+		os.Setenv("PROFILE", profile)
+		flagVars["PROFILE"] = true
+	}
+	if _, err := loadConfigFiles(); err != nil {
+		return fmt.Errorf("CONFIG_FILE: %w", err)
+	}
+	applyEnvNamespace()
+	for _, err := range loadSecretFiles() {
+		fmt.Fprintln(stderr, err)
+	}
+	if err := applyProfile(); err != nil {
+		return fmt.Errorf("PROFILE: %w", err)
+	}
+	return nil
+}
+
+// База истории для команд status и history. Путь берется из --db или из той же
+// конфигурации, что и при запуске сервиса; ошибки файлов секретов выводятся в stderr.
+func openHistoryDBForCommand(path, profile string, stderr io.Writer) (*HistoryDB, error) {
+	if path == "" {
+		if err := loadCommandEnv(profile, stderr); err != nil {
+			return nil, err
+		}
+		path = os.Getenv("HISTORY_DB")
+	}
+	if path == "" {
+		return nil, errors.New("База истории не настроена: укажите HISTORY_DB или --db")
+	}
+	// Команды только читают базу и не должны создавать пустую по ошибочному пути
+	if !isHistoryDBURL(path) {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("Ошибка при открытии базы истории: %w", err)
+		}
+	}
+	return openHistoryDB(path)
+}
+
+// Вывод проверок с доставкой по каналам и получателям в виде таблицы
+func writeStatus(w io.Writer, records []CheckRecord) {
+	if len(records) == 0 {
+		fmt.Fprintln(w, "Записей не найдено")
+		return
+	}
+
+	for i, r := range records {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		title := fmt.Sprintf("%s  %s  %s  порывы %.1f м/с, порог %.1f м/с",
+			r.CheckedAt.Local().Format("2006-01-02 15:04"), r.City, r.Severity, r.MaxWindGust, r.WindGustThreshold)
+		if r.Rule != "" {
+			title += ", правило " + r.Rule
+		}
+		if r.Reminder {
+			title += ", напоминание"
+		}
+		fmt.Fprintln(w, title)
+
+		if len(r.Deliveries) == 0 {
+			fmt.Fprintln(w, "  нет записей о доставке")
+			continue
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, d := range r.Deliveries {
+			recipient := d.Recipient
+			if recipient == "" {
+				recipient = "-"
+			}
+			attempt := ""
+			if d.Attempt > 0 {
+				attempt = fmt.Sprintf("попытка %d", d.Attempt)
+			}
+			line := fmt.Sprintf("  %s\t%s\t%s\t%s\t%s", d.Channel, recipient, d.Status, attempt, d.DeliveredAt.Local().Format("15:04:05"))
+			if d.Error != "" {
+				line += "\t" + d.Error
+			}
+			fmt.Fprintln(tw, line)
+		}
+		tw.Flush()
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Интервал комментариев-пингов в потоке событий: прокси не закрывают соединение без данных
+const streamPingInterval = 30 * time.Second
+
+// Очередь событий клиента; медленный клиент пропускает события сверх очереди
+const streamBufferSize = 16
+
+// Событие потока /api/v1/stream: результат проверки пункта или выпущенное предупреждение
+type streamEvent struct {
+	report *AlertReport
+	alert  *AlertRecord
+}
+
+// Подключение клиента к потоку событий
+func (d *Dashboard) subscribe() chan streamEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ch := make(chan streamEvent, streamBufferSize)
+	d.streams[ch] = struct{}{}
+	return ch
+}
+
+// Отключение клиента от потока событий
+func (d *Dashboard) unsubscribe(ch chan streamEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.streams, ch)
+}
+
+// Рассылка события подключенным клиентам
+func (d *Dashboard) publish(event streamEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for ch := range d.streams {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Рассылка выпущенного предупреждения; записи о проверках без превышения не рассылаются
+func (d *Dashboard) publishAlert(record AlertRecord) {
+	if record.Kind == recordAlert {
+		d.publish(streamEvent{alert: &record})
+	}
+}
+
+// Завершение потоков при остановке сервиса, чтобы открытые соединения не задерживали остановку HTTP-сервера
+func (d *Dashboard) closeStreams() {
+	d.closeOnce.Do(func() { close(d.done) })
+}
+
+// GET /api/v1/stream - поток Server-Sent Events для табло и страниц без опроса: сразу после
+// подключения и после каждой проверки приходят события status и forecast, при выпуске
+// предупреждения - alert. Данные событий совпадают с ответами /api/v1/status,
+// /api/v1/forecast (один пункт) и /api/v1/alerts (одно предупреждение).
+func (d *Dashboard) handleStream(store *ConfigStore, scheduler Scheduler, history *AlertHistory, pause *PauseControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeError(w, http.StatusInternalServerError, "потоковая передача не поддерживается")
+			return
+		}
+
+		events := d.subscribe()
+		defer d.unsubscribe(events)
+
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		// Отключает буферизацию ответа в nginx
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		send := func(name string, v any) bool {
+			data, err := json.Marshal(v)
+			if err != nil {
+				logErrorf("Ошибка при формировании события %s: %v", name, err)
+				return true
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+				return false
+			}
+			flusher.Flush()
+			return true
+		}
+		sendStatus := func() bool {
+			return send("status", d.status(store.Load(), scheduler, history, pause))
+		}
+
+		// Переподключение через 10 секунд, если соединение оборвалось
+		fmt.Fprint(w, "retry: 10000\n\n")
+		if !sendStatus() {
+			return
+		}
+		for _, report := range d.latest() {
+			if !send("forecast", apiForecastOf(report)) {
+				return
+			}
+		}
+
+		ping := time.NewTicker(streamPingInterval)
+		defer ping.Stop()
+		for {
+			var ok bool
+			select {
+			case event := <-events:
+				if event.alert != nil {
+					ok = send("alert", apiAlertOf(*event.alert))
+				} else {
+					ok = sendStatus() && send("forecast", apiForecastOf(event.report))
+				}
+			case <-ping.C:
+				_, err := fmt.Fprint(w, ": ping\n\n")
+				flusher.Flush()
+				ok = err == nil
+			case <-r.Context().Done():
+				return
+			case <-d.done:
+				return
+			}
+			if !ok {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Строгий режим (STRICT_CONFIG=true): неизвестные переменные в файлах конфигурации и
+// переменные с префиксом WINDALERTS_, а также значения, которые не удалось разобрать,
+// останавливают запуск вместо записи в журнал и значения по умолчанию
+func strictConfig() bool {
+	strict, _ := strconv.ParseBool(os.Getenv("STRICT_CONFIG"))
+	return strict
+}
+
+// Проверка конфигурации в строгом режиме: все проблемы одной ошибкой
+func checkStrictConfig() error {
+	problems := unknownConfigVariables()
+	problems = append(problems, validateConfig()...)
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("строгий режим, найдено проблем в конфигурации: %d\n- %s", len(problems), strings.Join(problems, "\n- "))
+}
+
+// Неизвестные переменные: из файлов конфигурации и из окружения с префиксом WINDALERTS_.
+// Переменные окружения без префикса не проверяются - в них есть и чужие (PATH, HOME).
+func unknownConfigVariables() configProblems {
+	var p configProblems
+	seen := map[string]bool{}
+	report := func(name string) {
+		base := strings.TrimPrefix(name, envPrefix)
+		if seen[base] || isConfigVariable(base) {
+			return
+		}
+		seen[base] = true
+		if hint := closestConfigVariable(base); hint != "" {
+			p.add("%s: неизвестный параметр, возможно, имелся в виду %s", name, hint)
+		} else {
+			p.add("%s: неизвестный параметр", name)
+		}
+	}
+
+	for _, name := range configFileNames {
+		report(name)
+	}
+	var prefixed []string
+	for _, kv := range os.Environ() {
+		if name, _, _ := strings.Cut(kv, "="); strings.HasPrefix(name, envPrefix) {
+			prefixed = append(prefixed, name)
+		}
+	}
+	sort.Strings(prefixed)
+	for _, name := range prefixed {
+		report(name)
+	}
+	return p
+}
+
+// Ближайшее по написанию имя параметра (не более двух опечаток) для подсказки
+func closestConfigVariable(name string) string {
+	suffix := ""
+	if strings.HasSuffix(name, "_FILE") {
+		name, suffix = strings.TrimSuffix(name, "_FILE"), "_FILE"
+	}
+	prefix := ""
+	if profile, profileName, ok := strings.Cut(name, profileSeparator); ok {
+		prefix, name = profile+profileSeparator, profileName
+	}
+
+	best, bestDistance := "", 3
+	for _, known := range configEnvVars {
+		if d := editDistance(name, known); d < bestDistance {
+			best, bestDistance = known, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return prefix + best + suffix
+}
+
+// Расстояние Левенштейна между строками
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
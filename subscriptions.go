@@ -0,0 +1,429 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	errRecipientExists   = errors.New("получатель уже есть в рассылке")
+	errRecipientNotFound = errors.New("получатель не найден")
+)
+
+// Изменения списка рассылки, сделанные через /api/recipients
+type subscriptionState struct {
+	Recipients []Recipient `json:"recipients,omitempty"` // Добавленные получатели и измененные настройки; порог в единицах UNITS
+	Removed    []string    `json:"removed,omitempty"`    // Адреса из EMAIL_TO и RECIPIENTS_FILE, исключенные из рассылки
+}
+
+// Подписки: получатели писем и их настройки, которыми управляют через API без изменения
+// переменных окружения и перезапуска. Изменения сохраняются в хранилище состояния
+// и накладываются на получателей из EMAIL_TO и RECIPIENTS_FILE при каждой загрузке конфигурации.
+type Subscriptions struct {
+	store StateStore
+	file  string
+
+	mu      sync.Mutex
+	state   subscriptionState
+	configs *ConfigStore
+}
+
+// Создание подписок с загрузкой сохраненного состояния
+func loadSubscriptions(store StateStore, file string) (*Subscriptions, error) {
+	s := &Subscriptions{store: store, file: file}
+	// Получив право рассылки, экземпляр применяет изменения, сделанные через другие экземпляры
+	store.onLeading(func() {
+		s.mu.Lock()
+		configs := s.configs
+		s.mu.Unlock()
+		if configs != nil {
+			configs.applySubscriptions()
+		}
+	})
+
+	data, err := store.read(stateSubscriptions, file)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении подписок: %w", err)
+	}
+	if data == nil {
+		return s, nil
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе подписок: %w", err)
+	}
+	return s, nil
+}
+
+// Подключение к конфигурации: подписки применяются сразу и после каждой перезагрузки
+func (s *Subscriptions) attach(configs *ConfigStore) {
+	s.mu.Lock()
+	s.configs = configs
+	s.mu.Unlock()
+	configs.attachSubscriptions(s)
+}
+
+// Конфигурация с получателями и настройками из подписок; исходная конфигурация не изменяется
+func (s *Subscriptions) apply(config *Config) *Config {
+	if s == nil {
+		return config
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	if len(s.state.Recipients) == 0 && len(s.state.Removed) == 0 {
+		return config
+	}
+
+	// Исключенные адреса и адреса с настройками из API
+	replaced := map[string]bool{}
+	for _, email := range s.state.Removed {
+		replaced[strings.ToLower(email)] = true
+	}
+	for _, r := range s.state.Recipients {
+		replaced[strings.ToLower(r.Email)] = true
+	}
+
+	c := *config
+	c.EmailTo, c.RecipientProfiles = nil, nil
+	for _, email := range config.EmailTo {
+		if !replaced[strings.ToLower(email)] {
+			c.EmailTo = append(c.EmailTo, email)
+		}
+	}
+	for _, profile := range config.RecipientProfiles {
+		if profile.Email == "" || !replaced[strings.ToLower(profile.Email)] {
+			c.RecipientProfiles = append(c.RecipientProfiles, profile)
+		}
+	}
+	for _, r := range s.state.Recipients {
+		r.Channels = slices.Clone(r.Channels)
+		r.Threshold = toMetersPerSecond(r.Threshold, config.Units)
+		if r.Units == "" {
+			r.Units = config.Units
+		}
+		if r.Language == "" {
+			r.Language = config.Language
+		}
+		c.RecipientProfiles = append(c.RecipientProfiles, r)
+		c.EmailTo = append(c.EmailTo, r.Email)
+	}
+	// Исключенные адреса не получают и письма мероприятий, сводки, пунктов и отдельного времени доставки
+	c.Unsubscribed = slices.Clone(s.state.Removed)
+	return &c
+}
+
+// Сохранение в хранилище; вызывается с захваченной блокировкой
+func (s *Subscriptions) save() error {
+	if !s.store.persistent(s.file) {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка при формировании JSON: %w", err)
+	}
+	if err := s.store.write(stateSubscriptions, s.file, data); err != nil {
+		return fmt.Errorf("ошибка при записи подписок: %w", err)
+	}
+	return nil
+}
+
+// Загрузка подписок, измененных другими экземплярами сервиса; вызывается с захваченной блокировкой
+func (s *Subscriptions) refresh() {
+	if !s.store.shared() {
+		return
+	}
+
+	data, err := s.store.read(stateSubscriptions, s.file)
+	if err != nil {
+		logErrorf("Ошибка при чтении подписок: %v", err)
+		return
+	}
+	var state subscriptionState
+	if data != nil {
+		if err := json.Unmarshal(data, &state); err != nil {
+			logErrorf("Ошибка при разборе подписок: %v", err)
+			return
+		}
+	}
+	s.state = state
+}
+
+// Изменение подписок под блокировкой с сохранением и применением к активной конфигурации
+func (s *Subscriptions) update(change func(config *Config) error) error {
+	s.mu.Lock()
+	s.refresh()
+	config := s.configs.Load()
+	previous := s.state
+	s.state = subscriptionState{
+		Recipients: slices.Clone(previous.Recipients),
+		Removed:    slices.Clone(previous.Removed),
+	}
+	err := change(config)
+	if err == nil {
+		err = s.save()
+	}
+	if err != nil {
+		s.state = previous
+	}
+	s.mu.Unlock()
+
+	if err == nil {
+		s.configs.applySubscriptions()
+	}
+	return err
+}
+
+// Добавление получателя
+func (s *Subscriptions) Add(r Recipient) error {
+	err := s.update(func(config *Config) error {
+		if subscribed(config, r.Email) {
+			return errRecipientExists
+		}
+		s.state.Removed = slices.DeleteFunc(s.state.Removed, func(email string) bool { return strings.EqualFold(email, r.Email) })
+		s.state.Recipients = append(s.state.Recipients, r)
+		return nil
+	})
+	if err == nil {
+		log.Printf("Получатель %s добавлен в рассылку через API", r.Email)
+	}
+	return err
+}
+
+// Замена настроек получателя; номер телефона и каналы остаются прежними
+func (s *Subscriptions) Update(email string, r Recipient) error {
+	err := s.update(func(config *Config) error {
+		if !subscribed(config, email) {
+			return errRecipientNotFound
+		}
+		current := config.recipientProfile(email)
+		r.Email, r.Phone, r.Channels = current.Email, current.Phone, current.Channels
+		if i := s.index(email); i >= 0 {
+			s.state.Recipients[i] = r
+		} else {
+			s.state.Recipients = append(s.state.Recipients, r)
+		}
+		return nil
+	})
+	if err == nil {
+		log.Printf("Настройки получателя %s изменены через API", email)
+	}
+	return err
+}
+
+// Исключение получателя из рассылки
+func (s *Subscriptions) Remove(email string) error {
+	err := s.update(func(config *Config) error {
+		if !subscribed(config, email) {
+			return errRecipientNotFound
+		}
+		if i := s.index(email); i >= 0 {
+			s.state.Recipients = slices.Delete(s.state.Recipients, i, i+1)
+		}
+		// Адрес мог прийти и из EMAIL_TO или RECIPIENTS_FILE
+		if !slices.ContainsFunc(s.state.Removed, func(e string) bool { return strings.EqualFold(e, email) }) {
+			s.state.Removed = append(s.state.Removed, email)
+		}
+		return nil
+	})
+	if err == nil {
+		log.Printf("Получатель %s исключен из рассылки через API", email)
+	}
+	return err
+}
+
+// Отписка по ссылке из письма: адрес исключается из всех рассылок, даже если его нет в EMAIL_TO.
+// Повторная отписка ничего не меняет.
+func (s *Subscriptions) Unsubscribe(email string) error {
+	changed := false
+	err := s.update(func(config *Config) error {
+		if i := s.index(email); i >= 0 {
+			s.state.Recipients = slices.Delete(s.state.Recipients, i, i+1)
+			changed = true
+		}
+		if !slices.ContainsFunc(s.state.Removed, func(e string) bool { return strings.EqualFold(e, email) }) {
+			s.state.Removed = append(s.state.Removed, email)
+			changed = true
+		}
+		return nil
+	})
+	if err == nil && changed {
+		log.Printf("Получатель %s отписался по ссылке из письма", email)
+	}
+	return err
+}
+
+// Индекс получателя среди подписок из API; -1, если его настройки через API не менялись
+func (s *Subscriptions) index(email string) int {
+	return slices.IndexFunc(s.state.Recipients, func(r Recipient) bool { return strings.EqualFold(r.Email, email) })
+}
+
+// Есть ли адрес в общей рассылке
+func subscribed(config *Config, email string) bool {
+	return slices.ContainsFunc(config.EmailTo, func(e string) bool { return strings.EqualFold(e, email) })
+}
+
+// Получатель в ответах API
+type subscriberView struct {
+	Recipient
+	Source string `json:"source"` // config - из EMAIL_TO или RECIPIENTS_FILE, api - добавлен или изменен через API
+}
+
+// Получатели общей рассылки с настройками; порог - в единицах UNITS, как при добавлении
+func (s *Subscriptions) List() []subscriberView {
+	config := s.configs.Load()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := map[string]bool{}
+	views := []subscriberView{}
+	for _, email := range config.EmailTo {
+		if seen[strings.ToLower(email)] {
+			continue
+		}
+		seen[strings.ToLower(email)] = true
+		views = append(views, s.view(config, email))
+	}
+	return views
+}
+
+// Получатель с настройками; вызывается с захваченной блокировкой
+func (s *Subscriptions) view(config *Config, email string) subscriberView {
+	profile := config.recipientProfile(email)
+	if profile.Threshold > 0 {
+		profile.Threshold = math.Round(convertSpeed(profile.Threshold, config.Units)*100) / 100
+	}
+	source := "config"
+	if s.index(email) >= 0 {
+		source = "api"
+	}
+	return subscriberView{Recipient: profile, Source: source}
+}
+
+// Проверка получателя из запроса: через API задаются адрес, имя, язык, единицы и личный порог.
+// email - адрес из пути запроса; в теле он тогда необязателен.
+func parseSubscriber(r *http.Request, email string) (Recipient, error) {
+	var recipient Recipient
+	if err := json.NewDecoder(r.Body).Decode(&recipient); err != nil {
+		return recipient, fmt.Errorf("некорректный JSON: %w", err)
+	}
+	if email != "" {
+		if recipient.Email != "" && !strings.EqualFold(recipient.Email, email) {
+			return recipient, errors.New("email: адрес в теле запроса не совпадает с адресом в пути")
+		}
+		recipient.Email = email
+	}
+	if recipient.Phone != "" || len(recipient.Channels) > 0 {
+		return recipient, errors.New("номера телефонов и каналы sms и call задаются в RECIPIENTS_FILE")
+	}
+	if recipient.Threshold < 0 {
+		return recipient, errors.New("threshold: порог не может быть отрицательным")
+	}
+	if recipient.Email != "" {
+		addr, err := mail.ParseAddress(recipient.Email)
+		if err != nil {
+			return recipient, fmt.Errorf("email: некорректный адрес %q", recipient.Email)
+		}
+		recipient.Email = addr.Address
+	}
+	if err := recipient.normalize(); err != nil {
+		return recipient, err
+	}
+	return recipient, nil
+}
+
+// Регистрация HTTP-маршрутов управления получателями
+func (s *Subscriptions) registerRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/recipients", s.handleRecipients)
+	mux.HandleFunc("/api/recipients/", s.handleRecipient)
+	mux.HandleFunc("/unsubscribe", s.handleUnsubscribe)
+}
+
+// GET - список получателей, POST - добавление получателя
+func (s *Subscriptions) handleRecipients(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.List())
+	case http.MethodPost:
+		recipient, err := parseSubscriber(r, "")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := s.Add(recipient); err != nil {
+			writeSubscriptionError(w, err)
+			return
+		}
+		s.writeRecipient(w, http.StatusCreated, recipient.Email)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+	}
+}
+
+// GET /api/recipients/{email} - настройки получателя, PUT - замена настроек, DELETE - исключение из рассылки
+func (s *Subscriptions) handleRecipient(w http.ResponseWriter, r *http.Request) {
+	email, err := url.PathUnescape(strings.TrimPrefix(r.URL.Path, "/api/recipients/"))
+	if err != nil || email == "" {
+		writeError(w, http.StatusNotFound, errRecipientNotFound.Error())
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if !subscribed(s.configs.Load(), email) {
+			writeError(w, http.StatusNotFound, errRecipientNotFound.Error())
+			return
+		}
+		s.writeRecipient(w, http.StatusOK, email)
+	case http.MethodPut:
+		recipient, err := parseSubscriber(r, email)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := s.Update(email, recipient); err != nil {
+			writeSubscriptionError(w, err)
+			return
+		}
+		s.writeRecipient(w, http.StatusOK, email)
+	case http.MethodDelete:
+		if err := s.Remove(email); err != nil {
+			writeSubscriptionError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+	}
+}
+
+// Ответ с настройками получателя по активной конфигурации
+func (s *Subscriptions) writeRecipient(w http.ResponseWriter, status int, email string) {
+	config := s.configs.Load()
+	s.mu.Lock()
+	view := s.view(config, email)
+	s.mu.Unlock()
+	writeJSON(w, status, view)
+}
+
+// Ответ с ошибкой изменения подписок
+func writeSubscriptionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errRecipientExists):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, errRecipientNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	default:
+		logErrorf("Ошибка при изменении подписок: %v", err)
+		writeError(w, http.StatusInternalServerError, "ошибка при сохранении подписок")
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Идентификатор структурированных данных RFC 5424 (32473 - номер для примеров и частного использования)
+const syslogSDID = "windalert@32473"
+
+// Коды facility syslog
+var syslogFacilities = map[string]int{
+	"user": 1, "daemon": 3, "local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// Настройки отправки событий на сервер syslog
+type SyslogConfig struct {
+	Addr     string // Адрес сервера host:port
+	Protocol string // udp, tcp или tls
+	Facility int
+	AppName  string
+	CAFile   string // Сертификат CA сервера для tls (по умолчанию системные)
+}
+
+// Загрузка настроек syslog из переменных окружения
+func loadSyslogConfig() SyslogConfig {
+	cfg := SyslogConfig{
+		Addr:     os.Getenv("SYSLOG_ADDR"),
+		Protocol: strings.ToLower(os.Getenv("SYSLOG_PROTOCOL")),
+		Facility: syslogFacilities["local0"],
+		AppName:  os.Getenv("SYSLOG_APP_NAME"),
+		CAFile:   os.Getenv("SYSLOG_TLS_CA"),
+	}
+
+	switch cfg.Protocol {
+	case "udp", "tcp", "tls":
+	case "":
+		cfg.Protocol = "udp"
+	default:
+		logWarnf("Ошибка парсинга SYSLOG_PROTOCOL: неизвестный протокол %q, используется значение по умолчанию", cfg.Protocol)
+		cfg.Protocol = "udp"
+	}
+
+	if envFacility := os.Getenv("SYSLOG_FACILITY"); envFacility != "" {
+		if val, ok := syslogFacilities[strings.ToLower(envFacility)]; ok {
+			cfg.Facility = val
+		} else {
+			logWarnf("Ошибка парсинга SYSLOG_FACILITY: неизвестное значение %q, используется значение по умолчанию", envFacility)
+		}
+	}
+
+	if cfg.AppName == "" {
+		cfg.AppName = "windalerts"
+	}
+
+	return cfg
+}
+
+// Отправка событий в syslog (RFC 5424) для SIEM
+type syslogNotifier struct {
+	config    SyslogConfig
+	tlsConfig *tls.Config
+	hostname  string
+}
+
+func newSyslogNotifier(config SyslogConfig) (*syslogNotifier, error) {
+	n := &syslogNotifier{config: config, hostname: "-"}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		n.hostname = hostname
+	}
+
+	if config.Protocol == "tls" {
+		host, _, err := net.SplitHostPort(config.Addr)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный адрес syslog %q: %w", config.Addr, err)
+		}
+		n.tlsConfig = &tls.Config{ServerName: host}
+
+		if config.CAFile != "" {
+			pem, err := os.ReadFile(config.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка при чтении сертификата CA: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("в файле %s нет сертификатов", config.CAFile)
+			}
+			n.tlsConfig.RootCAs = pool
+		}
+	}
+
+	return n, nil
+}
+
+func (n *syslogNotifier) Name() string {
+	return "syslog"
+}
+
+// Уровень важности syslog по уровню опасности предупреждения
+func syslogSeverity(s Severity) int {
+	switch s {
+	case SeverityRed:
+		return 2 // critical
+	case SeverityOrange:
+		return 3 // error
+	case SeverityYellow:
+		return 4 // warning
+	default:
+		return 6 // informational
+	}
+}
+
+// Экранирование значения параметра структурированных данных
+var syslogSDEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// Формирование сообщения в формате RFC 5424
+func (n *syslogNotifier) format(report *AlertReport) string {
+	msgID := "WINDALERT"
+	text := fmt.Sprintf(report.tr("%s: порывы ветра до %s превышают порог %s, уровень опасности %s", "%s: wind gusts up to %s exceed the threshold of %s, severity %s"),
+		report.City, report.speed(report.MaxWindGust, 2), report.speed(report.WindGustThreshold, 2), report.Severity)
+	if !report.ExceedsThreshold {
+		msgID = "WINDCLEAR"
+		text = fmt.Sprintf(report.tr("%s: порывы ветра до %s в пределах порога %s", "%s: wind gusts up to %s within the threshold of %s"),
+			report.City, report.speed(report.MaxWindGust, 2), report.speed(report.WindGustThreshold, 2))
+	}
+
+	sd := fmt.Sprintf(`[%s city="%s" severity="%s" max_gust="%s" threshold="%s"]`, syslogSDID,
+		syslogSDEscaper.Replace(report.City), report.Severity,
+		strconv.FormatFloat(report.MaxWindGust, 'f', 2, 64),
+		strconv.FormatFloat(report.WindGustThreshold, 'f', 2, 64))
+
+	// Сообщение в UTF-8 помечается BOM согласно RFC 5424
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s \ufeff%s",
+		n.config.Facility*8+syslogSeverity(report.Severity),
+		time.Now().Format(time.RFC3339Nano), n.hostname, n.config.AppName, os.Getpid(), msgID, sd, text)
+}
+
+// Событие отправляется и при отсутствии превышения, чтобы в SIEM было видно снятие предупреждения
+func (n *syslogNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	message := n.format(report)
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	var err error
+	switch n.config.Protocol {
+	case "tls":
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: n.tlsConfig}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", n.config.Addr)
+	case "tcp":
+		conn, err = dialer.DialContext(ctx, "tcp", n.config.Addr)
+	default:
+		conn, err = dialer.DialContext(ctx, "udp", n.config.Addr)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при подключении к серверу syslog: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetWriteDeadline(deadline)
+	}
+
+	// Для потоковых протоколов используется разделение по длине сообщения (RFC 6587, RFC 5425)
+	if n.config.Protocol != "udp" {
+		message = fmt.Sprintf("%d %s", len(message), message)
+	}
+
+	if _, err := conn.Write([]byte(message)); err != nil {
+		return fmt.Errorf("ошибка при отправке сообщения syslog: %w", err)
+	}
+
+	log.Println("Событие отправлено в syslog")
+	return nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Каталог шаблонов: TEMPLATE_DIR или прежнее имя TEMPLATES_DIR
+func templateDir(getenv func(name string) string) string {
+	if dir := getenv("TEMPLATE_DIR"); dir != "" {
+		return dir
+	}
+	return getenv("TEMPLATES_DIR")
+}
+
+// Шаблоны сообщений каналов из каталога TEMPLATE_DIR:
+// <канал>.tmpl - текст сообщения, <канал>.html.tmpl - HTML-версия (электронная почта, Matrix),
+// <канал>.subject.tmpl - тема (электронная почта, AWS SNS).
+// Шаблоны <template>.<канал>.tmpl используются для предупреждений правила с полем template (RULES_FILE).
+// Шаблоны заполняются данными AlertReport; каналы без шаблона используют стандартный текст.
+type MessageTemplates struct {
+	text    map[string]*template.Template
+	html    map[string]*htmltemplate.Template
+	subject map[string]*template.Template
+}
+
+// Пустой набор шаблонов
+func newMessageTemplates() *MessageTemplates {
+	return &MessageTemplates{
+		text:    make(map[string]*template.Template),
+		html:    make(map[string]*htmltemplate.Template),
+		subject: make(map[string]*template.Template),
+	}
+}
+
+// Функции, доступные в шаблонах сообщений
+var messageTemplateFuncs = map[string]any{
+	"upper": strings.ToUpper,
+	// Скорость ветра: {{speed .MaxWindGust .Units 1}} - с обозначением единиц,
+	// {{convert .MaxWindGust "kmh"}} - число в других единицах, {{beaufort .MaxWindGust}} - балл Бофорта
+	"speed":    formatSpeed,
+	"convert":  convertSpeed,
+	"beaufort": beaufort,
+	"unit":     func(units string) string { return speedUnitLabel(units, languageRU) },
+}
+
+// Загрузка шаблонов сообщений из каталога
+func loadMessageTemplates(dir string) (*MessageTemplates, error) {
+	t := newMessageTemplates()
+	if dir == "" {
+		return t, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске шаблонов: %w", err)
+	}
+
+	for _, path := range paths {
+		if err := t.load(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(paths) > 0 {
+		log.Printf("Загружено шаблонов сообщений: %d", len(paths))
+	}
+	return t, nil
+}
+
+// Проверка всех шаблонов каталога; в отличие от загрузки возвращает все найденные ошибки
+func checkMessageTemplates(dir string) []error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return []error{fmt.Errorf("ошибка при поиске шаблонов: %w", err)}
+	}
+
+	t := newMessageTemplates()
+	var errs []error
+	for _, path := range paths {
+		if err := t.load(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Загрузка одного файла шаблона
+func (t *MessageTemplates) load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("ошибка при чтении шаблона %s: %w", path, err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+	if channel, ok := strings.CutSuffix(name, ".html"); ok {
+		tmpl, err := htmltemplate.New(name).Funcs(messageTemplateFuncs).Parse(string(data))
+		if err != nil {
+			return fmt.Errorf("ошибка при парсинге шаблона %s: %w", path, err)
+		}
+		t.html[channel] = tmpl
+		return nil
+	}
+
+	if channel, ok := strings.CutSuffix(name, ".subject"); ok {
+		tmpl, err := template.New(name).Funcs(messageTemplateFuncs).Parse(string(data))
+		if err != nil {
+			return fmt.Errorf("ошибка при парсинге шаблона %s: %w", path, err)
+		}
+		t.subject[channel] = tmpl
+		return nil
+	}
+
+	tmpl, err := template.New(name).Funcs(messageTemplateFuncs).Parse(string(data))
+	if err != nil {
+		return fmt.Errorf("ошибка при парсинге шаблона %s: %w", path, err)
+	}
+	t.text[name] = tmpl
+	return nil
+}
+
+// Копия результата проверки с текстом, сформированным по шаблонам канала
+func (t *MessageTemplates) apply(channel string, report *AlertReport) (*AlertReport, error) {
+	name := t.templateName(channel, report)
+	textTmpl, hasText := t.text[name]
+	htmlTmpl, hasHTML := t.html[name]
+	subjectTmpl, hasSubject := t.subject[name]
+	if !hasText && !hasHTML && !hasSubject {
+		return report, nil
+	}
+
+	rendered := *report
+	var errs []error
+
+	if hasText {
+		var buf bytes.Buffer
+		if err := textTmpl.Execute(&buf, report); err != nil {
+			errs = append(errs, fmt.Errorf("ошибка при заполнении шаблона %s: %w", textTmpl.Name(), err))
+		} else {
+			rendered.Message = strings.TrimSpace(buf.String())
+		}
+	}
+
+	if hasHTML {
+		var buf bytes.Buffer
+		if err := htmlTmpl.Execute(&buf, report); err != nil {
+			errs = append(errs, fmt.Errorf("ошибка при заполнении шаблона %s: %w", htmlTmpl.Name(), err))
+		} else {
+			rendered.MessageHTML = buf.String()
+		}
+	}
+
+	if hasSubject {
+		var buf bytes.Buffer
+		if err := subjectTmpl.Execute(&buf, report); err != nil {
+			errs = append(errs, fmt.Errorf("ошибка при заполнении шаблона %s: %w", subjectTmpl.Name(), err))
+		} else {
+			// Тема - одна строка: переводы строк и лишние пробелы из шаблона убираются
+			rendered.Subject = strings.Join(strings.Fields(buf.String()), " ")
+		}
+	}
+
+	return &rendered, errors.Join(errs...)
+}
+
+// Имя шаблонов канала: шаблоны правила <template>.<канал> имеют приоритет над <канал>
+func (t *MessageTemplates) templateName(channel string, report *AlertReport) string {
+	if report.Template == "" {
+		return channel
+	}
+	name := report.Template + "." + channel
+	_, hasText := t.text[name]
+	_, hasHTML := t.html[name]
+	_, hasSubject := t.subject[name]
+	if hasText || hasHTML || hasSubject {
+		return name
+	}
+	return channel
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+)
+
+// Настройки Twilio
+type TwilioConfig struct {
+	AccountSID      string
+	AuthToken       string
+	From            string   // Номер Twilio, с которого отправляются SMS и совершаются звонки
+	SMSTo           []string // Номера для SMS
+	CallTo          []string // Номера для голосового оповещения
+	CallMinSeverity Severity // Минимальный уровень опасности для звонка
+	VoiceLanguage   string   // Язык синтеза речи
+}
+
+// Загрузка настроек Twilio из переменных окружения
+func loadTwilioConfig() TwilioConfig {
+	cfg := TwilioConfig{
+		AccountSID:      os.Getenv("TWILIO_ACCOUNT_SID"),
+		AuthToken:       os.Getenv("TWILIO_AUTH_TOKEN"),
+		From:            os.Getenv("TWILIO_FROM"),
+		SMSTo:           parseList(os.Getenv("TWILIO_SMS_TO")),
+		CallTo:          parseList(os.Getenv("TWILIO_CALL_TO")),
+		CallMinSeverity: SeverityRed, // Звонок - только для самого высокого уровня
+		VoiceLanguage:   os.Getenv("TWILIO_VOICE_LANGUAGE"),
+	}
+
+	if cfg.VoiceLanguage == "" {
+		cfg.VoiceLanguage = "ru-RU"
+	}
+
+	if envSeverity := os.Getenv("TWILIO_CALL_MIN_SEVERITY"); envSeverity != "" {
+		if val, err := parseSeverity(envSeverity); err == nil && val != SeverityNone {
+			cfg.CallMinSeverity = val
+		} else {
+			logWarnf("Ошибка парсинга TWILIO_CALL_MIN_SEVERITY: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	return cfg
+}
+
+// Отправка предупреждения по SMS через Twilio
+type twilioSMSNotifier struct {
+	config TwilioConfig
+}
+
+func newTwilioSMSNotifier(config TwilioConfig) *twilioSMSNotifier {
+	return &twilioSMSNotifier{config: config}
+}
+
+func (n *twilioSMSNotifier) Name() string {
+	return "sms"
+}
+
+func (n *twilioSMSNotifier) deliveryRecipients(report *AlertReport) []string {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+	return n.config.SMSTo
+}
+
+func (n *twilioSMSNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	// Короткий текст, чтобы сообщение уместилось в минимальное число сегментов
+	text := report.text(fmt.Sprintf(report.tr("Сильный ветер: %s, порывы до %s (порог %s). Не открывайте окна.", "Strong wind: %s, gusts up to %s (threshold %s). Keep windows closed."),
+		report.City, report.speed(report.MaxWindGust, 0), report.speed(report.WindGustThreshold, 0)))
+	if report.AckURL != "" && report.Message == "" {
+		text += report.tr(" Подтвердите: ", " Acknowledge: ") + report.AckURL
+	}
+
+	endpoint := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", url.PathEscape(n.config.AccountSID))
+	headers := map[string]string{"Authorization": basicAuth(n.config.AccountSID, n.config.AuthToken)}
+
+	failed := make(map[string]error)
+	for _, to := range n.config.SMSTo {
+		form := url.Values{
+			"To":   {to},
+			"From": {n.config.From},
+			"Body": {text},
+		}
+		if _, err := sendForm(ctx, endpoint, headers, form); err != nil {
+			logErrorf("Ошибка при отправке SMS на номер %s: %v", to, err)
+			failed[to] = err
+		}
+	}
+
+	if len(failed) > 0 {
+		return &recipientErrors{message: fmt.Sprintf("не доставлено SMS: %d из %d", len(failed), len(n.config.SMSTo)), failed: failed}
+	}
+
+	log.Printf("Предупреждение отправлено по SMS (%d номеров)", len(n.config.SMSTo))
+	return nil
+}
+
+// Голосовое оповещение через Twilio с синтезом речи
+type twilioVoiceNotifier struct {
+	config TwilioConfig
+}
+
+func newTwilioVoiceNotifier(config TwilioConfig) *twilioVoiceNotifier {
+	return &twilioVoiceNotifier{config: config}
+}
+
+func (n *twilioVoiceNotifier) Name() string {
+	return "call"
+}
+
+// Инструкции TwiML для звонка: сообщение произносится дважды
+type twimlResponse struct {
+	XMLName xml.Name   `xml:"Response"`
+	Say     []twimlSay `xml:"Say"`
+}
+
+type twimlSay struct {
+	Language string `xml:"language,attr"`
+	Text     string `xml:",chardata"`
+}
+
+// Скорость ветра для голосового сообщения: единицы произносятся полностью
+func spokenSpeed(report *AlertReport) string {
+	if report.Units == unitsBft {
+		return fmt.Sprintf(report.tr("%d баллов по шкале Бофорта", "Beaufort force %d"), beaufort(report.MaxWindGust))
+	}
+	names := map[string][2]string{
+		unitsMS:    {"метров в секунду", "meters per second"},
+		unitsKMH:   {"километров в час", "kilometers per hour"},
+		unitsMPH:   {"миль в час", "miles per hour"},
+		unitsKnots: {"узлов", "knots"},
+	}
+	name, ok := names[report.Units]
+	if !ok {
+		name = names[unitsMS]
+	}
+	return fmt.Sprintf("%.0f %s", convertSpeed(report.MaxWindGust, report.Units), report.tr(name[0], name[1]))
+}
+
+func (n *twilioVoiceNotifier) deliveryRecipients(report *AlertReport) []string {
+	if report.Severity < n.config.CallMinSeverity {
+		return nil
+	}
+	return n.config.CallTo
+}
+
+func (n *twilioVoiceNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if report.Severity < n.config.CallMinSeverity {
+		return nil
+	}
+
+	speech := report.text(fmt.Sprintf(report.tr(
+		"Внимание! Штормовое предупреждение. %s. %s ожидаются порывы ветра до %s. Уровень опасности: %s. Закройте окна и ворота складов.",
+		"Warning! Storm alert. %s. Wind gusts up to %[3]s are expected %[2]s. Severity: %[4]s. Close the windows and warehouse gates."),
+		report.City, capitalize(report.periodTitle()), spokenSpeed(report), report.severityTitle()))
+	twiml, err := xml.Marshal(twimlResponse{Say: []twimlSay{
+		{Language: n.config.VoiceLanguage, Text: speech},
+		{Language: n.config.VoiceLanguage, Text: speech},
+	}})
+	if err != nil {
+		return fmt.Errorf("ошибка при формировании TwiML: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Calls.json", url.PathEscape(n.config.AccountSID))
+	headers := map[string]string{"Authorization": basicAuth(n.config.AccountSID, n.config.AuthToken)}
+
+	failed := make(map[string]error)
+	for _, to := range n.config.CallTo {
+		form := url.Values{
+			"To":    {to},
+			"From":  {n.config.From},
+			"Twiml": {string(twiml)},
+		}
+		if _, err := sendForm(ctx, endpoint, headers, form); err != nil {
+			logErrorf("Ошибка при звонке на номер %s: %v", to, err)
+			failed[to] = err
+		}
+	}
+
+	if len(failed) > 0 {
+		return &recipientErrors{message: fmt.Sprintf("не удалось совершить звонков: %d из %d", len(failed), len(n.config.CallTo)), failed: failed}
+	}
+
+	log.Printf("Голосовое оповещение запущено (%d номеров)", len(n.config.CallTo))
+	return nil
+}
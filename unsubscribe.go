@@ -0,0 +1,148 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Настройки ссылки отписки в письмах
+type UnsubscribeConfig struct {
+	Secret    string // Ключ подписи ссылок (UNSUBSCRIBE_SECRET)
+	PublicURL string // Публичный адрес сервиса (PUBLIC_URL, по умолчанию FEED_LINK)
+}
+
+// Загрузка настроек отписки из переменных окружения
+func loadUnsubscribeConfig() UnsubscribeConfig {
+	cfg := UnsubscribeConfig{
+		Secret:    os.Getenv("UNSUBSCRIBE_SECRET"),
+		PublicURL: strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/"),
+	}
+	if cfg.PublicURL == "" {
+		cfg.PublicURL = strings.TrimSuffix(os.Getenv("FEED_LINK"), "/")
+	}
+	if cfg.Secret != "" && cfg.PublicURL == "" {
+		logWarnf("UNSUBSCRIBE_SECRET: не задан PUBLIC_URL, письма отправляются без ссылки отписки")
+	}
+	return cfg
+}
+
+// Включена ли ссылка отписки
+func (c UnsubscribeConfig) enabled() bool {
+	return c.Secret != "" && c.PublicURL != ""
+}
+
+// Подпись адреса: ссылку нельзя подобрать для чужого адреса, не зная UNSUBSCRIBE_SECRET
+func (c UnsubscribeConfig) token(email string) string {
+	mac := hmac.New(sha256.New, []byte(c.Secret))
+	mac.Write([]byte(strings.ToLower(email)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Проверка подписи ссылки
+func (c UnsubscribeConfig) valid(email, token string) bool {
+	if !c.enabled() || email == "" {
+		return false
+	}
+	return hmac.Equal([]byte(token), []byte(c.token(email)))
+}
+
+// Ссылка отписки для адреса
+func (c UnsubscribeConfig) link(email string) string {
+	return c.PublicURL + "/unsubscribe?email=" + url.QueryEscape(email) + "&token=" + c.token(email)
+}
+
+// Отписка в конце письма на языке получателя: ссылка в тексте и ссылка в HTML перед </body>
+func unsubscribeFooter(link, language, htmlBody, plainTextBody string) (string, string) {
+	text, label := "Вы получаете это письмо как получатель предупреждений о сильном ветре.", "Отписаться"
+	if language == languageEN {
+		text, label = "You are receiving this email as a recipient of strong wind alerts.", "Unsubscribe"
+	}
+
+	plainTextBody = strings.TrimRight(plainTextBody, "\n") + fmt.Sprintf("\n\n%s %s: %s\n", text, label, link)
+	footer := fmt.Sprintf("<p style=\"color: #777777; font-size: 12px;\">%s <a href=\"%s\">%s</a></p>\n",
+		html.EscapeString(text), html.EscapeString(link), label)
+	if i := strings.LastIndex(strings.ToLower(htmlBody), "</body>"); i >= 0 {
+		htmlBody = htmlBody[:i] + footer + htmlBody[i:]
+	} else {
+		htmlBody += footer
+	}
+	return htmlBody, plainTextBody
+}
+
+// Адреса без отписавшихся и исключенных из рассылки
+func (c *Config) withoutUnsubscribed(recipients []string) []string {
+	if len(c.Unsubscribed) == 0 {
+		return recipients
+	}
+	removed := map[string]bool{}
+	for _, email := range c.Unsubscribed {
+		removed[strings.ToLower(email)] = true
+	}
+	var active []string
+	for _, email := range recipients {
+		if !removed[strings.ToLower(email)] {
+			active = append(active, email)
+		}
+	}
+	return active
+}
+
+// Страница, открываемая по ссылке отписки
+var unsubscribePageTemplate = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"><title>Отписка от рассылки</title></head>
+<body style="font-family: Arial, sans-serif; text-align: center; padding: 40px;">
+    {{if .Done}}
+    <h1 style="color: #3c763d;">Вы отписались</h1>
+    <p>Предупреждения о сильном ветре больше не будут приходить на {{.Email}}.</p>
+    {{else}}
+    <h1>Отписка от рассылки</h1>
+    <p>Больше не присылать предупреждения о сильном ветре на {{.Email}}?</p>
+    <form method="post">
+        <p><button type="submit" style="padding: 10px 20px; font-size: 16px; background-color: #d9534f; color: #ffffff; border: 0; border-radius: 4px;">Отписаться</button></p>
+    </form>
+    {{end}}
+</body>
+</html>`))
+
+// GET /unsubscribe?email=...&token=... - страница с подтверждением: почтовые сканеры открывают ссылки
+// из писем, поэтому отписка выполняется только по POST - кнопкой на странице или в один клик
+// из почтового клиента (заголовки List-Unsubscribe и List-Unsubscribe-Post, RFC 8058)
+func (s *Subscriptions) handleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	email := r.URL.Query().Get("email")
+	if !s.configs.Load().Unsubscribe.valid(email, r.URL.Query().Get("token")) {
+		http.Error(w, "Ссылка отписки недействительна", http.StatusForbidden)
+		return
+	}
+
+	page := struct {
+		Email string
+		Done  bool
+	}{Email: email}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if err := s.Unsubscribe(email); err != nil {
+			logErrorf("Ошибка при отписке %s: %v", email, err)
+			http.Error(w, "Не удалось отписаться, попробуйте позже", http.StatusInternalServerError)
+			return
+		}
+		page.Done = true
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "метод не поддерживается")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := unsubscribePageTemplate.Execute(w, page); err != nil {
+		logErrorf("Ошибка при записи ответа: %v", err)
+	}
+}
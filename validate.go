@@ -0,0 +1,374 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Проблемы конфигурации, найденные при проверке
+type configProblems []string
+
+func (p *configProblems) add(format string, args ...any) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+// Команда validate: проверка конфигурации без запуска сервиса.
+// Возвращает код завершения: 0 - ошибок нет, 1 - найдены проблемы, 2 - неверные аргументы.
+func runValidate(args []string) int {
+	if err := applyFlags(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	// Как и при запуске, файл .env не переопределяет окружение и флаги
+	var problems configProblems
+	missing, err := loadConfigFiles()
+	if err != nil {
+		problems.add("CONFIG_FILE: %v", err)
+	}
+	// Отсутствие файла .env по умолчанию допустимо, явно указанного - нет
+	if lookupEnv("CONFIG_FILE") != "" {
+		for _, path := range missing {
+			problems.add("CONFIG_FILE: файл %s не найден", path)
+		}
+	}
+
+	applyEnvNamespace()
+	if _, err := configVersionOf(map[string]string{"CONFIG_VERSION": os.Getenv("CONFIG_VERSION")}); err != nil {
+		problems.add("%v", err)
+	}
+	for _, err := range loadSecretFiles() {
+		problems.add("%v", err)
+	}
+	if err := applyProfile(); err != nil {
+		problems.add("PROFILE: %v", err)
+	}
+	for _, err := range decryptConfigValues() {
+		problems.add("%v", err)
+	}
+	// Неизвестные параметры считаются ошибкой только в строгом режиме, как и при запуске
+	if strictConfig() {
+		problems = append(problems, unknownConfigVariables()...)
+	}
+	problems = append(problems, validateConfig()...)
+	if len(problems) == 0 {
+		fmt.Println("Конфигурация корректна")
+		return 0
+	}
+
+	fmt.Printf("Найдено проблем в конфигурации: %d\n", len(problems))
+	for _, problem := range problems {
+		fmt.Println("- " + problem)
+	}
+	return 1
+}
+
+// Проверка переменных окружения: обязательные поля, диапазоны значений, адреса,
+// выражения cron, файлы и шаблоны. Возвращает все найденные проблемы.
+func validateConfig() configProblems {
+	return validateEnv(os.Getenv)
+}
+
+// Проверка конфигурации по переданному окружению. Страница настроек проверяет новые
+// значения на копии окружения, не подставляя их в окружение процесса.
+func validateEnv(getenv func(name string) string) configProblems {
+	p := envValidator{getenv: getenv}
+
+	// Обязательные поля
+	for _, name := range []string{"OPENWEATHER_API_KEY", "EMAIL_FROM", "SMTP_SERVER", "SMTP_PORT"} {
+		if strings.TrimSpace(getenv(name)) == "" {
+			p.add("%s: не задано", name)
+		}
+	}
+	if getenv("CITY") == "" && getenv("LOCATIONS_FILE") == "" {
+		p.add("CITY: не задан город (или список пунктов LOCATIONS_FILE)")
+	}
+	if strings.TrimSpace(getenv("EMAIL_TO")) == "" && getenv("RECIPIENTS_FILE") == "" {
+		p.add("EMAIL_TO: не заданы получатели (или настройки получателей RECIPIENTS_FILE)")
+	}
+
+	// Диапазоны значений
+	p.checkInt("SMTP_PORT", 1, 65535)
+	p.checkInt("NOTIFICATION_HOUR", 0, 23)
+	p.checkInt("NOTIFICATION_MIN", 0, 59)
+	p.checkInt("LOOKAHEAD_DAYS", 0, maxLookaheadDays)
+	p.checkInt("SCHOOL_START_HOUR", 0, 23)
+	p.checkInt("SCHOOL_END_HOUR", 1, 24)
+	p.checkInt("MQTT_QOS", 0, 2)
+	p.checkInt("ACCURACY_DAYS", 1, 365)
+	p.checkInt("LOG_MAX_SIZE", 1, 100000)
+	p.checkInt("LOG_MAX_BACKUPS", 0, 10000)
+	p.checkInt("LOG_MAX_AGE", 0, 36500)
+	p.checkInt("STATSD_PORT", 1, 65535)
+	p.checkInt("OPS_ALERT_AFTER", 1, 1000)
+	threshold := p.checkPositive("WIND_GUST_THRESHOLD", 15)
+	orange := p.checkPositive("WIND_GUST_ORANGE_THRESHOLD", threshold+5)
+	red := p.checkPositive("WIND_GUST_RED_THRESHOLD", threshold+10)
+	if orange <= threshold {
+		p.add("WIND_GUST_ORANGE_THRESHOLD: %.2f не превышает основной порог %.2f", orange, threshold)
+	}
+	if red <= orange {
+		p.add("WIND_GUST_RED_THRESHOLD: %.2f не превышает оранжевый порог %.2f", red, orange)
+	}
+	p.checkPositive("DRONE_MAX_GUST", 1)
+	p.checkPositive("DRONE_MIN_VISIBILITY", 1)
+	p.checkPositive("POLL_NEAR_RATIO", 1)
+	for _, name := range []string{"POLL_INTERVAL", "POLL_INTERVAL_NEAR", "REMINDER_LEAD", "RETRY_INITIAL_DELAY", "ESCALATION_DELAY", "CONFIG_REFRESH_INTERVAL", "ACCURACY_INTERVAL"} {
+		p.checkDuration(name, false)
+	}
+	// RETRY_MAX_PERIOD=0 отключает повторную доставку
+	p.checkDuration("RETRY_MAX_PERIOD", true)
+	p.checkDuration("ALERT_COOLDOWN", true)
+	p.checkDuration("FORECAST_ARCHIVE_RETENTION", true)
+	p.checkDuration("FORECAST_CACHE_TTL", true)
+	p.checkDuration("REDIS_LEADER_TTL", false)
+	p.checkDuration("HEALTH_MAX_CHECK_AGE", true)
+	p.checkDuration("HEALTH_PROBE_INTERVAL", true)
+	p.checkDuration("LOG_ROTATE_INTERVAL", true)
+	for _, name := range []string{"DRY_RUN", "PREFLIGHT", "STRICT_CONFIG", "ALERT_DEDUP", "MQTT_RETAINED", "MQTT_HA_DISCOVERY", "XMPP_DIRECT_TLS", "LOG_COMPRESS", "PPROF", "DASHBOARD", "EMAIL_CHART"} {
+		p.checkBool(name)
+	}
+
+	// Значения из фиксированного набора
+	p.checkOneOf("MODE", modeWind, modeDrone, modeSchool)
+	p.checkOneOf("LOG_LEVEL", "debug", "info", "warn", "error")
+	p.checkOneOf("SCHEDULE", scheduleDaily, scheduleContinuous, scheduleCron, scheduleOnce)
+	p.checkOneOf("DAILY_CSV_DELIMITER", ",", ";", "tab", `\t`)
+	p.checkOneOf("LOCATIONS_REPORT", locationsSeparate, locationsCombined)
+	p.checkOneOf("STORE_BACKEND", storeJSON, storeBolt, "bbolt", storeRedis)
+	if strings.TrimSpace(getenv("STORE_BACKEND")) == storeRedis && getenv("REDIS_URL") == "" {
+		p.add("REDIS_URL: не задан адрес Redis для STORE_BACKEND=redis")
+	}
+	if pprof, _ := strconv.ParseBool(getenv("PPROF")); pprof && getenv("HEALTH_ADDR") == "" {
+		p.add("PPROF: профилирование доступно только на служебном адресе, задайте HEALTH_ADDR")
+	}
+	if dashboard, _ := strconv.ParseBool(getenv("DASHBOARD")); dashboard && getenv("HTTP_ADDR") == "" {
+		p.add("DASHBOARD: страница состояния открывается на HTTP-сервере, задайте HTTP_ADDR")
+	}
+	p.check("API_KEYS", func(value string) error {
+		_, err := parseAPIKeys(value)
+		return err
+	})
+	if getenv("API_KEYS") != "" && getenv("HTTP_ADDR") == "" {
+		p.add("API_KEYS: маршруты управления открываются на HTTP-сервере, задайте HTTP_ADDR")
+	}
+	if password := getenv("SETTINGS_PASSWORD"); password != "" {
+		if len(password) < 12 {
+			p.add("SETTINGS_PASSWORD: пароль короче 12 символов")
+		}
+		if getenv("HTTP_ADDR") == "" {
+			p.add("SETTINGS_PASSWORD: страница настроек открывается на HTTP-сервере, задайте HTTP_ADDR")
+		}
+		if getenv("API_KEYS") != "" {
+			p.add("SETTINGS_PASSWORD: при API_KEYS страница настроек открывается ключом с областью config, пароль не используется")
+		}
+	}
+	if secret := getenv("UNSUBSCRIBE_SECRET"); secret != "" {
+		if len(secret) < 16 {
+			p.add("UNSUBSCRIBE_SECRET: ключ короче 16 символов")
+		}
+		if getenv("PUBLIC_URL") == "" && getenv("FEED_LINK") == "" {
+			p.add("UNSUBSCRIBE_SECRET: для ссылки отписки задайте PUBLIC_URL")
+		}
+		if getenv("HTTP_ADDR") == "" {
+			p.add("UNSUBSCRIBE_SECRET: ссылка отписки открывается на HTTP-сервере, задайте HTTP_ADDR")
+		}
+	}
+	p.check("UNITS", func(value string) error { _, err := parseUnits(value); return err })
+	p.check("LANGUAGE", func(value string) error { _, err := parseLanguage(value); return err })
+	p.check("FEATURES", func(value string) error { _, err := parseFeatures(value); return err })
+	if weekday := getenv("DIGEST_WEEKDAY"); weekday != "" {
+		key := strings.ToLower(weekday)
+		if len(key) > 3 {
+			key = key[:3]
+		}
+		if _, ok := weekdayNames[key]; !ok {
+			p.add("DIGEST_WEEKDAY: неизвестный день недели %q", weekday)
+		}
+	}
+
+	// Адреса электронной почты
+	for _, name := range []string{"EMAIL_FROM", "EMAIL_TO", "PREVIEW_EMAIL_TO", "DIGEST_EMAIL_TO", "SCHOOL_EMAIL_TO", "OPS_ALERT_EMAIL_TO"} {
+		for _, address := range parseEmailList(getenv(name)) {
+			if _, err := mail.ParseAddress(address); err != nil {
+				p.add("%s: некорректный адрес %q", name, address)
+			}
+		}
+	}
+
+	// Расписание
+	cronExpr := getenv("CRON_SCHEDULE")
+	if strings.EqualFold(strings.TrimSpace(getenv("SCHEDULE")), scheduleCron) && cronExpr == "" {
+		p.add("CRON_SCHEDULE: не задано выражение cron для SCHEDULE=cron")
+	}
+	if cronExpr != "" {
+		p.check("CRON_SCHEDULE", func(value string) error { _, err := parseCron(value); return err })
+	}
+	p.check("CHECK_WINDOW", func(value string) error { _, err := parseCheckWindow(value); return err })
+	p.check("PREVIEW_TIME", func(value string) error { _, err := parseClock(value); return err })
+	p.check("DIGEST_TIME", func(value string) error { _, err := parseClock(value); return err })
+	p.check("QUIET_HOURS", func(value string) error { _, err := parseQuietHours(value); return err })
+	p.check("ROUTING_RULES", func(value string) error { _, err := parseRoutingRules(value); return err })
+	p.check("SCHOOL_AGE_GROUPS", func(value string) error { _, err := parseAgeGroups(value); return err })
+	p.check("PAUSE_UNTIL", func(value string) error { _, err := parsePauseUntil(value, time.Local); return err })
+	p.check("TIMEZONE", func(value string) error { _, err := time.LoadLocation(value); return err })
+	p.check("RECIPIENT_TIMES", func(value string) error {
+		slots, err := parseRecipientSlots(value)
+		for _, slot := range slots {
+			for _, address := range slot.Recipients {
+				if _, addrErr := mail.ParseAddress(address); addrErr != nil {
+					err = errors.Join(err, fmt.Errorf("некорректный адрес %q", address))
+				}
+			}
+		}
+		return err
+	})
+	for _, item := range parseList(getenv("BLACKOUT_DATES")) {
+		if _, err := parseBlackoutPeriod(item); err != nil {
+			p.add("BLACKOUT_DATES: %v", err)
+		}
+	}
+	if path := getenv("BLACKOUT_ICAL"); path != "" {
+		if _, err := loadICalBlackouts(path, time.Local); err != nil {
+			p.add("BLACKOUT_ICAL: %v", err)
+		}
+	}
+
+	// Пункты, получатели и шаблоны
+	if locations, err := readLocationsFile(getenv("LOCATIONS_FILE")); err != nil {
+		p.add("LOCATIONS_FILE: %v", err)
+	} else {
+		if len(locations) > 0 && getenv("RECIPIENT_TIMES") != "" {
+			p.add("RECIPIENT_TIMES: не поддерживается при проверке нескольких пунктов (LOCATIONS_FILE), задайте время проверки пункта (notification_time)")
+		}
+		for _, loc := range locations {
+			for _, address := range loc.Recipients {
+				if _, err := mail.ParseAddress(address); err != nil {
+					p.add("LOCATIONS_FILE: пункт %q: некорректный адрес %q", loc.title(), address)
+				}
+			}
+		}
+	}
+	units, err := parseUnits(getenv("UNITS"))
+	if err != nil {
+		units = unitsMS
+	}
+	if _, err := readAlertRules(getenv("RULES_FILE"), units); err != nil {
+		p.add("RULES_FILE: %v", err)
+	}
+	if profiles, err := readRecipientProfiles(getenv("RECIPIENTS_FILE")); err != nil {
+		p.add("RECIPIENTS_FILE: %v", err)
+	} else {
+		for _, profile := range profiles {
+			if profile.Email == "" {
+				continue
+			}
+			if _, err := mail.ParseAddress(profile.Email); err != nil {
+				p.add("RECIPIENTS_FILE: некорректный адрес %q", profile.Email)
+			}
+		}
+	}
+	if dir := templateDir(getenv); dir != "" {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			p.add("TEMPLATE_DIR: каталог %s не найден", dir)
+		}
+		for _, err := range checkMessageTemplates(dir) {
+			p.add("TEMPLATE_DIR: %v", err)
+		}
+	}
+
+	return p.configProblems
+}
+
+// Проверка значений окружения с накоплением найденных проблем
+type envValidator struct {
+	configProblems
+	getenv func(name string) string
+}
+
+// Проверка значения переменной функцией разбора, если переменная задана
+func (p *envValidator) check(name string, parse func(value string) error) {
+	value := p.getenv(name)
+	if value == "" {
+		return
+	}
+	if err := parse(value); err != nil {
+		p.add("%s: %v", name, err)
+	}
+}
+
+// Проверка целого числа в диапазоне
+func (p *envValidator) checkInt(name string, min, max int) {
+	p.check(name, func(value string) error {
+		val, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("ожидается целое число, получено %q", value)
+		}
+		if val < min || val > max {
+			return fmt.Errorf("значение %d вне диапазона %d-%d", val, min, max)
+		}
+		return nil
+	})
+}
+
+// Проверка положительного числа; возвращает значение или значение по умолчанию
+func (p *envValidator) checkPositive(name string, fallback float64) float64 {
+	result := fallback
+	p.check(name, func(value string) error {
+		val, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("ожидается число, получено %q", value)
+		}
+		if val <= 0 {
+			return fmt.Errorf("ожидается положительное число, получено %v", val)
+		}
+		result = val
+		return nil
+	})
+	return result
+}
+
+// Проверка длительности в формате Go (например, 15m, 1h30m)
+func (p *envValidator) checkDuration(name string, allowZero bool) {
+	p.check(name, func(value string) error {
+		val, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("ожидается длительность вида 15m или 1h, получено %q", value)
+		}
+		if val < 0 || val == 0 && !allowZero {
+			return fmt.Errorf("ожидается положительная длительность, получено %s", val)
+		}
+		return nil
+	})
+}
+
+// Проверка логического значения
+func (p *envValidator) checkBool(name string) {
+	p.check(name, func(value string) error {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("ожидается true или false, получено %q", value)
+		}
+		return nil
+	})
+}
+
+// Проверка значения из допустимого набора
+func (p *envValidator) checkOneOf(name string, allowed ...string) {
+	p.check(name, func(value string) error {
+		value = strings.ToLower(strings.TrimSpace(value))
+		for _, v := range allowed {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("неизвестное значение %q (допустимо: %s)", value, strings.Join(allowed, ", "))
+	})
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+// Адрес метода Viber REST API для отправки сообщений
+const viberSendMessageURL = "https://chatapi.viber.com/pa/send_message"
+
+// Настройки отправки сообщений от имени бота Viber
+type ViberConfig struct {
+	AuthToken  string   // Токен бота из панели администратора Viber
+	Receivers  []string // Идентификаторы подписчиков бота
+	SenderName string   // Имя отправителя (до 28 символов)
+}
+
+// Загрузка настроек Viber из переменных окружения
+func loadViberConfig() ViberConfig {
+	cfg := ViberConfig{
+		AuthToken:  os.Getenv("VIBER_AUTH_TOKEN"),
+		Receivers:  parseList(os.Getenv("VIBER_RECEIVERS")),
+		SenderName: os.Getenv("VIBER_SENDER_NAME"),
+	}
+
+	if cfg.SenderName == "" {
+		cfg.SenderName = "WindAlerts"
+	}
+
+	return cfg
+}
+
+// Отправка предупреждения подписчикам бота Viber
+type viberNotifier struct {
+	config ViberConfig
+}
+
+func newViberNotifier(config ViberConfig) *viberNotifier {
+	return &viberNotifier{config: config}
+}
+
+func (n *viberNotifier) Name() string {
+	return "viber"
+}
+
+type viberMessage struct {
+	Receiver      string      `json:"receiver"`
+	MinAPIVersion int         `json:"min_api_version"`
+	Sender        viberSender `json:"sender"`
+	Type          string      `json:"type"`
+	Text          string      `json:"text"`
+}
+
+type viberSender struct {
+	Name string `json:"name"`
+}
+
+// Ответ Viber API: при ошибке возвращается код 200 с ненулевым status
+type viberResponse struct {
+	Status        int    `json:"status"`
+	StatusMessage string `json:"status_message"`
+}
+
+func (n *viberNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	text := report.text("⚠️ " + formatAlertText(report))
+
+	var failed int
+	for _, receiver := range n.config.Receivers {
+		if err := n.send(ctx, receiver, text); err != nil {
+			logErrorf("Ошибка при отправке сообщения Viber получателю %s: %v", receiver, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("не доставлено сообщений Viber: %d из %d", failed, len(n.config.Receivers))
+	}
+
+	log.Printf("Предупреждение отправлено в Viber (%d получателей)", len(n.config.Receivers))
+	return nil
+}
+
+// Вызов send_message для одного получателя
+func (n *viberNotifier) send(ctx context.Context, receiver, text string) error {
+	message := viberMessage{
+		Receiver:      receiver,
+		MinAPIVersion: 1,
+		Sender:        viberSender{Name: n.config.SenderName},
+		Type:          "text",
+		Text:          text,
+	}
+	headers := map[string]string{"X-Viber-Auth-Token": n.config.AuthToken}
+
+	body, err := sendJSON(ctx, http.MethodPost, viberSendMessageURL, headers, message)
+	if err != nil {
+		return err
+	}
+
+	var result viberResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("ошибка при разборе JSON: %w", err)
+	}
+	if result.Status != 0 {
+		return fmt.Errorf("Viber API вернул ошибку %d: %s", result.Status, result.StatusMessage)
+	}
+
+	return nil
+}
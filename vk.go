@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/url"
+	"os"
+)
+
+// Адрес метода VK API для отправки сообщений
+const vkMessagesSendURL = "https://api.vk.com/method/messages.send"
+
+// Настройки отправки сообщений от имени сообщества ВКонтакте
+type VKConfig struct {
+	AccessToken string   // Ключ доступа сообщества с правом на сообщения
+	PeerIDs     []string // Идентификаторы получателей (пользователи или беседы 2000000000+id)
+	APIVersion  string
+}
+
+// Загрузка настроек ВКонтакте из переменных окружения
+func loadVKConfig() VKConfig {
+	cfg := VKConfig{
+		AccessToken: os.Getenv("VK_ACCESS_TOKEN"),
+		PeerIDs:     parseList(os.Getenv("VK_PEER_IDS")),
+		APIVersion:  os.Getenv("VK_API_VERSION"),
+	}
+
+	if cfg.APIVersion == "" {
+		cfg.APIVersion = "5.199"
+	}
+
+	return cfg
+}
+
+// Отправка предупреждения сообщениями сообщества ВКонтакте
+type vkNotifier struct {
+	config VKConfig
+}
+
+func newVKNotifier(config VKConfig) *vkNotifier {
+	return &vkNotifier{config: config}
+}
+
+func (n *vkNotifier) Name() string {
+	return "vk"
+}
+
+// Ответ VK API: при ошибке возвращается код 200 с объектом error
+type vkResponse struct {
+	Error *struct {
+		Code    int    `json:"error_code"`
+		Message string `json:"error_msg"`
+	} `json:"error"`
+}
+
+func (n *vkNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	message := report.text("⚠️ " + formatAlertText(report))
+
+	var failed int
+	for _, peerID := range n.config.PeerIDs {
+		if err := n.send(ctx, peerID, message); err != nil {
+			logErrorf("Ошибка при отправке сообщения ВКонтакте получателю %s: %v", peerID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("не доставлено сообщений ВКонтакте: %d из %d", failed, len(n.config.PeerIDs))
+	}
+
+	log.Printf("Предупреждение отправлено ВКонтакте (%d получателей)", len(n.config.PeerIDs))
+	return nil
+}
+
+// Вызов messages.send для одного получателя
+func (n *vkNotifier) send(ctx context.Context, peerID, message string) error {
+	form := url.Values{
+		"access_token": {n.config.AccessToken},
+		"v":            {n.config.APIVersion},
+		"peer_id":      {peerID},
+		"random_id":    {fmt.Sprint(rand.Int31())},
+		"message":      {message},
+	}
+
+	body, err := sendForm(ctx, vkMessagesSendURL, nil, form)
+	if err != nil {
+		return err
+	}
+
+	var result vkResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("ошибка при разборе JSON: %w", err)
+	}
+	if result.Error != nil {
+		return fmt.Errorf("VK API вернул ошибку %d: %s", result.Error.Code, result.Error.Message)
+	}
+
+	return nil
+}
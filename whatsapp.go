@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// Настройки отправки уведомлений через WhatsApp Cloud API
+type WhatsAppConfig struct {
+	AccessToken      string
+	PhoneNumberID    string   // Идентификатор номера отправителя в WhatsApp Business
+	To               []string // Номера получателей в международном формате без "+"
+	TemplateName     string   // Имя заранее одобренного шаблона сообщения
+	TemplateLanguage string   // Код языка шаблона, например ru
+	APIVersion       string   // Версия Graph API
+}
+
+// Загрузка настроек WhatsApp из переменных окружения
+func loadWhatsAppConfig() WhatsAppConfig {
+	cfg := WhatsAppConfig{
+		AccessToken:      os.Getenv("WHATSAPP_ACCESS_TOKEN"),
+		PhoneNumberID:    os.Getenv("WHATSAPP_PHONE_NUMBER_ID"),
+		To:               parseList(os.Getenv("WHATSAPP_TO")),
+		TemplateName:     os.Getenv("WHATSAPP_TEMPLATE"),
+		TemplateLanguage: os.Getenv("WHATSAPP_TEMPLATE_LANGUAGE"),
+		APIVersion:       os.Getenv("WHATSAPP_API_VERSION"),
+	}
+
+	if cfg.TemplateName == "" {
+		cfg.TemplateName = "wind_gust_alert"
+	}
+	if cfg.TemplateLanguage == "" {
+		cfg.TemplateLanguage = "ru"
+	}
+	if cfg.APIVersion == "" {
+		cfg.APIVersion = "v19.0"
+	}
+
+	return cfg
+}
+
+// Отправка предупреждения шаблонным сообщением WhatsApp
+type whatsAppNotifier struct {
+	config WhatsAppConfig
+}
+
+func newWhatsAppNotifier(config WhatsAppConfig) *whatsAppNotifier {
+	return &whatsAppNotifier{config: config}
+}
+
+func (n *whatsAppNotifier) Name() string {
+	return "whatsapp"
+}
+
+// Сообщение на основе шаблона для WhatsApp Cloud API
+type whatsAppTemplateMessage struct {
+	MessagingProduct string           `json:"messaging_product"`
+	To               string           `json:"to"`
+	Type             string           `json:"type"`
+	Template         whatsAppTemplate `json:"template"`
+}
+
+type whatsAppTemplate struct {
+	Name       string              `json:"name"`
+	Language   whatsAppLanguage    `json:"language"`
+	Components []whatsAppComponent `json:"components"`
+}
+
+type whatsAppLanguage struct {
+	Code string `json:"code"`
+}
+
+type whatsAppComponent struct {
+	Type       string              `json:"type"`
+	Parameters []whatsAppParameter `json:"parameters"`
+}
+
+type whatsAppParameter struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func (n *whatsAppNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	endpoint := fmt.Sprintf("https://graph.facebook.com/%s/%s/messages", n.config.APIVersion, n.config.PhoneNumberID)
+	headers := map[string]string{"Authorization": "Bearer " + n.config.AccessToken}
+
+	// Параметры шаблона: {{1}} - максимальный порыв ветра, {{2}} - пороговое значение
+	parameters := []whatsAppParameter{
+		{Type: "text", Text: strconv.FormatFloat(convertSpeed(report.MaxWindGust, report.Units), 'f', 1, 64)},
+		{Type: "text", Text: strconv.FormatFloat(convertSpeed(report.WindGustThreshold, report.Units), 'f', 1, 64)},
+	}
+
+	var failed int
+	for _, to := range n.config.To {
+		message := whatsAppTemplateMessage{
+			MessagingProduct: "whatsapp",
+			To:               to,
+			Type:             "template",
+			Template: whatsAppTemplate{
+				Name:     n.config.TemplateName,
+				Language: whatsAppLanguage{Code: n.config.TemplateLanguage},
+				Components: []whatsAppComponent{
+					{Type: "body", Parameters: parameters},
+				},
+			},
+		}
+
+		if _, err := sendJSON(ctx, http.MethodPost, endpoint, headers, message); err != nil {
+			logErrorf("Ошибка при отправке сообщения WhatsApp на номер %s: %v", to, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("не доставлено сообщений WhatsApp: %d из %d", failed, len(n.config.To))
+	}
+
+	log.Printf("Предупреждение отправлено в WhatsApp (%d получателей)", len(n.config.To))
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Окно проверки: часть суток (в минутах от полуночи), прогноз на которую оценивается
+type CheckWindow struct {
+	Start int
+	End   int
+}
+
+// Окно проверки по умолчанию: с полуночи до 19:00
+var defaultCheckWindow = CheckWindow{Start: 0, End: 19 * 60}
+
+// Загрузка окна проверки из переменной CHECK_WINDOW в формате "08:00-18:00"
+func loadCheckWindow() CheckWindow {
+	envWindow := os.Getenv("CHECK_WINDOW")
+	if envWindow == "" {
+		return defaultCheckWindow
+	}
+
+	window, err := parseCheckWindow(envWindow)
+	if err != nil {
+		logWarnf("Ошибка парсинга CHECK_WINDOW: %v, используется значение по умолчанию", err)
+		return defaultCheckWindow
+	}
+	return window
+}
+
+// Разбор окна проверки ЧЧ:ММ-ЧЧ:ММ; окно не может переходить через полночь
+func parseCheckWindow(value string) (CheckWindow, error) {
+	from, to, ok := strings.Cut(value, "-")
+	if !ok {
+		return CheckWindow{}, fmt.Errorf("ожидается период ЧЧ:ММ-ЧЧ:ММ, получено %q", value)
+	}
+
+	start, err := parseClock(from)
+	if err != nil {
+		return CheckWindow{}, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return CheckWindow{}, err
+	}
+	if end == 0 {
+		end = 24 * 60 // "24:00" не разбирается, поэтому конец суток задается как 00:00
+	}
+	if start >= end {
+		return CheckWindow{}, fmt.Errorf("начало окна должно быть раньше конца, получено %q", value)
+	}
+
+	return CheckWindow{Start: start, End: end}, nil
+}
+
+// Границы окна проверки в указанный день
+func (w CheckWindow) bounds(day time.Time) (time.Time, time.Time) {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, w.Start, 0, 0, day.Location()),
+		time.Date(day.Year(), day.Month(), day.Day(), 0, w.End, 0, 0, day.Location())
+}
+
+// Строковое представление окна для журнала
+func (w CheckWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xmppo/go-xmpp"
+)
+
+// Настройки отправки уведомлений по XMPP (Jabber)
+type XMPPConfig struct {
+	Server     string // Адрес сервера host:port (по умолчанию домен JID и порт 5222)
+	User       string // JID отправителя, например alerts@jabber.example.org
+	Password   string
+	Recipients []string // JID получателей
+	DirectTLS  bool     // Прямое TLS-подключение (порт 5223) вместо STARTTLS
+}
+
+// Загрузка настроек XMPP из переменных окружения
+func loadXMPPConfig() XMPPConfig {
+	cfg := XMPPConfig{
+		Server:     os.Getenv("XMPP_SERVER"),
+		User:       os.Getenv("XMPP_USER"),
+		Password:   os.Getenv("XMPP_PASSWORD"),
+		Recipients: parseList(os.Getenv("XMPP_RECIPIENTS")),
+	}
+
+	if cfg.Server == "" {
+		if i := strings.LastIndex(cfg.User, "@"); i >= 0 {
+			cfg.Server = cfg.User[i+1:] + ":5222"
+		}
+	}
+
+	if envDirectTLS := os.Getenv("XMPP_DIRECT_TLS"); envDirectTLS != "" {
+		if val, err := strconv.ParseBool(envDirectTLS); err == nil {
+			cfg.DirectTLS = val
+		} else {
+			logWarnf("Ошибка парсинга XMPP_DIRECT_TLS: %v, используется значение по умолчанию", err)
+		}
+	}
+
+	return cfg
+}
+
+// Отправка предупреждения сообщениями XMPP
+type xmppNotifier struct {
+	config XMPPConfig
+}
+
+func newXMPPNotifier(config XMPPConfig) *xmppNotifier {
+	return &xmppNotifier{config: config}
+}
+
+func (n *xmppNotifier) Name() string {
+	return "xmpp"
+}
+
+func (n *xmppNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(n.config.Server)
+	if err != nil {
+		return fmt.Errorf("некорректный адрес XMPP сервера %q: %w", n.config.Server, err)
+	}
+
+	options := xmpp.Options{
+		Host:        n.config.Server,
+		User:        n.config.User,
+		Password:    n.config.Password,
+		Resource:    "windalerts",
+		DialTimeout: 10 * time.Second,
+		NoTLS:       !n.config.DirectTLS,
+		StartTLS:    !n.config.DirectTLS,
+		TLSConfig:   &tls.Config{ServerName: host},
+		Session:     true,
+	}
+
+	client, err := options.NewClient()
+	if err != nil {
+		return fmt.Errorf("ошибка при подключении к XMPP серверу: %w", err)
+	}
+	defer client.Close()
+
+	message := report.text("⚠️ " + formatAlertText(report))
+
+	var failed int
+	for _, recipient := range n.config.Recipients {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := client.Send(xmpp.Chat{Remote: recipient, Type: "chat", Text: message}); err != nil {
+			logErrorf("Ошибка при отправке сообщения XMPP получателю %s: %v", recipient, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("не доставлено сообщений XMPP: %d из %d", failed, len(n.config.Recipients))
+	}
+
+	log.Printf("Предупреждение отправлено по XMPP (%d получателей)", len(n.config.Recipients))
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Настройки отправки уведомлений в Zulip
+type ZulipConfig struct {
+	Site     string // Адрес сервера Zulip, например https://chat.example.org
+	BotEmail string // Адрес электронной почты бота
+	APIKey   string // API-ключ бота
+	Stream   string // Канал (stream) для предупреждений
+	Topic    string // Тема; по умолчанию - город, чтобы предупреждения группировались
+}
+
+// Загрузка настроек Zulip из переменных окружения
+func loadZulipConfig() ZulipConfig {
+	return ZulipConfig{
+		Site:     strings.TrimSuffix(os.Getenv("ZULIP_SITE"), "/"),
+		BotEmail: os.Getenv("ZULIP_BOT_EMAIL"),
+		APIKey:   os.Getenv("ZULIP_API_KEY"),
+		Stream:   os.Getenv("ZULIP_STREAM"),
+		Topic:    os.Getenv("ZULIP_TOPIC"),
+	}
+}
+
+// Отправка предупреждения в канал Zulip через API бота
+type zulipNotifier struct {
+	config ZulipConfig
+}
+
+func newZulipNotifier(config ZulipConfig) *zulipNotifier {
+	return &zulipNotifier{config: config}
+}
+
+func (n *zulipNotifier) Name() string {
+	return "zulip"
+}
+
+func (n *zulipNotifier) Notify(ctx context.Context, report *AlertReport) error {
+	if !report.ExceedsThreshold {
+		return nil
+	}
+
+	topic := n.config.Topic
+	if topic == "" {
+		topic = report.City
+	}
+
+	var content strings.Builder
+	fmt.Fprintf(&content, report.tr(":warning: **Внимание!** %s ожидаются сильные порывы ветра (**%s**), что превышает безопасный порог (%s).\n",
+		":warning: **Warning!** %s strong wind gusts (**%s**) are expected, exceeding the safe threshold (%s).\n"),
+		capitalize(report.periodTitle()), report.speed(report.MaxWindGust, 2), report.speed(report.WindGustThreshold, 2))
+	fmt.Fprintf(&content, report.tr("Уровень опасности: %s\n", "Severity: %s\n"), report.severityTitle())
+	for _, f := range report.Forecasts {
+		fmt.Fprintf(&content, "* %s: %s\n", report.formatTime(f.Time), report.speed(f.WindGust, 2))
+	}
+	content.WriteString(report.tr("\nРекомендуется не открывать окна в офисе в течение дня.", "\nPlease keep the office windows closed during the day."))
+	if report.AckURL != "" {
+		fmt.Fprintf(&content, report.tr("\n[Подтвердить получение](%s)", "\n[Acknowledge](%s)"), report.AckURL)
+	}
+
+	form := url.Values{
+		"type":    {"stream"},
+		"to":      {n.config.Stream},
+		"topic":   {topic},
+		"content": {report.text(content.String())},
+	}
+	headers := map[string]string{"Authorization": basicAuth(n.config.BotEmail, n.config.APIKey)}
+
+	if _, err := sendForm(ctx, n.config.Site+"/api/v1/messages", headers, form); err != nil {
+		return fmt.Errorf("ошибка при отправке сообщения в Zulip: %w", err)
+	}
+
+	log.Printf("Предупреждение отправлено в Zulip (%s > %s)", n.config.Stream, topic)
+	return nil
+}